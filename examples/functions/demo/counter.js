@@ -0,0 +1,14 @@
+/**
+ * 累加计数器, 计数保存在函数的持久化存储中
+ *
+ * @param {context.Context: ctx} request context.
+ * @param {map: input} the input map of the the function.
+ */
+function handler(ctx, input) {
+  var got = storage.get("count");
+  var count = got[1] ? 0 : parseInt(got[0]);
+  count += input.step;
+  storage.set("count", String(count), 0);
+  console.log("count: " + count);
+  return {count: count};
+}
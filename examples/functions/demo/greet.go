@@ -0,0 +1,16 @@
+//go:build ignore
+
+package p
+
+import (
+	"fmt"
+
+	"github.com/fflow-tech/fflow-sdk-go/faas"
+)
+
+// handler 根据计数生成问候语
+func handler(ctx faas.Context, params map[string]interface{}) (interface{}, error) {
+	return map[string]interface{}{
+		"message": fmt.Sprintf("hello %v, this is run %v", params["name"], params["count"]),
+	}, nil
+}
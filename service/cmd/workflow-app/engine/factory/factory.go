@@ -29,6 +29,7 @@ import (
 	redisclient "github.com/fflow-tech/fflow/service/pkg/redis"
 	"github.com/fflow-tech/fflow/service/pkg/registry"
 	"github.com/fflow-tech/fflow/service/pkg/remote"
+	"github.com/fflow-tech/fflow/service/pkg/script"
 	"go.uber.org/dig"
 )
 
@@ -149,6 +150,7 @@ func provideRepo(container *dig.Container) {
 	container.Provide(repo.NewEventBusRepo)
 	container.Provide(repo.NewRemoteRepo)
 	container.Provide(repo.NewTriggerRepo)
+	container.Provide(repo.NewSecretRepo)
 	container.Provide(ports.NewRepoSet)
 }
 
@@ -181,7 +183,9 @@ func provideDomainService(container *dig.Container) {
 	container.Provide(command.NewNodeInstCommandService)
 	container.Provide(command.NewExternalEventCommandService)
 	container.Provide(command.NewWorkflowTriggerCommandService)
+	container.Provide(command.NewSecretCommandService)
 	container.Provide(query.NewWorkflowDefQueryService)
+	container.Provide(query.NewSecretQueryService)
 	container.Provide(query.NewNodeInstQueryService)
 	container.Provide(query.NewWorkflowInstQueryService)
 
@@ -218,10 +222,15 @@ func provideDAO(container *dig.Container) {
 	container.Provide(config.GetDefaultAbilityCallerConfig)
 	container.Provide(remote.NewDefaultAbilityCaller)
 	container.Provide(remote.NewDefaultCronClient)
+	container.Provide(config.GetDefaultTimerClientConfig)
+	container.Provide(remote.NewDefaultTimerClient)
 	container.Provide(remote.NewDefaultChatOpsClient)
 	container.Provide(remote.NewDefaultCloudEventClient)
 	container.Provide(sql.NewTriggerDAO)
+	container.Provide(sql.NewSecretDAO)
 
 	container.Provide(config.GetDefaultPermissionValidatorConfig)
 	container.Provide(remote.NewDefaultPermissionValidator)
+	container.Provide(remote.NewDefaultRbacClient)
+	container.Provide(script.NewRunner)
 }
@@ -40,12 +40,13 @@ func NewDriveEventProcessor(domainService *service.DomainService,
 	}
 	eventHandler := newDriveEventHandler(domainService)
 	p.eventHandlerMap = map[event.DriveEventType]func(ctx context.Context, driveEvent *dto.DriveEventDTO) error{
-		event.WorkflowStartDrive: eventHandler.handleWorkflowStartDriveEvent,
-		event.NodeScheduleDrive:  eventHandler.handleNodeScheduleDriveEvent,
-		event.NodeExecuteDrive:   eventHandler.handleNodeExecuteDriveEvent,
-		event.NodePollDrive:      eventHandler.handleNodePollDriveEvent,
-		event.NodeCompleteDrive:  eventHandler.handleNodeCompleteDriveEvent,
-		event.NodeRetryDrive:     eventHandler.handleNodeCompleteDriveEvent,
+		event.WorkflowStartDrive:      eventHandler.handleWorkflowStartDriveEvent,
+		event.NodeScheduleDrive:       eventHandler.handleNodeScheduleDriveEvent,
+		event.NodeExecuteDrive:        eventHandler.handleNodeExecuteDriveEvent,
+		event.NodePollDrive:           eventHandler.handleNodePollDriveEvent,
+		event.NodeCompleteDrive:       eventHandler.handleNodeCompleteDriveEvent,
+		event.NodeRetryDrive:          eventHandler.handleNodeCompleteDriveEvent,
+		event.WorkflowCompensateDrive: eventHandler.handleWorkflowCompensateDriveEvent,
 	}
 	return p
 }
@@ -154,3 +155,7 @@ func (h *driveEventHandler) handleNodeCompleteDriveEvent(ctx context.Context, ev
 func (h *driveEventHandler) handleNodeRetryDriveEvent(ctx context.Context, event *dto.DriveEventDTO) error {
 	return h.domainService.Commands.ConsumeNodeRetryDriveEvent(ctx, event)
 }
+
+func (h *driveEventHandler) handleWorkflowCompensateDriveEvent(ctx context.Context, event *dto.DriveEventDTO) error {
+	return h.domainService.Commands.ConsumeWorkflowCompensateDriveEvent(ctx, event)
+}
@@ -91,6 +91,36 @@ func (h *WorkflowEngineController) GetDefList(c *gin.Context) {
 	c.JSON(http.StatusOK, constants.NewSucceedWebRspWithTotal(data, total))
 }
 
+// GetDefGraph 查询流程定义的流程图
+// @Summary 查询流程定义的流程图
+// @Description 查询流程定义的 Mermaid 或者 Graphviz DOT 格式的流程图
+// @Tags 工作流定义相关接口
+// @Accept application/json
+// @Produce application/json
+// @Param def query dto.GetWorkflowDefGraphDTO true "查询流程图请求"
+// @Success 200 {object} constants.WebRsp
+// @Router /engine/api/v1/def/graph [get]
+func (h *WorkflowEngineController) GetDefGraph(c *gin.Context) {
+	var req dto.GetWorkflowDefGraphDTO
+	if err := bindReq(c, &req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+
+	// 如果是超管用户则可以查询任意流程的流程图
+	if utils.StrContains(config.GetRbacConfig().SuperAdmins, req.Creator) {
+		req.Creator = ""
+	}
+
+	data, err := h.domainService.Queries.GetWorkflowDefGraph(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(data))
+}
+
 // CreateDef 创建流程定义
 // @Summary 创建流程定义
 // @Description 创建流程定义
@@ -223,6 +253,106 @@ func (h *WorkflowEngineController) UploadDef(c *gin.Context) {
 	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(data))
 }
 
+// GetSecretList 批量查询密钥
+// @Summary 批量查询密钥
+// @Description 批量查询密钥, 不返回密钥值
+// @Tags 密钥相关接口
+// @Accept application/json
+// @Produce application/json
+// @Param secret query dto.PageQuerySecretDTO true "查询密钥请求"
+// @Success 200 {object} constants.WebRsp
+// @Router /engine/api/v1/secret/list [get]
+func (h *WorkflowEngineController) GetSecretList(c *gin.Context) {
+	var req dto.PageQuerySecretDTO
+	if err := bindReq(c, &req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+
+	data, total, err := h.domainService.Queries.GetSecretList(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, constants.NewSucceedWebRspWithTotal(data, total))
+}
+
+// CreateSecret 创建密钥
+// @Summary 创建密钥
+// @Description 创建密钥
+// @Tags 密钥相关接口
+// @Accept application/json
+// @Produce application/json
+// @Param secret body dto.CreateSecretDTO true "创建密钥请求"
+// @Success 200 {object} constants.WebRsp
+// @Router /engine/api/v1/secret/create [post]
+func (h *WorkflowEngineController) CreateSecret(c *gin.Context) {
+	var req dto.CreateSecretDTO
+	if err := bindReq(c, &req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+
+	data, err := h.domainService.Commands.CreateSecret(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(data))
+}
+
+// UpdateSecret 更新密钥
+// @Summary 更新密钥
+// @Description 更新密钥
+// @Tags 密钥相关接口
+// @Accept application/json
+// @Produce application/json
+// @Param secret body dto.UpdateSecretDTO true "更新密钥请求"
+// @Success 200 {object} constants.WebRsp
+// @Router /engine/api/v1/secret/update [post]
+func (h *WorkflowEngineController) UpdateSecret(c *gin.Context) {
+	var req dto.UpdateSecretDTO
+	if err := bindReq(c, &req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+
+	err := h.domainService.Commands.UpdateSecret(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(nil))
+}
+
+// DeleteSecret 删除密钥
+// @Summary 删除密钥
+// @Description 删除密钥
+// @Tags 密钥相关接口
+// @Accept application/json
+// @Produce application/json
+// @Param secret body dto.DeleteSecretDTO true "删除密钥请求"
+// @Success 200 {object} constants.WebRsp
+// @Router /engine/api/v1/secret/delete [post]
+func (h *WorkflowEngineController) DeleteSecret(c *gin.Context) {
+	var req dto.DeleteSecretDTO
+	if err := bindReq(c, &req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+
+	err := h.domainService.Commands.DeleteSecret(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(nil))
+}
+
 // ArchiveHistory 归档流程实例
 // @Summary 归档流程实例
 // @Description 归档流程实例
@@ -296,6 +426,30 @@ func (h *WorkflowEngineController) CancelInst(c *gin.Context) {
 	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(nil))
 }
 
+// CompensateInst 补偿流程实例
+// @Summary 补偿流程实例
+// @Description 按执行路径逆序补偿已经执行成功的服务节点, 流程实例还在执行中时会先取消
+// @Tags 工作流实例相关接口
+// @Accept application/json
+// @Produce application/json
+// @Param inst body dto.CompensateWorkflowInstDTO true "补偿流程实例请求"
+// @Success 200 {object} constants.WebRsp
+// @Router /engine/api/v1/inst/compensate [post]
+func (h *WorkflowEngineController) CompensateInst(c *gin.Context) {
+	var req dto.CompensateWorkflowInstDTO
+	if err := bindReq(c, &req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+
+	if err := h.domainService.Commands.CompensateWorkflowInst(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(nil))
+}
+
 // CompleteInst 标记流程实例结束
 // @Summary 标记流程实例结束
 // @Description 标记流程实例结束
@@ -496,6 +650,36 @@ func (h *WorkflowEngineController) GetInstList(c *gin.Context) {
 	c.JSON(http.StatusOK, constants.NewSucceedWebRspWithTotal(data, total))
 }
 
+// GetInstGraph 查询工作流实例的流程图
+// @Summary 查询工作流实例的流程图
+// @Description 查询工作流实例的 Mermaid 或者 Graphviz DOT 格式的流程图, 叠加执行路径和节点状态
+// @Tags 工作流实例相关接口
+// @Accept application/json
+// @Produce application/json
+// @Param inst query dto.GetWorkflowInstGraphDTO true "查询实例流程图请求"
+// @Success 200 {object} constants.WebRsp
+// @Router /engine/api/v1/inst/graph [get]
+func (h *WorkflowEngineController) GetInstGraph(c *gin.Context) {
+	var req dto.GetWorkflowInstGraphDTO
+	if err := bindReq(c, &req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+
+	// 如果是超管用户则可以查询任意流程的流程图
+	if utils.StrContains(config.GetRbacConfig().SuperAdmins, req.Operator) {
+		req.Operator = ""
+	}
+
+	data, err := h.domainService.Queries.GetWorkflowInstGraph(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(data))
+}
+
 // GetNodeInstDetail 查询节点实例信息
 // @Summary 查询节点实例信息
 // @Description 查询节点实例信息
@@ -570,6 +754,37 @@ func (h *WorkflowEngineController) ResumeNode(c *gin.Context) {
 	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(nil))
 }
 
+// TimerResumeNode 定时器服务到期后回调恢复等待中的节点
+// 回调只携带 Namespace 和 Authorization 请求头, 由 CallAuth 校验, 不依赖登录态
+// 失败时返回非 200 的状态码, 定时器服务才能感知到回调失败
+// @Summary 定时器回调恢复节点执行
+// @Description 定时器回调恢复节点执行
+// @Tags 节点相关接口
+// @Accept application/json
+// @Produce application/json
+// @Param nodeInst body dto.ResumeNodeDTO true "恢复节点执行请求"
+// @Success 200 {object} constants.WebRsp
+// @Router /engine/openapi/v1/node/resume [post]
+func (h *WorkflowEngineController) TimerResumeNode(c *gin.Context) {
+	var req dto.ResumeNodeDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+	if req.InstID == "" || req.NodeInstID == "" {
+		c.JSON(http.StatusBadRequest, constants.NewFailedWebRspWithMsg(errno.InvalidArgument,
+			"inst_id and node_inst_id must not be empty"))
+		return
+	}
+
+	if err := h.domainService.Commands.ResumeNode(c.Request.Context(), &req); err != nil {
+		c.JSON(http.StatusInternalServerError, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(nil))
+}
+
 // CancelNode 取消节点的执行
 // @Summary 取消节点执行
 // @Description 取消节点执行
@@ -670,6 +885,81 @@ func (h *WorkflowEngineController) CompleteNode(c *gin.Context) {
 	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(nil))
 }
 
+// ApproveNode 审批通过节点
+// @Summary 审批通过节点
+// @Description 审批通过节点, 提交的表单作为节点的输出
+// @Tags 节点相关接口
+// @Accept application/json
+// @Produce application/json
+// @Param nodeInst body dto.ApproveNodeDTO true "审批通过节点"
+// @Success 200 {object} constants.WebRsp
+// @Router /engine/api/v1/node/approve [post]
+func (h *WorkflowEngineController) ApproveNode(c *gin.Context) {
+	var req dto.ApproveNodeDTO
+	if err := bindReq(c, &req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+
+	err := h.domainService.Commands.ApproveNode(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(nil))
+}
+
+// RejectNode 审批拒绝节点
+// @Summary 审批拒绝节点
+// @Description 审批拒绝节点, 节点会被标记为失败
+// @Tags 节点相关接口
+// @Accept application/json
+// @Produce application/json
+// @Param nodeInst body dto.RejectNodeDTO true "审批拒绝节点"
+// @Success 200 {object} constants.WebRsp
+// @Router /engine/api/v1/node/reject [post]
+func (h *WorkflowEngineController) RejectNode(c *gin.Context) {
+	var req dto.RejectNodeDTO
+	if err := bindReq(c, &req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+
+	err := h.domainService.Commands.RejectNode(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(nil))
+}
+
+// DelegateNode 转交审批节点
+// @Summary 转交审批节点
+// @Description 转交审批节点给其他审批人
+// @Tags 节点相关接口
+// @Accept application/json
+// @Produce application/json
+// @Param nodeInst body dto.DelegateNodeDTO true "转交审批节点"
+// @Success 200 {object} constants.WebRsp
+// @Router /engine/api/v1/node/delegate [post]
+func (h *WorkflowEngineController) DelegateNode(c *gin.Context) {
+	var req dto.DelegateNodeDTO
+	if err := bindReq(c, &req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+
+	err := h.domainService.Commands.DelegateNode(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(nil))
+}
+
 // SendCronPresetEvent 发送即时处理定时触发事件
 // @Summary 发送定时触发事件
 // @Description 发送定时触发事件
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCommands 记录恢复节点的请求
+type fakeCommands struct {
+	ports.CommandPorts
+	resumeReq *dto.ResumeNodeDTO
+	resumeErr error
+}
+
+func (f *fakeCommands) ResumeNode(ctx context.Context, req *dto.ResumeNodeDTO) error {
+	f.resumeReq = req
+	return f.resumeErr
+}
+
+func TestWorkflowEngineController_TimerResumeNode(t *testing.T) {
+	// 和定时器服务的回调保持一致: 只有 Namespace 和 Authorization 请求头, 没有登录态的 cookie
+	resumeReq := &dto.ResumeNodeDTO{
+		Namespace:  "default",
+		DefID:      "1",
+		InstID:     "2",
+		NodeInstID: "3",
+		Operator:   "timer",
+		Reason:     "wait until 2026-01-01T00:00:00Z",
+	}
+	body, err := json.Marshal(resumeReq)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		body       string
+		resumeErr  error
+		wantStatus int
+		wantReq    *dto.ResumeNodeDTO
+	}{
+		{"回调恢复节点成功", string(body), nil, http.StatusOK, resumeReq},
+		{"恢复节点失败时返回错误状态码", string(body), fmt.Errorf("node inst is not waiting"),
+			http.StatusInternalServerError, resumeReq},
+		{"请求体不合法", "{", nil, http.StatusBadRequest, nil},
+		{"缺少节点实例ID", `{"inst_id": "2"}`, nil, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := &fakeCommands{resumeErr: tt.resumeErr}
+			controller := NewWorkflowEngineController(&service.DomainService{Commands: commands}, nil, nil)
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/engine/openapi/v1/node/resume", controller.TimerResumeNode)
+
+			req := httptest.NewRequest(http.MethodPost, "/engine/openapi/v1/node/resume",
+				bytes.NewBufferString(tt.body))
+			req.Header.Set("Namespace", "default")
+			req.Header.Set("Authorization", "Bearer token")
+			rsp := httptest.NewRecorder()
+			router.ServeHTTP(rsp, req)
+
+			assert.Equal(t, tt.wantStatus, rsp.Code)
+			assert.Equal(t, tt.wantReq, commands.resumeReq)
+		})
+	}
+}
@@ -72,19 +72,29 @@ func (s *Server) RegisterEngineController(controller *WorkflowEngineController)
 	{
 		defRouter.GET("get", controller.GetDefDetail)
 		defRouter.GET("list", controller.GetDefList)
+		defRouter.GET("graph", controller.GetDefGraph)
 		defRouter.POST("create", controller.CreateDef)
 		defRouter.POST("update", controller.UpdateDef)
 		defRouter.POST("enable", controller.EnableDef)
 		defRouter.POST("disable", controller.DisableDef)
 		defRouter.POST("upload", controller.UploadDef)
 	}
+	secretRouter := s.engineRouter.Group("/secret/").Use()
+	{
+		secretRouter.GET("list", controller.GetSecretList)
+		secretRouter.POST("create", controller.CreateSecret)
+		secretRouter.POST("update", controller.UpdateSecret)
+		secretRouter.POST("delete", controller.DeleteSecret)
+	}
 	instRouter := s.engineRouter.Group("/inst/").Use()
 	{
 		instRouter.GET("get", controller.GetInstDetail)
 		instRouter.GET("list", controller.GetInstList)
+		instRouter.GET("graph", controller.GetInstGraph)
 		instRouter.POST("start", controller.StartInst)
 		instRouter.POST("restart", controller.RestartInst)
 		instRouter.POST("cancel", controller.CancelInst)
+		instRouter.POST("compensate", controller.CompensateInst)
 		instRouter.POST("pause", controller.PauseInst)
 		instRouter.POST("resume", controller.ResumeInst)
 		instRouter.POST("complete", controller.CompleteInst)
@@ -99,6 +109,9 @@ func (s *Server) RegisterEngineController(controller *WorkflowEngineController)
 		nodeInstRouter.POST("resume", controller.ResumeNode)
 		nodeInstRouter.POST("cancel", controller.CancelNode)
 		nodeInstRouter.POST("complete", controller.CompleteNode)
+		nodeInstRouter.POST("approve", controller.ApproveNode)
+		nodeInstRouter.POST("reject", controller.RejectNode)
+		nodeInstRouter.POST("delegate", controller.DelegateNode)
 		nodeInstRouter.POST("skip", controller.SkipNode)
 		nodeInstRouter.POST("cancelskip", controller.CancelSkipNode)
 		nodeInstRouter.POST("timeout", controller.SetNodeTimeout)
@@ -117,6 +130,11 @@ func (s *Server) RegisterEngineController(controller *WorkflowEngineController)
 	{
 		defOpenAPIRouter.GET("list", controller.GetDefList)
 	}
+	// 定时器服务到期后通过该接口恢复等待中的节点
+	nodeOpenAPIRouter := s.openAPIRouter.Group("/node/").Use()
+	{
+		nodeOpenAPIRouter.POST("resume", controller.TimerResumeNode)
+	}
 }
 
 // Serve 启动监听
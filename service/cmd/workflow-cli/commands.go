@@ -0,0 +1,494 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/cmd/workflow-cli/factory"
+	"github.com/fflow-tech/fflow/service/cmd/workflow-cli/service"
+	"github.com/fflow-tech/fflow/service/cmd/workflow-cli/service/web"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/query/graph"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
+)
+
+// command 子命令
+type command struct {
+	name  string                                                              // 命令名称
+	args  []string                                                            // 位置参数名称, 可选的参数使用 [] 包裹
+	desc  string                                                              // 命令说明
+	flags func(fs *flag.FlagSet)                                              // 注册命令自己的参数
+	run   func(workflowService *service.WorkflowService, args []string) error // 执行命令
+}
+
+var (
+	listStatus   string
+	listName     string
+	listLimit    int
+	cancelReason string
+	graphFormat  string
+	graphOutput  string
+	serveAddr    string
+)
+
+// commands 支持的子命令
+var commands = []*command{
+	{
+		name:  "validate",
+		desc:  "Validate the workflow definition file and report all errors",
+		flags: addWorkflowFileFlags,
+		run:   validateWorkflow,
+	},
+	{
+		name:  "run",
+		desc:  "Run the workflow definition file and watch the instance until it ends",
+		flags: addWorkflowFileFlags,
+		run:   runWorkflow,
+	},
+	{
+		name: "list",
+		desc: "List workflow instances, the most recent first",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&listStatus, "status", "",
+				"Only list instances with the status: running, paused, succeed, failed, cancelled, timeout")
+			fs.StringVar(&listName, "name", "", "Only list instances whose workflow name contains the value")
+			fs.IntVar(&listLimit, "limit", 20, "Max count of instances to list")
+		},
+		run: listWorkflows,
+	},
+	{
+		name: "inspect",
+		args: []string{"inst_id"},
+		desc: "Show the workflow instance and its node instances with inputs, outputs and reasons",
+		run:  inspectWorkflow,
+	},
+	{
+		name: "resume",
+		args: []string{"inst_id"},
+		desc: "Resume the paused or interrupted workflow instance and watch it until it ends",
+		run:  resumeWorkflow,
+	},
+	{
+		name: "cancel",
+		args: []string{"inst_id"},
+		desc: "Cancel the workflow instance",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&cancelReason, "reason", "", "Reason of cancelling")
+		},
+		run: cancelWorkflow,
+	},
+	{
+		name: "skip-node",
+		args: []string{"inst_id", "node"},
+		desc: "Skip the node of the workflow instance, the running instance continues to execute",
+		run:  skipNode,
+	},
+	{
+		name: "rerun-node",
+		args: []string{"inst_id", "node"},
+		desc: "Rerun the node of the workflow instance, the finished instance restarts from the node",
+		run:  rerunNode,
+	},
+	{
+		name: "graph",
+		args: []string{"[inst_id]"},
+		desc: "Render the workflow definition file or the workflow instance with its execute path as a graph",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&workflowFile, "f", "", "Workflow definition file path, used when no instance ID is given")
+			fs.StringVar(&graphFormat, "format", "mermaid", "Graph format: mermaid or dot")
+			fs.StringVar(&graphOutput, "o", "", "Output file path, print to stdout if empty")
+		},
+		run: renderGraph,
+	},
+	{
+		name: "serve",
+		desc: "Keep the engine running, fire timer triggers and accept trigger events over HTTP",
+		flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&workflowFile, "f", "", "Workflow definition file path to deploy, "+
+				"the enabled definition with the same name is disabled")
+			fs.StringVar(&serveAddr, "addr", "127.0.0.1:8090", "Address of the local HTTP endpoint")
+		},
+		run: serveWorkflows,
+	},
+}
+
+// getCommand 根据名称获取子命令
+func getCommand(name string) (*command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return nil, false
+}
+
+// usage 命令的用法
+func (c *command) usage() string {
+	usage := fmt.Sprintf("fflow-cli %s [options]", c.name)
+	for _, arg := range c.args {
+		if isOptionalArg(arg) {
+			usage += " " + arg
+			continue
+		}
+		usage += fmt.Sprintf(" <%s>", arg)
+	}
+	return usage
+}
+
+// requiredArgs 必填的位置参数数量
+func (c *command) requiredArgs() int {
+	n := 0
+	for _, arg := range c.args {
+		if !isOptionalArg(arg) {
+			n++
+		}
+	}
+	return n
+}
+
+func isOptionalArg(arg string) bool {
+	return strings.HasPrefix(arg, "[")
+}
+
+// runCommand 解析参数并执行子命令, 返回进程的退出码
+func runCommand(cmd *command, arguments []string) int {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	addCommonFlags(fs)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "%s\n\nUsage:\n  %s\n\nOptions:\n", cmd.desc, cmd.usage())
+		fs.PrintDefaults()
+	}
+
+	args, err := parseCommandArgs(fs, arguments)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if len(args) < cmd.requiredArgs() || len(args) > len(cmd.args) {
+		fmt.Fprintf(os.Stderr, "Usage: %s\n", cmd.usage())
+		return 2
+	}
+
+	if err := initializeEnvironment(); err != nil {
+		fmt.Fprintf(os.Stderr, "Environment initialization failed: %v\n", err)
+		return 1
+	}
+	workflowService, err := initializeWorkflowService()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Initialize workflow service failed: %v\n", err)
+		return 1
+	}
+
+	if err := cmd.run(workflowService, args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parseCommandArgs 解析参数, 参数可以写在位置参数的前面或者后面
+func parseCommandArgs(fs *flag.FlagSet, arguments []string) ([]string, error) {
+	var args []string
+	for {
+		if err := fs.Parse(arguments); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return args, nil
+		}
+		args = append(args, fs.Arg(0))
+		arguments = fs.Args()[1:]
+	}
+}
+
+// validateWorkflow 校验工作流定义文件
+func validateWorkflow(workflowService *service.WorkflowService, args []string) error {
+	if workflowFile == "" {
+		return fmt.Errorf("workflow file is required")
+	}
+	data, err := readDefinitionFile(workflowFile)
+	if err != nil {
+		return err
+	}
+
+	errs := workflowService.ValidateWorkflow(utils.BytesToJsonStr(data))
+	if len(errs) == 0 {
+		fmt.Printf("%s is valid\n", workflowFile)
+		return nil
+	}
+	for _, err := range errs {
+		fmt.Printf("  - %s\n", err)
+	}
+	return fmt.Errorf("%s has %d error(s)", workflowFile, len(errs))
+}
+
+// runWorkflow 执行工作流并等待结束
+func runWorkflow(workflowService *service.WorkflowService, args []string) error {
+	printInterruptedWorkflows(workflowService)
+	instID, err := executeWorkflow(workflowService)
+	if err != nil {
+		return err
+	}
+	return waitWorkflow(workflowService, instID)
+}
+
+// listWorkflows 列出工作流实例
+func listWorkflows(workflowService *service.WorkflowService, args []string) error {
+	var status entity.InstStatus
+	if listStatus != "" {
+		if status = entity.GetInstStatusByStrValue(listStatus); status.IntValue() == 0 {
+			return fmt.Errorf("unknown status %s", listStatus)
+		}
+	}
+	insts, total, err := workflowService.ListWorkflows(status, listName, listLimit)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INST_ID\tNAME\tSTATUS\tSTART_AT\tCOMPLETED_AT\tPARENT_INST_ID")
+	for _, inst := range insts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", inst.InstID, inst.WorkflowDef.Name, inst.Status,
+			formatTime(inst.StartAt), formatTime(inst.CompletedAt), inst.ParentInstID)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nShowing %d of %d instance(s)\n", len(insts), total)
+	return nil
+}
+
+// inspectWorkflow 展示工作流实例和节点实例的详情
+func inspectWorkflow(workflowService *service.WorkflowService, args []string) error {
+	inst, err := workflowService.GetWorkflowStatus(args[0])
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Instance:\t%s\n", inst.InstID)
+	fmt.Fprintf(w, "Workflow:\t%s (def %s, version %d)\n", inst.WorkflowDef.Name, inst.WorkflowDef.DefID,
+		inst.WorkflowDef.Version)
+	fmt.Fprintf(w, "Status:\t%s\n", inst.Status)
+	fmt.Fprintf(w, "Start at:\t%s\n", formatTime(inst.StartAt))
+	fmt.Fprintf(w, "Completed at:\t%s\n", formatTime(inst.CompletedAt))
+	fmt.Fprintf(w, "Input:\t%s\n", formatValue(inst.Input))
+	fmt.Fprintf(w, "Output:\t%s\n", formatValue(inst.Output))
+	if reason := inst.Reason.FailedRootCause.FailedReason; reason != "" {
+		fmt.Fprintf(w, "Failed reason:\t%s\n", reason)
+	}
+	if len(inst.SkipNodes) > 0 {
+		fmt.Fprintf(w, "Skip nodes:\t%s\n", strings.Join(inst.SkipNodes, ", "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	printExecutionPath(inst.ExecutePath)
+
+	nodeInsts := inst.SchedNodeInsts
+	sort.Slice(nodeInsts, func(i, j int) bool { return nodeInsts[i].ScheduledAt.Before(nodeInsts[j].ScheduledAt) })
+	fmt.Println("Node instances:")
+	for _, nodeInst := range nodeInsts {
+		fmt.Printf("\n  %s [%s] %s\n", nodeInst.BasicNodeDef.RefName, nodeInst.NodeInstID, nodeInst.Status)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "    Type:\t%s\n", nodeInst.BasicNodeDef.Type)
+		fmt.Fprintf(w, "    Execute at:\t%s\n", formatTime(nodeInst.ExecuteAt))
+		fmt.Fprintf(w, "    Completed at:\t%s\n", formatTime(nodeInst.CompletedAt))
+		if nodeInst.RetryCount > 0 {
+			fmt.Fprintf(w, "    Retry count:\t%d\n", nodeInst.RetryCount)
+		}
+		fmt.Fprintf(w, "    Input:\t%s\n", formatValue(nodeInst.Input))
+		fmt.Fprintf(w, "    Output:\t%s\n", formatValue(nodeInst.Output))
+		for _, reason := range getNodeReasons(nodeInst.Reason) {
+			fmt.Fprintf(w, "    %s\n", reason)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getNodeReasons 获取节点实例中不为空的原因
+func getNodeReasons(reason *entity.NodeReason) []string {
+	if reason == nil {
+		return nil
+	}
+	var r []string
+	for _, item := range []struct{ name, value string }{
+		{"Run reason", reason.RunReason},
+		{"Rerun reason", reason.RerunReason},
+		{"Succeed reason", reason.SucceedReason},
+		{"Cancelled reason", reason.CancelledReason},
+		{"Failed reason", reason.FailedReason},
+		{"Timeout reason", reason.TimeoutReason},
+		{"Poll failed reason", reason.PollFailedReason},
+	} {
+		if item.value != "" {
+			r = append(r, fmt.Sprintf("%s:\t%s", item.name, item.value))
+		}
+	}
+	return r
+}
+
+// resumeWorkflow 恢复工作流实例并等待结束
+func resumeWorkflow(workflowService *service.WorkflowService, args []string) error {
+	if err := workflowService.ResumeWorkflow(args[0]); err != nil {
+		return err
+	}
+	return waitWorkflow(workflowService, args[0])
+}
+
+// cancelWorkflow 取消工作流实例
+func cancelWorkflow(workflowService *service.WorkflowService, args []string) error {
+	if err := workflowService.CancelWorkflow(args[0], cancelReason); err != nil {
+		return err
+	}
+	fmt.Printf("Workflow instance %s cancelled\n", args[0])
+	return nil
+}
+
+// skipNode 跳过节点, 实例还在执行中时等待结束
+func skipNode(workflowService *service.WorkflowService, args []string) error {
+	running, err := workflowService.SkipNode(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if !running {
+		fmt.Printf("Node %s will be skipped when workflow instance %s is restarted\n", args[1], args[0])
+		return nil
+	}
+	return waitWorkflow(workflowService, args[0])
+}
+
+// rerunNode 重跑节点并等待实例结束
+func rerunNode(workflowService *service.WorkflowService, args []string) error {
+	if err := workflowService.RerunNode(args[0], args[1]); err != nil {
+		return err
+	}
+	return waitWorkflow(workflowService, args[0])
+}
+
+// renderGraph 渲染工作流定义文件或者工作流实例的流程图
+func renderGraph(workflowService *service.WorkflowService, args []string) error {
+	format, err := graph.GetFormat(graphFormat)
+	if err != nil {
+		return err
+	}
+
+	var data string
+	switch {
+	case len(args) > 0:
+		data, err = workflowService.GetWorkflowGraph(args[0], format)
+	case workflowFile != "":
+		var def []byte
+		if def, err = readDefinitionFile(workflowFile); err != nil {
+			return err
+		}
+		data, err = workflowService.GetDefinitionGraph(utils.BytesToJsonStr(def), format)
+	default:
+		return fmt.Errorf("workflow file or instance ID is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	if graphOutput == "" {
+		fmt.Print(data)
+		return nil
+	}
+	if err := os.WriteFile(graphOutput, []byte(data), 0644); err != nil {
+		return fmt.Errorf("Failed to write graph file: %w", err)
+	}
+	fmt.Printf("Graph written to %s\n", graphOutput)
+	return nil
+}
+
+// serveWorkflows 常驻运行引擎, 调度定时触发器并通过 HTTP 接收触发器事件, 直到收到退出信号
+func serveWorkflows(workflowService *service.WorkflowService, args []string) error {
+	n, err := workflowService.ScheduleTimerTriggers()
+	if err != nil {
+		return fmt.Errorf("failed to schedule timer triggers: %w", err)
+	}
+	fmt.Printf("Scheduled %d timer trigger(s) of enabled workflow definitions\n", n)
+
+	if workflowFile != "" {
+		data, err := readDefinitionFile(workflowFile)
+		if err != nil {
+			return err
+		}
+		def, err := workflowService.DeployWorkflow(utils.BytesToJsonStr(data))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Deployed workflow definition %s, def_id: %s\n", def.Name, def.DefID)
+	}
+
+	webServer := web.NewServer(serveAddr, workflowService)
+	if err := webServer.Serve(); err != nil {
+		return fmt.Errorf("failed to serve on %s: %w", serveAddr, err)
+	}
+	fmt.Printf("Listening on http://%s, send trigger events with:\n", serveAddr)
+	fmt.Printf("  curl -X POST http://%s/engine/api/v1/event/sendtriggerevent "+
+		"-d '{\"key\": \"<event>\", \"value\": {}}'\n", serveAddr)
+	fmt.Println("Press Ctrl+C to exit fflow-cli")
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	shutdownGraceful(webServer.Close, factory.CloseEventClients)
+	return nil
+}
+
+// waitWorkflow 监控工作流实例直到结束, 实例没有成功时返回错误
+func waitWorkflow(workflowService *service.WorkflowService, instID string) error {
+	monitorWorkflow(workflowService, instID)
+	shutdownGraceful(factory.CloseEventClients)
+
+	inst, err := workflowService.GetWorkflowStatus(instID)
+	if err != nil {
+		return err
+	}
+	switch inst.Status {
+	case entity.InstSucceed:
+		return nil
+	case entity.InstRunning, entity.InstPaused:
+		return fmt.Errorf("workflow instance %s is interrupted, resume with `fflow-cli resume %s`",
+			instID, instID)
+	default:
+		return fmt.Errorf("workflow instance %s %s", instID, inst.Status)
+	}
+}
+
+// formatTime 格式化时间, 零值展示为 -
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+// formatValue 格式化输入输出, 空值展示为 -
+func formatValue(v map[string]interface{}) string {
+	if len(v) == 0 {
+		return "-"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// TestParseCommandArgs 测试参数写在位置参数的前面或者后面都可以解析
+func TestParseCommandArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments []string
+		wantArgs  []string
+		wantFile  string
+		wantWait  bool
+		wantErr   bool
+	}{
+		{"没有参数", nil, nil, "", false, false},
+		{"参数在位置参数前面", []string{"-f", "a.json", "-wait", "1"}, []string{"1"}, "a.json", true, false},
+		{"参数在位置参数后面", []string{"1", "-f", "a.json", "-wait"}, []string{"1"}, "a.json", true, false},
+		{"参数在位置参数中间", []string{"1", "-f", "a.json", "2"}, []string{"1", "2"}, "a.json", false, false},
+		{"-- 之后的都是位置参数", []string{"1", "--", "-wait"}, []string{"1", "-wait"}, "", false, false},
+		{"未知参数", []string{"1", "-unknown"}, nil, "", false, true},
+		{"参数缺少值", []string{"1", "-f"}, nil, "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			file := fs.String("f", "", "")
+			wait := fs.Bool("wait", false, "")
+
+			args, err := parseCommandArgs(fs, tt.arguments)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCommandArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) || *file != tt.wantFile || *wait != tt.wantWait {
+				t.Errorf("parseCommandArgs() = %q, file = %q, wait = %v, want %q, %q, %v",
+					args, *file, *wait, tt.wantArgs, tt.wantFile, tt.wantWait)
+			}
+		})
+	}
+}
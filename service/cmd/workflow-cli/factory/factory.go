@@ -4,6 +4,7 @@ package factory
 import (
 	"fmt"
 
+	"github.com/fflow-tech/fflow/service/internal/foundation/faas/pkg/runtimecontext"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/mq/memory"
 	memorymq "github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/mq/memory"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/storage/sql"
@@ -25,6 +26,7 @@ import (
 	"github.com/fflow-tech/fflow/service/pkg/provider"
 	"github.com/fflow-tech/fflow/service/pkg/registry"
 	"github.com/fflow-tech/fflow/service/pkg/remote"
+	"github.com/fflow-tech/fflow/service/pkg/script"
 	localsqlite "github.com/fflow-tech/fflow/service/pkg/sqlite"
 	"go.uber.org/dig"
 )
@@ -39,6 +41,9 @@ type Options struct {
 	ConfigClientType   pconfig.ProviderType
 	ConsulConfig       consul.Config
 	K8sConfig          k8s.Config
+	DBPath             string // 本地数据库文件路径, 为空时使用内存数据库
+	FunctionDir        string // 本地 FAAS 函数目录
+	StoragePath        string // FAAS 函数持久化存储的文件路径, 为空时只保存在内存中
 }
 
 // Option 选项方法
@@ -74,6 +79,27 @@ func WithK8sConfig(c k8s.Config) Option {
 	}
 }
 
+// WithDBPath 本地数据库文件路径
+func WithDBPath(path string) Option {
+	return func(o *Options) {
+		o.DBPath = path
+	}
+}
+
+// WithFunctionDir 本地 FAAS 函数目录
+func WithFunctionDir(dir string) Option {
+	return func(o *Options) {
+		o.FunctionDir = dir
+	}
+}
+
+// WithStoragePath FAAS 函数持久化存储的文件路径
+func WithStoragePath(path string) Option {
+	return func(o *Options) {
+		o.StoragePath = path
+	}
+}
+
 // New 初始化工厂
 func New(opts ...Option) error {
 	options := NewOptions(opts...)
@@ -88,7 +114,7 @@ func New(opts ...Option) error {
 	}
 
 	// 0. 提供底层的 DAO
-	provideDAO(container)
+	provideDAO(container, options)
 	// 1. 提供事件的客户端
 	provideEventClient(container)
 	// 2. 提供仓储层
@@ -130,6 +156,7 @@ func provideRepo(container *dig.Container) {
 	container.Provide(repo.NewEventBusRepoWithMemory)
 	container.Provide(repo.NewRemoteRepo)
 	container.Provide(repo.NewTriggerRepo)
+	container.Provide(repo.NewSecretRepo)
 	container.Provide(ports.NewRepoSet)
 }
 
@@ -162,7 +189,9 @@ func provideDomainService(container *dig.Container) {
 	container.Provide(command.NewNodeInstCommandService)
 	container.Provide(command.NewExternalEventCommandService)
 	container.Provide(command.NewWorkflowTriggerCommandService)
+	container.Provide(command.NewSecretCommandService)
 	container.Provide(query.NewWorkflowDefQueryService)
+	container.Provide(query.NewSecretQueryService)
 	container.Provide(query.NewNodeInstQueryService)
 	container.Provide(query.NewWorkflowInstQueryService)
 
@@ -181,9 +210,14 @@ func provideEventClient(container *dig.Container) {
 	container.Provide(memorymq.NewDriveEventClient)
 }
 
-func provideDAO(container *dig.Container) {
+func provideDAO(container *dig.Container, options Options) {
 	container.Provide(config.GetMySQLConfig)
-	container.Provide(localsqlite.GetMySQLClient)
+	container.Provide(func(c pconfig.MySQLConfig) (*mysql.Client, error) {
+		if options.DBPath != "" {
+			return localsqlite.GetFileMySQLClient(options.DBPath, c)
+		}
+		return localsqlite.GetMySQLClient(c)
+	})
 
 	// 注册DAO
 	container.Provide(sql.NewWorkflowDefDAO)
@@ -192,17 +226,29 @@ func provideDAO(container *dig.Container) {
 	container.Provide(sql.NewHistoryNodeInstDAO)
 	container.Provide(sql.NewNodeInstDAO)
 	container.Provide(sql.NewTriggerDAO)
+	container.Provide(sql.NewSecretDAO)
 
 	// 使用内存缓存替代Redis
 	container.Provide(memory.NewCacheDAO)
 
 	// 替换远程调用为本地mock
 	container.Provide(config.GetDefaultAbilityCallerConfig)
-	container.Provide(remote.NewDefaultAbilityCaller)
-	container.Provide(remote.NewDefaultCronClient)
+	container.Provide(func() (remote.FAASCaller, error) {
+		storage, err := runtimecontext.NewLocalStorageClient(options.StoragePath)
+		if err != nil {
+			return nil, err
+		}
+		return script.NewFunctionCaller(options.FunctionDir, storage)
+	})
+	container.Provide(remote.NewLocalAbilityCaller)
+	container.Provide(remote.NewLocalCronClient)
+	container.Provide(config.GetDefaultTimerClientConfig)
+	container.Provide(remote.NewDefaultTimerClient)
 	container.Provide(remote.NewDefaultChatOpsClient)
 	container.Provide(remote.NewDefaultCloudEventClient)
 	container.Provide(remote.NewDefaultPermissionValidator)
+	container.Provide(remote.NewLocalRbacClient)
+	container.Provide(script.NewRunner)
 }
 
 // GetDomainService 获取领域服务
@@ -271,6 +317,50 @@ func GetNodeInstRepo() (*repo.NodeInstRepo, error) {
 	return r, nil
 }
 
+// GetTriggerRepo 获取触发器仓储
+func GetTriggerRepo() (*repo.TriggerRepo, error) {
+	r := &repo.TriggerRepo{}
+	if err := container.Invoke(func(t *repo.TriggerRepo) {
+		r = t
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCronTriggerRegistry 获取定时触发器注册中心
+func GetCronTriggerRegistry() (*trigger.DefaultCronTriggerRegistry, error) {
+	r := &trigger.DefaultCronTriggerRegistry{}
+	if err := container.Invoke(func(t *trigger.DefaultCronTriggerRegistry) {
+		r = t
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CloseEventClients 关闭内存消息队列和本地定时任务, 丢弃所有等待投递的延迟事件
+func CloseEventClients(ch chan struct{}) error {
+	defer close(ch)
+	return container.Invoke(func(d *memorymq.DriveEventClient, c *memorymq.CronEventClient,
+		r *remote.DefaultCronClient) error {
+		if err := d.Close(); err != nil {
+			return err
+		}
+		if err := c.Close(); err != nil {
+			return err
+		}
+		return r.Close()
+	})
+}
+
+// ServeCronJobs 本地定时任务到期时交给定时触发器处理
+func ServeCronJobs() error {
+	return container.Invoke(func(c *remote.DefaultCronClient, d *service.DomainService) {
+		c.SetHandler(d.Commands.CronCallBack)
+	})
+}
+
 func initK8sClient(options Options) error {
 	k8sConfig := options.K8sConfig
 	k8sClient, err := k8s.NewClient(k8sConfig)
@@ -20,7 +20,6 @@ import (
 	"github.com/fflow-tech/fflow/service/cmd/workflow-cli/service"
 	"github.com/fflow-tech/fflow/service/cmd/workflow-cli/service/event"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
-	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
 	"github.com/fflow-tech/fflow/service/pkg/config"
 	"github.com/fflow-tech/fflow/service/pkg/k8s"
 	"github.com/fflow-tech/fflow/service/pkg/log"
@@ -29,17 +28,52 @@ import (
 )
 
 var (
-	globalConfigName = flag.String("config-name", "app", "The global config name")
-	globalConfigType = flag.String("config-type", "yaml", "The global config type")
-	globalConfigPath = flag.String("config-dir", ".fflow/", "The global config path")
-	definitionPath   = flag.String("def-dir", ".fflow/definitions", "Workflow definition history directory")
-	instancePath     = flag.String("inst-dir", ".fflow/instances", "Workflow instance history directory")
-	workflowFile     = flag.String("f", "", "Workflow definition file path, e.g. examples/example-http.json")
-	inputFile        = flag.String("i", "", "Workflow input file path, e.g. examples/example-http-input.json")
-	showHelp         = flag.Bool("h", false, "Show help information")
+	globalConfigName string
+	globalConfigType string
+	globalConfigPath string
+	definitionPath   string
+	instancePath     string
+	dbPath           string
+	functionDir      string
+	storagePath      string
+	workflowFile     string
+	inputFile        string
+	resumeInstID     string
 )
 
+// addCommonFlags 注册所有命令共用的参数
+func addCommonFlags(fs *flag.FlagSet) {
+	fs.StringVar(&globalConfigName, "config-name", "app", "The global config name")
+	fs.StringVar(&globalConfigType, "config-type", "yaml", "The global config type")
+	fs.StringVar(&globalConfigPath, "config-dir", ".fflow/", "The global config path")
+	fs.StringVar(&definitionPath, "def-dir", ".fflow/definitions", "Workflow definition history directory")
+	fs.StringVar(&instancePath, "inst-dir", ".fflow/instances", "Workflow instance history directory")
+	fs.StringVar(&dbPath, "db", ".fflow/fflow.db", "Local database file path, use in-memory database if empty")
+	fs.StringVar(&functionDir, "func-dir", ".fflow/functions", "Local FAAS function directory, "+
+		"functions are loaded from <func-dir>/<namespace>/<func>.js|.go")
+	fs.StringVar(&storagePath, "storage", ".fflow/storage.json",
+		"Local FAAS function storage file path, use in-memory storage if empty")
+}
+
+// addWorkflowFileFlags 注册工作流定义和输入文件的参数
+func addWorkflowFileFlags(fs *flag.FlagSet) {
+	fs.StringVar(&workflowFile, "f", "", "Workflow definition file path, e.g. examples/example-http.json")
+	fs.StringVar(&inputFile, "i", "", "Workflow input file path, e.g. examples/example-http-input.json")
+}
+
 func main() {
+	// 第一个参数是子命令时按照子命令执行
+	if len(os.Args) > 1 {
+		if cmd, ok := getCommand(os.Args[1]); ok {
+			os.Exit(runCommand(cmd, os.Args[2:]))
+		}
+	}
+
+	// 兼容没有子命令的用法, 等同于 run 命令
+	addCommonFlags(flag.CommandLine)
+	addWorkflowFileFlags(flag.CommandLine)
+	flag.StringVar(&resumeInstID, "resume", "", "Resume the interrupted workflow instance with the given ID")
+	showHelp := flag.Bool("h", false, "Show help information")
 	flag.Parse()
 
 	// 显示帮助信息
@@ -61,8 +95,8 @@ func main() {
 		panic(err)
 	}
 
-	// 执行工作流
-	instId, err := executeWorkflow(workflowService)
+	// 恢复中断的工作流或者执行新的工作流
+	instId, err := resumeOrExecuteWorkflow(workflowService)
 	if err != nil {
 		log.Fatalf("Failed to execute workflow: %v", err)
 		panic(err)
@@ -70,17 +104,20 @@ func main() {
 
 	// 监控工作流执行
 	monitorWorkflow(workflowService, instId)
+
+	// 退出前丢弃还未投递的延迟事件
+	shutdownGraceful(factory.CloseEventClients)
 }
 
 // 初始化环境：工厂、数据库、事件服务器和目录
 func initializeEnvironment() error {
 	// 命令行模式下先创建一个空的 app.yaml 文件，如果存在这个文件则忽略
-	configFilePath := fmt.Sprintf("%s/%s.%s", *globalConfigPath, *globalConfigName, *globalConfigType)
+	configFilePath := fmt.Sprintf("%s/%s.%s", globalConfigPath, globalConfigName, globalConfigType)
 
 	// 检查文件是否已存在
 	if _, err := os.Stat(configFilePath); os.IsNotExist(err) {
 		// 确保目录存在
-		if err := ensureDir(*globalConfigPath); err != nil {
+		if err := ensureDir(globalConfigPath); err != nil {
 			return fmt.Errorf("failed to create config directory: %w", err)
 		}
 
@@ -95,10 +132,13 @@ func initializeEnvironment() error {
 	if err := factory.New(factory.WithRegistryClientType(registry.Kubernetes),
 		factory.WithConfigClientType(config.Kubernetes),
 		factory.WithK8sConfig(k8s.Config{
-			GlobalConfigName: *globalConfigName,
-			GlobalConfigType: *globalConfigType,
-			GlobalConfigPath: *globalConfigPath,
+			GlobalConfigName: globalConfigName,
+			GlobalConfigType: globalConfigType,
+			GlobalConfigPath: globalConfigPath,
 		}),
+		factory.WithDBPath(dbPath),
+		factory.WithFunctionDir(functionDir),
+		factory.WithStoragePath(storagePath),
 	); err != nil {
 		return fmt.Errorf("factory init failed: %w", err)
 	}
@@ -114,11 +154,16 @@ func initializeEnvironment() error {
 		return fmt.Errorf("event server not serve: %w", err)
 	}
 
+	// 本地定时任务到期时触发对应的定时触发器
+	if err := factory.ServeCronJobs(); err != nil {
+		return fmt.Errorf("cron jobs not serve: %w", err)
+	}
+
 	// 创建工作流目录
-	if err := ensureDir(*definitionPath); err != nil {
+	if err := ensureDir(definitionPath); err != nil {
 		return fmt.Errorf("failed to ensure definition directory: %w", err)
 	}
-	if err := ensureDir(*instancePath); err != nil {
+	if err := ensureDir(instancePath); err != nil {
 		return fmt.Errorf("failed to ensure instance directory: %w", err)
 	}
 
@@ -127,24 +172,59 @@ func initializeEnvironment() error {
 
 // 初始化工作流服务
 func initializeWorkflowService() (*service.WorkflowService, error) {
-	return service.NewWorkflowService(*definitionPath, *instancePath)
+	return service.NewWorkflowService(definitionPath, instancePath)
+}
+
+// 恢复中断的工作流, 没有指定要恢复的实例时执行新的工作流
+func resumeOrExecuteWorkflow(workflowService *service.WorkflowService) (string, error) {
+	if resumeInstID != "" {
+		if err := workflowService.ResumeWorkflow(resumeInstID); err != nil {
+			return "", fmt.Errorf("failed to resume workflow: %w", err)
+		}
+		return resumeInstID, nil
+	}
+
+	printInterruptedWorkflows(workflowService)
+	return executeWorkflow(workflowService)
+}
+
+// 打印中断的工作流实例, 提示用户可以恢复执行
+func printInterruptedWorkflows(workflowService *service.WorkflowService) {
+	insts, err := workflowService.GetInterruptedWorkflows()
+	if err != nil {
+		log.Warnf("Failed to get interrupted workflow instances: %v", err)
+		return
+	}
+
+	var printed bool
+	for _, inst := range insts {
+		// 子流程实例跟随父流程实例一起恢复
+		if inst.ParentInstID != "" {
+			continue
+		}
+		if !printed {
+			fmt.Println("Interrupted workflow instances, resume with `fflow-cli -resume <inst_id>`:")
+			printed = true
+		}
+		fmt.Printf("  %s\t%s\t%s\n", inst.InstID, inst.WorkflowDef.Name, inst.StartAt.Format(time.DateTime))
+	}
 }
 
 // 执行工作流
 func executeWorkflow(workflowService *service.WorkflowService) (string, error) {
 	// 检查工作流文件
-	if *workflowFile == "" {
+	if workflowFile == "" {
 		return "", fmt.Errorf("workflow file is required")
 	}
 
 	// 复制工作流定义文件到定义目录
-	defJson, err := copyWorkflowFile(*workflowFile, *definitionPath)
+	defJson, err := copyWorkflowFile(workflowFile, definitionPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to copy workflow definition file: %w", err)
 	}
 
 	// 读取输入文件
-	inputMap, err := readInputFile(*inputFile)
+	inputMap, err := readInputFile(inputFile)
 	if err != nil {
 		return "", fmt.Errorf("failed to process input file: %w", err)
 	}
@@ -160,6 +240,11 @@ func executeWorkflow(workflowService *service.WorkflowService) (string, error) {
 
 // 读取输入文件并转换为map
 func readInputFile(filePath string) (map[string]interface{}, error) {
+	// 没有指定输入文件时使用空的输入
+	if filePath == "" {
+		return map[string]interface{}{}, nil
+	}
+
 	input, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read input file: %w", err)
@@ -203,20 +288,25 @@ func monitorWorkflowStatus(workflowService *service.WorkflowService, instId stri
 		fmt.Printf("Workflow instance status: %v\n", inst.Status)
 		saveWorkflowInstance(inst)
 
-		// 如果工作流实例状态为完成，则退出
-		if inst.Status == entity.InstSucceed || inst.Status == entity.InstFailed {
+		// 如果工作流实例状态为结束，则退出
+		if inst.Status.IsTerminal() {
 			printExecutionPath(inst.ExecutePath)
 			fmt.Println("Workflow instance execute completed, status: ", inst.Status, ", exit fflow-cli")
 			close(quit)
+			return
 		}
 	}
 }
 
 // 保存工作流实例
 func saveWorkflowInstance(inst *dto.WorkflowInstDTO) {
-	workflowFileName := strings.TrimSuffix(filepath.Base(*workflowFile), filepath.Ext(filepath.Base(*workflowFile)))
+	workflowFileName := strings.TrimSuffix(filepath.Base(workflowFile), filepath.Ext(filepath.Base(workflowFile)))
+	// 恢复执行时没有指定定义文件, 使用流程的名称
+	if workflowFile == "" {
+		workflowFileName = inst.WorkflowDef.Name
+	}
 	instanceFileName := fmt.Sprintf("%s_%s.json", workflowFileName, inst.InstID)
-	instanceFilePath := filepath.Join(*instancePath, instanceFileName)
+	instanceFilePath := filepath.Join(instancePath, instanceFileName)
 
 	instanceData, err := json.MarshalIndent(inst, "", "  ")
 	if err != nil {
@@ -317,12 +407,25 @@ func shutdownGraceful(fs ...func(chan struct{}) error) {
 func printHelp() {
 	fmt.Println("FFlow Workflow CLI")
 	fmt.Println("\nUsage:")
-	fmt.Println("  fflow-cli [options]")
+	fmt.Println("  fflow-cli <command> [options] [args]")
+	fmt.Println("  fflow-cli [options]    same as the run command")
+	fmt.Println("\nCommands:")
+	for _, cmd := range commands {
+		fmt.Printf("  %-12s %s\n", cmd.name, cmd.desc)
+	}
 	fmt.Println("\nOptions:")
 	flag.PrintDefaults()
+	fmt.Println("\nUse \"fflow-cli <command> -h\" for more information about a command.")
 	fmt.Println("\nExamples:")
-	fmt.Println("  fflow-cli -f examples/example-http.json -i examples/example-http-input.json")
+	fmt.Println("  fflow-cli validate -f examples/example-http.yaml")
+	fmt.Println("  fflow-cli run -f examples/example-http.json -i examples/example-http-input.json")
+	fmt.Println("  fflow-cli list -status failed")
+	fmt.Println("  fflow-cli inspect 1")
+	fmt.Println("  fflow-cli rerun-node 1 node1")
+	fmt.Println("  fflow-cli serve -f examples/example-trigger.yaml -addr 127.0.0.1:8090")
+	fmt.Println("  fflow-cli run -f examples/example-faas.yaml -func-dir examples/functions")
 	fmt.Println("  fflow-cli -f examples/example-http.yaml -i examples/example-http-input.json")
+	fmt.Println("  fflow-cli -resume 1")
 }
 
 func printExecutionPath(path [][]string) {
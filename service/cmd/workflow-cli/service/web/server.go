@@ -0,0 +1,87 @@
+// Package web 提供命令行 serve 模式下的本地 http 入口
+package web
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/cmd/workflow-cli/service"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/pkg/constants"
+	"github.com/fflow-tech/fflow/service/pkg/errno"
+	"github.com/fflow-tech/fflow/service/pkg/log"
+	"github.com/gin-gonic/gin"
+)
+
+// shutdownTimeout 关闭时等待处理中请求的时间
+const shutdownTimeout = 3 * time.Second
+
+// Server 本地 Web 容器, 路径和引擎服务的接口保持一致
+type Server struct {
+	server          *http.Server
+	workflowService *service.WorkflowService
+}
+
+// NewServer 新建本地 Web 容器
+func NewServer(addr string, workflowService *service.WorkflowService) *Server {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	s := &Server{
+		server:          &http.Server{Addr: addr, Handler: router},
+		workflowService: workflowService,
+	}
+	basicRouter := router.Group("engine")
+	basicRouter.GET("ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, constants.NewSucceedWebRsp("pong"))
+	})
+	eventRouter := basicRouter.Group("api/v1/event")
+	eventRouter.POST("sendtriggerevent", s.SendTriggerEvent)
+	return s
+}
+
+// Serve 启动监听, 端口被占用等错误会直接返回
+func (s *Server) Serve() error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("Web server exit, caused by %s", err)
+		}
+	}()
+	return nil
+}
+
+// Close 关闭 Web 容器
+func (s *Server) Close(ch chan struct{}) error {
+	defer close(ch)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+// SendTriggerEvent 发送触发器事件
+func (s *Server) SendTriggerEvent(c *gin.Context) {
+	var req dto.SendTriggerEventDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, err.Error()))
+		return
+	}
+	if req.Key == "" {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.InvalidArgument, "key must not be empty"))
+		return
+	}
+
+	if err := s.workflowService.SendTriggerEvent(req.Key, req.Value); err != nil {
+		c.JSON(http.StatusOK, constants.NewFailedWebRspWithMsg(errno.Internal, err.Error()))
+		return
+	}
+	log.Infof("Trigger event sent: %s", req.Key)
+	c.JSON(http.StatusOK, constants.NewSucceedWebRsp(nil))
+}
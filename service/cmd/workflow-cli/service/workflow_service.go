@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"sync"
@@ -9,9 +10,18 @@ import (
 
 	"github.com/fflow-tech/fflow/service/cmd/workflow-cli/factory"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/validator"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/query/graph"
+	"github.com/fflow-tech/fflow/service/pkg/constants"
 	"github.com/fflow-tech/fflow/service/pkg/log"
 )
 
+const (
+	interruptedPageSize    = 100 // 分页查询中断实例和已激活定义的大小
+	maxTimerTriggersPerDef = 100 // 每个定义最多查询的定时触发器数量
+)
+
 // WorkflowService 提供工作流管理和执行的服务
 type WorkflowService struct {
 	definitionPath string
@@ -90,6 +100,353 @@ func (s *WorkflowService) GetWorkflowStatus(id string) (*dto.WorkflowInstDTO, er
 	})
 }
 
+// GetInterruptedWorkflows 获取本地数据库中因为进程退出而中断的工作流实例
+func (s *WorkflowService) GetInterruptedWorkflows() ([]*dto.WorkflowInstDTO, error) {
+	domainService, err := factory.GetDomainService()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get domain service: %w", err)
+	}
+
+	// 命令行模式下只有一个进程, 执行中的实例在进程启动时都是中断的
+	var r []*dto.WorkflowInstDTO
+	for pageIndex := 1; ; pageIndex++ {
+		insts, _, err := domainService.Queries.GetWorkflowInstList(context.Background(), &dto.GetWorkflowInstListDTO{
+			PageQuery: constants.NewPageQuery(pageIndex, interruptedPageSize),
+			Status:    entity.InstRunning,
+		})
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, insts...)
+		if len(insts) < interruptedPageSize {
+			return r, nil
+		}
+	}
+}
+
+// ResumeWorkflow 恢复暂停或者中断的工作流实例
+func (s *WorkflowService) ResumeWorkflow(instID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	domainService, err := factory.GetDomainService()
+	if err != nil {
+		return fmt.Errorf("Failed to get domain service: %w", err)
+	}
+	inst, err := s.GetWorkflowStatus(instID)
+	if err != nil {
+		return fmt.Errorf("Failed to get workflow instance: %w", err)
+	}
+
+	switch inst.Status {
+	case entity.InstPaused:
+		return domainService.Commands.ResumeWorkflowInst(context.Background(), &dto.ResumeWorkflowInstDTO{
+			DefID:  inst.WorkflowDef.DefID,
+			InstID: instID,
+		})
+	case entity.InstRunning:
+		return s.recoverWorkflow(instID)
+	default:
+		return fmt.Errorf("workflow instance %s is %s, only running or paused instance can be resumed",
+			instID, inst.Status)
+	}
+}
+
+// recoverWorkflow 恢复中断的工作流实例, 同时恢复它创建的子流程实例
+func (s *WorkflowService) recoverWorkflow(instID string) error {
+	domainService, err := factory.GetDomainService()
+	if err != nil {
+		return fmt.Errorf("Failed to get domain service: %w", err)
+	}
+
+	interrupted, err := s.GetInterruptedWorkflows()
+	if err != nil {
+		return fmt.Errorf("Failed to get interrupted workflow instances: %w", err)
+	}
+	toBeRecovered := getInstWithChildren(instID, interrupted)
+	if len(toBeRecovered) == 0 {
+		return fmt.Errorf("workflow instance %s is not interrupted", instID)
+	}
+
+	for _, inst := range toBeRecovered {
+		if err := domainService.Commands.RecoverWorkflowInst(context.Background(), &dto.RecoverWorkflowInstDTO{
+			DefID:  inst.WorkflowDef.DefID,
+			InstID: inst.InstID,
+		}); err != nil {
+			return fmt.Errorf("Failed to recover workflow instance %s: %w", inst.InstID, err)
+		}
+		log.Infof("Workflow instance recovered: %s", inst.InstID)
+	}
+	return nil
+}
+
+// ValidateWorkflow 校验工作流定义, 返回定义中所有的错误
+func (s *WorkflowService) ValidateWorkflow(defJson string) []error {
+	return validator.ValidateDefJsonErrors(defJson)
+}
+
+// GetDefinitionGraph 获取工作流定义的流程图
+func (s *WorkflowService) GetDefinitionGraph(defJson string, format graph.Format) (string, error) {
+	def := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), def); err != nil {
+		return "", fmt.Errorf("Failed to parse workflow definition: %w", err)
+	}
+	return graph.RenderDef(def, format)
+}
+
+// GetWorkflowGraph 获取工作流实例的流程图, 叠加执行路径和节点状态
+func (s *WorkflowService) GetWorkflowGraph(instID string, format graph.Format) (string, error) {
+	domainService, err := factory.GetDomainService()
+	if err != nil {
+		return "", fmt.Errorf("Failed to get domain service: %w", err)
+	}
+
+	return domainService.Queries.GetWorkflowInstGraph(context.Background(), &dto.GetWorkflowInstGraphDTO{
+		InstID: instID,
+		Format: string(format),
+	})
+}
+
+// ListWorkflows 按照状态和名称查询工作流实例, 最近创建的在前
+func (s *WorkflowService) ListWorkflows(status entity.InstStatus, name string, limit int) (
+	[]*dto.WorkflowInstDTO, int64, error) {
+	domainService, err := factory.GetDomainService()
+	if err != nil {
+		return nil, 0, fmt.Errorf("Failed to get domain service: %w", err)
+	}
+
+	return domainService.Queries.GetWorkflowInstList(context.Background(), &dto.GetWorkflowInstListDTO{
+		PageQuery: constants.NewPageQuery(1, limit),
+		Status:    status,
+		Name:      name,
+	})
+}
+
+// CancelWorkflow 取消工作流实例
+func (s *WorkflowService) CancelWorkflow(instID, reason string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	domainService, err := factory.GetDomainService()
+	if err != nil {
+		return fmt.Errorf("Failed to get domain service: %w", err)
+	}
+	inst, err := s.GetWorkflowStatus(instID)
+	if err != nil {
+		return fmt.Errorf("Failed to get workflow instance: %w", err)
+	}
+
+	return domainService.Commands.CancelWorkflowInst(context.Background(), &dto.CancelWorkflowInstDTO{
+		DefID:  inst.WorkflowDef.DefID,
+		InstID: instID,
+		Reason: reason,
+	})
+}
+
+// SkipNode 跳过工作流实例中的节点, 实例还在执行中时恢复执行, 返回实例是否继续执行
+func (s *WorkflowService) SkipNode(instID, refName string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	domainService, err := factory.GetDomainService()
+	if err != nil {
+		return false, fmt.Errorf("Failed to get domain service: %w", err)
+	}
+	inst, err := s.GetWorkflowStatus(instID)
+	if err != nil {
+		return false, fmt.Errorf("Failed to get workflow instance: %w", err)
+	}
+
+	if err := domainService.Commands.SkipNode(context.Background(), &dto.SkipNodeDTO{
+		DefID:       inst.WorkflowDef.DefID,
+		InstID:      instID,
+		NodeRefName: refName,
+	}); err != nil {
+		return false, err
+	}
+	if inst.Status != entity.InstRunning {
+		return false, nil
+	}
+	return true, s.recoverWorkflow(instID)
+}
+
+// RerunNode 重跑工作流实例中的节点, 已经结束的实例从该节点开始重启
+func (s *WorkflowService) RerunNode(instID, refName string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	domainService, err := factory.GetDomainService()
+	if err != nil {
+		return fmt.Errorf("Failed to get domain service: %w", err)
+	}
+	inst, err := s.GetWorkflowStatus(instID)
+	if err != nil {
+		return fmt.Errorf("Failed to get workflow instance: %w", err)
+	}
+
+	// 已经结束的实例重跑节点后不会继续调度后面的节点, 需要从该节点重启
+	if inst.Status.IsTerminal() {
+		return domainService.Commands.RestartWorkflowInst(context.Background(), &dto.RestartWorkflowInstDTO{
+			DefID:       inst.WorkflowDef.DefID,
+			InstID:      instID,
+			NodeRefName: refName,
+		})
+	}
+
+	// 先恢复中断的实例, 避免重跑的节点完成后重复驱动
+	if inst.Status == entity.InstRunning {
+		if err := s.recoverWorkflow(instID); err != nil {
+			return err
+		}
+	}
+	return domainService.Commands.RerunNode(context.Background(), &dto.RerunNodeDTO{
+		DefID:       inst.WorkflowDef.DefID,
+		InstID:      instID,
+		NodeRefName: refName,
+	})
+}
+
+// DeployWorkflow 创建并激活工作流定义, 同名的已激活定义会被去激活, 避免旧定义的触发器重复触发
+func (s *WorkflowService) DeployWorkflow(defJson string) (*dto.WorkflowDefDTO, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	domainService, err := factory.GetDomainService()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get domain service: %w", err)
+	}
+	ctx := context.Background()
+	defID, err := domainService.Commands.CreateWorkflowDef(ctx, &dto.CreateWorkflowDefDTO{DefJson: defJson})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to save workflow definition: %w", err)
+	}
+	def, err := domainService.Queries.GetWorkflowDefByDefID(ctx, &dto.GetWorkflowDefDTO{DefID: defID})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get workflow definition: %w", err)
+	}
+
+	enabledDefs, err := s.getEnabledWorkflowDefs(def.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, enabledDef := range enabledDefs {
+		// 名称是模糊匹配的, 子流程定义跟随父流程定义去激活
+		if enabledDef.Name != def.Name || enabledDef.ParentDefID != "" {
+			continue
+		}
+		if err := domainService.Commands.DisableWorkflowDef(ctx, &dto.DisableWorkflowDefDTO{
+			DefID: enabledDef.DefID,
+		}); err != nil {
+			return nil, fmt.Errorf("Failed to disable workflow definition %s: %w", enabledDef.DefID, err)
+		}
+		log.Infof("Workflow definition disabled: %s", enabledDef.DefID)
+	}
+
+	if err := domainService.Commands.EnableWorkflowDef(ctx, &dto.EnableWorkflowDefDTO{DefID: defID}); err != nil {
+		return nil, fmt.Errorf("Failed to enable workflow definition: %w", err)
+	}
+	log.Infof("Workflow definition enabled: %s", defID)
+	return def, nil
+}
+
+// ScheduleTimerTriggers 重新调度本地数据库中已激活的定时触发器, 返回调度的触发器数量
+// 内存消息队列中等待投递的定时事件和本地定时任务在进程退出时会丢失, 需要在启动时按照注册时的逻辑重新调度
+func (s *WorkflowService) ScheduleTimerTriggers() (int, error) {
+	triggerRepo, err := factory.GetTriggerRepo()
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get trigger repo: %w", err)
+	}
+	cronTriggerRegistry, err := factory.GetCronTriggerRegistry()
+	if err != nil {
+		return 0, fmt.Errorf("Failed to get cron trigger registry: %w", err)
+	}
+	defs, err := s.getEnabledWorkflowDefs("")
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, def := range defs {
+		triggers, err := triggerRepo.PageQuery(&dto.PageQueryTriggerDTO{
+			DefID:     def.DefID,
+			Type:      entity.Timer,
+			Status:    entity.EnabledTrigger,
+			PageQuery: constants.NewPageQuery(1, maxTimerTriggersPerDef),
+		})
+		if err != nil {
+			return n, fmt.Errorf("Failed to query timer triggers of %s: %w", def.DefID, err)
+		}
+		for _, trigger := range triggers {
+			if err := cronTriggerRegistry.Schedule(trigger); err != nil {
+				return n, err
+			}
+			log.Infof("Timer trigger %s of %s scheduled", trigger.RefName, def.DefID)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// SendTriggerEvent 发送触发器事件, 匹配事件名称的触发器会被触发
+func (s *WorkflowService) SendTriggerEvent(key string, value map[string]interface{}) error {
+	eventBusRepo, err := factory.GetEventBusRepo()
+	if err != nil {
+		return fmt.Errorf("Failed to get event bus repo: %w", err)
+	}
+	return eventBusRepo.SendTriggerEvent(context.Background(), key, value)
+}
+
+// getEnabledWorkflowDefs 获取已激活的工作流定义, 名称为空时获取全部
+func (s *WorkflowService) getEnabledWorkflowDefs(name string) ([]*dto.WorkflowDefDTO, error) {
+	domainService, err := factory.GetDomainService()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get domain service: %w", err)
+	}
+
+	var r []*dto.WorkflowDefDTO
+	for pageIndex := 1; ; pageIndex++ {
+		defs, _, err := domainService.Queries.GetWorkflowDefList(context.Background(), &dto.PageQueryWorkflowDefDTO{
+			Name:      name,
+			Status:    entity.Enabled.IntValue(),
+			PageQuery: constants.NewPageQuery(pageIndex, interruptedPageSize),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get enabled workflow definitions: %w", err)
+		}
+		r = append(r, defs...)
+		if len(defs) < interruptedPageSize {
+			return r, nil
+		}
+	}
+}
+
+// getInstWithChildren 获取指定的实例以及它所有的子孙实例
+func getInstWithChildren(instID string, insts []*dto.WorkflowInstDTO) []*dto.WorkflowInstDTO {
+	var r []*dto.WorkflowInstDTO
+	ids := map[string]bool{instID: true}
+	for _, inst := range insts {
+		if inst.InstID == instID {
+			r = append(r, inst)
+		}
+	}
+	if len(r) == 0 {
+		return nil
+	}
+
+	// 子流程实例在父流程实例之后创建, 逐层查找直到没有新的子孙实例
+	for found := true; found; {
+		found = false
+		for _, inst := range insts {
+			if !ids[inst.InstID] && ids[inst.ParentInstID] {
+				ids[inst.InstID] = true
+				r = append(r, inst)
+				found = true
+			}
+		}
+	}
+	return r
+}
+
 // 确保目录存在，如果不存在则创建
 func ensureDir(dir string) error {
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
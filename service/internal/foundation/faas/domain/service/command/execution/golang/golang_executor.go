@@ -1,6 +1,7 @@
 package golang
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"reflect"
@@ -69,12 +70,28 @@ func (e *golangExecutor) Execute(ctx faas.Context, code string, params map[strin
 		return nil, nil, errors.New(fmt.Sprintf("the function is not %v, which is illegal", constants.FunctionTypeStr))
 	}
 
-	// 执行函数并拿到返回值
+	// 执行函数并拿到返回值, context 结束时停止解释器中还在执行的代码
+	release := interruptOnDone(ctx.Context(), interpret)
+	defer release()
 	result, err = handler(ctx, params)
+	if ctxErr := ctx.Context().Err(); ctxErr != nil {
+		return nil, ctx.Logs(), fmt.Errorf("execute interrupted: %w", ctxErr)
+	}
 	logs = ctx.Logs()
 	return
 }
 
+// interruptOnDone context 结束时停止解释器中正在执行的代码, 避免死循环的脚本一直占用协程
+// yaegi 只能通过 EvalWithContext 停止解释器, 这里执行一个阻塞的语句来等待 context 结束
+// 调用标准库中阻塞的方法 (如 time.Sleep) 时无法中断, 需要等待方法返回
+func interruptOnDone(ctx context.Context, interpret *interp.Interpreter) (release func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		_, _ = interpret.EvalWithContext(ctx, "select {}")
+	}()
+	return cancel
+}
+
 // beforeExecute 执行前校验，如果要对要执行的代码做一些限制可以写在这里
 func (e *golangExecutor) beforeExecute() error {
 	return nil
@@ -1,6 +1,7 @@
 package js
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/fflow-tech/fflow-sdk-go/faas"
@@ -32,6 +33,12 @@ func (e *javascriptExecutor) Execute(ctx faas.Context, code string, params map[s
 	}()
 
 	vm := goja.New()
+	// context 结束时中断脚本的执行, 避免死循环的脚本一直占用协程
+	stop := context.AfterFunc(ctx.Context(), func() {
+		vm.Interrupt(ctx.Context().Err())
+	})
+	defer stop()
+
 	pgm, err := e.beforeExecute(ctx, vm, code)
 	if err != nil {
 		return
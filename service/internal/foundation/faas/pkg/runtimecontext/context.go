@@ -23,11 +23,17 @@ type RuntimeContext struct {
 
 // NewRuntimeContext 初始化一个 context
 func NewRuntimeContext(ctx context.Context, f *entity.Function, r *http.Request) RuntimeContext {
+	return NewRuntimeContextWithStorage(ctx, f, r, redis.GetClient(config.GetRedisConfig()))
+}
+
+// NewRuntimeContextWithStorage 初始化一个使用指定存储后端的 context
+func NewRuntimeContextWithStorage(ctx context.Context, f *entity.Function, r *http.Request,
+	client StorageClient) RuntimeContext {
 	return RuntimeContext{
 		ctx:      ctx,
 		metadata: &entity.Metadata{Function: f},
 		log:      newRuntimeLogger(ctx, f),
-		storage:  newStorage(ctx, f, redis.GetClient(config.GetRedisConfig())),
+		storage:  newStorage(ctx, f, client),
 		request:  r,
 	}
 }
@@ -0,0 +1,116 @@
+package runtimecontext
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrKeyNotFound key 不存在或者已经过期, 和 redis 返回 nil 的语义一致
+var ErrKeyNotFound = errors.New("key not found or expired")
+
+// LocalStorageClient 本地存储后端, 没有 redis 时使用, 文件路径不为空时每次修改都会写入文件
+type LocalStorageClient struct {
+	path  string
+	mutex sync.Mutex
+	items map[string]localItem
+}
+
+// localItem 本地存储的值和过期时间
+type localItem struct {
+	Value    string    `json:"value"`
+	ExpireAt time.Time `json:"expire_at"`
+}
+
+// NewLocalStorageClient 初始化本地存储后端, 文件存在时加载文件中的数据
+func NewLocalStorageClient(path string) (*LocalStorageClient, error) {
+	c := &LocalStorageClient{path: path, items: map[string]localItem{}}
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &c.items); err != nil {
+			return nil, fmt.Errorf("failed to load local storage %s: %w", path, err)
+		}
+	}
+	return c, nil
+}
+
+// Get 获取 key 的值
+func (c *LocalStorageClient) Get(ctx context.Context, key string) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	item, ok := c.items[key]
+	if !ok || time.Now().After(item.ExpireAt) {
+		return "", ErrKeyNotFound
+	}
+	return item.Value, nil
+}
+
+// Set 设置 key 的值, 过期时间单位为秒
+func (c *LocalStorageClient) Set(ctx context.Context, key, value string, expireTime int64) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.items[key] = localItem{Value: value, ExpireAt: time.Now().Add(time.Duration(expireTime) * time.Second)}
+	return c.save()
+}
+
+// Expire 设置 key 的过期时间, key 不存在时不做处理
+func (c *LocalStorageClient) Expire(ctx context.Context, key string, expireTime int64) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	item, ok := c.items[key]
+	if !ok || time.Now().After(item.ExpireAt) {
+		return nil
+	}
+	item.ExpireAt = time.Now().Add(time.Duration(expireTime) * time.Second)
+	c.items[key] = item
+	return c.save()
+}
+
+// Del 删除
+func (c *LocalStorageClient) Del(ctx context.Context, key string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.items, key)
+	return c.save()
+}
+
+// save 清理过期的 key 后写入文件, 先写临时文件再重命名, 避免进程退出时文件损坏
+func (c *LocalStorageClient) save() error {
+	if c.path == "" {
+		return nil
+	}
+	now := time.Now()
+	for key, item := range c.items {
+		if now.After(item.ExpireAt) {
+			delete(c.items, key)
+		}
+	}
+
+	data, err := json.Marshal(c.items)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.path)
+}
@@ -6,31 +6,38 @@ import (
 	"time"
 
 	"github.com/fflow-tech/fflow/service/internal/foundation/faas/domain/entity"
-	"github.com/fflow-tech/fflow/service/pkg/redis"
 	"github.com/fflow-tech/fflow/service/pkg/utils"
 )
 
 const timeForHalfYear = time.Hour * 24 * 30 * 6
 
-// Storage 利用 redis 实现持久化能力
+// StorageClient 持久化存储的后端, 默认使用 redis
+type StorageClient interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string, expireTime int64) error
+	Expire(ctx context.Context, key string, expireTime int64) error
+	Del(ctx context.Context, key string) error
+}
+
+// Storage 函数的持久化能力, 存储后端默认使用 redis
 type Storage struct {
-	ctx         context.Context
-	function    *entity.Function
-	redisClient *redis.Client
+	ctx      context.Context
+	function *entity.Function
+	client   StorageClient
 }
 
 // 获取一个 Storage 实例
-func newStorage(ctx context.Context, function *entity.Function, client *redis.Client) *Storage {
+func newStorage(ctx context.Context, function *entity.Function, client StorageClient) *Storage {
 	return &Storage{
-		ctx:         ctx,
-		function:    function,
-		redisClient: client,
+		ctx:      ctx,
+		function: function,
+		client:   client,
 	}
 }
 
 // Get 获取 key 的值
 func (s *Storage) Get(key string) (any, error) {
-	return s.redisClient.Get(context.Background(), s.getRealKey(key))
+	return s.client.Get(context.Background(), s.getRealKey(key))
 }
 
 // Set 设置 key 的值，如果没有设置过期时间，默认半年过期
@@ -47,17 +54,17 @@ func (s *Storage) Set(key string, value any, expireTime int64) error {
 	default:
 		realValue = utils.StructToJsonStr(value)
 	}
-	return s.redisClient.Set(context.Background(), s.getRealKey(key), realValue, t)
+	return s.client.Set(context.Background(), s.getRealKey(key), realValue, t)
 }
 
 // Expire 标记过期
 func (s *Storage) Expire(key string, expireTime int64) error {
-	return s.redisClient.Expire(context.Background(), s.getRealKey(key), expireTime)
+	return s.client.Expire(context.Background(), s.getRealKey(key), expireTime)
 }
 
 // Del 删除
 func (s *Storage) Del(key string) error {
-	return s.redisClient.Del(context.Background(), s.getRealKey(key))
+	return s.client.Del(context.Background(), s.getRealKey(key))
 }
 
 // getRealKey 获取拼装了默认的 key 前缀的真实 key
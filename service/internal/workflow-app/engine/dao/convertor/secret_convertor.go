@@ -0,0 +1,42 @@
+package convertor
+
+import (
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/storage/po"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+)
+
+var (
+	SecretConvertor = &secretConvertorImpl{} // 转换器
+)
+
+type secretConvertorImpl struct {
+}
+
+// ConvertCreateDTOToPO 转换
+func (*secretConvertorImpl) ConvertCreateDTOToPO(d *dto.CreateSecretDTO) *po.SecretPO {
+	return &po.SecretPO{
+		Namespace:   d.Namespace,
+		Name:        d.Name,
+		Value:       d.Value,
+		Description: d.Description,
+		Creator:     d.Operator,
+		Updater:     d.Operator,
+	}
+}
+
+// ConvertUpdateDTOToPO 转换
+func (*secretConvertorImpl) ConvertUpdateDTOToPO(d *dto.UpdateSecretDTO) *po.SecretPO {
+	return &po.SecretPO{
+		Value:       d.Value,
+		Description: d.Description,
+		Updater:     d.Operator,
+	}
+}
+
+// ConvertPageQueryDTOToPO 转换
+func (*secretConvertorImpl) ConvertPageQueryDTOToPO(d *dto.PageQuerySecretDTO) *po.SecretPO {
+	return &po.SecretPO{
+		Namespace: d.Namespace,
+		Name:      d.Name,
+	}
+}
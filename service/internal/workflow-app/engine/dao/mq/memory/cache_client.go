@@ -6,11 +6,15 @@ import (
 	"time"
 
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/cache"
+	"github.com/fflow-tech/fflow/service/pkg/redis"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
+	"github.com/go-redsync/redsync/v4"
 )
 
 // CacheDAO 内存缓存实现
 type CacheDAO struct {
 	data  map[string]cacheItem
+	locks map[string]*lockState
 	mutex sync.RWMutex
 }
 
@@ -22,7 +26,8 @@ type cacheItem struct {
 // NewCacheDAO 创建新的内存缓存实例
 func NewCacheDAO() *CacheDAO {
 	return &CacheDAO{
-		data: make(map[string]cacheItem),
+		data:  make(map[string]cacheItem),
+		locks: make(map[string]*lockState),
 	}
 }
 
@@ -37,11 +42,11 @@ func (m *CacheDAO) Set(key string, value string, ttl int64) error {
 	return nil
 }
 
-// SetNX 如果不存在则设置
+// SetNX 如果不存在则设置, 已经过期的 key 视为不存在
 func (m *CacheDAO) SetNX(key string, value string, ttl int64) (interface{}, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	if _, exists := m.data[key]; exists {
+	if item, exists := m.data[key]; exists && !time.Now().After(item.expireTime) {
 		return nil, errors.New("key already exists")
 	}
 	m.data[key] = cacheItem{
@@ -62,25 +67,86 @@ func (m *CacheDAO) Get(key string) (string, error) {
 	return item.value, nil
 }
 
-// GetDistributeLock 获取分布式锁
+// GetDistributeLock 获取分布式锁, 只尝试一次
 func (m *CacheDAO) GetDistributeLock(name string, expireTime time.Duration) cache.DistributeLock {
-	// 简单实现，返回一个空的锁
-	return &memoryDistributeLock{}
+	return m.newDistributeLock(name, expireTime, 1, 0)
 }
 
 // GetDistributeLockWithRetry 获取分布式锁，如果没有拿到的话会在一定时间内重试
 func (m *CacheDAO) GetDistributeLockWithRetry(name string, expireTime time.Duration, trys int, retryDelay time.Duration) cache.DistributeLock {
-	// 简单实现，返回一个空的锁
-	return &memoryDistributeLock{}
+	return m.newDistributeLock(name, expireTime, trys, retryDelay)
 }
 
-// memoryDistributeLock 内存分布式锁的简单实现
-type memoryDistributeLock struct{}
+func (m *CacheDAO) newDistributeLock(name string, expireTime time.Duration, trys int,
+	retryDelay time.Duration) *memoryDistributeLock {
+	if trys <= 0 {
+		trys = 1
+	}
+	return &memoryDistributeLock{dao: m, name: name, expireTime: expireTime, trys: trys, retryDelay: retryDelay}
+}
+
+// lockState 锁的持有状态
+type lockState struct {
+	owner      int // 持有锁的协程 ID
+	count      int // 重入次数
+	expireTime time.Time
+}
+
+// memoryDistributeLock 进程内的可重入锁, 过期和重试的语义与 redis 实现保持一致
+type memoryDistributeLock struct {
+	dao        *CacheDAO
+	name       string
+	expireTime time.Duration
+	trys       int
+	retryDelay time.Duration
+}
 
+// Lock 加锁, 同一个协程可以重入, 锁被占用时按照配置重试, 重试失败返回 redis.ErrFailedGetDLock
 func (l *memoryDistributeLock) Lock() error {
-	return nil
+	owner := utils.GetCurrentGoroutineID()
+	for i := 0; i < l.trys; i++ {
+		if i > 0 {
+			time.Sleep(l.retryDelay)
+		}
+		if l.tryLock(owner) {
+			return nil
+		}
+	}
+	return redis.ErrFailedGetDLock
+}
+
+func (l *memoryDistributeLock) tryLock(owner int) bool {
+	l.dao.mutex.Lock()
+	defer l.dao.mutex.Unlock()
+	state, exists := l.dao.locks[l.name]
+	if exists && time.Now().Before(state.expireTime) {
+		if state.owner != owner {
+			return false
+		}
+		state.count++
+		return true
+	}
+	// 锁不存在或者已经过期
+	l.dao.locks[l.name] = &lockState{owner: owner, count: 1, expireTime: time.Now().Add(l.expireTime)}
+	return true
 }
 
+// Unlock 解锁, 重入时只减少计数, 锁已经过期或者不属于当前协程时返回 redsync.ErrLockAlreadyExpired
 func (l *memoryDistributeLock) Unlock() (bool, error) {
+	owner := utils.GetCurrentGoroutineID()
+	l.dao.mutex.Lock()
+	defer l.dao.mutex.Unlock()
+	state, exists := l.dao.locks[l.name]
+	if !exists || state.owner != owner {
+		return false, redsync.ErrLockAlreadyExpired
+	}
+	if !time.Now().Before(state.expireTime) {
+		delete(l.dao.locks, l.name)
+		return false, redsync.ErrLockAlreadyExpired
+	}
+	state.count--
+	if state.count <= 0 {
+		delete(l.dao.locks, l.name)
+	}
 	return true, nil
 }
@@ -75,3 +75,102 @@ func TestCacheDAO_LockAndUnlock(t *testing.T) {
 		t.Fatalf("Lock after Unlock failed: %v", err)
 	}
 }
+
+func TestCacheDAO_SetNXExpired(t *testing.T) {
+	cache := NewCacheDAO()
+	key := "testKeyNXExpired"
+
+	if err := cache.Set(key, "oldValue", 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// 已经过期的 key 视为不存在
+	time.Sleep(10 * time.Millisecond)
+	if _, err := cache.SetNX(key, "newValue", 1); err != nil {
+		t.Fatalf("SetNX on expired key failed: %v", err)
+	}
+	got, err := cache.Get(key)
+	if err != nil || got != "newValue" {
+		t.Errorf("Get returned wrong value: got %v, err %v, want newValue", got, err)
+	}
+}
+
+func TestCacheDAO_DistributeLock(t *testing.T) {
+	tests := []struct {
+		name       string
+		expireTime time.Duration
+		trys       int
+		holdTime   time.Duration // 其他协程持有锁的时间
+		wantErr    bool
+	}{
+		{"锁被占用且不重试的情况", time.Second, 1, 200 * time.Millisecond, true},
+		{"锁被占用重试后拿到锁的情况", time.Second, 10, 50 * time.Millisecond, false},
+		{"锁过期后被其他协程拿到的情况", 30 * time.Millisecond, 5, time.Second, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewCacheDAO()
+			key := "testLockKey"
+
+			locked := make(chan struct{})
+			go func() {
+				lock := cache.GetDistributeLock(key, tt.expireTime)
+				if err := lock.Lock(); err != nil {
+					t.Errorf("Lock failed: %v", err)
+				}
+				close(locked)
+				time.Sleep(tt.holdTime)
+				lock.Unlock()
+			}()
+			<-locked
+
+			err := cache.GetDistributeLockWithRetry(key, tt.expireTime, tt.trys, 20*time.Millisecond).Lock()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Lock() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCacheDAO_ReentrantLock(t *testing.T) {
+	cache := NewCacheDAO()
+	key := "testReentrantLockKey"
+
+	// 同一个协程可以重入
+	outer := cache.GetDistributeLock(key, time.Second)
+	inner := cache.GetDistributeLock(key, time.Second)
+	if err := outer.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := inner.Lock(); err != nil {
+		t.Fatalf("Reentrant lock failed: %v", err)
+	}
+
+	// 内层解锁后仍然持有锁
+	if ok, err := inner.Unlock(); !ok || err != nil {
+		t.Fatalf("Unlock inner failed: %v", err)
+	}
+	tryLockInOtherRoutine := func() error {
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- cache.GetDistributeLock(key, time.Second).Lock()
+		}()
+		return <-errCh
+	}
+	if err := tryLockInOtherRoutine(); err == nil {
+		t.Fatal("Expected lock to be held after inner unlock")
+	}
+
+	// 外层解锁后其他协程可以拿到锁
+	if ok, err := outer.Unlock(); !ok || err != nil {
+		t.Fatalf("Unlock outer failed: %v", err)
+	}
+	if err := tryLockInOtherRoutine(); err != nil {
+		t.Fatalf("Expected lock to be released, got %v", err)
+	}
+
+	// 锁已经被其他协程持有时解锁失败
+	if ok, err := outer.Unlock(); ok || err == nil {
+		t.Error("Expected unlock of lock held by others to fail")
+	}
+}
@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"container/heap"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrDelayQueueClosed 延迟队列已经关闭
+var ErrDelayQueueClosed = errors.New("delay queue closed")
+
+// DelayQueue 基于最小堆的延迟队列, 到达投递时间后由后台协程投递, 不会阻塞发送方
+type DelayQueue struct {
+	mu     sync.Mutex
+	items  delayItemHeap
+	seq    uint64
+	wakeup chan struct{}
+	closed chan struct{}
+	once   sync.Once
+}
+
+// delayItem 延迟投递项
+type delayItem struct {
+	deliverAt time.Time
+	seq       uint64 // 投递时间相同时按照入队顺序投递
+	deliver   func()
+}
+
+// NewDelayQueue 创建延迟队列并启动后台投递协程
+func NewDelayQueue() *DelayQueue {
+	q := &DelayQueue{
+		wakeup: make(chan struct{}, 1),
+		closed: make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Push 在 deliverAt 时刻执行 deliver, deliverAt 已过期时会尽快执行
+func (q *DelayQueue) Push(deliverAt time.Time, deliver func()) error {
+	q.mu.Lock()
+	select {
+	case <-q.closed:
+		q.mu.Unlock()
+		return ErrDelayQueueClosed
+	default:
+	}
+	q.seq++
+	heap.Push(&q.items, &delayItem{deliverAt: deliverAt, seq: q.seq, deliver: deliver})
+	q.mu.Unlock()
+
+	// 唤醒后台协程重新计算下一次投递时间
+	select {
+	case q.wakeup <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Len 返回等待投递的数量
+func (q *DelayQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.Len()
+}
+
+// Close 关闭延迟队列, 丢弃所有等待投递的项
+func (q *DelayQueue) Close() {
+	q.once.Do(func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		close(q.closed)
+		q.items = nil
+	})
+}
+
+func (q *DelayQueue) run() {
+	for {
+		ready, wait := q.popReady(time.Now())
+		for _, deliver := range ready {
+			select {
+			case <-q.closed:
+				return
+			default:
+			}
+			deliver()
+		}
+
+		var timeout <-chan time.Time
+		var timer *time.Timer
+		if wait >= 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+		select {
+		case <-q.closed:
+		case <-q.wakeup:
+		case <-timeout:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		select {
+		case <-q.closed:
+			return
+		default:
+		}
+	}
+}
+
+// popReady 弹出所有已经到期的项, 并返回距离下一项到期的时间, 队列为空时返回 -1
+func (q *DelayQueue) popReady(now time.Time) ([]func(), time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var ready []func()
+	for q.items.Len() > 0 && !q.items[0].deliverAt.After(now) {
+		ready = append(ready, heap.Pop(&q.items).(*delayItem).deliver)
+	}
+	if q.items.Len() == 0 {
+		return ready, -1
+	}
+	return ready, q.items[0].deliverAt.Sub(now)
+}
+
+// delayItemHeap 按照投递时间排序的最小堆, 实现 heap.Interface
+type delayItemHeap []*delayItem
+
+// Len 长度
+func (h delayItemHeap) Len() int {
+	return len(h)
+}
+
+// Less 比较
+func (h delayItemHeap) Less(i, j int) bool {
+	if h[i].deliverAt.Equal(h[j].deliverAt) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].deliverAt.Before(h[j].deliverAt)
+}
+
+// Swap 交换
+func (h delayItemHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+// Push 入堆
+func (h *delayItemHeap) Push(x interface{}) {
+	*h = append(*h, x.(*delayItem))
+}
+
+// Pop 出堆
+func (h *delayItemHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelayQueue_Push(t *testing.T) {
+	tests := []struct {
+		name    string
+		delays  []time.Duration
+		wantSeq []int
+	}{
+		{"按照投递时间排序", []time.Duration{30 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond},
+			[]int{1, 2, 0}},
+		{"投递时间相同时按照入队顺序", []time.Duration{10 * time.Millisecond, 10 * time.Millisecond}, []int{0, 1}},
+		{"已经过期的立即投递", []time.Duration{20 * time.Millisecond, -time.Second}, []int{1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewDelayQueue()
+			defer q.Close()
+
+			now := time.Now()
+			got := make(chan int, len(tt.delays))
+			for i, delay := range tt.delays {
+				i := i
+				assert.NoError(t, q.Push(now.Add(delay), func() { got <- i }))
+			}
+			for _, want := range tt.wantSeq {
+				assert.Equal(t, want, <-got)
+			}
+			assert.Equal(t, 0, q.Len())
+		})
+	}
+}
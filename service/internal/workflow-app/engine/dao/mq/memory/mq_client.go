@@ -12,35 +12,79 @@ import (
 
 // Client 内存实现的 Client
 type Client struct {
-	messages map[string]chan interface{}
-	mu       sync.Mutex
+	messages   map[string]chan interface{}
+	mu         sync.Mutex
+	delayQueue *DelayQueue
 }
 
 // NewClient 创建一个新的 Client
 func NewClient() *Client {
 	return &Client{
-		messages: make(map[string]chan interface{}),
+		messages:   make(map[string]chan interface{}),
+		delayQueue: NewDelayQueue(),
 	}
 }
 
 // SendMessage 发送消息
 func (mc *Client) SendMessage(ctx context.Context, topic string, msg interface{}) (string, error) {
-	mc.mu.Lock()
-	defer mc.mu.Unlock()
-	if _, exists := mc.messages[topic]; !exists {
-		mc.messages[topic] = make(chan interface{}, 100) // 使用缓冲通道
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+
+	memoryMsg := NewBasicMemoryMessage(topic, msgBytes)
+	mc.publish(memoryMsg)
+	return memoryMsg.ID().String(), nil
+}
+
+// SendKeyMessage 发送指定 key 的消息, 消费者可以通过 key 区分事件
+func (mc *Client) SendKeyMessage(ctx context.Context, topic, key string, msg interface{}) (string, error) {
+	msgBytes, err := json.Marshal(msg)
+	if err != nil {
+		return "", err
 	}
 
+	memoryMsg := NewBasicMemoryMessage(topic, msgBytes)
+	memoryMsg.key = key
+	mc.publish(memoryMsg)
+	return memoryMsg.ID().String(), nil
+}
+
+// SendDelayMessage 在 deliverAt 时刻投递消息, 消息在发送时序列化, 不会阻塞调用方
+func (mc *Client) SendDelayMessage(ctx context.Context, topic string, deliverAt time.Time,
+	msg interface{}) (string, error) {
 	msgBytes, err := json.Marshal(msg)
 	if err != nil {
 		return "", err
 	}
 
 	memoryMsg := NewBasicMemoryMessage(topic, msgBytes)
-	mc.messages[topic] <- memoryMsg
+	if err := mc.delayQueue.Push(deliverAt, func() { mc.publish(memoryMsg) }); err != nil {
+		return "", err
+	}
 	return memoryMsg.ID().String(), nil
 }
 
+// DelayQueueLen 返回等待投递的延迟消息数量
+func (mc *Client) DelayQueueLen() int {
+	return mc.delayQueue.Len()
+}
+
+// Close 关闭客户端, 丢弃所有等待投递的延迟消息
+func (mc *Client) Close() error {
+	mc.delayQueue.Close()
+	return nil
+}
+
+func (mc *Client) publish(msg *Message) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if _, exists := mc.messages[msg.Topic()]; !exists {
+		mc.messages[msg.Topic()] = make(chan interface{}, 100) // 使用缓冲通道
+	}
+	mc.messages[msg.Topic()] <- msg
+}
+
 // NewConsumer 创建一个新的消费者
 func (mc *Client) NewConsumer(ctx context.Context, topic, group string, handle func(context.Context, interface{}) error) (mq.Consumer, error) {
 	mc.mu.Lock()
@@ -98,14 +142,23 @@ func (mdec *DriveEventClient) SendEvent(ctx context.Context, msg interface{}) er
 
 // SendDelayEvent 发送延迟事件
 func (mdec *DriveEventClient) SendDelayEvent(ctx context.Context, deliverAfter time.Duration, msg interface{}) error {
-	time.Sleep(deliverAfter)
-	return mdec.SendEvent(ctx, msg)
+	return mdec.SendPresetEvent(ctx, time.Now().Add(deliverAfter), msg)
 }
 
 // SendPresetEvent 发送预设事件
 func (mdec *DriveEventClient) SendPresetEvent(ctx context.Context, deliverAt time.Time, msg interface{}) error {
-	time.Sleep(time.Until(deliverAt))
-	return mdec.SendEvent(ctx, msg)
+	_, err := mdec.client.SendDelayMessage(ctx, "drive-event", deliverAt, msg)
+	return err
+}
+
+// DelayQueueLen 返回等待投递的延迟事件数量
+func (mdec *DriveEventClient) DelayQueueLen() int {
+	return mdec.client.DelayQueueLen()
+}
+
+// Close 关闭客户端, 丢弃所有等待投递的延迟事件
+func (mdec *DriveEventClient) Close() error {
+	return mdec.client.Close()
 }
 
 // GetEventType 获取事件类型
@@ -171,11 +224,20 @@ func (mcec *CronEventClient) NewConsumer(ctx context.Context, group string, hand
 
 // SendPresetEvent 发送预设事件
 func (mcec *CronEventClient) SendPresetEvent(ctx context.Context, deliverAt time.Time, msg interface{}) error {
-	time.Sleep(time.Until(deliverAt))
-	_, err := mcec.client.SendMessage(ctx, "cron-event", msg)
+	_, err := mcec.client.SendDelayMessage(ctx, "cron-event", deliverAt, msg)
 	return err
 }
 
+// DelayQueueLen 返回等待投递的预设事件数量
+func (mcec *CronEventClient) DelayQueueLen() int {
+	return mcec.client.DelayQueueLen()
+}
+
+// Close 关闭客户端, 丢弃所有等待投递的预设事件
+func (mcec *CronEventClient) Close() error {
+	return mcec.client.Close()
+}
+
 // GetEventType 获取事件类型
 func (mcec *CronEventClient) GetEventType(message interface{}) (string, error) {
 	return getEventType(message)
@@ -200,7 +262,7 @@ func (mtec *TriggerEventClient) NewConsumer(ctx context.Context, group string, h
 
 // SendEvent 发送事件
 func (mtec *TriggerEventClient) SendEvent(ctx context.Context, key string, value interface{}) error {
-	_, err := mtec.client.SendMessage(ctx, "trigger-event", value)
+	_, err := mtec.client.SendKeyMessage(ctx, "trigger-event", key, value)
 	return err
 }
 
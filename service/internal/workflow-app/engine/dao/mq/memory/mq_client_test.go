@@ -49,12 +49,14 @@ func TestNewConsumer(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Create a consumer and handle messages
+	received := make(chan interface{}, 1)
 	consumer, err := client.NewConsumer(ctx, topic, "subName", func(ctx context.Context, message interface{}) error {
-		assert.Equal(t, msg, message)
+		received <- message
 		return nil
 	})
 	assert.NoError(t, err)
 	assert.NotNil(t, consumer)
+	assert.Equal(t, `"`+msg+`"`, string((<-received).(*Message).Payload()))
 }
 
 func TestDriveEventClient_SendEvent(t *testing.T) {
@@ -68,36 +70,77 @@ func TestDriveEventClient_SendEvent(t *testing.T) {
 
 func TestDriveEventClient_SendDelayEvent(t *testing.T) {
 	client := NewDriveEventClient()
+	defer client.Close()
 	ctx := context.Background()
 	msg := "delay-event-message"
 	delay := 1 * time.Second
 
-	start := time.Now()
-	err := client.SendDelayEvent(ctx, delay, msg)
-	duration := time.Since(start)
+	received := make(chan time.Time, 1)
+	_, err := client.NewConsumer(ctx, "drive-event", func(ctx context.Context, message interface{}) error {
+		received <- time.Now()
+		return nil
+	})
+	assert.NoError(t, err)
 
+	// 发送延迟事件不会阻塞调用方
+	start := time.Now()
+	err = client.SendDelayEvent(ctx, delay, msg)
 	assert.NoError(t, err)
-	assert.GreaterOrEqual(t, duration, delay)
+	assert.Less(t, time.Since(start), delay)
+	assert.Equal(t, 1, client.DelayQueueLen())
+
+	// 到达投递时间后才会被消费
+	receivedAt := <-received
+	assert.GreaterOrEqual(t, receivedAt.Sub(start), delay)
+	assert.Equal(t, 0, client.DelayQueueLen())
 }
 
 func TestDriveEventClient_SendPresetEvent(t *testing.T) {
 	client := NewDriveEventClient()
+	defer client.Close()
 	ctx := context.Background()
-	msg := "preset-event-message"
-	deliverAt := time.Now().Add(1 * time.Second)
-
-	start := time.Now()
-	err := client.SendPresetEvent(ctx, deliverAt, msg)
-	duration := time.Since(start)
+	now := time.Now()
 
+	received := make(chan string, 3)
+	_, err := client.NewConsumer(ctx, "drive-event", func(ctx context.Context, message interface{}) error {
+		received <- string(message.(*Message).Payload())
+		return nil
+	})
 	assert.NoError(t, err)
-	assert.GreaterOrEqual(t, duration, 1*time.Second)
-	consumer, err := client.NewConsumer(ctx, "drive-event", func(ctx context.Context, message interface{}) error {
-		assert.Equal(t, msg, message)
+
+	// 按照投递时间而不是发送顺序投递
+	assert.NoError(t, client.SendPresetEvent(ctx, now.Add(300*time.Millisecond), "third"))
+	assert.NoError(t, client.SendPresetEvent(ctx, now.Add(100*time.Millisecond), "first"))
+	assert.NoError(t, client.SendPresetEvent(ctx, now.Add(200*time.Millisecond), "second"))
+	assert.Equal(t, 3, client.DelayQueueLen())
+
+	assert.Equal(t, `"first"`, <-received)
+	assert.Equal(t, `"second"`, <-received)
+	assert.Equal(t, `"third"`, <-received)
+}
+
+func TestDriveEventClient_Close(t *testing.T) {
+	client := NewDriveEventClient()
+	ctx := context.Background()
+
+	received := make(chan interface{}, 1)
+	_, err := client.NewConsumer(ctx, "drive-event", func(ctx context.Context, message interface{}) error {
+		received <- message
 		return nil
 	})
 	assert.NoError(t, err)
-	assert.NotNil(t, consumer)
+
+	assert.NoError(t, client.SendDelayEvent(ctx, 200*time.Millisecond, "dropped-message"))
+	assert.NoError(t, client.Close())
+	assert.Equal(t, 0, client.DelayQueueLen())
+
+	// 关闭后等待投递的事件会被丢弃, 也不能再发送新的延迟事件
+	select {
+	case <-received:
+		t.Error("Expected pending event to be dropped after close")
+	case <-time.After(400 * time.Millisecond):
+	}
+	assert.ErrorIs(t, client.SendDelayEvent(ctx, time.Millisecond, "new-message"), ErrDelayQueueClosed)
 }
 
 func TestExternalEventClient_SendEvent(t *testing.T) {
@@ -111,22 +154,24 @@ func TestExternalEventClient_SendEvent(t *testing.T) {
 
 func TestCronEventClient_SendPresetEvent(t *testing.T) {
 	client := NewCronEventClient()
+	defer client.Close()
 	ctx := context.Background()
 	msg := "cron-event-message"
 	deliverAt := time.Now().Add(1 * time.Second)
 
-	start := time.Now()
-	err := client.SendPresetEvent(ctx, deliverAt, msg)
-	duration := time.Since(start)
-
-	assert.NoError(t, err)
-	assert.GreaterOrEqual(t, duration, 1*time.Second)
+	received := make(chan time.Time, 1)
 	consumer, err := client.NewConsumer(ctx, "cron-event", func(ctx context.Context, message interface{}) error {
-		assert.Equal(t, msg, message)
+		received <- time.Now()
 		return nil
 	})
 	assert.NoError(t, err)
 	assert.NotNil(t, consumer)
+
+	start := time.Now()
+	err = client.SendPresetEvent(ctx, deliverAt, msg)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 1*time.Second)
+	assert.False(t, (<-received).Before(deliverAt))
 }
 
 func TestTriggerEventClient_SendEvent(t *testing.T) {
@@ -138,3 +183,21 @@ func TestTriggerEventClient_SendEvent(t *testing.T) {
 	err := client.SendEvent(ctx, key, value)
 	assert.NoError(t, err)
 }
+
+func TestTriggerEventClient_SendEventWithKey(t *testing.T) {
+	client := NewTriggerEventClient()
+	ctx := context.Background()
+
+	received := make(chan interface{}, 1)
+	consumer, err := client.NewConsumer(ctx, "subName", func(ctx context.Context, message interface{}) error {
+		received <- message
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, consumer)
+
+	assert.NoError(t, client.SendEvent(ctx, "order_paid", map[string]interface{}{"orderId": "1"}))
+	msg := (<-received).(*Message)
+	assert.Equal(t, "order_paid", msg.Key())
+	assert.Equal(t, `{"orderId":"1"}`, string(msg.Payload()))
+}
@@ -0,0 +1,21 @@
+package po
+
+import (
+	"gorm.io/gorm"
+)
+
+// SecretPO 密钥
+type SecretPO struct {
+	gorm.Model
+	Namespace   string `gorm:"column:namespace;NOT NULL"` // 命名空间
+	Name        string `gorm:"column:name;NOT NULL"`      // 密钥名称
+	Value       string `gorm:"column:value;NOT NULL"`     // 加密后的密钥值
+	Description string `gorm:"column:description"`        // 密钥描述
+	Creator     string `gorm:"column:creator;NOT NULL"`   // 创建人
+	Updater     string `gorm:"column:updater"`            // 最后一次修改人
+}
+
+// TableName 密钥表名
+func (m *SecretPO) TableName() string {
+	return "secret"
+}
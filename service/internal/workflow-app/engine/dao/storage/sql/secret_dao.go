@@ -0,0 +1,131 @@
+package sql
+
+import (
+	"fmt"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/convertor"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/storage/po"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/pkg/log"
+	"github.com/fflow-tech/fflow/service/pkg/mysql"
+	"github.com/fflow-tech/fflow/service/pkg/seq"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// SecretDAO 密钥数据访问对象
+type SecretDAO struct {
+	db *mysql.Client
+}
+
+// NewSecretDAO 密钥数据访问对象构造函数
+func NewSecretDAO(db *mysql.Client) *SecretDAO {
+	return &SecretDAO{db: db}
+}
+
+// Transaction 事务
+func (dao *SecretDAO) Transaction(f func(*mysql.Client) error) error {
+	return dao.db.Transaction(func(tx *gorm.DB) error {
+		return f(mysql.NewClient(tx))
+	})
+}
+
+// Create 创建密钥
+func (dao *SecretDAO) Create(d *dto.CreateSecretDTO) (*po.SecretPO, error) {
+	id, err := seq.NewUint()
+	if err != nil {
+		return nil, err
+	}
+
+	p := convertor.SecretConvertor.ConvertCreateDTOToPO(d)
+	p.ID = id
+	if err := dao.db.Create(p).Error; err != nil {
+		log.Errorf("Failed to create secret, caused by %s", err)
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Get 获取密钥
+func (dao *SecretDAO) Get(d *dto.GetSecretDTO) (*po.SecretPO, error) {
+	if utils.IsZero(d.Namespace) || utils.IsZero(d.Name) {
+		return nil, fmt.Errorf("get secret `Namespace` and `Name` must not be zero, "+
+			"Namespace:[%s] Name:[%s]", d.Namespace, d.Name)
+	}
+
+	secret := &po.SecretPO{}
+	p := &po.SecretPO{Namespace: d.Namespace, Name: d.Name}
+	if err := dao.db.ReadFromSlave(false).Where(p).Take(secret).Error; err != nil {
+		log.Errorf("Failed to get secret, caused by %s", err)
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// Update 更新密钥
+func (dao *SecretDAO) Update(d *dto.UpdateSecretDTO) error {
+	if utils.IsZero(d.Namespace) || utils.IsZero(d.Name) {
+		return fmt.Errorf("update secret `Namespace` and `Name` must not be zero, "+
+			"Namespace:[%s] Name:[%s]", d.Namespace, d.Name)
+	}
+
+	p := convertor.SecretConvertor.ConvertUpdateDTOToPO(d)
+	if err := dao.db.Where("namespace = ? and name = ?", d.Namespace, d.Name).Updates(p).Error; err != nil {
+		log.Errorf("Failed to update secret, caused by %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// Delete 删除密钥, 直接物理删除以便可以重新创建同名密钥
+func (dao *SecretDAO) Delete(d *dto.DeleteSecretDTO) error {
+	if utils.IsZero(d.Namespace) || utils.IsZero(d.Name) {
+		return fmt.Errorf("delete secret `Namespace` and `Name` must not be zero, "+
+			"Namespace:[%s] Name:[%s]", d.Namespace, d.Name)
+	}
+
+	p := &po.SecretPO{Namespace: d.Namespace, Name: d.Name}
+	if err := dao.db.Unscoped().Where(p).Delete(&po.SecretPO{}).Error; err != nil {
+		log.Errorf("Failed to delete secret, caused by %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// PageQuery 分页查询密钥
+func (dao *SecretDAO) PageQuery(d *dto.PageQuerySecretDTO) ([]*po.SecretPO, error) {
+	if utils.IsZero(d.Namespace) {
+		return nil, fmt.Errorf("page query secret `Namespace` must not be zero, Namespace:[%s]", d.Namespace)
+	}
+
+	var secrets []*po.SecretPO
+	p := convertor.SecretConvertor.ConvertPageQueryDTOToPO(d)
+	if err := dao.db.ReadFromSlave(false).
+		Where(p).Order(d.OrderStr()).Offset(d.GetOffset()).Limit(d.GetLimit()).Find(&secrets).Error; err != nil {
+		log.Errorf("Failed to page query secrets, caused by %s", err)
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// Count 根据条件获取密钥总数
+func (dao *SecretDAO) Count(d *dto.PageQuerySecretDTO) (int64, error) {
+	if utils.IsZero(d.Namespace) {
+		return 0, fmt.Errorf("count secret `Namespace` must not be zero, Namespace:[%s]", d.Namespace)
+	}
+
+	var totalCount int64
+	p := convertor.SecretConvertor.ConvertPageQueryDTOToPO(d)
+	if err := dao.db.ReadFromSlave(false).
+		Model(&po.SecretPO{}).Where(p).Count(&totalCount).Error; err != nil {
+		log.Errorf("Failed to get secrets count, caused by %s", err)
+		return 0, err
+	}
+
+	return totalCount, nil
+}
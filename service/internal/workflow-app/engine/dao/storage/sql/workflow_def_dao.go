@@ -244,10 +244,11 @@ func (dao *WorkflowDefDAO) GetSubWorkflowLastVersion(d *dto.GetSubworkflowDefDTO
 	}
 
 	r := &po.WorkflowDefPO{}
+	// 使用 ->> 取出 JSON 中的值再比较, SQLite 中 -> 取出的是带引号的 JSON 文本, 和字符串参数比较时不相等
 	if err := dao.db.ReadFromSlave(false).
 		Where("parent_def_id = ?", d.ParentDefID).
-		Where("attribute -> '$.ref_name' = (?)", d.RefName).
-		Where("attribute -> '$.parent_def_version' = (?)", d.ParentDefVersion).
+		Where("attribute ->> '$.ref_name' = (?)", d.RefName).
+		Where("attribute ->> '$.parent_def_version' = (?)", d.ParentDefVersion).
 		Last(r).Error; err != nil {
 		log.Errorf("Failed to get subworkflow last version for workflow [%d][%d][%s], caused by %s",
 			d.ParentDefID, d.ParentDefVersion, d.RefName, err)
@@ -0,0 +1,63 @@
+package sql
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/storage/po"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/pkg/mysql"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// TestWorkflowDefDAO_GetSubWorkflowLastVersion 测试按照 attribute 中的引用名称和父流程版本查询子流程
+// 本地模式使用 SQLite, 需要用 ->> 取出 JSON 中的值再比较, -> 取出的是 JSON 文本, 和字符串参数比较时不相等
+func TestWorkflowDefDAO_GetSubWorkflowLastVersion(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "fflow.db")), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&po.WorkflowDefPO{}))
+	dao := NewWorkflowDefDAO(mysql.NewClient(db))
+
+	subworkflows := []*po.WorkflowDefPO{
+		{DefID: 2, ParentDefID: 1, Version: 1, Attribute: po.WorkflowDefAttr{RefName: "deploy", ParentDefVersion: 1}},
+		{DefID: 3, ParentDefID: 1, Version: 1, Attribute: po.WorkflowDefAttr{RefName: "deploy", ParentDefVersion: 2}},
+		{DefID: 3, ParentDefID: 1, Version: 2, Attribute: po.WorkflowDefAttr{RefName: "deploy", ParentDefVersion: 2}},
+		{DefID: 4, ParentDefID: 1, Version: 1, Attribute: po.WorkflowDefAttr{RefName: "notify", ParentDefVersion: 2}},
+	}
+	for _, subworkflow := range subworkflows {
+		assert.NoError(t, db.Create(subworkflow).Error)
+	}
+
+	tests := []struct {
+		name        string
+		req         *dto.GetSubworkflowDefDTO
+		wantDefID   uint64
+		wantVersion int
+		wantErr     bool
+	}{
+		{"获取父流程版本对应的子流程", &dto.GetSubworkflowDefDTO{ParentDefID: "1", ParentDefVersion: 1, RefName: "deploy"},
+			2, 1, false},
+		{"获取子流程的最新版本", &dto.GetSubworkflowDefDTO{ParentDefID: "1", ParentDefVersion: 2, RefName: "deploy"},
+			3, 2, false},
+		{"按照引用名称区分子流程", &dto.GetSubworkflowDefDTO{ParentDefID: "1", ParentDefVersion: 2, RefName: "notify"},
+			4, 1, false},
+		{"子流程不存在", &dto.GetSubworkflowDefDTO{ParentDefID: "1", ParentDefVersion: 3, RefName: "deploy"},
+			0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dao.GetSubWorkflowLastVersion(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetSubWorkflowLastVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.wantDefID, got.DefID)
+			assert.Equal(t, tt.wantVersion, got.Version)
+		})
+	}
+}
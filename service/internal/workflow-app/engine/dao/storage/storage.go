@@ -75,3 +75,14 @@ type TriggerDAO interface {
 	Count(d *dto.PageQueryTriggerDTO) (int64, error)
 	QueryTriggerByName(d *dto.QueryTriggerDTO) ([]*po.TriggerPO, error)
 }
+
+// SecretDAO 存储层接口
+type SecretDAO interface {
+	Transaction
+	Create(d *dto.CreateSecretDTO) (*po.SecretPO, error)
+	Get(d *dto.GetSecretDTO) (*po.SecretPO, error)
+	Update(d *dto.UpdateSecretDTO) error
+	Delete(d *dto.DeleteSecretDTO) error
+	PageQuery(d *dto.PageQuerySecretDTO) ([]*po.SecretPO, error)
+	Count(d *dto.PageQuerySecretDTO) (int64, error)
+}
@@ -128,6 +128,22 @@ func (c *eventConvertorImpl) ConvertEntityToNodeFailEvent(nodeInst *entity.NodeI
 	return externalEvent, nil
 }
 
+// ConvertEntityToNodeProgressEvent 转换
+func (c *eventConvertorImpl) ConvertEntityToNodeProgressEvent(nodeInst *entity.NodeInst,
+	seq int, delta string) (event.NodeProgressEvent, error) {
+	externalEvent := event.NodeProgressEvent{
+		BasicEvent: c.newNodeInstExternalBasicEvent(event.NodeProgress, nodeInst),
+		DefID:      nodeInst.DefID,
+		DefVersion: strconv.Itoa(nodeInst.DefVersion),
+		InstID:     nodeInst.InstID,
+		Node:       nodeInst.BasicNodeDef.RefName,
+		NodeInstID: nodeInst.NodeInstID,
+		Seq:        seq,
+		Delta:      delta,
+	}
+	return externalEvent, nil
+}
+
 // ConvertEntityToNodeCancelEvent 转换
 func (c *eventConvertorImpl) ConvertEntityToNodeCancelEvent(nodeInst *entity.NodeInst) (event.NodeCancelEvent, error) {
 	externalEvent := event.NodeCancelEvent{
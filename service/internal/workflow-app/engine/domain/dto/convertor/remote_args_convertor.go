@@ -5,6 +5,7 @@ import (
 
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
 	"github.com/fflow-tech/fflow/service/pkg/remote"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
 )
 
 var (
@@ -41,3 +42,19 @@ func (*abilityArgsConvertorImpl) ConvertEntityToCallFAASDTO(e *entity.FAASArgs)
 		MockMode:  e.MockMode,
 	}
 }
+
+// ConvertEntityToCallMCPDTO 转换，参数未对齐，手动转换
+func (*abilityArgsConvertorImpl) ConvertEntityToCallMCPDTO(e *entity.MCPArgs) *remote.CallMCPReqDTO {
+	arguments := e.Body
+	if arguments == nil {
+		arguments = utils.StringMapToInterfaceMap(e.Parameters)
+	}
+	return &remote.CallMCPReqDTO{
+		URL:       e.URL,
+		Transport: e.Transport,
+		Header:    e.Headers,
+		Tool:      e.Tool,
+		Arguments: arguments,
+		MockMode:  e.MockMode,
+	}
+}
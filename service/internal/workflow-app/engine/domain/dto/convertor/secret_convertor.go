@@ -0,0 +1,35 @@
+package convertor
+
+import (
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+)
+
+var (
+	SecretConvertor = &secretConvertorImpl{} // 转换器
+)
+
+type secretConvertorImpl struct {
+}
+
+// ConvertEntityToDTO 转换, 不包含密钥值
+func (*secretConvertorImpl) ConvertEntityToDTO(e *entity.Secret) *dto.SecretDTO {
+	return &dto.SecretDTO{
+		Namespace:   e.Namespace,
+		Name:        e.Name,
+		Description: e.Description,
+		Creator:     e.Creator,
+		Updater:     e.Updater,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
+
+// ConvertEntitiesToDTOs 批量转换
+func (c *secretConvertorImpl) ConvertEntitiesToDTOs(es []*entity.Secret) []*dto.SecretDTO {
+	ds := make([]*dto.SecretDTO, 0, len(es))
+	for _, e := range es {
+		ds = append(ds, c.ConvertEntityToDTO(e))
+	}
+	return ds
+}
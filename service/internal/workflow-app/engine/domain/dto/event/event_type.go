@@ -41,6 +41,7 @@ const (
 	NodeFail            ExternalEventType = "NodeFailEvent"            // 节点执行失败事件
 	NodeTimeout         ExternalEventType = "NodeTimeoutEvent"         // 节点超时事件
 	NodeNearTimeout     ExternalEventType = "NodeNearTimeoutEvent"     // 节点接近超时事件
+	NodeProgress        ExternalEventType = "NodeProgressEvent"        // 节点执行进度事件
 )
 
 var (
@@ -64,6 +65,7 @@ var (
 		NodeTimeout:     true,
 		NodeNearTimeout: true,
 		NodeAsynWait:    true,
+		NodeProgress:    true,
 	}
 )
 
@@ -89,12 +91,13 @@ func (t DriveEventType) String() string {
 
 // 内部驱动事件类型枚举
 const (
-	WorkflowStartDrive DriveEventType = "WorkflowStartDriveEvent" // 流程启动驱动事件
-	NodeScheduleDrive  DriveEventType = "NodeScheduleDriveEvent"  // 节点被调度驱动事件
-	NodeExecuteDrive   DriveEventType = "NodeExecuteDriveEvent"   // 节点执行驱动事件
-	NodePollDrive      DriveEventType = "NodePollDriveEvent"      // 节点轮询驱动事件
-	NodeCompleteDrive  DriveEventType = "NodeCompleteDriveEvent"  // 节点完成驱动事件
-	NodeRetryDrive     DriveEventType = "NodeRetryDriveEvent"     // 节点重试驱动事件
+	WorkflowStartDrive      DriveEventType = "WorkflowStartDriveEvent"      // 流程启动驱动事件
+	NodeScheduleDrive       DriveEventType = "NodeScheduleDriveEvent"       // 节点被调度驱动事件
+	NodeExecuteDrive        DriveEventType = "NodeExecuteDriveEvent"        // 节点执行驱动事件
+	NodePollDrive           DriveEventType = "NodePollDriveEvent"           // 节点轮询驱动事件
+	NodeCompleteDrive       DriveEventType = "NodeCompleteDriveEvent"       // 节点完成驱动事件
+	NodeRetryDrive          DriveEventType = "NodeRetryDriveEvent"          // 节点重试驱动事件
+	WorkflowCompensateDrive DriveEventType = "WorkflowCompensateDriveEvent" // 流程补偿驱动事件
 )
 
 // BasicEvent 基础信息
@@ -54,3 +54,10 @@ type NodeRetryDriveEvent struct {
 	InstID     string `json:"inst_id"`
 	NodeInstID string `json:"node_inst_id"`
 }
+
+// WorkflowCompensateDriveEvent 流程补偿事件
+type WorkflowCompensateDriveEvent struct {
+	BasicEvent
+	DefID  string `json:"def_id"`
+	InstID string `json:"inst_id"`
+}
@@ -149,6 +149,18 @@ type NodeFailEvent struct {
 	NodeInstID string `json:"node_inst_id"` // 节点实例ID
 }
 
+// NodeProgressEvent 节点执行进度事件, 用于流式输出等长时间执行的节点
+type NodeProgressEvent struct {
+	BasicEvent
+	DefID      string `json:"def_id"`       // 流程定义ID
+	DefVersion string `json:"def_version"`  // 流程定义版本号
+	InstID     string `json:"inst_id"`      // 流程实例ID
+	Node       string `json:"node"`         // 节点引用名称
+	NodeInstID string `json:"node_inst_id"` // 节点实例ID
+	Seq        int    `json:"seq"`          // 进度序号, 从 0 开始递增
+	Delta      string `json:"delta"`        // 本次新增的内容
+}
+
 // NodeWaitEvent 节点等待事件
 type NodeWaitEvent struct {
 	BasicEvent
@@ -149,6 +149,42 @@ type CompleteNodeDTO struct {
 	Reason      string                 `json:"reason,omitempty"` // 操作原因
 }
 
+// ApproveNodeDTO 审批通过节点
+type ApproveNodeDTO struct {
+	Namespace   string                 `json:"namespace,omitempty"`
+	DefID       string                 `json:"def_id,omitempty"`
+	InstID      string                 `json:"inst_id,omitempty"`
+	NodeInstID  string                 `json:"node_inst_id,omitempty"`
+	NodeRefName string                 `json:"node_ref_name,omitempty"`
+	Form        map[string]interface{} `json:"form,omitempty"` // 提交的审批表单, 作为节点的输出
+	Operator    string                 `json:"operator,omitempty"`
+	Reason      string                 `json:"reason,omitempty"` // 审批意见
+}
+
+// RejectNodeDTO 审批拒绝节点
+type RejectNodeDTO struct {
+	Namespace   string                 `json:"namespace,omitempty"`
+	DefID       string                 `json:"def_id,omitempty"`
+	InstID      string                 `json:"inst_id,omitempty"`
+	NodeInstID  string                 `json:"node_inst_id,omitempty"`
+	NodeRefName string                 `json:"node_ref_name,omitempty"`
+	Form        map[string]interface{} `json:"form,omitempty"` // 提交的审批表单, 作为节点的输出
+	Operator    string                 `json:"operator,omitempty"`
+	Reason      string                 `json:"reason,omitempty"` // 拒绝原因
+}
+
+// DelegateNodeDTO 转交审批节点
+type DelegateNodeDTO struct {
+	Namespace   string `json:"namespace,omitempty"`
+	DefID       string `json:"def_id,omitempty"`
+	InstID      string `json:"inst_id,omitempty"`
+	NodeInstID  string `json:"node_inst_id,omitempty"`
+	NodeRefName string `json:"node_ref_name,omitempty"`
+	Assignee    string `json:"assignee,omitempty"` // 被转交的审批人
+	Operator    string `json:"operator,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
 // PollingNodeDTO 轮询节点
 type PollingNodeDTO struct {
 	Namespace  string `json:"namespace,omitempty"`
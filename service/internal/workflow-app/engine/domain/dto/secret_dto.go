@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/fflow-tech/fflow/service/pkg/constants"
+)
+
+// SecretDTO 密钥, 不包含密钥值
+type SecretDTO struct {
+	Namespace   string    `json:"namespace,omitempty"`
+	Name        string    `json:"name,omitempty"`        // 密钥名称
+	Description string    `json:"description,omitempty"` // 密钥描述
+	Creator     string    `json:"creator,omitempty"`     // 创建人
+	Updater     string    `json:"updater,omitempty"`     // 最后一次修改人
+	CreatedAt   time.Time `json:"created_at"`            // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`            // 修改时间
+}
+
+// CreateSecretDTO 创建密钥请求
+type CreateSecretDTO struct {
+	Namespace   string `json:"namespace,omitempty" binding:"required"`
+	Name        string `json:"name,omitempty" binding:"required"`  // [必填] 密钥名称
+	Value       string `json:"value,omitempty" binding:"required"` // [必填] 密钥值
+	Description string `json:"description,omitempty"`              // 密钥描述
+	Operator    string `json:"operator,omitempty"`                 // 操作人
+}
+
+// UpdateSecretDTO 更新密钥请求
+type UpdateSecretDTO struct {
+	Namespace   string `json:"namespace,omitempty" binding:"required"`
+	Name        string `json:"name,omitempty" binding:"required"` // [必填] 密钥名称
+	Value       string `json:"value,omitempty"`                   // 密钥值, 为空时不修改
+	Description string `json:"description,omitempty"`             // 密钥描述
+	Operator    string `json:"operator,omitempty"`                // 操作人
+}
+
+// GetSecretDTO 获取密钥请求
+type GetSecretDTO struct {
+	Namespace string `form:"namespace" json:"namespace,omitempty"`
+	Name      string `form:"name" json:"name,omitempty"`
+}
+
+// DeleteSecretDTO 删除密钥请求
+type DeleteSecretDTO struct {
+	Namespace string `json:"namespace,omitempty" binding:"required"`
+	Name      string `json:"name,omitempty" binding:"required"` // [必填] 密钥名称
+	Operator  string `json:"operator,omitempty"`                // 操作人
+}
+
+// PageQuerySecretDTO 分页查询密钥请求
+type PageQuerySecretDTO struct {
+	Namespace string `form:"namespace" json:"namespace,omitempty"`
+	Name      string `form:"name" json:"name,omitempty"`
+	*constants.PageQuery
+	*constants.Order
+}
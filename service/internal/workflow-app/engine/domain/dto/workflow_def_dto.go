@@ -47,6 +47,15 @@ type GetWorkflowDefDTO struct {
 	ReadFromSlave bool   `json:"read_from_slave,omitempty"` // 是否从备库读取数据
 }
 
+// GetWorkflowDefGraphDTO 流程定义的流程图查询
+type GetWorkflowDefGraphDTO struct {
+	Namespace string `json:"namespace,omitempty"`
+	Creator   string `form:"creator" json:"creator,omitempty"`
+	DefID     string `form:"def_id" json:"def_id,omitempty" binding:"required"`
+	Version   int    `form:"version" json:"version,omitempty"` // 为空时查询最新版本
+	Format    string `form:"format" json:"format,omitempty"`   // mermaid 或者 dot, 默认为 mermaid
+}
+
 // GetSubworkflowDefDTO 子流程定义查询
 type GetSubworkflowDefDTO struct {
 	Namespace        string `json:"namespace,omitempty"`
@@ -45,6 +45,15 @@ type CancelWorkflowInstDTO struct {
 	Reason    string `json:"reason,omitempty"`
 }
 
+// CompensateWorkflowInstDTO 补偿实例请求
+type CompensateWorkflowInstDTO struct {
+	Namespace string `json:"namespace,omitempty"`
+	DefID     string `json:"def_id,omitempty"`
+	InstID    string `json:"inst_id,omitempty" binding:"required"`
+	Operator  string `json:"operator,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
 // PauseWorkflowInstDTO 暂停实例请求
 type PauseWorkflowInstDTO struct {
 	Namespace string `json:"namespace,omitempty"`
@@ -63,6 +72,13 @@ type ResumeWorkflowInstDTO struct {
 	Reason    string `json:"reason,omitempty"`
 }
 
+// RecoverWorkflowInstDTO 恢复中断的实例请求
+type RecoverWorkflowInstDTO struct {
+	Namespace string `json:"namespace,omitempty"`
+	DefID     string `json:"def_id,omitempty"`
+	InstID    string `json:"inst_id,omitempty" binding:"required"`
+}
+
 // UpdateWorkflowInstCtxDTO 更新流程实例上下文请求
 type UpdateWorkflowInstCtxDTO struct {
 	Namespace string                 `json:"namespace,omitempty"`
@@ -137,6 +153,15 @@ type GetWorkflowInstDTO struct {
 	CurNodeInstID string `form:"cur_node_inst_id" json:"cur_node_inst_id,omitempty"`
 }
 
+// GetWorkflowInstGraphDTO 流程实例的流程图查询, 在流程定义的流程图上叠加执行路径和节点状态
+type GetWorkflowInstGraphDTO struct {
+	Namespace string `json:"namespace,omitempty"`
+	Operator  string `form:"operator" json:"operator,omitempty"`
+	InstID    string `form:"inst_id" json:"inst_id,omitempty" binding:"required"`
+	DefID     string `form:"def_id" json:"def_id,omitempty"`
+	Format    string `form:"format" json:"format,omitempty"` // mermaid 或者 dot, 默认为 mermaid
+}
+
 // GetWorkflowInstsByIDsDTO 查询请求
 type GetWorkflowInstsByIDsDTO struct {
 	Namespace string   `json:"namespace,omitempty"`
@@ -47,6 +47,151 @@ type NodeInst struct {
 	Nexts             []string               `json:"nexts,omitempty"`             // 节点所有可能的下一个节点
 	Parents           []string               `json:"parents,omitempty"`           // 节点所有的父节点
 	WaitForDebug      bool                   `json:"wait_for_debug,omitempty"`    // 因为调试阻塞
+	TokenUsage        *TokenUsage            `json:"token_usage,omitempty"`       // 大模型 token 用量
+	Error             *NodeError             `json:"error,omitempty"`             // 执行失败的错误信息
+	CompensateFor     string                 `json:"compensate_for,omitempty"`    // 被补偿的节点实例ID, 不为空表示当前是补偿节点实例
+	Approval          *Approval              `json:"approval,omitempty"`          // 审批节点的审批状态
+	TriggerID         string                 `json:"trigger_id,omitempty"`        // 等待事件节点注册的触发器ID
+	ChildInstIDs      []string               `json:"child_inst_ids,omitempty"`    // 动态子流程节点启动的子流程实例ID
+}
+
+// Approval 审批节点实例的审批状态
+type Approval struct {
+	Assignees   []string               `json:"assignees,omitempty"`   // 当前的审批人
+	Roles       []string               `json:"roles,omitempty"`       // 当前的审批角色
+	Form        map[string]interface{} `json:"form,omitempty"`        // 审批表单的 JSON Schema
+	Escalated   bool                   `json:"escalated,omitempty"`   // 是否已经升级
+	Delegations []*ApprovalDelegation  `json:"delegations,omitempty"` // 转交记录
+}
+
+// ApprovalDelegation 审批转交记录
+type ApprovalDelegation struct {
+	From   string    `json:"from,omitempty"`
+	To     string    `json:"to,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// IsAssignee 用户是否可以审批, operatorRoles 为用户在流程命名空间下的角色
+func (a *Approval) IsAssignee(operator string, operatorRoles []string) bool {
+	if operator == "" {
+		return false
+	}
+	if utils.StrContains(a.Assignees, operator) {
+		return true
+	}
+	for _, role := range operatorRoles {
+		if utils.StrContains(a.Roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delegate 转交审批, 审批人转交时替换为被转交人, 通过角色审批的用户转交时追加被转交人
+func (a *Approval) Delegate(from, to, reason string) {
+	assignees := []string{}
+	for _, assignee := range a.Assignees {
+		if assignee != from && assignee != to {
+			assignees = append(assignees, assignee)
+		}
+	}
+	a.Assignees = append(assignees, to)
+	a.Delegations = append(a.Delegations, &ApprovalDelegation{
+		From:   from,
+		To:     to,
+		Reason: reason,
+		At:     time.Now(),
+	})
+}
+
+// Escalate 升级审批, 追加升级的审批人和审批角色, 只会升级一次
+func (a *Approval) Escalate(assignees, roles []string) bool {
+	if a.Escalated {
+		return false
+	}
+	a.Escalated = true
+	a.Assignees = appendIfNotContains(a.Assignees, assignees...)
+	a.Roles = appendIfNotContains(a.Roles, roles...)
+	return true
+}
+
+func appendIfNotContains(s []string, elems ...string) []string {
+	for _, e := range elems {
+		if e != "" && !utils.StrContains(s, e) {
+			s = append(s, e)
+		}
+	}
+	return s
+}
+
+// NodeError 节点执行失败的错误信息
+type NodeError struct {
+	Class      ErrorClass `json:"class,omitempty"`
+	StatusCode int        `json:"status_code,omitempty"` // HTTP 错误时的状态码
+}
+
+// TokenUsage 大模型 token 用量
+type TokenUsage struct {
+	Model            string  `json:"model,omitempty"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost,omitempty"`
+}
+
+// Add 累加 token 用量
+func (u *TokenUsage) Add(other *TokenUsage) {
+	if other == nil {
+		return
+	}
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.Cost += other.Cost
+}
+
+// SumTokenUsage 汇总节点实例的 token 用量, 没有任何用量时返回 nil
+func SumTokenUsage(nodeInsts []*NodeInst) *TokenUsage {
+	var total *TokenUsage
+	for _, nodeInst := range nodeInsts {
+		if nodeInst == nil || nodeInst.TokenUsage == nil {
+			continue
+		}
+		if total == nil {
+			total = &TokenUsage{}
+		}
+		total.Add(nodeInst.TokenUsage)
+	}
+	return total
+}
+
+// GetNodeError 获取节点失败的错误信息, 节点没有失败时返回空
+func GetNodeError(nodeInst *NodeInst) *NodeError {
+	switch nodeInst.Status {
+	case NodeInstTimeout:
+		return &NodeError{Class: ErrorClassTimeout}
+	case NodeInstCancelled:
+		return &NodeError{Class: ErrorClassCancelled}
+	case NodeInstFailed:
+		if nodeInst.Error != nil {
+			return nodeInst.Error
+		}
+		return &NodeError{Class: ErrorClassError}
+	default:
+		return nil
+	}
+}
+
+// MatchErrorRoute 根据节点的错误信息获取第一个匹配的错误路由, 返回需要执行的节点
+func MatchErrorRoute(nodeInst *NodeInst) (string, bool) {
+	nodeErr := GetNodeError(nodeInst)
+	for _, route := range nodeInst.BasicNodeDef.OnError {
+		if route.Match(nodeErr) {
+			return route.Next, true
+		}
+	}
+	return "", false
 }
 
 // NodeReason 原因
@@ -396,6 +541,10 @@ func getAllConnectNextNodes(workflowDef *WorkflowDef, curBasicNodeDef *BasicNode
 		return getNextsIfNodeIsSwitchNode(workflowDef, curBasicNodeDef)
 	}
 
+	if curBasicNodeDef.Type == LoopNode {
+		return getNextsIfNodeIsLoopNode(workflowDef, curBasicNodeDef)
+	}
+
 	next, err := GetNextNode(workflowDef, curBasicNodeDef)
 	if err != nil {
 		return nil, err
@@ -403,6 +552,24 @@ func getAllConnectNextNodes(workflowDef *WorkflowDef, curBasicNodeDef *BasicNode
 	return []string{next}, nil
 }
 
+func getNextsIfNodeIsLoopNode(workflowDef *WorkflowDef, curBasicNodeDef *BasicNodeDef) ([]string, error) {
+	next, err := GetNextNode(workflowDef, curBasicNodeDef)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeDef, err := GetNodeDefByRefName(workflowDef, curBasicNodeDef.RefName)
+	if err != nil {
+		return nil, err
+	}
+
+	loopNodeDef := nodeDef.(LoopNodeDef)
+	if loopNodeDef.Body == "" || loopNodeDef.Body == next {
+		return []string{next}, nil
+	}
+	return []string{loopNodeDef.Body, next}, nil
+}
+
 func getNextsIfNodeIsForkNode(workflowDef *WorkflowDef, curBasicNodeDef *BasicNodeDef) ([]string, error) {
 	if curBasicNodeDef.Type != ForkNode {
 		next, err := GetNextNode(workflowDef, curBasicNodeDef)
@@ -474,6 +641,78 @@ func GetLastNodeInsts(nodeInsts []*NodeInst) []*NodeInst {
 	return r
 }
 
+// IsCompensation 是否为补偿节点实例
+func (n *NodeInst) IsCompensation() bool {
+	return n.CompensateFor != ""
+}
+
+// FilterOutCompensationNodeInsts 过滤掉补偿节点实例, 补偿节点实例不参与流程调度
+func FilterOutCompensationNodeInsts(nodeInsts []*NodeInst) []*NodeInst {
+	r := []*NodeInst{}
+	for _, nodeInst := range nodeInsts {
+		if !nodeInst.IsCompensation() {
+			r = append(r, nodeInst)
+		}
+	}
+	return r
+}
+
+// GetNodeInstsToBeCompensated 按执行路径逆序拿出需要补偿的节点实例
+// 只包含执行成功且还没有补偿成功的节点实例, 同一个节点的多次执行按节点实例ID顺序对应到执行路径中的多次出现
+func GetNodeInstsToBeCompensated(executePath [][]string, nodeInsts []*NodeInst) []*NodeInst {
+	compensated := map[string]bool{}
+	for _, nodeInst := range nodeInsts {
+		if nodeInst.IsCompensation() && nodeInst.Status == NodeInstSucceed {
+			compensated[nodeInst.CompensateFor] = true
+		}
+	}
+
+	// 计算每个节点在执行路径中每次出现的位置
+	positions := map[string][]int{}
+	index := 0
+	for _, refNames := range executePath {
+		for _, refName := range refNames {
+			positions[refName] = append(positions[refName], index)
+			index++
+		}
+	}
+
+	type positionedNodeInst struct {
+		position int
+		nodeInst *NodeInst
+	}
+	var candidates []positionedNodeInst
+	for refName, refNameNodeInsts := range GetRefNameNodeInstMap(FilterOutCompensationNodeInsts(nodeInsts)) {
+		SortByNodeInstIDAsc(refNameNodeInsts)
+		refNamePositions := positions[refName]
+		for i, nodeInst := range refNameNodeInsts {
+			if nodeInst.Status != NodeInstSucceed || compensated[nodeInst.NodeInstID] {
+				continue
+			}
+			// 重跑等没有出现在执行路径中的实例, 认为在该节点最后一次出现的位置执行
+			position := -1
+			if i < len(refNamePositions) {
+				position = refNamePositions[i]
+			} else if len(refNamePositions) > 0 {
+				position = refNamePositions[len(refNamePositions)-1]
+			}
+			candidates = append(candidates, positionedNodeInst{position: position, nodeInst: nodeInst})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].position != candidates[j].position {
+			return candidates[i].position > candidates[j].position
+		}
+		return candidates[i].nodeInst.NodeInstID > candidates[j].nodeInst.NodeInstID
+	})
+	r := []*NodeInst{}
+	for _, candidate := range candidates {
+		r = append(r, candidate.nodeInst)
+	}
+	return r
+}
+
 // GetOldestNodeInsts 拿出最老的节点执行实例, 每个节点只拿最先一次执行的实例
 func GetOldestNodeInsts(nodeInsts []*NodeInst) []*NodeInst {
 	r := []*NodeInst{}
@@ -207,3 +207,149 @@ var defJson = `
     ]
 }
 `
+
+// TestSumTokenUsage 测试汇总 token 用量
+func TestSumTokenUsage(t *testing.T) {
+	tests := []struct {
+		name      string
+		nodeInsts []*NodeInst
+		want      *TokenUsage
+	}{
+		{"没有节点实例的情况", nil, nil},
+		{"节点实例都没有用量的情况", []*NodeInst{{}, {}}, nil},
+		{"部分节点实例有用量的情况", []*NodeInst{
+			{TokenUsage: &TokenUsage{Model: "gpt-4o", PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, Cost: 0.1}},
+			{},
+			{TokenUsage: &TokenUsage{Model: "gpt-4o-mini", PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}},
+		}, &TokenUsage{PromptTokens: 11, CompletionTokens: 7, TotalTokens: 18, Cost: 0.1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SumTokenUsage(tt.nodeInsts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SumTokenUsage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGetNodeInstsToBeCompensated 测试按执行路径逆序获取需要补偿的节点实例
+func TestGetNodeInstsToBeCompensated(t *testing.T) {
+	newNodeInst := func(nodeInstID, refName string, status NodeInstStatus, compensateFor string) *NodeInst {
+		return &NodeInst{
+			NodeInstID:    nodeInstID,
+			BasicNodeDef:  BasicNodeDef{RefName: refName},
+			Status:        status,
+			CompensateFor: compensateFor,
+		}
+	}
+	tests := []struct {
+		name        string
+		executePath [][]string
+		nodeInsts   []*NodeInst
+		want        []string
+	}{
+		{"顺序执行逆序补偿", [][]string{{"a"}, {"b"}, {"c"}}, []*NodeInst{
+			newNodeInst("1", "a", NodeInstSucceed, ""),
+			newNodeInst("2", "b", NodeInstSucceed, ""),
+			newNodeInst("3", "c", NodeInstFailed, ""),
+		}, []string{"2", "1"}},
+		{"并行分支按节点实例ID逆序", [][]string{{"fork"}, {"a", "b"}}, []*NodeInst{
+			newNodeInst("1", "fork", NodeInstSucceed, ""),
+			newNodeInst("2", "a", NodeInstSucceed, ""),
+			newNodeInst("3", "b", NodeInstSucceed, ""),
+		}, []string{"3", "2", "1"}},
+		{"循环中多次执行的节点分别补偿", [][]string{{"a"}, {"b"}, {"a"}, {"b"}}, []*NodeInst{
+			newNodeInst("1", "a", NodeInstSucceed, ""),
+			newNodeInst("2", "b", NodeInstSucceed, ""),
+			newNodeInst("3", "a", NodeInstSucceed, ""),
+			newNodeInst("4", "b", NodeInstSucceed, ""),
+		}, []string{"4", "3", "2", "1"}},
+		{"跳过已经补偿成功的节点实例", [][]string{{"a"}, {"b"}}, []*NodeInst{
+			newNodeInst("1", "a", NodeInstSucceed, ""),
+			newNodeInst("2", "b", NodeInstSucceed, ""),
+			newNodeInst("3", "b", NodeInstSucceed, "2"),
+			newNodeInst("4", "a", NodeInstFailed, "1"),
+		}, []string{"1"}},
+		{"重跑的节点实例在最后一次出现的位置补偿", [][]string{{"a"}, {"b"}}, []*NodeInst{
+			newNodeInst("1", "a", NodeInstSucceed, ""),
+			newNodeInst("2", "b", NodeInstFailed, ""),
+			newNodeInst("3", "a", NodeInstSucceed, ""),
+		}, []string{"3", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, nodeInst := range GetNodeInstsToBeCompensated(tt.executePath, tt.nodeInsts) {
+				got = append(got, nodeInst.NodeInstID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetNodeInstsToBeCompensated() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestApprovalIsAssignee 测试判断用户是否可以审批
+func TestApprovalIsAssignee(t *testing.T) {
+	approval := &Approval{Assignees: []string{"alice"}, Roles: []string{"manager"}}
+	tests := []struct {
+		name          string
+		operator      string
+		operatorRoles []string
+		want          bool
+	}{
+		{"审批人可以审批", "alice", nil, true},
+		{"拥有审批角色的用户可以审批", "bob", []string{"dev", "manager"}, true},
+		{"没有审批角色的用户不能审批", "bob", []string{"dev"}, false},
+		{"操作人为空不能审批", "", []string{"manager"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := approval.IsAssignee(tt.operator, tt.operatorRoles); got != tt.want {
+				t.Errorf("IsAssignee() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestApprovalDelegate 测试转交审批
+func TestApprovalDelegate(t *testing.T) {
+	tests := []struct {
+		name      string
+		assignees []string
+		from      string
+		to        string
+		want      []string
+	}{
+		{"审批人转交时替换为被转交人", []string{"alice", "bob"}, "alice", "carol", []string{"bob", "carol"}},
+		{"通过角色审批的用户转交时追加被转交人", []string{"alice"}, "bob", "carol", []string{"alice", "carol"}},
+		{"转交给已有的审批人不重复添加", []string{"alice", "bob"}, "alice", "bob", []string{"bob"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approval := &Approval{Assignees: tt.assignees}
+			approval.Delegate(tt.from, tt.to, "")
+			if !reflect.DeepEqual(approval.Assignees, tt.want) {
+				t.Errorf("Delegate() got = %v, want %v", approval.Assignees, tt.want)
+			}
+			if len(approval.Delegations) != 1 || approval.Delegations[0].From != tt.from {
+				t.Errorf("Delegate() delegations = %v", approval.Delegations)
+			}
+		})
+	}
+}
+
+// TestApprovalEscalate 测试升级审批只会升级一次
+func TestApprovalEscalate(t *testing.T) {
+	approval := &Approval{Assignees: []string{"alice"}, Roles: []string{"manager"}}
+	if !approval.Escalate([]string{"alice", "boss"}, []string{"director"}) {
+		t.Fatalf("Escalate() got = false, want true")
+	}
+	if !reflect.DeepEqual(approval.Assignees, []string{"alice", "boss"}) ||
+		!reflect.DeepEqual(approval.Roles, []string{"manager", "director"}) {
+		t.Errorf("Escalate() got assignees = %v, roles = %v", approval.Assignees, approval.Roles)
+	}
+	if approval.Escalate([]string{"ceo"}, nil) {
+		t.Errorf("Escalate() got = true for already escalated approval, want false")
+	}
+}
@@ -0,0 +1,101 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	secretNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	secretRefRegexp  = regexp.MustCompile(`\$\{secrets\.([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// Secret 密钥
+type Secret struct {
+	ID          string    `json:"id,omitempty"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Name        string    `json:"name,omitempty"`        // 密钥名称
+	Value       string    `json:"-"`                     // 解密后的密钥值, 不允许序列化
+	Description string    `json:"description,omitempty"` // 密钥描述
+	Creator     string    `json:"creator,omitempty"`     // 创建人
+	Updater     string    `json:"updater,omitempty"`     // 最后一次修改人
+	CreatedAt   time.Time `json:"created_at"`            // 创建时间
+	UpdatedAt   time.Time `json:"updated_at"`            // 修改时间
+}
+
+// IsValidSecretName 密钥名称是否合法
+func IsValidSecretName(name string) bool {
+	return secretNameRegexp.MatchString(name)
+}
+
+// SecretRef 密钥引用占位符
+func SecretRef(name string) string {
+	return fmt.Sprintf("${secrets.%s}", name)
+}
+
+// SecretRefs 表达式上下文中的 secrets 对象
+// 表达式计算时只返回引用占位符, 真正的密钥值在节点执行时才会替换
+type SecretRefs struct {
+}
+
+// SelectGVal 实现 gval.Selector 接口
+func (SecretRefs) SelectGVal(_ context.Context, key string) (interface{}, error) {
+	if !IsValidSecretName(key) {
+		return nil, fmt.Errorf("invalid secret name [%s]", key)
+	}
+	return SecretRef(key), nil
+}
+
+// FindSecretRefs 查找字符串中引用的所有密钥名称
+func FindSecretRefs(s string) []string {
+	var names []string
+	for _, match := range secretRefRegexp.FindAllStringSubmatch(s, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// ReplaceSecretRefs 将字符串中的密钥引用替换为密钥值
+func ReplaceSecretRefs(s string, getValue func(name string) (string, error)) (string, error) {
+	var err error
+	r := secretRefRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+		var value string
+		value, err = getValue(secretRefRegexp.FindStringSubmatch(ref)[1])
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return r, nil
+}
+
+// RedactSecrets 将字符串中出现的密钥值还原为引用占位符, values 为密钥名称到密钥值的映射
+func RedactSecrets(s string, values map[string]string) string {
+	if s == "" || len(values) == 0 {
+		return s
+	}
+	// 先替换较长的值, 避免某个值是另一个值的子串时替换不完整
+	names := make([]string, 0, len(values))
+	for name, value := range values {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(values[names[i]]) != len(values[names[j]]) {
+			return len(values[names[i]]) > len(values[names[j]])
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		s = strings.ReplaceAll(s, values[name], SecretRef(name))
+	}
+	return s
+}
@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fflow-tech/fflow/service/pkg/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReplaceSecretRefs 测试替换密钥引用
+func TestReplaceSecretRefs(t *testing.T) {
+	values := map[string]string{"token": "abc", "user": "admin"}
+	getValue := func(name string) (string, error) {
+		if v, ok := values[name]; ok {
+			return v, nil
+		}
+		return "", fmt.Errorf("secret %s not found", name)
+	}
+	tests := []struct {
+		name    string
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{"没有引用的情况", "Bearer xxx", "Bearer xxx", false},
+		{"整个字符串都是引用的情况", "${secrets.token}", "abc", false},
+		{"字符串中包含多个引用的情况", "${secrets.user}:${secrets.token}", "admin:abc", false},
+		{"普通表达式不替换的情况", "${w.i.token}", "${w.i.token}", false},
+		{"引用的密钥不存在的情况", "${secrets.unknown}", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReplaceSecretRefs(tt.s, getValue)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestRedactSecrets 测试将密钥值还原为引用
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		values map[string]string
+		want   string
+	}{
+		{"没有密钥的情况", "token abc", nil, "token abc"},
+		{"包含密钥值的情况", "Bearer abc", map[string]string{"token": "abc"}, "Bearer ${secrets.token}"},
+		{"密钥值互为子串的情况", "abcdef abc", map[string]string{"a": "abc", "b": "abcdef"},
+			"${secrets.b} ${secrets.a}"},
+		{"密钥值为空的情况", "abc", map[string]string{"token": ""}, "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RedactSecrets(tt.s, tt.values))
+		})
+	}
+}
+
+// TestSecretRefsInExpression 测试表达式中引用密钥时只返回引用占位符
+func TestSecretRefsInExpression(t *testing.T) {
+	ctx := map[string]interface{}{"secrets": SecretRefs{}}
+	tests := []struct {
+		name    string
+		expr    string
+		want    interface{}
+		wantErr bool
+	}{
+		{"直接引用密钥的情况", "${secrets.token}", "${secrets.token}", false},
+		{"格式化字符串中引用密钥的情况", `${sprintf("Bearer %s", secrets.token)}`, "Bearer ${secrets.token}", false},
+		{"密钥名称不合法的情况", `${secrets["a-b"]}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expr.NewDefaultEvaluator().Evaluate(ctx, tt.expr)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +22,15 @@ var (
 	nodeRefNameKeys        = []string{"nodeRefName", "node_ref_name"}
 )
 
+const (
+	// LoopCtxKey 上下文中引用最近一次执行的循环节点输出的 key
+	LoopCtxKey = "loop"
+	// ErrorCtxKey 上下文中引用最近一次触发错误路由的节点错误信息的 key
+	ErrorCtxKey = "error"
+	// SecretsCtxKey 上下文中引用密钥的 key
+	SecretsCtxKey = "secrets"
+)
+
 // WorkflowDef 流程实体定义
 // 因为和定义文件相关联, 所以 json 使用驼峰命名
 type WorkflowDef struct {
@@ -36,6 +48,8 @@ type WorkflowDef struct {
 	Timeout          Timeout                  `json:"timeout,omitempty"`
 	Triggers         []map[string]TriggerDef  `json:"triggers,omitempty"`
 	Input            []map[string]InputKeyDef `json:"input,omitempty"`
+	Output           []map[string]OutputDef   `json:"output,omitempty"`       // 流程输出的定义
+	OutputPolicy     OutputPolicy             `json:"outputPolicy,omitempty"` // 输出不符合定义时的处理策略
 	Owner            Owner                    `json:"owner,omitempty"`
 	Msg              WorkflowMsg              `json:"msg,omitempty"`
 	Biz              map[string]interface{}   `json:"biz,omitempty"`
@@ -43,6 +57,8 @@ type WorkflowDef struct {
 	Webhooks         []string                 `json:"webhooks,omitempty"`
 	Nodes            []map[string]interface{} `json:"nodes,omitempty"`
 	Subworkflows     []map[string]WorkflowDef `json:"subworkflows,omitempty"`
+	TokenBudget      *TokenBudget             `json:"tokenBudget,omitempty"`    // 大模型 token 预算
+	AutoCompensate   bool                     `json:"autoCompensate,omitempty"` // 流程失败时自动执行补偿
 	CreatedAt        time.Time                `json:"createdAt,omitempty"`
 }
 
@@ -84,6 +100,7 @@ type BasicNodeDef struct {
 	Timeout       NodeTimeout            `json:"timeout,omitempty"`
 	Wait          Wait                   `json:"wait,omitempty"`
 	Schedule      Schedule               `json:"schedule,omitempty"`
+	OnError       []ErrorRoute           `json:"onError,omitempty"` // 节点失败时的错误路由
 	Args          interface{}            `json:"args,omitempty"`
 	Next          string                 `json:"next,omitempty"`
 	Index         int                    `json:"index,omitempty"`         // 在整体中的序列号
@@ -124,9 +141,36 @@ type NodeTimeout struct {
 type Wait struct {
 	Duration  string          `json:"duration,omitempty"`
 	Expr      string          `json:"expr,omitempty"`
+	Until     string          `json:"until,omitempty"`    // 等待到指定的时间, 支持表达式
+	Timezone  string          `json:"timezone,omitempty"` // until 和 expr 使用的时区, 默认为服务的时区
 	AllowDays AllowDaysPolicy `json:"allowDays,omitempty"`
 }
 
+// waitUntilLayouts until 支持的时间格式, 不带时区的格式按照 timezone 解析
+var waitUntilLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// Location 获取等待配置的时区, 为空时使用服务的时区
+func (w Wait) Location() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(w.Timezone)
+}
+
+// ParseUntil 按照等待配置的时区解析 until 的时间, until 需要是已经计算好表达式的值
+func (w Wait) ParseUntil(until string) (time.Time, error) {
+	loc, err := w.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, layout := range waitUntilLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(until), loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("illegal wait until time `%s`, support formats: %v", until, waitUntilLayouts)
+}
+
 // Schedule 调度配置
 type Schedule struct {
 	FailedPolicy       FailedPolicy       `json:"failedPolicy,omitempty"`
@@ -134,11 +178,78 @@ type Schedule struct {
 	ExecuteTimesPolicy ExecuteTimesPolicy `json:"executeTimesPolicy,omitempty"`
 }
 
+// ErrorRoute 错误路由, 节点失败时根据错误类型执行对应的处理节点
+type ErrorRoute struct {
+	Errors     []ErrorClass `json:"errors,omitempty"`     // 匹配的错误类型, 为空时匹配所有错误
+	HTTPStatus []string     `json:"httpStatus,omitempty"` // 匹配的 HTTP 状态码, 支持 404、5xx、500-599 三种格式
+	Next       string       `json:"next,omitempty"`       // 匹配后执行的节点
+}
+
+// Match 判断节点错误是否匹配当前路由
+func (r ErrorRoute) Match(nodeErr *NodeError) bool {
+	if nodeErr == nil {
+		return false
+	}
+	if len(r.Errors) > 0 && !ContainsErrorClass(r.Errors, nodeErr.Class) {
+		return false
+	}
+	if len(r.HTTPStatus) == 0 {
+		return true
+	}
+	// 配置了状态码时只匹配 HTTP 状态码错误
+	if nodeErr.Class != ErrorClassHTTP {
+		return false
+	}
+	for _, status := range r.HTTPStatus {
+		min, max, err := ParseHTTPStatusRange(status)
+		if err == nil && nodeErr.StatusCode >= min && nodeErr.StatusCode <= max {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsErrorClass 错误分类列表中是否包含指定的分类
+func ContainsErrorClass(classes []ErrorClass, class ErrorClass) bool {
+	for _, c := range classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseHTTPStatusRange 解析 HTTP 状态码配置, 返回状态码的范围
+func ParseHTTPStatusRange(status string) (int, int, error) {
+	status = strings.TrimSpace(strings.ToLower(status))
+	if len(status) == 3 && strings.HasSuffix(status, "xx") {
+		n, err := strconv.Atoi(status[:1])
+		if err != nil || n < 1 || n > 5 {
+			return 0, 0, fmt.Errorf("illegal http status [%s]", status)
+		}
+		return n * 100, n*100 + 99, nil
+	}
+	if parts := strings.SplitN(status, "-", 2); len(parts) == 2 {
+		min, minErr := strconv.Atoi(strings.TrimSpace(parts[0]))
+		max, maxErr := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if minErr != nil || maxErr != nil || min > max {
+			return 0, 0, fmt.Errorf("illegal http status [%s]", status)
+		}
+		return min, max, nil
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return 0, 0, fmt.Errorf("illegal http status [%s]", status)
+	}
+	return code, code, nil
+}
+
 // ServiceNodeDef 服务节点定义
 type ServiceNodeDef struct {
 	BasicNodeDef
-	PollArgs   interface{} `json:"pollArgs,omitempty"`
-	CancelArgs interface{} `json:"cancelArgs,omitempty"`
+	PollArgs       interface{} `json:"pollArgs,omitempty"`
+	CancelArgs     interface{} `json:"cancelArgs,omitempty"`
+	CompensateArgs interface{} `json:"compensateArgs,omitempty"` // 补偿参数, 流程补偿时按执行路径逆序调用
 }
 
 // SwitchNodeDef Switch节点定义
@@ -169,13 +280,37 @@ type ForkNodeDef struct {
 	Fork []string `json:"fork,omitempty"`
 }
 
-// SubworkflowNodeDef 子流程节点定义
+// SubworkflowNodeDef 子流程节点定义, 配置 items 时为数组中的每个元素启动一个子流程
 type SubworkflowNodeDef struct {
 	BasicNodeDef
 	Subworkflow string          `json:"subworkflow,omitempty"` // 内部定义的子流程的 refname，和 ID 必填一个
 	ID          string          `json:"id,omitempty"`          // 流程定义 ID
 	Version     int             `json:"version,omitempty"`     // 流程定义版本
 	Args        SubworkflowArgs `json:"args,omitempty"`
+	Items       interface{}     `json:"items,omitempty"`  // 动态启动子流程的数组, 一般为返回数组的表达式
+	Join        JoinPolicy      `json:"join,omitempty"`   // 动态子流程的完成策略, 默认为 ALL
+	Quorum      int             `json:"quorum,omitempty"` // 完成策略为 QUORUM 时需要成功的子流程数量
+}
+
+// FanOut 是否为数组中的每个元素启动一个子流程
+func (d SubworkflowNodeDef) FanOut() bool {
+	return d.Items != nil && d.Items != ""
+}
+
+// RequiredSucceeded 动态子流程中需要成功的子流程数量
+func (d SubworkflowNodeDef) RequiredSucceeded(total int) int {
+	switch d.Join {
+	case JoinAny:
+		// 没有元素时直接成功
+		if total == 0 {
+			return 0
+		}
+		return 1
+	case JoinQuorum:
+		return d.Quorum
+	default:
+		return total
+	}
 }
 
 // SubworkflowArgs 子流程节点参数
@@ -185,6 +320,93 @@ type SubworkflowArgs struct {
 	Input    map[string]interface{} `json:"input,omitempty"`
 }
 
+// ForeachNodeDef Foreach节点定义, 对数组中的每个元素执行一次内联节点或者子流程
+type ForeachNodeDef struct {
+	BasicNodeDef
+	Items          interface{}            `json:"items,omitempty"`          // 需要遍历的数组, 一般为返回数组的表达式
+	MaxConcurrency int                    `json:"maxConcurrency,omitempty"` // 最大并发数, 为空时使用默认值
+	MaxFailures    int                    `json:"maxFailures,omitempty"`    // 允许失败的元素数量, 小于 0 表示不限制
+	Node           map[string]interface{} `json:"node,omitempty"`           // 内联节点定义, 和子流程配置二选一
+	Subworkflow    string                 `json:"subworkflow,omitempty"`    // 内部定义的子流程的 refname
+	ID             string                 `json:"id,omitempty"`             // 子流程的流程定义 ID
+	Version        int                    `json:"version,omitempty"`        // 子流程的流程定义版本
+	Args           SubworkflowArgs        `json:"args,omitempty"`           // 子流程参数
+}
+
+// RunSubworkflow 是否为每个元素启动子流程
+func (d ForeachNodeDef) RunSubworkflow() bool {
+	return d.Subworkflow != "" || d.ID != ""
+}
+
+// LoopNodeDef Loop节点定义, condition 为循环条件, 循环体的最后一个节点需要指回循环节点
+type LoopNodeDef struct {
+	BasicNodeDef
+	Body          string `json:"body,omitempty"`          // 循环体的第一个节点
+	MaxIterations int    `json:"maxIterations,omitempty"` // 最大迭代次数, 为空时使用默认值
+}
+
+// ApprovalNodeDef 审批节点定义, 节点执行后等待审批人通过、拒绝或者转交
+type ApprovalNodeDef struct {
+	BasicNodeDef
+	Assignees  []string               `json:"assignees,omitempty"`  // 审批人, 支持表达式
+	Roles      []string               `json:"roles,omitempty"`      // 审批角色, 拥有角色的用户都可以审批
+	Form       map[string]interface{} `json:"form,omitempty"`       // 审批表单的 JSON Schema, 为空时不校验
+	Escalation ApprovalEscalation     `json:"escalation,omitempty"` // 接近超时时升级的审批人
+}
+
+// ApprovalEscalation 审批升级配置
+type ApprovalEscalation struct {
+	Assignees []string `json:"assignees,omitempty"` // 升级的审批人, 支持表达式
+	Roles     []string `json:"roles,omitempty"`     // 升级的审批角色
+}
+
+// ScriptNodeDef 脚本节点定义, 在引擎内直接执行内联的脚本, 不需要发布 FAAS 函数
+type ScriptNodeDef struct {
+	BasicNodeDef
+	Language string                 `json:"language,omitempty"` // 脚本语言, 支持 javascript 和 golang
+	Code     string                 `json:"code,omitempty"`     // 脚本代码, 和 FAAS 函数一样以 handler 作为入口
+	Input    map[string]interface{} `json:"input,omitempty"`    // 脚本入参, 支持表达式
+}
+
+// ScriptLanguages 脚本节点支持的语言, 和 FAAS 函数支持的语言一致
+var ScriptLanguages = []string{"javascript", "golang"}
+
+// MaxScriptTimeout 脚本节点最大的执行超时时间, 和 FAAS 函数一致
+const MaxScriptTimeout = 60 * time.Second
+
+// IsSupportedScriptLanguage 是否是脚本节点支持的语言
+func IsSupportedScriptLanguage(language string) bool {
+	for _, l := range ScriptLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitEventNodeDef 等待事件节点定义, 节点执行后一直等待, 直到收到匹配的触发器事件或者节点超时
+type WaitEventNodeDef struct {
+	BasicNodeDef
+	Event       string `json:"event,omitempty"`       // 等待的事件, 即触发器事件的 key
+	Correlation string `json:"correlation,omitempty"` // 关联表达式, 可以通过 event 引用事件内容
+}
+
+var exprRE = regexp.MustCompile(`\$\{([^{}]*)\}`)
+
+// CorrelationExpr 获取关联表达式, 包含多个表达式时合并为一个表达式, 为空时匹配所有事件
+// 如 ${event.data.orderId} == ${w.input.orderId} 会合并为 ${(event.data.orderId) == (w.input.orderId)}
+func (d WaitEventNodeDef) CorrelationExpr() string {
+	correlation := strings.TrimSpace(d.Correlation)
+	if correlation == "" {
+		return "${true}"
+	}
+	matches := exprRE.FindAllStringIndex(correlation, -1)
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(correlation) {
+		return correlation
+	}
+	return "${" + exprRE.ReplaceAllString(correlation, "($1)") + "}"
+}
+
 // AssignNodeDef Assign节点定义
 type AssignNodeDef struct {
 	BasicNodeDef
@@ -266,6 +488,8 @@ type FAASArgs struct {
 type MCPArgs struct {
 	ServiceNodeBasicArgs
 	URL        string            `json:"url,omitempty"`
+	Transport  string            `json:"transport,omitempty"` // 传输方式, STREAMABLE_HTTP 或 SSE, 为空时根据 url 判断
+	Headers    map[string]string `json:"headers,omitempty"`
 	Tool       string            `json:"tool,omitempty"`
 	Parameters map[string]string `json:"parameters,omitempty"`
 }
@@ -281,6 +505,25 @@ type OpenAIArgs struct {
 	Stream      bool                `json:"stream,default:false"`                                // 是否使用流式响应
 	APIKey      string              `json:"apiKey"`                                              // OpenAI API Key
 	BaseURL     string              `json:"baseURL,default:https://api.openai.com/v1,omitempty"` // OpenAI API Base URL
+	Provider    string              `json:"provider,omitempty"`                                  // 引擎配置中的大模型服务名称, 配置后忽略 apiKey 和 baseURL
+	// 以下为结构化输出的配置, 配置后会将模型返回的内容解析到输出的 json 字段中
+	ResponseFormat string                 `json:"responseFormat,omitempty"` // 返回格式, 支持 text/json_object/json_schema
+	JSONSchema     map[string]interface{} `json:"jsonSchema,omitempty"`     // 输出需要满足的 JSON Schema, 配置后默认使用 json_schema 格式
+	SchemaRetries  int                    `json:"schemaRetries,omitempty"`  // 输出不满足 Schema 时重新请求模型的次数
+	// 以下为智能体模式的配置, 配置了 Tools 时进入智能体模式
+	Tools         []OpenAITool `json:"tools,omitempty"`         // 模型可以调用的工具
+	MaxIterations int          `json:"maxIterations,omitempty"` // 最大的模型调用轮数, 为空时使用默认值
+}
+
+// OpenAITool 智能体模式下模型可以调用的工具, 根据 Protocol 使用对应的服务参数执行
+type OpenAITool struct {
+	Name        string                 `json:"name"`                  // 工具名称
+	Description string                 `json:"description,omitempty"` // 工具描述
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`  // 参数的 JSON Schema, MCP 工具为空时使用服务端的定义
+	Protocol    string                 `json:"protocol"`              // 工具协议, 支持 HTTP/FAAS/MCP
+	HTTP        *HTTPArgs              `json:"http,omitempty"`        // HTTP 工具参数
+	FAAS        *FAASArgs              `json:"faas,omitempty"`        // FAAS 工具参数
+	MCP         *MCPArgs               `json:"mcp,omitempty"`         // MCP 工具参数
 }
 
 // GetServiceNodeArgs 获取服务节点参数
@@ -325,9 +568,10 @@ type ServiceNodeArgsType string
 
 // ServiceNodeArgsType 服务节点参数类型
 const (
-	NormalArgs  ServiceNodeArgsType = "NORMAL"
-	PollingArgs ServiceNodeArgsType = "POLLING"
-	CancelArgs  ServiceNodeArgsType = "CANCEL"
+	NormalArgs     ServiceNodeArgsType = "NORMAL"
+	PollingArgs    ServiceNodeArgsType = "POLLING"
+	CancelArgs     ServiceNodeArgsType = "CANCEL"
+	CompensateArgs ServiceNodeArgsType = "COMPENSATE"
 )
 
 // GetServiceNodeBasicArgs 从服务节点里面拿出基础的参数
@@ -344,6 +588,8 @@ func GetServiceNodeBasicArgs(originNodeDef interface{}, argsType ServiceNodeArgs
 		return getServiceNodeBasicArgs(serviceNodeDef.PollArgs)
 	case CancelArgs:
 		return getServiceNodeBasicArgs(serviceNodeDef.CancelArgs)
+	case CompensateArgs:
+		return getServiceNodeBasicArgs(serviceNodeDef.CompensateArgs)
 	default:
 		return nil, fmt.Errorf("illegal args type:%s", argsType)
 	}
@@ -372,6 +618,8 @@ func getServiceNodeOriginArgs(originNodeDef interface{}, argsType ServiceNodeArg
 		return serviceNodeDef.PollArgs, nil
 	case CancelArgs:
 		return serviceNodeDef.CancelArgs, nil
+	case CompensateArgs:
+		return serviceNodeDef.CompensateArgs, nil
 	default:
 		return nil, fmt.Errorf("illegal args type:%s", argsType)
 	}
@@ -390,6 +638,71 @@ type InputKeyDef struct {
 	Required bool          `json:"required,omitempty"`
 }
 
+// OutputDef 输出key定义
+type OutputDef struct {
+	Type     OutputType    `json:"type,omitempty"` // 值的类型, 为空时不校验
+	Options  []interface{} `json:"options,omitempty"`
+	Required bool          `json:"required,omitempty"`
+}
+
+// OutputType 输出值的类型, 按照 json 的类型划分
+type OutputType string
+
+const (
+	OutputString  OutputType = "string"
+	OutputNumber  OutputType = "number"
+	OutputInteger OutputType = "integer"
+	OutputBoolean OutputType = "boolean"
+	OutputObject  OutputType = "object"
+	OutputArray   OutputType = "array"
+)
+
+// IsValid 是否为合法的类型
+func (t OutputType) IsValid() bool {
+	switch t {
+	case "", OutputString, OutputNumber, OutputInteger, OutputBoolean, OutputObject, OutputArray:
+		return true
+	default:
+		return false
+	}
+}
+
+// Match 判断 json 解析后的值是否符合类型
+func (t OutputType) Match(v interface{}) bool {
+	switch t {
+	case "":
+		return true
+	case OutputString:
+		_, ok := v.(string)
+		return ok
+	case OutputNumber:
+		_, ok := v.(float64)
+		return ok
+	case OutputInteger:
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case OutputBoolean:
+		_, ok := v.(bool)
+		return ok
+	case OutputObject:
+		_, ok := v.(map[string]interface{})
+		return ok
+	case OutputArray:
+		_, ok := v.([]interface{})
+		return ok
+	default:
+		return false
+	}
+}
+
+// OutputPolicy 流程输出不符合定义时的处理策略
+type OutputPolicy string
+
+const (
+	OutputFail OutputPolicy = "FAIL" // 流程失败, 默认值
+	OutputWarn OutputPolicy = "WARN" // 只在流程实例中记录原因, 流程仍然成功
+)
+
 // Timeout 流程超时设置
 type Timeout struct {
 	Duration string        `json:"duration,omitempty"`
@@ -397,6 +710,21 @@ type Timeout struct {
 	Policy   TimeoutPolicy `json:"policy,omitempty"`
 }
 
+// TokenBudget 流程实例的大模型 token 预算
+type TokenBudget struct {
+	MaxTokens int               `json:"maxTokens,omitempty"` // 最大 token 数量, 0 表示不限制
+	MaxCost   float64           `json:"maxCost,omitempty"`   // 最大费用, 0 表示不限制
+	Policy    TokenBudgetPolicy `json:"policy,omitempty"`    // 超出预算后的处理策略
+}
+
+// TokenBudgetPolicy 超出 token 预算后的处理策略
+type TokenBudgetPolicy string
+
+const (
+	TokenBudgetFail  TokenBudgetPolicy = "FAIL"  // 超出预算则流程失败, 默认值
+	TokenBudgetPause TokenBudgetPolicy = "PAUSE" // 超出预算则暂停流程
+)
+
 // DefStatus 流程定义状态枚举
 type DefStatus struct {
 	intValue int
@@ -485,8 +813,8 @@ type AllowDaysPolicy string
 
 const (
 	Any     AllowDaysPolicy = "ANY"     // 任意时间, 默认值
-	Week    AllowDaysPolicy = "WEEK"    // 工作日+周末 非腾讯节假日
-	Weekend AllowDaysPolicy = "WEEKEND" // 周六周日
+	Week    AllowDaysPolicy = "WEEK"    // 工作日, 排除节假日日历中的节假日, 包含调休的工作日
+	Weekend AllowDaysPolicy = "WEEKEND" // 休息日, 周六周日和节假日日历中的节假日
 )
 
 // UnmarshalJSON 重写反序列化方法
@@ -506,6 +834,27 @@ func (p AllowDaysPolicy) String() string {
 	return string(p)
 }
 
+// JoinPolicy 动态子流程的完成策略
+type JoinPolicy string
+
+const (
+	JoinAll    JoinPolicy = "ALL"    // 所有子流程成功后节点成功, 默认值
+	JoinAny    JoinPolicy = "ANY"    // 任意一个子流程成功后节点成功
+	JoinQuorum JoinPolicy = "QUORUM" // 成功的子流程数量达到 quorum 后节点成功
+)
+
+// UnmarshalJSON 重写反序列化方法
+func (s *JoinPolicy) UnmarshalJSON(b []byte) error {
+	var j string
+	err := json.Unmarshal(b, &j)
+	if err != nil {
+		return err
+	}
+
+	*s = JoinPolicy(strings.ToUpper(j))
+	return nil
+}
+
 // FailedPolicy 失败策略
 type FailedPolicy string
 
@@ -531,6 +880,38 @@ func (s FailedPolicy) String() string {
 	return string(s)
 }
 
+// ErrorClass 节点错误类型
+type ErrorClass string
+
+const (
+	ErrorClassTimeout    ErrorClass = "TIMEOUT"    // 节点超时
+	ErrorClassHTTP       ErrorClass = "HTTP"       // HTTP 请求返回错误状态码
+	ErrorClassExpression ErrorClass = "EXPRESSION" // 表达式计算错误
+	ErrorClassCancelled  ErrorClass = "CANCELLED"  // 节点被取消
+	ErrorClassError      ErrorClass = "ERROR"      // 其他执行失败
+)
+
+// ErrorClasses 所有的节点错误类型
+var ErrorClasses = []ErrorClass{ErrorClassTimeout, ErrorClassHTTP, ErrorClassExpression,
+	ErrorClassCancelled, ErrorClassError}
+
+// UnmarshalJSON 重写反序列化方法
+func (c *ErrorClass) UnmarshalJSON(b []byte) error {
+	var j string
+	err := json.Unmarshal(b, &j)
+	if err != nil {
+		return err
+	}
+
+	*c = ErrorClass(strings.ToUpper(j))
+	return nil
+}
+
+// String 字符串值
+func (c ErrorClass) String() string {
+	return string(c)
+}
+
 // SchedulePolicy 调度策略
 type SchedulePolicy string
 
@@ -629,6 +1010,8 @@ const (
 	CancelNode         ActionType = "CANCEL_SKIP_NODE"
 	CompleteNode       ActionType = "COMPLETE_NODE"
 	SetNodeTimeout     ActionType = "SET_NODE_TIMEOUT"
+	CompensateWorkflow ActionType = "COMPENSATE_WORKFLOW"
+	ReceiveEvent       ActionType = "RECEIVE_EVENT" // 等待事件节点收到事件, 只在节点执行时注册
 )
 
 // UnmarshalJSON 重写反序列化方法
@@ -658,6 +1041,11 @@ const (
 	RefNode           NodeType = "REF"            // 引用节点, 功能节点支持引用, 非功能节点不支持引用
 	WaitNode          NodeType = "WAIT"           // 等待节点
 	EventNode         NodeType = "EVENT"          // 事件节点
+	ForeachNode       NodeType = "FOREACH"        // 遍历节点
+	LoopNode          NodeType = "LOOP"           // 循环节点
+	ApprovalNode      NodeType = "APPROVAL"       // 审批节点
+	ScriptNode        NodeType = "SCRIPT"         // 脚本节点
+	WaitEventNode     NodeType = "WAIT_EVENT"     // 等待事件节点
 )
 
 // UnmarshalJSON 重写反序列化方法
@@ -795,6 +1183,26 @@ func NewNodeDef(node map[string]interface{}, nodeIndex int) (interface{}, error)
 		nodeDef := EventNodeDef{BasicNodeDef: BasicNodeDef{RefName: refName}}
 		err := utils.ToOtherInterfaceValue(&nodeDef, nodeDefMap)
 		return nodeDef, err
+	case ForeachNode:
+		nodeDef := ForeachNodeDef{BasicNodeDef: BasicNodeDef{RefName: refName}}
+		err := utils.ToOtherInterfaceValue(&nodeDef, nodeDefMap)
+		return nodeDef, err
+	case LoopNode:
+		nodeDef := LoopNodeDef{BasicNodeDef: BasicNodeDef{RefName: refName}}
+		err := utils.ToOtherInterfaceValue(&nodeDef, nodeDefMap)
+		return nodeDef, err
+	case ApprovalNode:
+		nodeDef := ApprovalNodeDef{BasicNodeDef: BasicNodeDef{RefName: refName}}
+		err := utils.ToOtherInterfaceValue(&nodeDef, nodeDefMap)
+		return nodeDef, err
+	case ScriptNode:
+		nodeDef := ScriptNodeDef{BasicNodeDef: BasicNodeDef{RefName: refName}}
+		err := utils.ToOtherInterfaceValue(&nodeDef, nodeDefMap)
+		return nodeDef, err
+	case WaitEventNode:
+		nodeDef := WaitEventNodeDef{BasicNodeDef: BasicNodeDef{RefName: refName}}
+		err := utils.ToOtherInterfaceValue(&nodeDef, nodeDefMap)
+		return nodeDef, err
 	default:
 		return nil, fmt.Errorf("Unsupport NodeType=%s ", nodeType)
 	}
@@ -847,6 +1255,26 @@ func ToActualNodeDef(nodeType NodeType, oldDef interface{}) (interface{}, error)
 		newDef := EventNodeDef{}
 		err := utils.ToOtherInterfaceValue(&newDef, oldDef)
 		return newDef, err
+	case ForeachNode:
+		newDef := ForeachNodeDef{}
+		err := utils.ToOtherInterfaceValue(&newDef, oldDef)
+		return newDef, err
+	case LoopNode:
+		newDef := LoopNodeDef{}
+		err := utils.ToOtherInterfaceValue(&newDef, oldDef)
+		return newDef, err
+	case ApprovalNode:
+		newDef := ApprovalNodeDef{}
+		err := utils.ToOtherInterfaceValue(&newDef, oldDef)
+		return newDef, err
+	case ScriptNode:
+		newDef := ScriptNodeDef{}
+		err := utils.ToOtherInterfaceValue(&newDef, oldDef)
+		return newDef, err
+	case WaitEventNode:
+		newDef := WaitEventNodeDef{}
+		err := utils.ToOtherInterfaceValue(&newDef, oldDef)
+		return newDef, err
 	default:
 		return nil, fmt.Errorf("Unsupport NodeType=%s ", nodeType)
 	}
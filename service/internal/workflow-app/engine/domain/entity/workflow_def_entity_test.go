@@ -3,6 +3,7 @@ package entity
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/fflow-tech/fflow/service/pkg/log"
 )
@@ -186,3 +187,114 @@ var testDefJson = `{
       "projectName": "测试项目"
     }
   }`
+
+// TestErrorRouteMatch 测试错误路由匹配
+func TestErrorRouteMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		route   ErrorRoute
+		nodeErr *NodeError
+		want    bool
+	}{
+		{"没有配置错误类型时匹配所有错误", ErrorRoute{}, &NodeError{Class: ErrorClassCancelled}, true},
+		{"错误类型不匹配", ErrorRoute{Errors: []ErrorClass{ErrorClassTimeout}},
+			&NodeError{Class: ErrorClassError}, false},
+		{"状态码按百位匹配", ErrorRoute{HTTPStatus: []string{"5XX"}},
+			&NodeError{Class: ErrorClassHTTP, StatusCode: 502}, true},
+		{"状态码按范围匹配", ErrorRoute{HTTPStatus: []string{"400-404"}},
+			&NodeError{Class: ErrorClassHTTP, StatusCode: 403}, true},
+		{"状态码不在范围内", ErrorRoute{HTTPStatus: []string{"400-404", "429"}},
+			&NodeError{Class: ErrorClassHTTP, StatusCode: 500}, false},
+		{"配置了状态码时不匹配其他错误", ErrorRoute{HTTPStatus: []string{"5xx"}},
+			&NodeError{Class: ErrorClassTimeout}, false},
+		{"没有错误时不匹配", ErrorRoute{}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.route.Match(tt.nodeErr); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWaitEventNodeDef_CorrelationExpr 测试等待事件节点关联表达式
+func TestWaitEventNodeDef_CorrelationExpr(t *testing.T) {
+	tests := []struct {
+		name        string
+		correlation string
+		want        string
+	}{
+		{"没有配置时匹配所有事件", "", "${true}"},
+		{"单个表达式直接使用", "${event.data.orderId == w.input.orderId}",
+			"${event.data.orderId == w.input.orderId}"},
+		{"多个表达式合并为一个", "${event.data.orderId} == ${w.input.orderId}",
+			"${(event.data.orderId) == (w.input.orderId)}"},
+		{"表达式和常量混合", "${event.data.amount} > 100 && ${event.data.type} == 'pay'",
+			"${(event.data.amount) > 100 && (event.data.type) == 'pay'}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := WaitEventNodeDef{Correlation: tt.correlation}
+			if got := d.CorrelationExpr(); got != tt.want {
+				t.Errorf("CorrelationExpr() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWait_ParseUntil(t *testing.T) {
+	tests := []struct {
+		name    string
+		wait    Wait
+		until   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"按照时区解析", Wait{Timezone: "Asia/Shanghai"}, "2030-01-02 09:00:00",
+			time.Date(2030, 1, 2, 1, 0, 0, 0, time.UTC), false},
+		{"只有日期", Wait{Timezone: "UTC"}, "2030-01-02", time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC), false},
+		{"RFC3339 使用自带的时区", Wait{Timezone: "Asia/Shanghai"}, "2030-01-02T09:00:00+09:00",
+			time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC), false},
+		{"时间格式错误", Wait{}, "tomorrow", time.Time{}, true},
+		{"时区错误", Wait{Timezone: "Asia/Nowhere"}, "2030-01-02", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.wait.ParseUntil(tt.until)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUntil() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseUntil() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputType_Match(t *testing.T) {
+	tests := []struct {
+		name       string
+		outputType OutputType
+		value      interface{}
+		want       bool
+	}{
+		{"不限制类型", "", []interface{}{1.0}, true},
+		{"字符串", OutputString, "a", true},
+		{"数字", OutputNumber, 1.5, true},
+		{"整数", OutputInteger, 2.0, true},
+		{"整数不能有小数", OutputInteger, 2.5, false},
+		{"布尔值", OutputBoolean, true, true},
+		{"对象", OutputObject, map[string]interface{}{"a": 1.0}, true},
+		{"数组", OutputArray, []interface{}{"a"}, true},
+		{"类型不符", OutputString, 1.0, false},
+		{"未知类型", OutputType("date"), "2030-01-01", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.outputType.Match(tt.value); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -45,6 +45,55 @@ type WorkflowInst struct {
 	CurBlockedBreakpoint                    string                 `json:"cur_blocked_breakpoint,omitempty"`                       // 当前被阻塞的断点(调试模式下)
 	DebugMockNodes                          []string               `json:"debug_mock_nodes,omitempty"`                             // 调试模式下需要 MOCK 的节点
 	CurDebugMode                            DebugMode              `json:"cur_debug_mode,omitempty"`                               // 当前调试模式
+	TokenUsage                              *TokenUsage            `json:"token_usage,omitempty"`                                  // 所有节点实例的大模型 token 用量
+	TokenBudgetExceeded                     bool                   `json:"token_budget_exceeded,omitempty"`                        // 是否已经因为超出 token 预算被处理过
+	LoopStates                              map[string]*LoopState  `json:"loop_states,omitempty"`                                  // 循环节点的执行状态, key 为循环节点的引用名称
+	Compensation                            *Compensation          `json:"compensation,omitempty"`                                 // 流程补偿的执行情况
+	OutputViolation                         string                 `json:"output_violation,omitempty"`                             // 输出不符合流程定义的原因
+}
+
+// LoopOutput 循环节点的输出
+type LoopOutput struct {
+	Index                int                    `json:"index"`                          // 迭代序号, 从 0 开始, 退出循环时为已完成的迭代次数
+	Last                 map[string]interface{} `json:"last"`                           // 上一次迭代中循环体节点的输出, key 为节点引用名称
+	Continue             bool                   `json:"continue"`                       // 是否继续执行循环体
+	MaxIterationsReached bool                   `json:"maxIterationsReached,omitempty"` // 是否因为达到最大迭代次数退出
+}
+
+// LoopState 循环节点的执行状态
+type LoopState struct {
+	Index      int    `json:"index"`                  // 最近一次执行的迭代序号, 从 0 开始
+	NodeInstID string `json:"node_inst_id,omitempty"` // 最近一次执行的循环节点实例 ID
+	Running    bool   `json:"running,omitempty"`      // 是否正在执行循环体
+}
+
+// CompensationStatus 流程补偿状态
+type CompensationStatus string
+
+// 流程补偿状态枚举
+const (
+	CompensationRunning CompensationStatus = "running" // 补偿中
+	CompensationSucceed CompensationStatus = "succeed" // 补偿成功
+	CompensationFailed  CompensationStatus = "failed"  // 补偿失败, 可以再次发起补偿, 已经补偿成功的节点不会重复补偿
+)
+
+// Compensation 流程补偿的执行情况, 每个节点的补偿结果记录在对应的补偿节点实例中
+type Compensation struct {
+	Status       CompensationStatus  `json:"status,omitempty"`
+	Operator     string              `json:"operator,omitempty"`
+	Reason       string              `json:"reason,omitempty"`
+	FailedReason string              `json:"failed_reason,omitempty"`
+	Records      []*CompensateRecord `json:"records,omitempty"`
+	StartAt      time.Time           `json:"start_at"`
+	CompletedAt  time.Time           `json:"completed_at"`
+}
+
+// CompensateRecord 单个节点实例的补偿记录
+type CompensateRecord struct {
+	RefName              string `json:"ref_name,omitempty"`
+	NodeInstID           string `json:"node_inst_id,omitempty"`            // 被补偿的节点实例ID
+	CompensateNodeInstID string `json:"compensate_node_inst_id,omitempty"` // 补偿节点实例ID
+	Status               string `json:"status,omitempty"`
 }
 
 // InDebugMode 是否处于调试模式
@@ -52,6 +101,18 @@ func (w *WorkflowInst) InDebugMode() bool {
 	return w.CurDebugMode != ""
 }
 
+// ExceedTokenBudget 判断流程实例的 token 用量是否超出预算
+func (w *WorkflowInst) ExceedTokenBudget() bool {
+	if w.WorkflowDef == nil || w.WorkflowDef.TokenBudget == nil || w.TokenUsage == nil {
+		return false
+	}
+	budget := w.WorkflowDef.TokenBudget
+	if budget.MaxTokens > 0 && w.TokenUsage.TotalTokens > budget.MaxTokens {
+		return true
+	}
+	return budget.MaxCost > 0 && w.TokenUsage.Cost > budget.MaxCost
+}
+
 // DebugMode 调试类型
 type DebugMode string
 
@@ -202,10 +263,76 @@ func ConvertToCtx(inst *WorkflowInst) (map[string]interface{}, error) {
 		"owner":     ownerMap,
 		"o":         ownerMap,
 	}
+	// 节点引用名称优先, 不覆盖名为 secrets、loop 和 error 的节点
+	if _, exists := m[SecretsCtxKey]; !exists {
+		m[SecretsCtxKey] = SecretRefs{}
+	}
+	if _, exists := m[LoopCtxKey]; !exists {
+		m[LoopCtxKey] = getLatestLoopOutput(inst)
+	}
+	if _, exists := m[ErrorCtxKey]; !exists {
+		m[ErrorCtxKey] = getLatestRoutedError(inst)
+	}
 
 	return m, nil
 }
 
+// getLatestRoutedError 获取最近一次触发错误路由的节点错误信息
+func getLatestRoutedError(inst *WorkflowInst) map[string]interface{} {
+	var latest *NodeInst
+	for _, nodeInst := range inst.SchedNodeInsts {
+		if _, ok := MatchErrorRoute(nodeInst); !ok {
+			continue
+		}
+		if latest == nil || utils.MaxUint64Str(latest.NodeInstID, nodeInst.NodeInstID) != latest.NodeInstID {
+			latest = nodeInst
+		}
+	}
+	if latest == nil {
+		return map[string]interface{}{}
+	}
+	nodeErr := GetNodeError(latest)
+	return map[string]interface{}{
+		"node":       latest.BasicNodeDef.RefName,
+		"nodeInstID": latest.NodeInstID,
+		"class":      nodeErr.Class.String(),
+		"statusCode": nodeErr.StatusCode,
+		"message":    getNodeErrorMessage(latest),
+	}
+}
+
+// getNodeErrorMessage 获取节点失败、超时或者取消的原因
+func getNodeErrorMessage(nodeInst *NodeInst) string {
+	if nodeInst.Reason == nil {
+		return ""
+	}
+	switch nodeInst.Status {
+	case NodeInstTimeout:
+		return nodeInst.Reason.TimeoutReason
+	case NodeInstCancelled:
+		return nodeInst.Reason.CancelledReason
+	default:
+		return nodeInst.Reason.FailedReason
+	}
+}
+
+// getLatestLoopOutput 获取最近一次执行的循环节点的输出, 嵌套循环时为最内层的循环
+func getLatestLoopOutput(inst *WorkflowInst) map[string]interface{} {
+	var latest *NodeInst
+	for _, nodeInst := range inst.SchedNodeInsts {
+		if nodeInst.BasicNodeDef.Type != LoopNode {
+			continue
+		}
+		if latest == nil || utils.MaxUint64Str(latest.NodeInstID, nodeInst.NodeInstID) != latest.NodeInstID {
+			latest = nodeInst
+		}
+	}
+	if latest == nil || latest.Output == nil {
+		return map[string]interface{}{}
+	}
+	return latest.Output
+}
+
 func appendDefaultVariables(inst *WorkflowInst) {
 	for _, k := range workflowInstIDKeys {
 		inst.Variables[k] = inst.InstID
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"testing"
+)
+
+// TestExceedTokenBudget 测试 token 用量是否超出预算
+func TestExceedTokenBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget *TokenBudget
+		usage  *TokenUsage
+		want   bool
+	}{
+		{"没有配置预算的情况", nil, &TokenUsage{TotalTokens: 100}, false},
+		{"没有用量的情况", &TokenBudget{MaxTokens: 10}, nil, false},
+		{"token 数量未超出的情况", &TokenBudget{MaxTokens: 100}, &TokenUsage{TotalTokens: 100}, false},
+		{"token 数量超出的情况", &TokenBudget{MaxTokens: 100}, &TokenUsage{TotalTokens: 101}, true},
+		{"费用超出的情况", &TokenBudget{MaxCost: 0.5}, &TokenUsage{TotalTokens: 1, Cost: 0.6}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := &WorkflowInst{WorkflowDef: &WorkflowDef{TokenBudget: tt.budget}, TokenUsage: tt.usage}
+			if got := inst.ExceedTokenBudget(); got != tt.want {
+				t.Errorf("ExceedTokenBudget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConvertToCtx_ReservedKeys 测试节点引用名称和上下文保留的 key 相同时节点优先
+func TestConvertToCtx_ReservedKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		refName string
+		key     string
+	}{
+		{"名为 secrets 的节点", "secrets", SecretsCtxKey},
+		{"名为 loop 的节点", "loop", LoopCtxKey},
+		{"名为 error 的节点", "error", ErrorCtxKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := &WorkflowInst{SchedNodeInsts: []*NodeInst{{
+				BasicNodeDef: BasicNodeDef{RefName: tt.refName},
+				Output:       map[string]interface{}{"k": "v"},
+			}}}
+			ctx, err := ConvertToCtx(inst)
+			if err != nil {
+				t.Fatalf("ConvertToCtx() error = %v", err)
+			}
+			nodeInfo, ok := ctx[tt.key].(map[string]interface{})
+			if !ok || nodeInfo["output"] == nil {
+				t.Errorf("ConvertToCtx() %s = %v, want node info", tt.key, ctx[tt.key])
+			}
+		})
+	}
+
+	ctx, err := ConvertToCtx(&WorkflowInst{})
+	if err != nil {
+		t.Fatalf("ConvertToCtx() error = %v", err)
+	}
+	if _, ok := ctx[SecretsCtxKey].(SecretRefs); !ok {
+		t.Errorf("ConvertToCtx() secrets = %v, want SecretRefs", ctx[SecretsCtxKey])
+	}
+}
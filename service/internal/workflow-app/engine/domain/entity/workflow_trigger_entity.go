@@ -84,6 +84,21 @@ type CompleteNodeActionArgs struct {
 	Output map[string]interface{} `json:"output,omitempty"`
 }
 
+// CompensateWorkflowActionArgs 补偿流程
+type CompensateWorkflowActionArgs struct {
+	BasicActionArgs
+	DefID  string `json:"defID,omitempty"`
+	InstID string `json:"instID,omitempty"`
+}
+
+// ReceiveEventActionArgs 等待事件节点收到事件, 事件内容作为节点的输出
+type ReceiveEventActionArgs struct {
+	BasicActionArgs
+	Node       string                 `json:"node,omitempty"`
+	NodeInstID string                 `json:"nodeInstID,omitempty"`
+	Event      map[string]interface{} `json:"event,omitempty"`
+}
+
 // TriggerLevel 触发器级别
 type TriggerLevel int
 
@@ -174,6 +189,14 @@ func GetActionArgs(originActionArgs interface{}, actionType ActionType) (interfa
 		action := &CompleteNodeActionArgs{}
 		err := utils.ToOtherInterfaceValue(&action, originActionArgs)
 		return action, err
+	case CompensateWorkflow:
+		action := &CompensateWorkflowActionArgs{}
+		err := utils.ToOtherInterfaceValue(&action, originActionArgs)
+		return action, err
+	case ReceiveEvent:
+		action := &ReceiveEventActionArgs{}
+		err := utils.ToOtherInterfaceValue(&action, originActionArgs)
+		return action, err
 	default:
 		return nil, fmt.Errorf("unsupported actionType=%s", actionType)
 	}
@@ -194,6 +217,8 @@ var (
 		CancelNode:         InstTrigger,
 		CompleteNode:       InstTrigger,
 		SetNodeTimeout:     InstTrigger,
+		CompensateWorkflow: InstTrigger,
+		ReceiveEvent:       InstTrigger,
 	}
 )
 
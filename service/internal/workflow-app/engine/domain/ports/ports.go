@@ -14,6 +14,7 @@ type CommandPorts interface {
 	NodeInstCommandPorts
 	ExternalEventCommandPorts
 	WorkflowTriggerCommandPorts
+	SecretCommandPorts
 }
 
 // QueryPorts 查接口
@@ -21,6 +22,7 @@ type QueryPorts interface {
 	WorkflowDefQueryPorts
 	WorkflowInstQueryPorts
 	NodeInstQueryPorts
+	SecretQueryPorts
 }
 
 // WorkflowDefCommandPorts 流程定义接口
@@ -40,6 +42,8 @@ type WorkflowDefQueryPorts interface {
 	GetWorkflowDefList(context.Context, *dto.PageQueryWorkflowDefDTO) ([]*dto.WorkflowDefDTO, int64, error)
 	// GetSubworkflowByParentDefIDAndRefName 获取子流程定义
 	GetSubworkflowByParentDefIDAndRefName(context.Context, *dto.GetSubworkflowDefDTO) (*dto.WorkflowDefDTO, error)
+	// GetWorkflowDefGraph 查询流程定义的流程图
+	GetWorkflowDefGraph(context.Context, *dto.GetWorkflowDefGraphDTO) (string, error)
 }
 
 // WorkflowInstQueryPorts 流程实例接口
@@ -48,6 +52,8 @@ type WorkflowInstQueryPorts interface {
 	GetWorkflowInst(context.Context, *dto.GetWorkflowInstDTO) (*dto.WorkflowInstDTO, error)
 	// GetWorkflowInstList 查询流程实例列表
 	GetWorkflowInstList(context.Context, *dto.GetWorkflowInstListDTO) ([]*dto.WorkflowInstDTO, int64, error)
+	// GetWorkflowInstGraph 查询流程实例的流程图
+	GetWorkflowInstGraph(context.Context, *dto.GetWorkflowInstGraphDTO) (string, error)
 }
 
 // WorkflowInstCommandPorts 流程实例接口
@@ -55,12 +61,15 @@ type WorkflowInstCommandPorts interface {
 	StartWorkflowInst(context.Context, *dto.StartWorkflowInstDTO) (string, error)           // 启动流程实例
 	RestartWorkflowInst(context.Context, *dto.RestartWorkflowInstDTO) error                 // 重启流程实例
 	CancelWorkflowInst(context.Context, *dto.CancelWorkflowInstDTO) error                   // 取消流程实例
+	CompensateWorkflowInst(context.Context, *dto.CompensateWorkflowInstDTO) error           // 补偿流程实例
 	CompleteWorkflowInst(context.Context, *dto.CompleteWorkflowInstDTO) error               // 标记流程实例完成
 	PauseWorkflowInst(context.Context, *dto.PauseWorkflowInstDTO) error                     // 暂停流程实例
 	ResumeWorkflowInst(context.Context, *dto.ResumeWorkflowInstDTO) error                   // 恢复流程实例
+	RecoverWorkflowInst(context.Context, *dto.RecoverWorkflowInstDTO) error                 // 恢复中断的流程实例
 	UpdateWorkflowInstCtx(context.Context, *dto.UpdateWorkflowInstCtxDTO) error             // 更新流程上下文
 	ConsumeWorkflowStartDriveEvent(context.Context, *dto.DriveEventDTO) error               // 消费流程启动驱动事件
 	ConsumeNodeCompleteDriveEvent(context.Context, *dto.DriveEventDTO) error                // 消费节点完成驱动事件
+	ConsumeWorkflowCompensateDriveEvent(context.Context, *dto.DriveEventDTO) error          // 消费流程补偿驱动事件
 	DebugWorkflowInst(context.Context, *dto.DebugWorkflowInstDTO) error                     // 更新流程调试信息
 	CheckTimeout(context.Context) error                                                     // 检查超时情况
 	ArchiveHistoryWorkflowInsts(context.Context, *dto.ArchiveHistoryWorkflowInstsDTO) error // 归档流程实例
@@ -78,6 +87,9 @@ type NodeInstCommandPorts interface {
 	ResumeNode(context.Context, *dto.ResumeNodeDTO) error                    // 恢复节点执行
 	CancelNode(context.Context, *dto.CancelNodeDTO) error                    // 取消节点执行
 	CompleteNode(context.Context, *dto.CompleteNodeDTO) error                // 标记节点执行完成
+	ApproveNode(context.Context, *dto.ApproveNodeDTO) error                  // 审批通过节点
+	RejectNode(context.Context, *dto.RejectNodeDTO) error                    // 审批拒绝节点
+	DelegateNode(context.Context, *dto.DelegateNodeDTO) error                // 转交审批节点
 	SetTimeout(context.Context, *dto.SetNodeTimeoutDTO) error                // 标记节点超时
 	SetNearTimeout(context.Context, *dto.SetNodeNearTimeoutDTO) error        // 标记节点接近超时
 }
@@ -101,3 +113,16 @@ type WorkflowTriggerCommandPorts interface {
 	ConsumeTriggerEvent(context.Context, *dto.TriggerEventDTO) error         // 消费触发器事件
 	CronCallBack(context.Context, string) error                              // 定时器回调方法
 }
+
+// SecretCommandPorts 密钥写接口
+type SecretCommandPorts interface {
+	CreateSecret(context.Context, *dto.CreateSecretDTO) (string, error) // 创建密钥
+	UpdateSecret(context.Context, *dto.UpdateSecretDTO) error           // 更新密钥
+	DeleteSecret(context.Context, *dto.DeleteSecretDTO) error           // 删除密钥
+}
+
+// SecretQueryPorts 密钥查询接口
+type SecretQueryPorts interface {
+	// GetSecretList 查询密钥列表, 不返回密钥值
+	GetSecretList(context.Context, *dto.PageQuerySecretDTO) ([]*dto.SecretDTO, int64, error)
+}
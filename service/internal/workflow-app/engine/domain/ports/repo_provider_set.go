@@ -14,6 +14,7 @@ type RepoProviderSet struct {
 	cacheRepo           CacheRepository
 	remoteRepo          RemoteRepository
 	triggerRepo         TriggerRepository
+	secretRepo          SecretRepository
 }
 
 // WorkflowDefRepo 流程定义仓储层
@@ -56,6 +57,11 @@ func (r *RepoProviderSet) TriggerRepo() TriggerRepository {
 	return r.triggerRepo
 }
 
+// SecretRepo 密钥仓储层
+func (r *RepoProviderSet) SecretRepo() SecretRepository {
+	return r.secretRepo
+}
+
 // NewRepoSet 实例化
 func NewRepoSet(defRepo *repo.WorkflowDefRepo,
 	instRepo *repo.WorkflowInstRepo,
@@ -64,7 +70,8 @@ func NewRepoSet(defRepo *repo.WorkflowDefRepo,
 	archiveRepo *repo.WorkflowArchiveRepo,
 	cacheRepo *repo.CacheRepo,
 	remoteRepo *repo.RemoteRepo,
-	triggerRepo *repo.TriggerRepo) *RepoProviderSet {
+	triggerRepo *repo.TriggerRepo,
+	secretRepo *repo.SecretRepo) *RepoProviderSet {
 	return &RepoProviderSet{
 		workflowDefRepo:     defRepo,
 		workflowInstRepo:    instRepo,
@@ -74,5 +81,6 @@ func NewRepoSet(defRepo *repo.WorkflowDefRepo,
 		cacheRepo:           cacheRepo,
 		remoteRepo:          remoteRepo,
 		triggerRepo:         triggerRepo,
+		secretRepo:          secretRepo,
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/mq"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/pkg/mcp"
 	"github.com/fflow-tech/fflow/service/pkg/remote"
 )
 
@@ -91,11 +92,16 @@ type CacheRepository interface {
 type RemoteRepository interface {
 	CallFAAS(context.Context, *remote.CallFAASReqDTO) (map[string]interface{}, error) // 调用 faas 接口
 	CallHTTP(context.Context, *remote.CallHTTPReqDTO) (map[string]interface{}, error) // 调用 http 接口
+	CallMCP(context.Context, *remote.CallMCPReqDTO) (*mcp.CallToolResult, error)      // 调用 mcp 工具
+	GetMCPTool(context.Context, *remote.CallMCPReqDTO) (*mcp.Tool, error)             // 获取 mcp 工具定义
+	RunScript(context.Context, *remote.RunScriptReqDTO) (interface{}, error)          // 执行内联脚本
 	AddCronJob(*remote.AddCronJobDTO) error                                           // 添加定时任务
 	CancelCronJob(jobName string) error                                               // 取消定时任务
+	AddDelayJob(context.Context, *remote.AddDelayJobReqDTO) error                     // 添加延时任务
 	SendMsgToUser(userID, msg string) error                                           // 发送企微消息给用户
 	SendMsgToGroup(chatID, msg string) error                                          // 发送企微消息给群聊
 	SendCloudEvent(ctx context.Context, req *remote.SendCloudEventDTO) error          // 发送事件
+	GetRolesForUserInDomain(c context.Context, domain, user string) ([]string, error) // 获取用户在指定域下的角色
 }
 
 // TriggerRepository 触发器仓储层接口
@@ -107,3 +113,13 @@ type TriggerRepository interface {
 	PageQuery(*dto.PageQueryTriggerDTO) ([]*entity.Trigger, error) // 分页查询触发器
 	QueryByName(*dto.QueryTriggerDTO) ([]*entity.Trigger, error)   // 根据名称查询触发器
 }
+
+// SecretRepository 密钥仓储层接口
+type SecretRepository interface {
+	Create(*dto.CreateSecretDTO) (string, error)                 // 创建密钥
+	Update(*dto.UpdateSecretDTO) error                           // 更新密钥
+	Delete(*dto.DeleteSecretDTO) error                           // 删除密钥
+	Get(*dto.GetSecretDTO) (*entity.Secret, error)               // 获取密钥, 包含解密后的密钥值
+	Count(*dto.PageQuerySecretDTO) (int64, error)                // 统计密钥数量
+	PageQuery(*dto.PageQuerySecretDTO) ([]*entity.Secret, error) // 分页查询密钥, 不包含密钥值
+}
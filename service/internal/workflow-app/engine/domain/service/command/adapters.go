@@ -8,6 +8,7 @@ type Adapters struct {
 	*NodeInstCommandService
 	*ExternalEventCommandService
 	*WorkflowTriggerCommandService
+	*SecretCommandService
 }
 
 // NewCommandAdapters 初始化适配器
@@ -16,11 +17,13 @@ func NewCommandAdapters(defService *WorkflowDefCommandService,
 	nodeInstService *NodeInstCommandService,
 	externalEventService *ExternalEventCommandService,
 	triggerCommandService *WorkflowTriggerCommandService,
+	secretCommandService *SecretCommandService,
 ) *Adapters {
 	return &Adapters{WorkflowDefCommandService: defService,
 		WorkflowInstCommandService:    instService,
 		NodeInstCommandService:        nodeInstService,
 		ExternalEventCommandService:   externalEventService,
 		WorkflowTriggerCommandService: triggerCommandService,
+		SecretCommandService:          secretCommandService,
 	}
 }
@@ -0,0 +1,179 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/common"
+	"github.com/fflow-tech/fflow/service/pkg/expr"
+	"github.com/fflow-tech/fflow/service/pkg/log"
+	"github.com/fflow-tech/fflow/service/pkg/logs"
+)
+
+// ApprovalNodeExecutor 审批节点执行器
+// 节点执行时记录审批人, 之后一直处于运行中, 直到审批人通过、拒绝或者节点超时
+type ApprovalNodeExecutor struct {
+	workflowInstRepo ports.WorkflowInstRepository
+	exprEvaluator    expr.Evaluator
+	msgSender        common.MsgSender
+}
+
+// NewApprovalNodeExecutor 初始化执行器
+func NewApprovalNodeExecutor(repoProviderSet *ports.RepoProviderSet,
+	workflowProviderSet *WorkflowProviderSet) *ApprovalNodeExecutor {
+	return &ApprovalNodeExecutor{
+		workflowInstRepo: repoProviderSet.WorkflowInstRepo(),
+		exprEvaluator:    workflowProviderSet.ExprEvaluator(),
+		msgSender:        workflowProviderSet.MsgSender(),
+	}
+}
+
+// Execute 执行节点, 计算审批人并记录审批状态
+func (d *ApprovalNodeExecutor) Execute(ctx context.Context, nodeInst *entity.NodeInst) error {
+	nodeDef, err := entity.ToActualNodeDef(nodeInst.BasicNodeDef.Type, nodeInst.NodeDef)
+	if err != nil {
+		return err
+	}
+	actualNodeDef := nodeDef.(entity.ApprovalNodeDef)
+
+	assignees, err := d.evaluateAssignees(nodeInst, actualNodeDef.Assignees)
+	if err != nil {
+		return err
+	}
+	if len(assignees) == 0 && len(actualNodeDef.Roles) == 0 {
+		return fmt.Errorf("[%s]approval node [%s] has no assignees",
+			logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), nodeInst.BasicNodeDef.RefName)
+	}
+
+	nodeInst.Approval = &entity.Approval{
+		Assignees: assignees,
+		Roles:     actualNodeDef.Roles,
+		Form:      actualNodeDef.Form,
+	}
+	return nil
+}
+
+// Escalate 接近超时时追加升级的审批人, 并通知升级的审批人
+func (d *ApprovalNodeExecutor) Escalate(ctx context.Context, nodeInst *entity.NodeInst) (bool, error) {
+	if nodeInst.Approval == nil {
+		return false, nil
+	}
+	nodeDef, err := entity.ToActualNodeDef(nodeInst.BasicNodeDef.Type, nodeInst.NodeDef)
+	if err != nil {
+		return false, err
+	}
+	escalation := nodeDef.(entity.ApprovalNodeDef).Escalation
+	if len(escalation.Assignees) == 0 && len(escalation.Roles) == 0 {
+		return false, nil
+	}
+
+	assignees, err := d.evaluateAssignees(nodeInst, escalation.Assignees)
+	if err != nil {
+		return false, err
+	}
+	if !nodeInst.Approval.Escalate(assignees, escalation.Roles) {
+		return false, nil
+	}
+
+	if len(assignees) > 0 {
+		msgInfo := map[string]interface{}{
+			"InstID":      nodeInst.InstID,
+			"NodeRefName": nodeInst.BasicNodeDef.RefName,
+		}
+		// 通知失败不影响升级
+		if err := d.msgSender.SendWeChatMsg(strings.Join(assignees, ";"),
+			common.NodeInstApprovalEscalate, msgInfo); err != nil {
+			log.Warnf("[%s]Failed to notify escalated assignees of node %s, caused by %s",
+				logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), nodeInst.BasicNodeDef.RefName, err)
+		}
+	}
+	return true, nil
+}
+
+// evaluateAssignees 计算审批人表达式, 表达式的结果可以是单个用户或者用户数组
+func (d *ApprovalNodeExecutor) evaluateAssignees(nodeInst *entity.NodeInst,
+	assignees []string) ([]string, error) {
+	if len(assignees) == 0 {
+		return nil, nil
+	}
+
+	workflowInst, err := d.workflowInstRepo.Get(&dto.GetWorkflowInstDTO{
+		InstID: nodeInst.InstID,
+		DefID:  nodeInst.DefID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, 0, len(assignees))
+	for _, assignee := range assignees {
+		values = append(values, assignee)
+	}
+	result, err := evaluateMapForCurNodeInst(d.exprEvaluator, workflowInst, nodeInst,
+		map[string]interface{}{"assignees": values})
+	if err != nil {
+		return nil, fmt.Errorf("[%s]failed to evaluate approval assignees: %w",
+			logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), err)
+	}
+
+	var r []string
+	for _, value := range toInterfaceSlice(result["assignees"]) {
+		for _, assignee := range toInterfaceSlice(value) {
+			if s := strings.TrimSpace(fmt.Sprint(assignee)); assignee != nil && s != "" {
+				r = append(r, s)
+			}
+		}
+	}
+	return r, nil
+}
+
+// toInterfaceSlice 转换为数组, 不是数组时作为单个元素
+func toInterfaceSlice(v interface{}) []interface{} {
+	switch value := v.(type) {
+	case []interface{}:
+		return value
+	case []string:
+		r := make([]interface{}, 0, len(value))
+		for _, s := range value {
+			r = append(r, s)
+		}
+		return r
+	default:
+		return []interface{}{v}
+	}
+}
+
+// Polling 轮询节点
+func (d *ApprovalNodeExecutor) Polling(ctx context.Context, nodeInst *entity.NodeInst) error {
+	return nil
+}
+
+// Cancel 取消执行节点
+func (d *ApprovalNodeExecutor) Cancel(ctx context.Context, nodeInst *entity.NodeInst) error {
+	nodeInst.Status = entity.NodeInstCancelled
+	return nil
+}
+
+// AsyncComplete 是否异步完成
+func (d *ApprovalNodeExecutor) AsyncComplete(inst *entity.NodeInst) bool {
+	return true
+}
+
+// AsyncByTrigger 通过审批接口完成节点
+func (d *ApprovalNodeExecutor) AsyncByTrigger(inst *entity.NodeInst) bool {
+	return true
+}
+
+// AsyncByPolling 通过轮询实现异步
+func (d *ApprovalNodeExecutor) AsyncByPolling(inst *entity.NodeInst) bool {
+	return false
+}
+
+// Type 获取是哪种节点类型的处理器
+func (d *ApprovalNodeExecutor) Type() entity.NodeType {
+	return entity.ApprovalNode
+}
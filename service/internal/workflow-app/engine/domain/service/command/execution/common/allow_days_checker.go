@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/config"
 )
 
 // AllowDaysChecker 执行时间检查器
@@ -12,34 +13,37 @@ type AllowDaysChecker interface {
 	Check(checkTime time.Time, allowDaysPolicy entity.AllowDaysPolicy) (bool, error) // 检查时间是否满足可执行的条件
 }
 
-// DefaultAllowDaysChecker 默认执行时间检查器
+// DefaultAllowDaysChecker 默认执行时间检查器, 工作日和休息日按照节假日日历判断
 type DefaultAllowDaysChecker struct {
+	calendar *holidayCalendar
 }
 
 // NewDefaultAllowDaysChecker 初始化
 func NewDefaultAllowDaysChecker() (*DefaultAllowDaysChecker, error) {
-	return &DefaultAllowDaysChecker{}, nil
+	calendar, err := getHolidayCalendar(config.GetHolidayCalendarConfig())
+	if err != nil {
+		return nil, err
+	}
+	return &DefaultAllowDaysChecker{calendar: calendar}, nil
 }
 
 // checkAllowDaysFunc 判断是否在可执行的时间段内对应的检测方法
-var checkAllowDaysFunc = map[entity.AllowDaysPolicy]func(checkTime time.Time) bool{
-	entity.Any: func(checkTime time.Time) bool { return true },
-	entity.Week: func(checkTime time.Time) bool {
-		Weekend := checkTime.Weekday()
-		return Weekend != time.Saturday && Weekend != time.Sunday
+var checkAllowDaysFunc = map[entity.AllowDaysPolicy]func(calendar *holidayCalendar, checkTime time.Time) bool{
+	entity.Any: func(calendar *holidayCalendar, checkTime time.Time) bool { return true },
+	entity.Week: func(calendar *holidayCalendar, checkTime time.Time) bool {
+		return calendar.isWorkday(checkTime)
 	},
-	entity.Weekend: func(checkTime time.Time) bool {
-		Weekend := checkTime.Weekday()
-		return Weekend == time.Sunday || Weekend == time.Saturday
+	entity.Weekend: func(calendar *holidayCalendar, checkTime time.Time) bool {
+		return !calendar.isWorkday(checkTime)
 	},
 }
 
-// Check 检查时间是否满足可执行的条件
+// Check 检查时间是否满足可执行的条件, 按照 checkTime 所在时区的日期判断
 func (d *DefaultAllowDaysChecker) Check(checkTime time.Time, allowDaysPolicy entity.AllowDaysPolicy) (bool, error) {
 	checkFunc, ok := checkAllowDaysFunc[allowDaysPolicy]
 	// 默认使用 ANY 策略
 	if !ok {
-		return checkAllowDaysFunc[entity.Any](checkTime), nil
+		return checkAllowDaysFunc[entity.Any](d.calendar, checkTime), nil
 	}
-	return checkFunc(checkTime), nil
+	return checkFunc(d.calendar, checkTime), nil
 }
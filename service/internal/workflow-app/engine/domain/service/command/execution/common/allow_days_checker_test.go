@@ -1,11 +1,13 @@
 package common
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/pkg/provider"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -20,11 +22,19 @@ type allowDaysSuite struct {
 	allowDaysChecker AllowDaysChecker
 }
 
-// SetupTest 执行用例执行前准备工作
+// SetupTest 执行用例执行前准备工作, 用例中的节假日依赖内置的节假日日历
 func (s *allowDaysSuite) SetupTest() {
+	provider.InjectConfigProvider(&fakeConfigProvider{configs: map[string]string{
+		"HolidayCalendar": fmt.Sprintf(`{"source":"%s"}`, builtinCalendarSource),
+	}})
 	s.allowDaysChecker, _ = NewDefaultAllowDaysChecker()
 }
 
+// TearDownTest 恢复默认的配置
+func (s *allowDaysSuite) TearDownTest() {
+	provider.InjectConfigProvider(&fakeConfigProvider{configs: map[string]string{}})
+}
+
 // TestCheckAllowDays CheckAllowDays测试用例
 func (s *allowDaysSuite) TestCheckAllowDays() {
 	type args struct {
@@ -0,0 +1,167 @@
+package common
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/log"
+)
+
+const (
+	dateLayout              = "2006-01-02"
+	dateRangeSep            = "~"
+	calendarRefreshInterval = time.Hour       // 外部日历的刷新间隔
+	calendarLoadTimeout     = 5 * time.Second // 加载 http 日历的超时时间
+	maxCalendarRangeDays    = 366             // 单个日期区间的最大天数
+	builtinCalendarSource   = "builtin-cn"    // 内置中国节假日日历的来源名称
+)
+
+// builtinCalendar 内置的中国节假日日历, 只包含 2021 到 2025 年, 需要显式配置 source 才会使用
+//
+//go:embed holidays_cn.json
+var builtinCalendar []byte
+
+// HolidayCalendar 节假日日历, 日期支持 2006-01-02 和 2006-01-02~2006-01-07 两种格式
+type HolidayCalendar struct {
+	Holidays []string `json:"holidays"` // 节假日
+	Workdays []string `json:"workdays"` // 调休的工作日
+}
+
+// holidayCalendar 解析后的节假日日历
+type holidayCalendar struct {
+	holidays map[string]bool
+	workdays map[string]bool
+}
+
+// isWorkday 判断是否为工作日, 调休的工作日优先, 其次是节假日, 最后按照周一到周五判断
+func (c *holidayCalendar) isWorkday(t time.Time) bool {
+	date := t.Format(dateLayout)
+	if c.workdays[date] {
+		return true
+	}
+	if c.holidays[date] {
+		return false
+	}
+	weekday := t.Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday
+}
+
+// calendarCache 缓存从外部来源加载的日历, 避免每次检查都重新加载
+var calendarCache = struct {
+	sync.Mutex
+	source   string
+	loadedAt time.Time
+	calendar *HolidayCalendar
+}{}
+
+// getHolidayCalendar 根据配置获取节假日日历, 配置中额外的日期会合并到日历中
+func getHolidayCalendar(conf *config.HolidayCalendarConfig) (*holidayCalendar, error) {
+	base, err := loadHolidayCalendarWithCache(conf.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &holidayCalendar{holidays: map[string]bool{}, workdays: map[string]bool{}}
+	for _, dates := range [][]string{base.Holidays, conf.Holidays} {
+		if err := addDates(calendar.holidays, dates); err != nil {
+			return nil, err
+		}
+	}
+	for _, dates := range [][]string{base.Workdays, conf.Workdays} {
+		if err := addDates(calendar.workdays, dates); err != nil {
+			return nil, err
+		}
+	}
+	return calendar, nil
+}
+
+// loadHolidayCalendarWithCache 加载日历, 加载失败时使用上一次加载成功的日历
+func loadHolidayCalendarWithCache(source string) (*HolidayCalendar, error) {
+	calendarCache.Lock()
+	defer calendarCache.Unlock()
+
+	if calendarCache.calendar != nil && calendarCache.source == source &&
+		time.Since(calendarCache.loadedAt) < calendarRefreshInterval {
+		return calendarCache.calendar, nil
+	}
+
+	calendar, err := loadHolidayCalendar(source)
+	if err != nil {
+		if calendarCache.calendar != nil && calendarCache.source == source {
+			log.Warnf("Failed to reload holiday calendar from %s, use the last one, caused by %s", source, err)
+			return calendarCache.calendar, nil
+		}
+		return nil, fmt.Errorf("failed to load holiday calendar from %s: %w", source, err)
+	}
+
+	calendarCache.source = source
+	calendarCache.loadedAt = time.Now()
+	calendarCache.calendar = calendar
+	return calendar, nil
+}
+
+// loadHolidayCalendar 从内置日历、本地文件或者 http 地址加载日历, 没有配置来源时不使用任何日历
+func loadHolidayCalendar(source string) (*HolidayCalendar, error) {
+	var (
+		content []byte
+		err     error
+	)
+	switch {
+	case source == "":
+		return &HolidayCalendar{}, nil
+	case source == builtinCalendarSource:
+		content = builtinCalendar
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		var rsp *resty.Response
+		rsp, err = resty.New().SetTimeout(calendarLoadTimeout).R().Get(source)
+		if err == nil && rsp.IsError() {
+			err = fmt.Errorf("unexpected status code %d", rsp.StatusCode())
+		}
+		if rsp != nil {
+			content = rsp.Body()
+		}
+	default:
+		content, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &HolidayCalendar{}
+	if err := json.Unmarshal(content, calendar); err != nil {
+		return nil, err
+	}
+	return calendar, nil
+}
+
+// addDates 解析日期和日期区间, 添加到集合中
+func addDates(dates map[string]bool, values []string) error {
+	for _, value := range values {
+		start, end, hasRange := strings.Cut(strings.TrimSpace(value), dateRangeSep)
+		startDate, err := time.Parse(dateLayout, strings.TrimSpace(start))
+		if err != nil {
+			return fmt.Errorf("invalid date %s in holiday calendar: %w", value, err)
+		}
+		endDate := startDate
+		if hasRange {
+			if endDate, err = time.Parse(dateLayout, strings.TrimSpace(end)); err != nil {
+				return fmt.Errorf("invalid date %s in holiday calendar: %w", value, err)
+			}
+		}
+		if endDate.Before(startDate) || endDate.Sub(startDate) > maxCalendarRangeDays*24*time.Hour {
+			return fmt.Errorf("invalid date range %s in holiday calendar", value)
+		}
+		for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+			dates[d.Format(dateLayout)] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	engineconfig "github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/provider"
+)
+
+func TestMain(m *testing.M) {
+	provider.InjectConfigProvider(&fakeConfigProvider{configs: map[string]string{}})
+	os.Exit(m.Run())
+}
+
+// fakeConfigProvider 按照配置 key 返回固定的配置
+type fakeConfigProvider struct {
+	configs map[string]string
+}
+
+func (f *fakeConfigProvider) GetAny(ctx context.Context, k config.Key, t interface{}) error {
+	conf, ok := f.configs[k.Key]
+	if !ok {
+		return fmt.Errorf("config %s not found", k.Key)
+	}
+	return json.Unmarshal([]byte(conf), t)
+}
+
+func (f *fakeConfigProvider) GetString(ctx context.Context, k config.Key) (string, error) {
+	return f.configs[k.Key], nil
+}
+
+func TestHolidayCalendar_IsWorkday(t *testing.T) {
+	calendarFile := filepath.Join(t.TempDir(), "calendar.json")
+	if err := os.WriteFile(calendarFile,
+		[]byte(`{"holidays":["2030-05-01~2030-05-03"],"workdays":["2030-05-04"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	builtin := &engineconfig.HolidayCalendarConfig{Source: builtinCalendarSource}
+	tests := []struct {
+		name string
+		conf *engineconfig.HolidayCalendarConfig
+		date string
+		want bool
+	}{
+		{"未配置日历-按照周一到周五判断", &engineconfig.HolidayCalendarConfig{}, "2021-10-01", true},
+		{"未配置日历-周末", &engineconfig.HolidayCalendarConfig{}, "2021-10-09", false},
+		{"内置日历-国庆节", builtin, "2021-10-01", false},
+		{"内置日历-调休工作日", builtin, "2021-10-09", true},
+		{"内置日历-普通工作日", builtin, "2021-08-11", true},
+		{"内置日历-普通周末", builtin, "2021-08-14", false},
+		{"文件日历-区间内的节假日", &engineconfig.HolidayCalendarConfig{Source: calendarFile}, "2030-05-02", false},
+		{"文件日历-调休工作日", &engineconfig.HolidayCalendarConfig{Source: calendarFile}, "2030-05-04", true},
+		{"配置额外的节假日", &engineconfig.HolidayCalendarConfig{Holidays: []string{"2030-06-03"}}, "2030-06-03", false},
+		{"配置额外的工作日", &engineconfig.HolidayCalendarConfig{Workdays: []string{"2030-06-08"}}, "2030-06-08", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar, err := getHolidayCalendar(tt.conf)
+			if err != nil {
+				t.Fatalf("getHolidayCalendar() error = %v", err)
+			}
+			date, _ := time.Parse(dateLayout, tt.date)
+			if got := calendar.isWorkday(date); got != tt.want {
+				t.Errorf("isWorkday() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddDates(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []string
+		wantCount int
+		wantErr   bool
+	}{
+		{"单个日期", []string{"2030-01-01"}, 1, false},
+		{"日期区间", []string{"2030-01-30 ~ 2030-02-02"}, 4, false},
+		{"非法日期", []string{"2030-13-01"}, 0, true},
+		{"结束日期早于开始日期", []string{"2030-02-02~2030-01-30"}, 0, true},
+		{"区间过长", []string{"2030-01-01~2032-01-01"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates := map[string]bool{}
+			err := addDates(dates, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("addDates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(dates) != tt.wantCount {
+				t.Errorf("addDates() got %d dates, want %d", len(dates), tt.wantCount)
+			}
+		})
+	}
+}
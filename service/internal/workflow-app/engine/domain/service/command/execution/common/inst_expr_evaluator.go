@@ -1,6 +1,8 @@
 package common
 
 import (
+	"fmt"
+
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
 	"github.com/fflow-tech/fflow/service/pkg/expr"
@@ -9,13 +11,14 @@ import (
 // InstExprEvaluator 实例表达式计算器
 type InstExprEvaluator struct {
 	workflowInstRepo ports.WorkflowInstRepository
+	secretRepo       ports.SecretRepository
 	exprEvaluator    expr.Evaluator
 }
 
 // NewInstExprEvaluator 初始化实例表达式计算器
-func NewInstExprEvaluator(workflowInstRepo ports.WorkflowInstRepository,
+func NewInstExprEvaluator(workflowInstRepo ports.WorkflowInstRepository, secretRepo ports.SecretRepository,
 	exprEvaluator expr.Evaluator) *InstExprEvaluator {
-	return &InstExprEvaluator{workflowInstRepo: workflowInstRepo, exprEvaluator: exprEvaluator}
+	return &InstExprEvaluator{workflowInstRepo: workflowInstRepo, secretRepo: secretRepo, exprEvaluator: exprEvaluator}
 }
 
 // EvaluateMapByInstCtx 根据实例上下文动态替换 map
@@ -29,6 +32,22 @@ func (d *InstExprEvaluator) EvaluateMapByInstCtx(query *dto.GetWorkflowInstDTO,
 	return d.exprEvaluator.EvaluateMap(ctx, oldMap)
 }
 
+// EvaluateArgsByInstCtx 根据实例上下文计算节点参数, 并将参数中的密钥引用替换为密钥值
+// 执行后需要通过返回的 SecretResolver 将节点实例和错误中的密钥值脱敏
+func (d *InstExprEvaluator) EvaluateArgsByInstCtx(query *dto.GetWorkflowInstDTO, namespace string,
+	args map[string]interface{}) (map[string]interface{}, *SecretResolver, error) {
+	evaluated, err := d.EvaluateMapByInstCtx(query, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	secrets := NewSecretResolver(d.secretRepo, namespace)
+	resolved, err := secrets.Resolve(evaluated)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve secrets: %w", err)
+	}
+	return resolved.(map[string]interface{}), secrets, nil
+}
+
 // MatchCondition 计算是否匹配
 func (d *InstExprEvaluator) MatchCondition(query *dto.GetWorkflowInstDTO, condition string) (bool, error) {
 	ctx, err := d.workflowInstRepo.GetWorkflowInstCtx(query)
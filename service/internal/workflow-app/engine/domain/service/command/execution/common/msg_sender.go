@@ -27,6 +27,7 @@ const (
 	NodeInstNearTimeoutAlert MsgTemplate = "NODE_INST_NEAR_TIMEOUT_ALERT" // 节点接近超时告警模板
 	InstExceptionalAlert     MsgTemplate = "INST_EXCEPTIONAL_ALERT"       // 流程实例异常告警
 	NodeInstExceptionalAlert MsgTemplate = "NODE_INST_EXCEPTIONAL_ALERT"  // 节点实例异常告警
+	NodeInstApprovalEscalate MsgTemplate = "NODE_INST_APPROVAL_ESCALATE"  // 审批节点升级通知
 )
 
 // DefaultMsgSender 默认消息发送者
@@ -0,0 +1,231 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+)
+
+// SecretResolver 节点执行时将参数中的 ${secrets.name} 替换为真正的密钥值, 执行后再将结果中的密钥值还原为引用
+type SecretResolver struct {
+	secretRepo ports.SecretRepository
+	namespace  string
+	values     map[string]string // 本次执行用到的密钥名称到密钥值的映射
+}
+
+func NewSecretResolver(secretRepo ports.SecretRepository, namespace string) *SecretResolver {
+	return &SecretResolver{secretRepo: secretRepo, namespace: namespace, values: map[string]string{}}
+}
+
+// secretResolverKey 上下文中保存 SecretResolver 的 key
+type secretResolverKey struct{}
+
+// WithSecretResolver 将 SecretResolver 放入上下文, 执行器在执行过程中对外发送内容时可以用来脱敏
+func WithSecretResolver(ctx context.Context, r *SecretResolver) context.Context {
+	return context.WithValue(ctx, secretResolverKey{}, r)
+}
+
+// GetSecretResolver 获取上下文中的 SecretResolver, 不存在时返回 nil
+func GetSecretResolver(ctx context.Context) *SecretResolver {
+	r, _ := ctx.Value(secretResolverKey{}).(*SecretResolver)
+	return r
+}
+
+// Resolve 替换参数中的密钥引用, 返回替换后的新参数, 不会修改原参数
+func (r *SecretResolver) Resolve(args interface{}) (interface{}, error) {
+	if args == nil {
+		return nil, nil
+	}
+	v, err := replaceStrings(reflect.ValueOf(args), func(s string) (string, error) {
+		return entity.ReplaceSecretRefs(s, r.getValue)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+func (r *SecretResolver) getValue(name string) (string, error) {
+	if value, ok := r.values[name]; ok {
+		return value, nil
+	}
+	if r.secretRepo == nil {
+		return "", fmt.Errorf("secret [%s] not found in namespace [%s]", name, r.namespace)
+	}
+	secret, err := r.secretRepo.Get(&dto.GetSecretDTO{Namespace: r.namespace, Name: name})
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret [%s] in namespace [%s]: %w", name, r.namespace, err)
+	}
+	r.values[name] = secret.Value
+	return secret.Value, nil
+}
+
+// RedactNodeInst 将节点实例的输入输出和原因中出现的密钥值还原为引用, 避免落库以及通过 webhook 泄露
+func (r *SecretResolver) RedactNodeInst(nodeInst *entity.NodeInst) {
+	if len(r.values) == 0 {
+		return
+	}
+	nodeInst.Input = r.redactMap(nodeInst.Input)
+	nodeInst.Output = r.redactMap(nodeInst.Output)
+	nodeInst.PollInput = r.redactMap(nodeInst.PollInput)
+	nodeInst.PollOutput = r.redactMap(nodeInst.PollOutput)
+	nodeInst.CancelInput = r.redactMap(nodeInst.CancelInput)
+	nodeInst.CancelOutput = r.redactMap(nodeInst.CancelOutput)
+	if nodeInst.Reason != nil {
+		reason := r.redact(reflect.ValueOf(*nodeInst.Reason)).Interface().(entity.NodeReason)
+		nodeInst.Reason = &reason
+	}
+}
+
+// RedactError 将错误信息中出现的密钥值还原为引用
+func (r *SecretResolver) RedactError(err error) error {
+	if err == nil || len(r.values) == 0 {
+		return err
+	}
+	msg := entity.RedactSecrets(err.Error(), r.values)
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
+
+// Redact 将字符串中出现的密钥值还原为引用, r 为 nil 时原样返回
+func (r *SecretResolver) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	return entity.RedactSecrets(s, r.values)
+}
+
+// PartialSecretLen 返回字符串末尾可能是某个密钥值开头部分的最大长度
+// 流式输出时密钥值可能被拆分到多次发送中, 末尾的这部分需要等后续内容到达后再脱敏
+func (r *SecretResolver) PartialSecretLen(s string) int {
+	if r == nil {
+		return 0
+	}
+	length := 0
+	for _, value := range r.values {
+		for n := len(value) - 1; n > length; n-- {
+			if strings.HasSuffix(s, value[:n]) {
+				length = n
+				break
+			}
+		}
+	}
+	return length
+}
+
+func (r *SecretResolver) redactMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	return r.redact(reflect.ValueOf(m)).Interface().(map[string]interface{})
+}
+
+func (r *SecretResolver) redact(v reflect.Value) reflect.Value {
+	// 脱敏只做字符串替换, 不会返回错误
+	v, _ = replaceStrings(v, func(s string) (string, error) {
+		return entity.RedactSecrets(s, r.values), nil
+	})
+	return v
+}
+
+// redactedError 脱敏后的错误
+type redactedError struct {
+	msg string
+	err error
+}
+
+// Error 返回脱敏后的错误信息
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+// Unwrap 返回原始错误
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// replaceStrings 递归替换值中的所有字符串, 返回替换后的副本
+func replaceStrings(v reflect.Value, replace func(string) (string, error)) (reflect.Value, error) {
+	switch v.Kind() {
+	case reflect.String:
+		s, err := replace(v.String())
+		if err != nil {
+			return v, err
+		}
+		r := reflect.New(v.Type()).Elem()
+		r.SetString(s)
+		return r, nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, nil
+		}
+		e, err := replaceStrings(v.Elem(), replace)
+		if err != nil {
+			return v, err
+		}
+		r := reflect.New(v.Type()).Elem()
+		r.Set(e)
+		return r, nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, nil
+		}
+		e, err := replaceStrings(v.Elem(), replace)
+		if err != nil {
+			return v, err
+		}
+		r := reflect.New(v.Type().Elem())
+		r.Elem().Set(e)
+		return r, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return v, nil
+		}
+		r := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			e, err := replaceStrings(iter.Value(), replace)
+			if err != nil {
+				return v, err
+			}
+			r.SetMapIndex(iter.Key(), e)
+		}
+		return r, nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return v, nil
+		}
+		r := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			e, err := replaceStrings(v.Index(i), replace)
+			if err != nil {
+				return v, err
+			}
+			r.Index(i).Set(e)
+		}
+		return r, nil
+	case reflect.Struct:
+		r := reflect.New(v.Type()).Elem()
+		r.Set(v)
+		for i := 0; i < r.NumField(); i++ {
+			if !r.Field(i).CanSet() {
+				continue
+			}
+			e, err := replaceStrings(r.Field(i), replace)
+			if err != nil {
+				return v, err
+			}
+			r.Field(i).Set(e)
+		}
+		return r, nil
+	default:
+		return v, nil
+	}
+}
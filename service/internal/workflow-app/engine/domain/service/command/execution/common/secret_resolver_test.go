@@ -0,0 +1,128 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSecretRepo 测试用的密钥仓储, 以 namespace/name 为键
+type fakeSecretRepo struct {
+	ports.SecretRepository
+	values map[string]string
+}
+
+func (r *fakeSecretRepo) Get(d *dto.GetSecretDTO) (*entity.Secret, error) {
+	value, ok := r.values[d.Namespace+"/"+d.Name]
+	if !ok {
+		return nil, fmt.Errorf("record not found")
+	}
+	return &entity.Secret{Namespace: d.Namespace, Name: d.Name, Value: value}, nil
+}
+
+// TestSecretResolver_Resolve 测试执行时替换参数中的密钥引用
+func TestSecretResolver_Resolve(t *testing.T) {
+	repo := &fakeSecretRepo{values: map[string]string{"ns/token": "abc", "other/token": "xyz"}}
+	body := map[string]interface{}{
+		"auth":  "${secrets.token}",
+		"items": []interface{}{map[string]interface{}{"key": "k-${secrets.token}"}},
+		"count": 1,
+	}
+	tests := []struct {
+		name      string
+		namespace string
+		args      interface{}
+		want      interface{}
+		wantErr   bool
+	}{
+		{"替换 HTTP 参数中的密钥引用", "ns", &entity.HTTPArgs{
+			ServiceNodeBasicArgs: entity.ServiceNodeBasicArgs{Body: body},
+			URL:                  "https://example.com",
+			Headers:              map[string]string{"Authorization": "Bearer ${secrets.token}"},
+		}, &entity.HTTPArgs{
+			ServiceNodeBasicArgs: entity.ServiceNodeBasicArgs{Body: map[string]interface{}{
+				"auth":  "abc",
+				"items": []interface{}{map[string]interface{}{"key": "k-abc"}},
+				"count": 1,
+			}},
+			URL:     "https://example.com",
+			Headers: map[string]string{"Authorization": "Bearer abc"},
+		}, false},
+		{"替换 MCP 请求头中的密钥引用", "ns", &entity.MCPArgs{
+			URL:     "https://example.com/mcp",
+			Headers: map[string]string{"Authorization": "Bearer ${secrets.token}"},
+		}, &entity.MCPArgs{
+			URL:     "https://example.com/mcp",
+			Headers: map[string]string{"Authorization": "Bearer abc"},
+		}, false},
+		{"替换脚本入参中的密钥引用", "ns", map[string]interface{}{"env": map[string]interface{}{"TOKEN": "${secrets.token}"}},
+			map[string]interface{}{"env": map[string]interface{}{"TOKEN": "abc"}}, false},
+		{"按节点所在命名空间查找密钥", "other", &entity.OpenAIArgs{APIKey: "${secrets.token}"},
+			&entity.OpenAIArgs{APIKey: "xyz"}, false},
+		{"引用的密钥不存在", "ns", &entity.OpenAIArgs{APIKey: "${secrets.unknown}"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSecretResolver(repo, tt.namespace).Resolve(tt.args)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+	// 替换时不能修改原来的参数, 原参数可能是节点实例的输入
+	assert.Equal(t, "${secrets.token}", body["auth"])
+	assert.Equal(t, "k-${secrets.token}", body["items"].([]interface{})[0].(map[string]interface{})["key"])
+}
+
+// TestSecretResolver_Redact 测试执行后将密钥值还原为引用
+func TestSecretResolver_Redact(t *testing.T) {
+	resolver := NewSecretResolver(&fakeSecretRepo{values: map[string]string{"ns/token": "abc"}}, "ns")
+	_, err := resolver.Resolve(&entity.OpenAIArgs{APIKey: "${secrets.token}"})
+	assert.Nil(t, err)
+
+	nodeInst := &entity.NodeInst{
+		Input:  map[string]interface{}{"headers": map[string]interface{}{"Authorization": "Bearer abc"}},
+		Output: map[string]interface{}{"echo": []interface{}{"abc", 1}},
+		Reason: &entity.NodeReason{FailedReason: "invalid key abc"},
+	}
+	resolver.RedactNodeInst(nodeInst)
+	assert.Equal(t, map[string]interface{}{
+		"headers": map[string]interface{}{"Authorization": "Bearer ${secrets.token}"}}, nodeInst.Input)
+	assert.Equal(t, map[string]interface{}{"echo": []interface{}{"${secrets.token}", 1}}, nodeInst.Output)
+	assert.Equal(t, "invalid key ${secrets.token}", nodeInst.Reason.FailedReason)
+
+	originErr := errors.New("request with key abc failed")
+	err = resolver.RedactError(originErr)
+	assert.Equal(t, "request with key ${secrets.token} failed", err.Error())
+	assert.True(t, errors.Is(err, originErr))
+}
+
+// TestSecretResolver_PartialSecretLen 测试获取末尾可能是密钥值开头部分的长度
+func TestSecretResolver_PartialSecretLen(t *testing.T) {
+	resolver := NewSecretResolver(&fakeSecretRepo{values: map[string]string{"ns/token": "sk-abc"}}, "ns")
+	_, err := resolver.Resolve("${secrets.token}")
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name     string
+		resolver *SecretResolver
+		s        string
+		want     int
+	}{
+		{"末尾是密钥值的开头部分", resolver, "key: sk-a", 4},
+		{"末尾是完整的密钥值", resolver, "key: sk-abc", 0},
+		{"末尾不是密钥值的开头部分", resolver, "key: abc", 0},
+		{"没有密钥", nil, "key: sk-a", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.resolver.PartialSecretLen(tt.s))
+		})
+	}
+}
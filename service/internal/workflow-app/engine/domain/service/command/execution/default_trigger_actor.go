@@ -14,6 +14,7 @@ import (
 // DefaultTriggerActor 默认触发器 Actor
 type DefaultTriggerActor struct {
 	eventBusRepo   ports.EventBusRepository
+	triggerRepo    ports.TriggerRepository
 	workflowRunner WorkflowRunner
 	nodeRunner     NodeRunner
 }
@@ -24,6 +25,7 @@ func NewDefaultActor(repoProvider *ports.RepoProviderSet,
 	nodeRunner *DefaultNodeRunner) *DefaultTriggerActor {
 	return &DefaultTriggerActor{
 		eventBusRepo:   repoProvider.EventBusRepo(),
+		triggerRepo:    repoProvider.TriggerRepo(),
 		workflowRunner: workflowRunner,
 		nodeRunner:     nodeRunner}
 }
@@ -91,3 +93,40 @@ func (h DefaultTriggerActor) OnCompleteNode(ctx context.Context,
 
 	return h.nodeRunner.Complete(ctx, completeNode)
 }
+
+// OnReceiveEvent 响应等待事件节点收到事件, 事件内容作为节点的输出, 触发器只生效一次
+func (h *DefaultTriggerActor) OnReceiveEvent(ctx context.Context,
+	trigger *entity.Trigger, actionArgs interface{}) error {
+	realActionArgs := actionArgs.(*entity.ReceiveEventActionArgs)
+
+	completeNode := &dto.CompleteNodeDTO{
+		DefID:       trigger.DefID,
+		InstID:      trigger.InstID,
+		NodeInstID:  realActionArgs.NodeInstID,
+		NodeRefName: realActionArgs.Node,
+		Status:      entity.NodeInstSucceed,
+		Output:      realActionArgs.Event,
+		Operator:    realActionArgs.Operator,
+		Reason:      fmt.Sprintf("on receive event, trigger:%s", trigger.TriggerID),
+	}
+	completeErr := h.nodeRunner.Complete(ctx, completeNode)
+	if err := DisableWaitEventTrigger(h.triggerRepo, trigger.DefID, trigger.DefVersion,
+		trigger.InstID, trigger.TriggerID); err != nil {
+		return err
+	}
+	return completeErr
+}
+
+// OnCompensateWorkflow 响应补偿流程
+func (h DefaultTriggerActor) OnCompensateWorkflow(ctx context.Context,
+	trigger *entity.Trigger, actionArgs interface{}) error {
+	realActionArgs := actionArgs.(*entity.CompensateWorkflowActionArgs)
+
+	compensateDTO := &dto.CompensateWorkflowInstDTO{
+		DefID:    trigger.DefID,
+		InstID:   trigger.InstID,
+		Operator: realActionArgs.Operator,
+		Reason:   fmt.Sprintf("on compensate workflow, trigger:%s", utils.StructToJsonStr(trigger)),
+	}
+	return h.workflowRunner.Compensate(ctx, compensateDTO)
+}
@@ -14,4 +14,6 @@ var (
 	defLockExpireTime                 = 20 * time.Second                                       // 流程定义锁的过期时间
 	sendAlertKeyTtl                   = 100 * 365 * 24 * 60 * 60 * time.Second                 // 避免重复发送超时消息的缓存 key 超时时间
 	successConditionNotMatchErrFormat = "node `successCondition` [%s] is not match, output=%s" // 成功条件不满足的错误模板
+	waitTimerOperator                 = "timer"                                                // 定时器恢复等待节点时的操作人
+	waitTimerNamePrefix               = "nodeWait"                                             // 等待节点注册的定时器名称前缀
 )
@@ -18,7 +18,7 @@ import (
 // needDelay 判断执行前是否需要等待
 func needDelay(nodeInst *entity.NodeInst) bool {
 	waitConf := nodeInst.BasicNodeDef.Wait
-	return waitConf.Duration != "" || waitConf.Expr != ""
+	return waitConf.Duration != "" || waitConf.Expr != "" || waitConf.Until != ""
 }
 
 // needRetry 判断节点是否重试
@@ -67,6 +67,19 @@ func getMaxNodeInstID(inst *entity.WorkflowInst) string {
 	return maxID
 }
 
+// needAutoCompensate 流程失败且配置了自动补偿时, 需要等到所有并行分支的节点都结束后才能开始补偿
+func needAutoCompensate(inst *entity.WorkflowInst) bool {
+	if inst.Status != entity.InstFailed || !inst.WorkflowDef.AutoCompensate || inst.Compensation != nil {
+		return false
+	}
+	for _, nodeInst := range inst.SchedNodeInsts {
+		if !nodeInst.Status.IsTerminal() {
+			return false
+		}
+	}
+	return true
+}
+
 // getWorkflowInstLockName 获取流程实例锁的名称
 func getWorkflowInstLockName(instID string) string {
 	return strings.Join([]string{utils.GetEnv(), "inst", instID}, ":")
@@ -172,3 +185,45 @@ func getNodeTimeoutSendAlertKey(nodeInst *entity.NodeInst, template common.MsgTe
 		nodeInst.NodeInstID},
 		":")
 }
+
+// maxWaitSearchTimes 查找满足 allowDays 的执行时间的最大次数, 避免配置错误时死循环
+const maxWaitSearchTimes = 366
+
+// getWaitDeliverAt 计算等待节点的执行时间, until 优先于 expr, until 需要是已经计算好表达式的值
+// 执行时间不满足 allowDays 时, until 顺延到下一个满足条件的日期, expr 查找下一个满足条件的时间
+func getWaitDeliverAt(wait entity.Wait, until string, now time.Time,
+	allowDaysChecker common.AllowDaysChecker) (time.Time, error) {
+	loc, err := wait.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var deliverAt time.Time
+	if until != "" {
+		if deliverAt, err = wait.ParseUntil(until); err != nil {
+			return time.Time{}, err
+		}
+	} else if deliverAt, err = utils.GetNextTimeByExpr(wait.Expr, now.In(loc)); err != nil {
+		return time.Time{}, err
+	}
+
+	for i := 0; i < maxWaitSearchTimes; i++ {
+		if deliverAt.IsZero() {
+			return time.Time{}, fmt.Errorf("no next time for wait expr `%s`", wait.Expr)
+		}
+		// 按照配置的时区判断是否满足 allowDays
+		allow, err := allowDaysChecker.Check(deliverAt.In(loc), wait.AllowDays)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if allow {
+			return deliverAt, nil
+		}
+		if until != "" {
+			deliverAt = deliverAt.In(loc).AddDate(0, 0, 1)
+		} else if deliverAt, err = utils.GetNextTimeByExpr(wait.Expr, deliverAt.In(loc)); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Time{}, fmt.Errorf("no wait time matches allowDays `%s` in %d times", wait.AllowDays, maxWaitSearchTimes)
+}
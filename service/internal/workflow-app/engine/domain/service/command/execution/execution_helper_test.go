@@ -106,3 +106,89 @@ func TestNeedRetry(t *testing.T) {
 		})
 	}
 }
+
+// weekdayChecker 只允许周一到周五执行的检查器
+type weekdayChecker struct{}
+
+func (weekdayChecker) Check(checkTime time.Time, policy entity.AllowDaysPolicy) (bool, error) {
+	if policy != entity.Week {
+		return true, nil
+	}
+	return checkTime.Weekday() != time.Saturday && checkTime.Weekday() != time.Sunday, nil
+}
+
+// TestGetWaitDeliverAt 测试获取等待节点的执行时间
+func TestGetWaitDeliverAt(t *testing.T) {
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	// 2030-01-04 是周五
+	now := time.Date(2030, 1, 4, 10, 0, 0, 0, shanghai)
+	tests := []struct {
+		name    string
+		wait    entity.Wait
+		until   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"until 按照时区解析", entity.Wait{Timezone: "Asia/Shanghai"}, "2030-01-08 09:00",
+			time.Date(2030, 1, 8, 1, 0, 0, 0, time.UTC), false},
+		{"until 使用 RFC3339", entity.Wait{Timezone: "Asia/Shanghai"}, "2030-01-08T09:00:00Z",
+			time.Date(2030, 1, 8, 9, 0, 0, 0, time.UTC), false},
+		{"until 是周六顺延到周一", entity.Wait{Timezone: "Asia/Shanghai", AllowDays: entity.Week}, "2030-01-05 09:00",
+			time.Date(2030, 1, 7, 9, 0, 0, 0, shanghai), false},
+		{"until 格式错误", entity.Wait{}, "next monday", time.Time{}, true},
+		{"时区错误", entity.Wait{Timezone: "Mars/Olympus"}, "2030-01-08", time.Time{}, true},
+		{"expr 下一个工作日 9 点", entity.Wait{Expr: "0 9 * * *", Timezone: "Asia/Shanghai", AllowDays: entity.Week}, "",
+			time.Date(2030, 1, 7, 9, 0, 0, 0, shanghai), false},
+		{"expr 不限制日期", entity.Wait{Expr: "0 9 * * *", Timezone: "Asia/Shanghai"}, "",
+			time.Date(2030, 1, 5, 9, 0, 0, 0, shanghai), false},
+		{"expr 没有满足 allowDays 的时间", entity.Wait{Expr: "0 9 * * 6", AllowDays: entity.Week}, "",
+			time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getWaitDeliverAt(tt.wait, tt.until, now, weekdayChecker{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getWaitDeliverAt() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("getWaitDeliverAt() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNeedAutoCompensate 测试流程失败后是否需要自动补偿
+func TestNeedAutoCompensate(t *testing.T) {
+	newInst := func(status entity.InstStatus, autoCompensate bool,
+		nodeStatuses ...entity.NodeInstStatus) *entity.WorkflowInst {
+		inst := &entity.WorkflowInst{
+			WorkflowDef: &entity.WorkflowDef{AutoCompensate: autoCompensate},
+			Status:      status,
+		}
+		for _, nodeStatus := range nodeStatuses {
+			inst.SchedNodeInsts = append(inst.SchedNodeInsts, &entity.NodeInst{Status: nodeStatus})
+		}
+		return inst
+	}
+	compensatedInst := newInst(entity.InstFailed, true, entity.NodeInstFailed)
+	compensatedInst.Compensation = &entity.Compensation{Status: entity.CompensationRunning}
+
+	tests := []struct {
+		name string
+		inst *entity.WorkflowInst
+		want bool
+	}{
+		{"所有分支都已经结束", newInst(entity.InstFailed, true, entity.NodeInstFailed, entity.NodeInstSucceed), true},
+		{"还有并行分支在执行", newInst(entity.InstFailed, true, entity.NodeInstFailed, entity.NodeInstRunning), false},
+		{"没有配置自动补偿", newInst(entity.InstFailed, false, entity.NodeInstFailed), false},
+		{"流程不是失败状态", newInst(entity.InstCancelled, true, entity.NodeInstCancelled), false},
+		{"已经开始补偿", compensatedInst, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needAutoCompensate(tt.inst); got != tt.want {
+				t.Errorf("needAutoCompensate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
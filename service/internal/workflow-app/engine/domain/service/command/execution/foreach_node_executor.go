@@ -0,0 +1,694 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/nodeexecutor"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/constants"
+	"github.com/fflow-tech/fflow/service/pkg/expr"
+	"github.com/fflow-tech/fflow/service/pkg/log"
+	"github.com/fflow-tech/fflow/service/pkg/logs"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
+)
+
+const (
+	defaultForeachMaxConcurrency = 10        // 默认的最大并发数
+	foreachCtxKey                = "foreach" // 表达式中引用当前元素的 key
+)
+
+var (
+	// foreachItemNodeEvalKeys 内联节点中需要根据当前元素计算的字段
+	foreachItemNodeEvalKeys = []string{"args", "output"}
+	// foreachDeferredArgKeys 内联节点参数中需要在执行后计算的条件, 遍历时不提前计算
+	foreachDeferredArgKeys = []string{"successCondition", "pollCondition", "cancelCondition"}
+)
+
+// ForeachNodeExecutor 遍历节点执行器实现
+// 配置内联节点时通过轮询驱动, 每次轮询按照最大并发数执行一批元素并保存进度, 配置子流程时为每个元素启动一个子流程,
+// 子流程完成后再启动后续的元素, 所有元素执行完成后按元素顺序汇总输出
+type ForeachNodeExecutor struct {
+	workflowRunner       WorkflowRunner
+	workflowInstRepo     ports.WorkflowInstRepository
+	nodeExecutorRegistry nodeexecutor.Registry
+	exprEvaluator        expr.Evaluator
+	nodeInstRepo         ports.NodeInstRepository
+	subworkflowExecutor  *SubWorkflowExecutor
+}
+
+// NewForeachNodeExecutor 新建
+func NewForeachNodeExecutor(workflowRunner WorkflowRunner, repoProviderSet *ports.RepoProviderSet,
+	workflowProviderSet *WorkflowProviderSet, subworkflowExecutor *SubWorkflowExecutor) *ForeachNodeExecutor {
+	return &ForeachNodeExecutor{
+		workflowRunner:       workflowRunner,
+		workflowInstRepo:     repoProviderSet.WorkflowInstRepo(),
+		nodeInstRepo:         repoProviderSet.NodeInstRepo(),
+		nodeExecutorRegistry: workflowProviderSet.NodeExecutorRegistry(),
+		exprEvaluator:        workflowProviderSet.ExprEvaluator(),
+		subworkflowExecutor:  subworkflowExecutor,
+	}
+}
+
+// foreachItemDetail 单个元素的执行情况
+type foreachItemDetail struct {
+	Index  int                    `json:"index"`
+	Status entity.NodeInstStatus  `json:"status"`
+	InstID string                 `json:"instID,omitempty"` // 子流程实例 ID
+	Output map[string]interface{} `json:"output,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// foreachState 遍历节点的执行状态, 保存在节点实例的输出中
+type foreachState struct {
+	Items      []interface{}        `json:"items"`
+	Results    []interface{}        `json:"results"` // 按元素顺序汇总的输出, 失败的元素为空
+	Details    []*foreachItemDetail `json:"details"`
+	Total      int                  `json:"total"`
+	Succeeded  int                  `json:"succeeded"`
+	Failed     int                  `json:"failed"`
+	Cancelling bool                 `json:"cancelling,omitempty"` // 正在取消子流程, 取消引起的子流程状态变化不需要处理
+}
+
+// newForeachState 新建
+func newForeachState(items []interface{}) *foreachState {
+	s := &foreachState{
+		Items:   items,
+		Results: make([]interface{}, len(items)),
+		Details: make([]*foreachItemDetail, 0, len(items)),
+		Total:   len(items),
+	}
+	for i := range items {
+		s.Details = append(s.Details, &foreachItemDetail{Index: i, Status: entity.NodeInstScheduled})
+	}
+	return s
+}
+
+// getForeachState 从节点实例的输出中获取执行状态
+func getForeachState(nodeInst *entity.NodeInst) (*foreachState, error) {
+	s := &foreachState{}
+	if err := utils.ToOtherInterfaceValue(s, nodeInst.Output); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// save 保存执行状态到节点实例的输出中
+func (s *foreachState) save(nodeInst *entity.NodeInst) error {
+	output, err := utils.StructToMap(s)
+	if err != nil {
+		return err
+	}
+	nodeInst.Output = output
+	return nil
+}
+
+// complete 记录元素的执行结果
+func (s *foreachState) complete(index int, status entity.NodeInstStatus, output map[string]interface{},
+	errMsg string) {
+	detail := s.Details[index]
+	detail.Status, detail.Output, detail.Error = status, output, errMsg
+	if status == entity.NodeInstSucceed {
+		s.Results[index] = output
+		s.Succeeded++
+		return
+	}
+	s.Failed++
+}
+
+// count 获取指定状态的元素数量
+func (s *foreachState) count(status entity.NodeInstStatus) int {
+	r := 0
+	for _, detail := range s.Details {
+		if detail.Status == status {
+			r++
+		}
+	}
+	return r
+}
+
+// exceedMaxFailures 失败的元素数量是否超过允许的数量
+func (s *foreachState) exceedMaxFailures(maxFailures int) bool {
+	return maxFailures >= 0 && s.Failed > maxFailures
+}
+
+// getDetailByInstID 根据子流程实例 ID 获取元素的执行情况
+func (s *foreachState) getDetailByInstID(instID string) *foreachItemDetail {
+	for _, detail := range s.Details {
+		if detail.InstID == instID {
+			return detail
+		}
+	}
+	return nil
+}
+
+// Execute 执行节点
+func (d *ForeachNodeExecutor) Execute(ctx context.Context, nodeInst *entity.NodeInst) error {
+	nodeDef, err := d.getNodeDef(nodeInst)
+	if err != nil {
+		return err
+	}
+	inst, err := d.workflowInstRepo.Get(&dto.GetWorkflowInstDTO{InstID: nodeInst.InstID, DefID: nodeInst.DefID})
+	if err != nil {
+		return err
+	}
+	baseCtx, err := d.getBaseCtx(inst, nodeInst)
+	if err != nil {
+		return err
+	}
+	items, err := d.evaluateItems(baseCtx, nodeDef)
+	if err != nil {
+		return err
+	}
+
+	state := newForeachState(items)
+	if nodeDef.RunSubworkflow() {
+		err = d.executeSubworkflows(nodeInst, nodeDef, baseCtx, state)
+	} else {
+		err = d.prepareNodes(nodeInst, nodeDef, state)
+	}
+	if err != nil {
+		return err
+	}
+	return state.save(nodeInst)
+}
+
+// prepareNodes 检查内联节点, 元素在后续的轮询中执行, 避免在流程实例的锁中执行所有元素
+func (d *ForeachNodeExecutor) prepareNodes(nodeInst *entity.NodeInst, nodeDef entity.ForeachNodeDef,
+	state *foreachState) error {
+	if _, err := d.getItemNodeExecutor(nodeInst, nodeDef); err != nil {
+		return err
+	}
+	if state.Total == 0 {
+		d.finish(nodeInst, nodeDef, state)
+	}
+	return nil
+}
+
+// getBaseCtx 获取计算表达式的基础上下文
+func (d *ForeachNodeExecutor) getBaseCtx(inst *entity.WorkflowInst,
+	nodeInst *entity.NodeInst) (map[string]interface{}, error) {
+	ctx, err := entity.ConvertToCtx(inst)
+	if err != nil {
+		return nil, err
+	}
+	if err := entity.AppendNodeInfoToCtxKey(ctx, nodeInst, constants.ThisNode); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// getItemCtx 获取计算单个元素表达式的上下文, 通过 foreach.item 和 foreach.index 引用当前元素
+func (d *ForeachNodeExecutor) getItemCtx(baseCtx map[string]interface{}, state *foreachState,
+	index int) map[string]interface{} {
+	ctx := make(map[string]interface{}, len(baseCtx)+1)
+	for k, v := range baseCtx {
+		ctx[k] = v
+	}
+	ctx[foreachCtxKey] = map[string]interface{}{
+		"item":  state.Items[index],
+		"index": index,
+		"total": state.Total,
+	}
+	return ctx
+}
+
+// evaluateItems 计算需要遍历的数组
+func (d *ForeachNodeExecutor) evaluateItems(ctx map[string]interface{},
+	nodeDef entity.ForeachNodeDef) ([]interface{}, error) {
+	r, err := d.exprEvaluator.EvaluateMap(ctx, map[string]interface{}{"items": nodeDef.Items})
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate foreach items: %w", err)
+	}
+	items, err := toItemArray(r["items"])
+	if err != nil {
+		return nil, fmt.Errorf("foreach %w", err)
+	}
+	return items, nil
+}
+
+// toItemArray 将计算后的 items 转换为数组, 为空时返回空数组
+func toItemArray(items interface{}) ([]interface{}, error) {
+	if items == nil {
+		return []interface{}{}, nil
+	}
+	if v, ok := items.([]interface{}); ok {
+		return v, nil
+	}
+	value := reflect.ValueOf(items)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return nil, fmt.Errorf("items must be an array, but got %T", items)
+	}
+	result := make([]interface{}, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		result = append(result, value.Index(i).Interface())
+	}
+	return result, nil
+}
+
+// executeNodes 并发执行下一批等待执行的元素, 一批的数量不超过最大并发数
+// 超过允许的失败数量或者所有元素都执行完成时设置节点状态
+func (d *ForeachNodeExecutor) executeNodes(ctx context.Context, nodeInst *entity.NodeInst,
+	nodeDef entity.ForeachNodeDef, baseCtx map[string]interface{}, state *foreachState) error {
+	executor, err := d.getItemNodeExecutor(nodeInst, nodeDef)
+	if err != nil {
+		return err
+	}
+
+	batch := d.nextNodeBatch(nodeDef, state)
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		itemInsts = make([]*entity.NodeInst, len(batch))
+	)
+	for i, index := range batch {
+		wg.Add(1)
+		go func(i, index int) {
+			defer wg.Done()
+			itemInst, err := d.newItemNodeInst(nodeInst, nodeDef, d.getItemCtx(baseCtx, state, index), index)
+			if err == nil {
+				itemInsts[i] = itemInst
+				err = d.executeItemNode(ctx, executor, itemInst)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				state.complete(index, entity.NodeInstFailed, nil, err.Error())
+				return
+			}
+			state.complete(index, entity.NodeInstSucceed, itemInst.Output, "")
+		}(i, index)
+	}
+	wg.Wait()
+
+	if usage := entity.SumTokenUsage(itemInsts); usage != nil {
+		if nodeInst.TokenUsage == nil {
+			nodeInst.TokenUsage = &entity.TokenUsage{}
+		}
+		nodeInst.TokenUsage.Add(usage)
+	}
+	if len(d.nextNodeBatch(nodeDef, state)) == 0 {
+		d.finish(nodeInst, nodeDef, state)
+	}
+	return nil
+}
+
+// nextNodeBatch 获取下一批需要执行的元素, 超过允许的失败数量后不再执行后续的元素
+func (d *ForeachNodeExecutor) nextNodeBatch(nodeDef entity.ForeachNodeDef, state *foreachState) []int {
+	if state.exceedMaxFailures(nodeDef.MaxFailures) {
+		return nil
+	}
+	var batch []int
+	for _, detail := range state.Details {
+		if len(batch) >= getForeachMaxConcurrency(nodeDef) {
+			break
+		}
+		if detail.Status == entity.NodeInstScheduled {
+			batch = append(batch, detail.Index)
+		}
+	}
+	return batch
+}
+
+// getItemNodeExecutor 获取内联节点的执行器, 内联节点必须是同步完成的节点
+func (d *ForeachNodeExecutor) getItemNodeExecutor(nodeInst *entity.NodeInst,
+	nodeDef entity.ForeachNodeDef) (nodeexecutor.NodeExecutor, error) {
+	if len(nodeDef.Node) == 0 {
+		return nil, fmt.Errorf("foreach node [%s] must configure node or subworkflow", nodeDef.RefName)
+	}
+	basicNodeDef, err := entity.GetBasicNodeDefFromNodeDef(nodeDef.Node)
+	if err != nil {
+		return nil, err
+	}
+	executor, exists := d.nodeExecutorRegistry.GetExecutor(basicNodeDef.Type)
+	if !exists {
+		return nil, fmt.Errorf("failed to get executor, nodeType=%s", basicNodeDef.Type)
+	}
+	if executor.AsyncComplete(&entity.NodeInst{BasicNodeDef: *basicNodeDef, NodeDef: nodeDef.Node}) {
+		return nil, fmt.Errorf("foreach node [%s] inline node must complete synchronously", nodeDef.RefName)
+	}
+	return executor, nil
+}
+
+// newItemNodeInst 根据当前元素生成内联节点的实例, 内联节点和遍历节点共用节点实例 ID
+func (d *ForeachNodeExecutor) newItemNodeInst(nodeInst *entity.NodeInst, nodeDef entity.ForeachNodeDef,
+	itemCtx map[string]interface{}, index int) (*entity.NodeInst, error) {
+	itemNodeDef, err := d.evaluateItemNodeDef(itemCtx, nodeDef.Node)
+	if err != nil {
+		return nil, err
+	}
+	itemNodeDef["refName"] = fmt.Sprintf("%s[%d]", nodeDef.RefName, index)
+	basicNodeDef, err := entity.GetBasicNodeDefFromNodeDef(itemNodeDef)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.NodeInst{
+		NodeDef:      itemNodeDef,
+		BasicNodeDef: *basicNodeDef,
+		Namespace:    nodeInst.Namespace,
+		DefID:        nodeInst.DefID,
+		DefVersion:   nodeInst.DefVersion,
+		InstID:       nodeInst.InstID,
+		NodeInstID:   nodeInst.NodeInstID,
+		Status:       entity.NodeInstRunning,
+		Owner:        nodeInst.Owner,
+		ExecuteAt:    time.Now(),
+		Operator:     &entity.NodeOperator{},
+		Reason:       &entity.NodeReason{},
+	}, nil
+}
+
+// evaluateItemNodeDef 根据当前元素计算内联节点定义中的表达式
+func (d *ForeachNodeExecutor) evaluateItemNodeDef(itemCtx map[string]interface{},
+	node map[string]interface{}) (map[string]interface{}, error) {
+	r := make(map[string]interface{}, len(node))
+	for k, v := range node {
+		r[k] = v
+	}
+	for _, key := range foreachItemNodeEvalKeys {
+		m, ok := r[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		toEvaluate, deferred := splitForeachDeferredArgs(m)
+		evaluated, err := d.exprEvaluator.EvaluateMap(itemCtx, toEvaluate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate foreach node %s: %w", key, err)
+		}
+		for k, v := range deferred {
+			evaluated[k] = v
+		}
+		r[key] = evaluated
+	}
+	return r, nil
+}
+
+// splitForeachDeferredArgs 拆分出执行后才计算的条件
+func splitForeachDeferredArgs(m map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	toEvaluate, deferred := map[string]interface{}{}, map[string]interface{}{}
+	for k, v := range m {
+		if utils.StrContains(foreachDeferredArgKeys, k) {
+			deferred[k] = v
+			continue
+		}
+		toEvaluate[k] = v
+	}
+	return toEvaluate, deferred
+}
+
+// executeItemNode 执行单个元素的内联节点
+func (d *ForeachNodeExecutor) executeItemNode(ctx context.Context, executor nodeexecutor.NodeExecutor,
+	itemInst *entity.NodeInst) error {
+	if err := executor.Execute(ctx, itemInst); err != nil {
+		return err
+	}
+	if itemInst.Status == entity.NodeInstFailed {
+		return errors.New(itemInst.Reason.FailedReason)
+	}
+	itemInst.Status = entity.NodeInstSucceed
+	return nil
+}
+
+// executeSubworkflows 为元素启动子流程, 同时运行的子流程数量不超过最大并发数
+func (d *ForeachNodeExecutor) executeSubworkflows(nodeInst *entity.NodeInst, nodeDef entity.ForeachNodeDef,
+	baseCtx map[string]interface{}, state *foreachState) error {
+	subworkflowDef, err := d.subworkflowExecutor.getSubworkflowDef(nodeInst, toSubworkflowNodeDef(nodeDef))
+	if err != nil {
+		return err
+	}
+	nodeInst.SubworkflowDefID = subworkflowDef.DefID
+	return d.startSubworkflows(nodeInst, nodeDef, baseCtx, state)
+}
+
+// toSubworkflowNodeDef 转换成子流程节点定义, 用于获取子流程定义
+func toSubworkflowNodeDef(nodeDef entity.ForeachNodeDef) entity.SubworkflowNodeDef {
+	return entity.SubworkflowNodeDef{
+		BasicNodeDef: nodeDef.BasicNodeDef,
+		Subworkflow:  nodeDef.Subworkflow,
+		ID:           nodeDef.ID,
+		Version:      nodeDef.Version,
+		Args:         nodeDef.Args,
+	}
+}
+
+// startSubworkflows 启动等待执行的元素, 所有元素都完成时设置节点状态
+func (d *ForeachNodeExecutor) startSubworkflows(nodeInst *entity.NodeInst, nodeDef entity.ForeachNodeDef,
+	baseCtx map[string]interface{}, state *foreachState) error {
+	running := state.count(entity.NodeInstRunning)
+	for _, detail := range state.Details {
+		if running >= getForeachMaxConcurrency(nodeDef) || state.exceedMaxFailures(nodeDef.MaxFailures) {
+			break
+		}
+		if detail.Status != entity.NodeInstScheduled {
+			continue
+		}
+		instID, err := d.startSubworkflow(nodeInst, nodeDef, d.getItemCtx(baseCtx, state, detail.Index), detail.Index)
+		if err != nil {
+			log.Errorf("[%s]Failed to start foreach subworkflow for item [%d], caused by %s",
+				logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), detail.Index, err)
+			state.complete(detail.Index, entity.NodeInstFailed, nil, err.Error())
+			continue
+		}
+		detail.Status, detail.InstID = entity.NodeInstRunning, instID
+		running++
+	}
+
+	if running == 0 {
+		d.finish(nodeInst, nodeDef, state)
+	}
+	return nil
+}
+
+// startSubworkflow 为单个元素启动子流程
+func (d *ForeachNodeExecutor) startSubworkflow(nodeInst *entity.NodeInst, nodeDef entity.ForeachNodeDef,
+	itemCtx map[string]interface{}, index int) (string, error) {
+	input, err := d.exprEvaluator.EvaluateMap(itemCtx, nodeDef.Args.Input)
+	if err != nil {
+		return "", fmt.Errorf("failed to evaluate foreach subworkflow input: %w", err)
+	}
+	return d.workflowRunner.Start(context.Background(), &dto.StartWorkflowInstDTO{
+		DefID:            nodeInst.SubworkflowDefID,
+		ParentInstID:     nodeInst.InstID,
+		ParentNodeInstID: nodeInst.NodeInstID,
+		Name:             nodeDef.Args.Name,
+		Creator:          nodeDef.Args.Operator,
+		Input:            input,
+		Reason: fmt.Sprintf("Start foreach subworkflow [subworkflowDefID:%s] for item [%d] of "+
+			"defID:[%s]|instID:[%s]|nodeInstID:[%s]", nodeInst.SubworkflowDefID, index,
+			nodeInst.DefID, nodeInst.InstID, nodeInst.NodeInstID),
+	})
+}
+
+// AwareOfSubworkflow 遍历节点启动的子流程都由执行器处理
+func (d *ForeachNodeExecutor) AwareOfSubworkflow(nodeInst *entity.NodeInst) bool {
+	return true
+}
+
+// OnSubworkflowUpdated 子流程结束时记录元素的执行结果并启动后续的元素
+func (d *ForeachNodeExecutor) OnSubworkflowUpdated(ctx context.Context, nodeInst *entity.NodeInst,
+	subworkflowInst *entity.WorkflowInst) (bool, error) {
+	if nodeInst.Status.IsTerminal() || !subworkflowInst.Status.IsTerminal() {
+		return false, nil
+	}
+	state, err := getForeachState(nodeInst)
+	if err != nil || state.Cancelling {
+		return false, err
+	}
+	detail := state.getDetailByInstID(subworkflowInst.InstID)
+	if detail == nil || detail.Status.IsTerminal() {
+		return false, nil
+	}
+	status, errMsg := getForeachItemStatus(subworkflowInst)
+	state.complete(detail.Index, status, subworkflowInst.Output, errMsg)
+
+	nodeDef, err := d.getNodeDef(nodeInst)
+	if err != nil {
+		return false, err
+	}
+	inst, err := d.workflowInstRepo.Get(&dto.GetWorkflowInstDTO{InstID: nodeInst.InstID, DefID: nodeInst.DefID})
+	if err != nil {
+		return false, err
+	}
+	baseCtx, err := d.getBaseCtx(inst, nodeInst)
+	if err != nil {
+		return false, err
+	}
+	if err := d.startSubworkflows(nodeInst, nodeDef, baseCtx, state); err != nil {
+		return false, err
+	}
+	return true, state.save(nodeInst)
+}
+
+// getForeachItemStatus 根据子流程的状态获取元素的状态
+func getForeachItemStatus(subworkflowInst *entity.WorkflowInst) (entity.NodeInstStatus, string) {
+	switch subworkflowInst.Status {
+	case entity.InstSucceed:
+		return entity.NodeInstSucceed, ""
+	case entity.InstFailed:
+		return entity.NodeInstFailed, subworkflowInst.Reason.FailedRootCause.FailedReason
+	case entity.InstTimeout:
+		return entity.NodeInstTimeout, fmt.Sprintf("subworkflow [%s] timeout", subworkflowInst.InstID)
+	default:
+		return entity.NodeInstCancelled, fmt.Sprintf("subworkflow [%s] cancelled", subworkflowInst.InstID)
+	}
+}
+
+// finish 所有元素执行完成后根据失败数量设置节点状态
+func (d *ForeachNodeExecutor) finish(nodeInst *entity.NodeInst, nodeDef entity.ForeachNodeDef,
+	state *foreachState) {
+	// 因为超过允许的失败数量而没有执行的元素标记为取消
+	for _, detail := range state.Details {
+		if detail.Status == entity.NodeInstScheduled {
+			detail.Status = entity.NodeInstCancelled
+		}
+	}
+
+	nodeInst.CompletedAt = time.Now()
+	if state.exceedMaxFailures(nodeDef.MaxFailures) {
+		nodeInst.Status = entity.NodeInstFailed
+		nodeInst.Reason.FailedReason = fmt.Sprintf("foreach failed items %d > maxFailures %d, first error: %s",
+			state.Failed, nodeDef.MaxFailures, getFirstForeachError(state))
+		return
+	}
+	nodeInst.Status = entity.NodeInstSucceed
+	nodeInst.Reason.SucceedReason = fmt.Sprintf("foreach %d items completed, %d succeed, %d failed",
+		state.Total, state.Succeeded, state.Failed)
+}
+
+// getFirstForeachError 获取第一个失败元素的原因
+func getFirstForeachError(state *foreachState) string {
+	for _, detail := range state.Details {
+		if detail.Error != "" {
+			return fmt.Sprintf("[%d]%s", detail.Index, detail.Error)
+		}
+	}
+	return ""
+}
+
+// getForeachMaxConcurrency 获取最大并发数
+func getForeachMaxConcurrency(nodeDef entity.ForeachNodeDef) int {
+	if nodeDef.MaxConcurrency <= 0 {
+		return defaultForeachMaxConcurrency
+	}
+	return nodeDef.MaxConcurrency
+}
+
+// Polling 轮询节点, 每次轮询执行一批内联节点, 进度在轮询结束后保存到节点实例中
+func (d *ForeachNodeExecutor) Polling(ctx context.Context, nodeInst *entity.NodeInst) error {
+	nodeDef, err := d.getNodeDef(nodeInst)
+	if err != nil {
+		return err
+	}
+	if nodeDef.RunSubworkflow() {
+		return nil
+	}
+	state, err := getForeachState(nodeInst)
+	if err != nil {
+		return err
+	}
+	inst, err := d.workflowInstRepo.Get(&dto.GetWorkflowInstDTO{InstID: nodeInst.InstID, DefID: nodeInst.DefID})
+	if err != nil {
+		return err
+	}
+	baseCtx, err := d.getBaseCtx(inst, nodeInst)
+	if err != nil {
+		return err
+	}
+	if err := d.executeNodes(ctx, nodeInst, nodeDef, baseCtx, state); err != nil {
+		return err
+	}
+	return state.save(nodeInst)
+}
+
+// AwareOfPolling 内联节点的轮询由执行器在所有元素执行完成后结束
+func (d *ForeachNodeExecutor) AwareOfPolling(nodeInst *entity.NodeInst) bool {
+	return d.AsyncByPolling(nodeInst)
+}
+
+// Cancel 取消执行节点, 同时取消正在运行的子流程
+func (d *ForeachNodeExecutor) Cancel(ctx context.Context, nodeInst *entity.NodeInst) error {
+	if nodeInst.SubworkflowDefID == "" {
+		nodeInst.Status = entity.NodeInstCancelled
+		return nil
+	}
+
+	state, err := getForeachState(nodeInst)
+	if err != nil {
+		return err
+	}
+	// 取消子流程之前先保存取消标记, 其他进程处理子流程的状态变化时也可以忽略
+	state.Cancelling = true
+	if err := state.save(nodeInst); err != nil {
+		return err
+	}
+	if err := saveNodeInst(d.nodeInstRepo, nodeInst); err != nil {
+		return err
+	}
+	for _, detail := range state.Details {
+		if detail.Status != entity.NodeInstRunning && detail.Status != entity.NodeInstScheduled {
+			continue
+		}
+		if detail.Status == entity.NodeInstRunning {
+			if err := d.workflowRunner.Cancel(ctx, &dto.CancelWorkflowInstDTO{
+				DefID:    nodeInst.SubworkflowDefID,
+				InstID:   detail.InstID,
+				Operator: nodeInst.Operator.CancelledOperator,
+			}); err != nil {
+				log.Errorf("[%s]Failed to cancel foreach subworkflow [%s], caused by %s",
+					logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), detail.InstID, err)
+			}
+		}
+		detail.Status = entity.NodeInstCancelled
+	}
+
+	nodeInst.Status = entity.NodeInstCancelled
+	return state.save(nodeInst)
+}
+
+// AsyncComplete 是否异步完成
+func (d *ForeachNodeExecutor) AsyncComplete(nodeInst *entity.NodeInst) bool {
+	return d.AsyncByTrigger(nodeInst) || d.AsyncByPolling(nodeInst)
+}
+
+// AsyncByTrigger 通过触发器实现异步
+func (d *ForeachNodeExecutor) AsyncByTrigger(nodeInst *entity.NodeInst) bool {
+	// 配置子流程时, 节点的完成是根据所有子流程的完成事件来异步确定的
+	nodeDef, err := d.getNodeDef(nodeInst)
+	if err != nil {
+		log.Warnf("Failed to decide async by trigger, caused by %s", err)
+		return false
+	}
+	return nodeDef.RunSubworkflow()
+}
+
+// AsyncByPolling 通过轮询实现异步
+func (d *ForeachNodeExecutor) AsyncByPolling(nodeInst *entity.NodeInst) bool {
+	// 配置内联节点时, 元素是在每次轮询中分批执行的
+	nodeDef, err := d.getNodeDef(nodeInst)
+	if err != nil {
+		log.Warnf("Failed to decide async by polling, caused by %s", err)
+		return false
+	}
+	return !nodeDef.RunSubworkflow()
+}
+
+// Type 获取是哪种节点类型的处理器
+func (d *ForeachNodeExecutor) Type() entity.NodeType {
+	return entity.ForeachNode
+}
+
+// getNodeDef 获取节点定义
+func (d *ForeachNodeExecutor) getNodeDef(nodeInst *entity.NodeInst) (entity.ForeachNodeDef, error) {
+	nodeDef, err := entity.ToActualNodeDef(entity.ForeachNode, nodeInst.NodeDef)
+	if err != nil {
+		return entity.ForeachNodeDef{}, err
+	}
+	return nodeDef.(entity.ForeachNodeDef), nil
+}
@@ -0,0 +1,225 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/nodeexecutor"
+	"github.com/fflow-tech/fflow/service/pkg/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeItemNodeExecutor 测试用的内联节点执行器, 输出计算后的 output, fail 为 true 时执行失败
+type fakeItemNodeExecutor struct {
+	nodeexecutor.NodeExecutor
+	running    int32
+	maxRunning int32
+}
+
+func (e *fakeItemNodeExecutor) Execute(ctx context.Context, nodeInst *entity.NodeInst) error {
+	running := atomic.AddInt32(&e.running, 1)
+	defer atomic.AddInt32(&e.running, -1)
+	for {
+		maxRunning := atomic.LoadInt32(&e.maxRunning)
+		if running <= maxRunning || atomic.CompareAndSwapInt32(&e.maxRunning, maxRunning, running) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	output := nodeInst.NodeDef.(map[string]interface{})["output"].(map[string]interface{})
+	if output["fail"] == true {
+		return errors.New("item failed")
+	}
+	nodeInst.Output = output
+	return nil
+}
+
+func (e *fakeItemNodeExecutor) AsyncComplete(nodeInst *entity.NodeInst) bool {
+	return false
+}
+
+func (e *fakeItemNodeExecutor) Type() entity.NodeType {
+	return entity.TransformNode
+}
+
+// TestForeachNodeExecutor_ExecuteNodes 测试每次轮询按最大并发数执行一批内联节点并按顺序汇总输出
+func TestForeachNodeExecutor_ExecuteNodes(t *testing.T) {
+	items := []interface{}{
+		map[string]interface{}{"name": "a"},
+		map[string]interface{}{"name": "b", "fail": true},
+		map[string]interface{}{"name": "c"},
+	}
+	tests := []struct {
+		name           string
+		maxConcurrency int
+		maxFailures    int
+		items          []interface{}
+		wantStatus     entity.NodeInstStatus
+		wantResults    []interface{}
+		wantFailed     int
+		wantMaxRunning int32
+		wantPolls      int
+	}{
+		{"没有失败的元素", 2, 0, []interface{}{items[0], items[2]}, entity.NodeInstSucceed,
+			[]interface{}{map[string]interface{}{"name": "a", "index": 0},
+				map[string]interface{}{"name": "c", "index": 1}}, 0, 2, 1},
+		{"失败数量在允许范围内", 1, 1, items, entity.NodeInstSucceed,
+			[]interface{}{map[string]interface{}{"name": "a", "index": 0}, nil,
+				map[string]interface{}{"name": "c", "index": 2}}, 1, 1, 3},
+		{"不限制失败数量", 3, -1, items, entity.NodeInstSucceed,
+			[]interface{}{map[string]interface{}{"name": "a", "index": 0}, nil,
+				map[string]interface{}{"name": "c", "index": 2}}, 1, 3, 1},
+		{"分批执行", 2, -1, items, entity.NodeInstSucceed,
+			[]interface{}{map[string]interface{}{"name": "a", "index": 0}, nil,
+				map[string]interface{}{"name": "c", "index": 2}}, 1, 2, 2},
+		{"超过允许的失败数量后不再执行后续元素", 1, 0, items, entity.NodeInstFailed,
+			[]interface{}{map[string]interface{}{"name": "a", "index": 0}, nil, nil}, 1, 1, 2},
+		{"空数组直接成功", 2, 0, []interface{}{}, entity.NodeInstSucceed, []interface{}{}, 0, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			itemExecutor := &fakeItemNodeExecutor{}
+			registry := nodeexecutor.NewDefaultRegistry()
+			registry.Register(itemExecutor)
+			d := &ForeachNodeExecutor{nodeExecutorRegistry: registry, exprEvaluator: expr.NewDefaultEvaluator()}
+			nodeDef := entity.ForeachNodeDef{
+				BasicNodeDef:   entity.BasicNodeDef{RefName: "docs", Type: entity.ForeachNode},
+				MaxConcurrency: tt.maxConcurrency,
+				MaxFailures:    tt.maxFailures,
+				Node: map[string]interface{}{
+					"type": "TRANSFORM",
+					"output": map[string]interface{}{
+						"name":  "${foreach.item.name}",
+						"fail":  "${foreach.item.fail == true}",
+						"index": "${foreach.index}",
+					},
+				},
+			}
+			nodeInst := &entity.NodeInst{Status: entity.NodeInstRunning, Reason: &entity.NodeReason{}}
+			assert.Nil(t, newForeachState(tt.items).save(nodeInst))
+
+			// 每次轮询都从节点实例的输出中恢复进度, 直到节点结束
+			var state *foreachState
+			polls := 0
+			for !nodeInst.Status.IsTerminal() && polls <= len(tt.items) {
+				var err error
+				state, err = getForeachState(nodeInst)
+				assert.Nil(t, err)
+				assert.Nil(t, d.executeNodes(context.Background(), nodeInst, nodeDef, map[string]interface{}{}, state))
+				assert.Nil(t, state.save(nodeInst))
+				polls++
+			}
+			assert.Equal(t, tt.wantPolls, polls)
+			assert.Equal(t, tt.wantStatus, nodeInst.Status)
+			assert.Equal(t, tt.wantFailed, state.Failed)
+			assert.Equal(t, tt.wantMaxRunning, itemExecutor.maxRunning)
+			for i, result := range tt.wantResults {
+				if result == nil {
+					assert.Nil(t, state.Results[i])
+					continue
+				}
+				want := result.(map[string]interface{})
+				got := state.Results[i].(map[string]interface{})
+				assert.Equal(t, want["name"], got["name"])
+				assert.EqualValues(t, want["index"], got["index"])
+			}
+		})
+	}
+}
+
+// TestForeachNodeExecutor_EvaluateItems 测试计算需要遍历的数组
+func TestForeachNodeExecutor_EvaluateItems(t *testing.T) {
+	ctx := map[string]interface{}{
+		"w": map[string]interface{}{"i": map[string]interface{}{
+			"docs": []interface{}{"a", "b"},
+			"tags": []string{"x"},
+			"name": "n",
+		}},
+	}
+	tests := []struct {
+		name    string
+		items   interface{}
+		want    []interface{}
+		wantErr bool
+	}{
+		{"表达式返回数组", "${w.i.docs}", []interface{}{"a", "b"}, false},
+		{"表达式返回其他类型的切片", "${w.i.tags}", []interface{}{"x"}, false},
+		{"直接配置数组", []interface{}{1, 2}, []interface{}{1, 2}, false},
+		{"表达式返回的不是数组", "${w.i.name}", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &ForeachNodeExecutor{exprEvaluator: expr.NewDefaultEvaluator()}
+			got, err := d.evaluateItems(ctx, entity.ForeachNodeDef{Items: tt.items})
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// TestGetForeachItemStatus 测试根据子流程状态获取元素状态
+func TestGetForeachItemStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		inst       *entity.WorkflowInst
+		wantStatus entity.NodeInstStatus
+		wantErr    string
+	}{
+		{"子流程成功", &entity.WorkflowInst{Status: entity.InstSucceed}, entity.NodeInstSucceed, ""},
+		{"子流程失败", &entity.WorkflowInst{Status: entity.InstFailed, Reason: &entity.InstReason{
+			FailedRootCause: entity.InstFailedRootCause{FailedReason: "boom"}}}, entity.NodeInstFailed, "boom"},
+		{"子流程超时", &entity.WorkflowInst{InstID: "1", Status: entity.InstTimeout},
+			entity.NodeInstTimeout, "subworkflow [1] timeout"},
+		{"子流程取消", &entity.WorkflowInst{InstID: "1", Status: entity.InstCancelled},
+			entity.NodeInstCancelled, "subworkflow [1] cancelled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, errMsg := getForeachItemStatus(tt.inst)
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantErr, errMsg)
+		})
+	}
+}
+
+// TestForeachNodeExecutor_Cancel 测试取消节点时先保存取消标记再取消正在运行的子流程
+func TestForeachNodeExecutor_Cancel(t *testing.T) {
+	runner := &fakeCancelWorkflowRunner{}
+	nodeInstRepo := &fakeNodeInstRepo{}
+	d := &ForeachNodeExecutor{workflowRunner: runner, nodeInstRepo: nodeInstRepo}
+	nodeInst := &entity.NodeInst{
+		SubworkflowDefID: "10",
+		Status:           entity.NodeInstRunning,
+		Operator:         &entity.NodeOperator{},
+	}
+	state := newForeachState([]interface{}{"a", "b", "c"})
+	state.Details[0].Status, state.Details[0].InstID = entity.NodeInstSucceed, "child-0"
+	state.Details[1].Status, state.Details[1].InstID = entity.NodeInstRunning, "child-1"
+	assert.Nil(t, state.save(nodeInst))
+
+	assert.Nil(t, d.Cancel(context.Background(), nodeInst))
+	assert.Equal(t, []string{"child-1"}, runner.cancelled)
+	assert.Equal(t, entity.NodeInstCancelled, nodeInst.Status)
+	got, err := getForeachState(nodeInst)
+	assert.Nil(t, err)
+	assert.Equal(t, []entity.NodeInstStatus{entity.NodeInstSucceed, entity.NodeInstCancelled,
+		entity.NodeInstCancelled}, []entity.NodeInstStatus{got.Details[0].Status, got.Details[1].Status,
+		got.Details[2].Status})
+
+	// 其他进程重新加载节点实例后忽略取消引起的子流程状态变化
+	assert.Len(t, nodeInstRepo.updated, 1)
+	savedNodeInst := &entity.NodeInst{}
+	assert.Nil(t, json.Unmarshal([]byte(nodeInstRepo.updated[0].Context), savedNodeInst))
+	assert.Equal(t, entity.NodeInstRunning, savedNodeInst.Status)
+	updated, err := d.OnSubworkflowUpdated(context.Background(), savedNodeInst, &entity.WorkflowInst{
+		InstID: "child-1", Status: entity.InstCancelled, Reason: &entity.InstReason{},
+	})
+	assert.Nil(t, err)
+	assert.False(t, updated)
+}
@@ -128,6 +128,10 @@ func (r *DefaultNodePoller) needContinuePolling(inst *entity.WorkflowInst, nodeI
 }
 
 func (r *DefaultNodePoller) afterPolling(nodeInst *entity.NodeInst) error {
+	if r.awareOfPolling(nodeInst) {
+		return r.afterAwarePolling(nodeInst)
+	}
+
 	inst, err := r.workflowInstRepo.Get(dto.NewGetWorkflowInstDTO(nodeInst.InstID, nodeInst.DefID, ""))
 	if err != nil {
 		return err
@@ -146,6 +150,27 @@ func (r *DefaultNodePoller) afterPolling(nodeInst *entity.NodeInst) error {
 	return r.updateNodeInstStatusAndSendDriveEvent(nodeInst)
 }
 
+// awareOfPolling 是否由节点执行器决定轮询何时结束
+func (r *DefaultNodePoller) awareOfPolling(nodeInst *entity.NodeInst) bool {
+	executor, exists := r.nodeExecutorRegistry.GetExecutor(nodeInst.BasicNodeDef.Type)
+	if !exists {
+		return false
+	}
+	awareExecutor, ok := executor.(nodeexecutor.PollingAwareExecutor)
+	return ok && awareExecutor.AwareOfPolling(nodeInst)
+}
+
+// afterAwarePolling 节点在轮询中结束时驱动流程继续执行, 否则马上进行下一次轮询
+func (r *DefaultNodePoller) afterAwarePolling(nodeInst *entity.NodeInst) error {
+	if nodeInst.Status.IsTerminal() {
+		return r.updateNodeInstStatusAndSendDriveEvent(nodeInst)
+	}
+	if err := r.workflowUpdater.SendNodeDriveEvent(nodeInst, event.NodePollDrive); err != nil {
+		return fmt.Errorf("failed to produce poll drive msg, caused by %s: %w", err, errno.Unavailable)
+	}
+	return nil
+}
+
 func (r *DefaultNodePoller) sendPollDriveEvent(nodeInst *entity.NodeInst) error {
 	deliverAfter, err := r.evaluatePollingDurationTime(nodeInst)
 	if err != nil {
@@ -3,6 +3,10 @@ package execution
 import (
 	"testing"
 	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/nodeexecutor"
+	"github.com/stretchr/testify/assert"
 )
 
 // Test_getPollMaxDuration 测试
@@ -50,3 +54,41 @@ func Test_getPollInitialDuration(t *testing.T) {
 		})
 	}
 }
+
+// fakePollingAwareExecutor 在轮询中自行结束节点的执行器
+type fakePollingAwareExecutor struct {
+	fakeAsyncExecutor
+}
+
+func (e *fakePollingAwareExecutor) AwareOfPolling(*entity.NodeInst) bool { return true }
+
+// TestDefaultNodePoller_AfterAwarePolling 测试由执行器决定轮询何时结束
+func TestDefaultNodePoller_AfterAwarePolling(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     entity.NodeInstStatus
+		wantEvents []string
+	}{
+		{"节点还在执行时马上进行下一次轮询", entity.NodeInstRunning, []string{"NodePollDriveEvent:1"}},
+		{"节点成功后驱动流程继续执行", entity.NodeInstSucceed,
+			[]string{"update:1:false", "NodeCompleteDriveEvent:1"}},
+		{"节点失败后驱动流程继续执行", entity.NodeInstFailed,
+			[]string{"update:1:false", "NodeCompleteDriveEvent:1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := nodeexecutor.NewDefaultRegistry()
+			registry.Register(&fakePollingAwareExecutor{fakeAsyncExecutor{nodeType: entity.ForeachNode, polling: true}})
+			updater := &fakeRecoverUpdater{}
+			r := &DefaultNodePoller{workflowUpdater: updater, nodeExecutorRegistry: registry}
+			nodeInst := &entity.NodeInst{
+				NodeInstID:   "1",
+				Status:       tt.status,
+				BasicNodeDef: entity.BasicNodeDef{Type: entity.ForeachNode},
+			}
+
+			assert.Nil(t, r.afterPolling(nodeInst))
+			assert.Equal(t, tt.wantEvents, updater.events)
+		})
+	}
+}
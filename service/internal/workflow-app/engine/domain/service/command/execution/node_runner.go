@@ -2,6 +2,7 @@ package execution
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,8 +13,10 @@ import (
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/common"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/nodeexecutor"
+	"github.com/fflow-tech/fflow/service/pkg/expr"
 	"github.com/fflow-tech/fflow/service/pkg/log"
 	"github.com/fflow-tech/fflow/service/pkg/logs"
+	"github.com/fflow-tech/fflow/service/pkg/remote"
 	"github.com/fflow-tech/fflow/service/pkg/utils"
 	"github.com/jinzhu/copier"
 )
@@ -29,6 +32,7 @@ type NodeRunner interface {
 	Polling(ctx context.Context, req *dto.PollingNodeDTO) error
 	Schedule(ctx context.Context, req *dto.ScheduleNodeDTO) error
 	Complete(ctx context.Context, req *dto.CompleteNodeDTO) error
+	CompleteWithinLock(ctx context.Context, req *dto.CompleteNodeDTO) error
 	SetTimeout(ctx context.Context, req *dto.SetNodeTimeoutDTO) error
 	SetNearTimeout(ctx context.Context, req *dto.SetNodeNearTimeoutDTO) error
 }
@@ -60,7 +64,7 @@ func NewDefaultNodeRunner(repoProviderSet *ports.RepoProviderSet,
 		nodeExecutorRegistry: workflowProviderSet.NodeExecutorRegistry(),
 		msgSender:            workflowProviderSet.MsgSender(),
 	}
-	instExprEvaluator := common.NewInstExprEvaluator(repoProviderSet.WorkflowInstRepo(),
+	instExprEvaluator := common.NewInstExprEvaluator(repoProviderSet.WorkflowInstRepo(), repoProviderSet.SecretRepo(),
 		workflowProviderSet.ExprEvaluator())
 	r.nodeExecutorRegistry.Register(nodeexecutor.NewAssignNodeExecutor(
 		repoProviderSet.WorkflowInstRepo(), workflowProviderSet.ExprEvaluator()))
@@ -71,7 +75,13 @@ func NewDefaultNodeRunner(repoProviderSet *ports.RepoProviderSet,
 	r.nodeExecutorRegistry.Register(nodeexecutor.NewWaitNodeExecutor())
 	r.nodeExecutorRegistry.Register(nodeexecutor.NewExclusiveJoinNodeExecutor(repoProviderSet.WorkflowInstRepo()))
 	r.nodeExecutorRegistry.Register(nodeexecutor.NewTransformNodeExecutor(instExprEvaluator))
+	r.nodeExecutorRegistry.Register(nodeexecutor.NewScriptNodeExecutor(
+		repoProviderSet.RemoteRepo(), instExprEvaluator))
 	r.nodeExecutorRegistry.Register(NewEventNodeExecutor(repoProviderSet, workflowProviderSet))
+	r.nodeExecutorRegistry.Register(nodeexecutor.NewLoopNodeExecutor(
+		repoProviderSet.WorkflowInstRepo(), workflowProviderSet.ExprEvaluator()))
+	r.nodeExecutorRegistry.Register(NewApprovalNodeExecutor(repoProviderSet, workflowProviderSet))
+	r.nodeExecutorRegistry.Register(NewWaitEventNodeExecutor(repoProviderSet))
 	r.workflowUpdater = workflowUpdater
 	r.nodePoller = nodePoller
 	return r
@@ -414,14 +424,22 @@ func (r *DefaultNodeRunner) doCancel(ctx context.Context, req *dto.CancelNodeDTO
 
 	if !executor.AsyncComplete(nodeInst) {
 		nodeInst.Status = entity.NodeInstCancelled
-		return r.workflowUpdater.UpdateNodeInstWithStatus(nodeInst)
+	} else if err := executor.Cancel(ctx, nodeInst); err != nil {
+		return err
 	}
 
-	if err := executor.Cancel(ctx, nodeInst); err != nil {
+	if err := r.workflowUpdater.UpdateNodeInstWithStatus(nodeInst); err != nil {
 		return err
 	}
+	return r.sendDriveEventIfHasErrorRoute(nodeInst)
+}
 
-	return r.workflowUpdater.UpdateNodeInstWithStatus(nodeInst)
+// sendDriveEventIfHasErrorRoute 节点超时或者取消时不会驱动流程, 如果配置了匹配的错误路由, 驱动流程执行对应的处理节点
+func (r *DefaultNodeRunner) sendDriveEventIfHasErrorRoute(nodeInst *entity.NodeInst) error {
+	if _, ok := entity.MatchErrorRoute(nodeInst); !ok {
+		return nil
+	}
+	return r.workflowUpdater.SendNodeDriveEvent(nodeInst, event.NodeCompleteDrive)
 }
 
 // Complete 标记完成
@@ -435,6 +453,11 @@ func (r *DefaultNodeRunner) Complete(ctx context.Context, req *dto.CompleteNodeD
 	return r.doComplete(ctx, req)
 }
 
+// CompleteWithinLock 标记节点完成, 调用方需要已经持有实例锁, 用于加锁后还需要先做校验的场景
+func (r *DefaultNodeRunner) CompleteWithinLock(ctx context.Context, req *dto.CompleteNodeDTO) error {
+	return r.doComplete(ctx, req)
+}
+
 func (r *DefaultNodeRunner) doComplete(ctx context.Context, req *dto.CompleteNodeDTO) error {
 	nodeInst, err := r.getNodeInstByInstIDAndNodeInstID(req.InstID, req.NodeInstID, req.NodeRefName)
 	if err != nil {
@@ -517,10 +540,10 @@ func (r *DefaultNodeRunner) SetNearTimeout(ctx context.Context, req *dto.SetNode
 	}
 	defer lock.Unlock()
 
-	return r.doSetNearTimeout(req)
+	return r.doSetNearTimeout(ctx, req)
 }
 
-func (r *DefaultNodeRunner) doSetNearTimeout(req *dto.SetNodeNearTimeoutDTO) error {
+func (r *DefaultNodeRunner) doSetNearTimeout(ctx context.Context, req *dto.SetNodeNearTimeoutDTO) error {
 	// 0. 获取节点实例
 	nodeInst, err := r.getNodeInstByInstIDAndRefName(req.InstID, req.NodeRefName)
 	if err != nil {
@@ -535,10 +558,32 @@ func (r *DefaultNodeRunner) doSetNearTimeout(req *dto.SetNodeNearTimeoutDTO) err
 			nodeInst.BasicNodeDef.RefName, nodeInst.NodeInstID)
 	}
 
-	// 2. 根据接近超时策略进行操作
+	// 2. 节点支持升级时先进行升级
+	if err := r.escalateIfNearTimeout(ctx, nodeInst); err != nil {
+		return err
+	}
+
+	// 3. 根据接近超时策略进行操作
 	return r.doNearTimeoutOperationByPolicy(nodeInst)
 }
 
+// escalateIfNearTimeout 节点接近超时时, 如果执行器支持升级则进行升级并保存节点实例
+func (r *DefaultNodeRunner) escalateIfNearTimeout(ctx context.Context, nodeInst *entity.NodeInst) error {
+	executor, exists := r.nodeExecutorRegistry.GetExecutor(nodeInst.BasicNodeDef.Type)
+	if !exists {
+		return nil
+	}
+	escalatableExecutor, ok := executor.(nodeexecutor.EscalatableExecutor)
+	if !ok {
+		return nil
+	}
+	escalated, err := escalatableExecutor.Escalate(ctx, nodeInst)
+	if err != nil || !escalated {
+		return err
+	}
+	return r.workflowUpdater.UpdateNodeInstWithStatus(nodeInst)
+}
+
 func (r *DefaultNodeRunner) doTimeoutOperationByPolicy(ctx context.Context, nodeInst *entity.NodeInst) error {
 	// 0. 判断超时策略, 当没有值时则为默认的超时策略 entity.TimeoutWf
 	timeoutPolicy := nodeInst.BasicNodeDef.Timeout.Policy
@@ -564,7 +609,7 @@ func (r *DefaultNodeRunner) doNearTimeoutOperationByPolicy(nodeInst *entity.Node
 }
 
 // terminalNodeInstIfTimeout 函数处理节点超时策略为 TIME_OUT_WF 时的逻辑，节点类型为异步时终止节点执行，同步则直接返回
-// 节点超时不会影响流程的状态
+// 节点超时不会影响流程的状态, 配置了匹配的错误路由时执行对应的处理节点
 func (r *DefaultNodeRunner) terminalNodeInstIfTimeout(ctx context.Context, nodeInst *entity.NodeInst) error {
 	// 0. 获取节点执行器
 	executor, exists := r.nodeExecutorRegistry.GetExecutor(nodeInst.BasicNodeDef.Type)
@@ -580,7 +625,10 @@ func (r *DefaultNodeRunner) terminalNodeInstIfTimeout(ctx context.Context, nodeI
 	}
 	// 2. 设置节点实例状态为超时并发送外部事件
 	nodeInst.Status = entity.NodeInstTimeout
-	return r.workflowUpdater.UpdateNodeInstWithStatus(nodeInst)
+	if err := r.workflowUpdater.UpdateNodeInstWithStatus(nodeInst); err != nil {
+		return err
+	}
+	return r.sendDriveEventIfHasErrorRoute(nodeInst)
 }
 
 // sendAlertIfTimeout 节点超时时发送告警
@@ -722,9 +770,11 @@ func (r *DefaultNodeRunner) doExecute(ctx context.Context, executor nodeexecutor
 			logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), nodeInst.NodeInstID, time.Since(startTime))
 	}()
 	// 实际执行失败的情况, 不用返回错误, 直接将错误放到错误原因里面
+	nodeInst.Error = nil
 	if err := executor.Execute(ctx, nodeInst); err != nil {
 		nodeInst.Status = entity.NodeInstFailed
 		nodeInst.Reason.FailedReason = err.Error()
+		nodeInst.Error = newNodeError(err)
 	}
 
 	// 当节点失败时判断本身是否重试
@@ -746,6 +796,19 @@ func (r *DefaultNodeRunner) doExecute(ctx context.Context, executor nodeexecutor
 	return nil
 }
 
+// newNodeError 根据执行错误获取节点的错误类型
+func newNodeError(err error) *entity.NodeError {
+	var httpErr *remote.HTTPStatusError
+	if errors.As(err, &httpErr) {
+		return &entity.NodeError{Class: entity.ErrorClassHTTP, StatusCode: httpErr.StatusCode}
+	}
+	var evalErr *expr.EvalError
+	if errors.As(err, &evalErr) {
+		return &entity.NodeError{Class: entity.ErrorClassExpression}
+	}
+	return &entity.NodeError{Class: entity.ErrorClassError}
+}
+
 // retryNode 节点重试
 func (r *DefaultNodeRunner) retryNode(curNodeInst *entity.NodeInst) error {
 	retryDelay, err := getRetryDelay(curNodeInst)
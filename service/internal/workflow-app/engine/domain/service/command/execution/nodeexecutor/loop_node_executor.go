@@ -0,0 +1,144 @@
+package nodeexecutor
+
+import (
+	"context"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/constants"
+	"github.com/fflow-tech/fflow/service/pkg/expr"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
+)
+
+const (
+	defaultLoopMaxIterations = 100 // 默认最大迭代次数
+)
+
+// LoopNodeExecutor LOOP 节点执行器实现
+// 每次进入循环节点时计算循环条件, 由决策器根据输出决定调度循环体还是下一个节点
+// 循环的执行状态在节点完成后根据输出和决策结果一起保存, 执行器中不更新流程实例
+type LoopNodeExecutor struct {
+	workflowInstRepo ports.WorkflowInstRepository
+	exprEvaluator    expr.Evaluator
+}
+
+// NewLoopNodeExecutor 初始化执行器
+func NewLoopNodeExecutor(workflowInstRepo ports.WorkflowInstRepository,
+	exprEvaluator expr.Evaluator) *LoopNodeExecutor {
+	return &LoopNodeExecutor{workflowInstRepo: workflowInstRepo, exprEvaluator: exprEvaluator}
+}
+
+// Execute 执行节点
+func (d *LoopNodeExecutor) Execute(ctx context.Context, nodeInst *entity.NodeInst) error {
+	nodeDef, err := entity.ToActualNodeDef(nodeInst.BasicNodeDef.Type, nodeInst.NodeDef)
+	if err != nil {
+		return err
+	}
+	inst, err := d.workflowInstRepo.Get(&dto.GetWorkflowInstDTO{
+		InstID: nodeInst.InstID,
+		DefID:  nodeInst.DefID,
+	})
+	if err != nil {
+		return err
+	}
+
+	output, err := d.evaluate(inst, nodeInst, nodeDef.(entity.LoopNodeDef))
+	if err != nil {
+		return err
+	}
+	nodeInst.Output, err = utils.StructToMap(output)
+	return err
+}
+
+// evaluate 计算本次迭代的序号和循环条件
+func (d *LoopNodeExecutor) evaluate(inst *entity.WorkflowInst, nodeInst *entity.NodeInst,
+	nodeDef entity.LoopNodeDef) (*entity.LoopOutput, error) {
+	output := &entity.LoopOutput{Last: map[string]interface{}{}}
+	// 上一次迭代还在执行循环体时本次为下一次迭代, 否则为重新进入循环
+	if state, ok := inst.LoopStates[nodeDef.RefName]; ok && state.Running {
+		output.Index = state.Index + 1
+		output.Last = getLoopBodyOutputs(inst, nodeDef.RefName, state.NodeInstID)
+	}
+
+	maxIterations := nodeDef.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = defaultLoopMaxIterations
+	}
+	if output.Index >= maxIterations {
+		output.MaxIterationsReached = true
+		return output, nil
+	}
+
+	match, err := d.matchCondition(inst, nodeInst, nodeDef.Condition, output)
+	if err != nil {
+		return nil, err
+	}
+	output.Continue = match
+	return output, nil
+}
+
+// matchCondition 计算循环条件, 条件为空时只受最大迭代次数限制
+func (d *LoopNodeExecutor) matchCondition(inst *entity.WorkflowInst, nodeInst *entity.NodeInst,
+	condition string, output *entity.LoopOutput) (bool, error) {
+	if condition == "" {
+		return true, nil
+	}
+
+	ctx, err := entity.ConvertToCtx(inst)
+	if err != nil {
+		return false, err
+	}
+	if err := entity.AppendNodeInfoToCtxKey(ctx, nodeInst, constants.ThisNode); err != nil {
+		return false, err
+	}
+	if ctx[entity.LoopCtxKey], err = utils.StructToMap(output); err != nil {
+		return false, err
+	}
+
+	return d.exprEvaluator.Match(ctx, condition)
+}
+
+// getLoopBodyOutputs 获取上一次迭代中循环体节点的输出, 即上一次循环节点实例之后调度的节点
+func getLoopBodyOutputs(inst *entity.WorkflowInst, refName, lastNodeInstID string) map[string]interface{} {
+	r := map[string]interface{}{}
+	for _, nodeInst := range inst.SchedNodeInsts {
+		if nodeInst.BasicNodeDef.RefName == refName ||
+			utils.MaxUint64Str(lastNodeInstID, nodeInst.NodeInstID) == lastNodeInstID {
+			continue
+		}
+		r[nodeInst.BasicNodeDef.RefName] = nodeInst.Output
+	}
+	return r
+}
+
+// Polling 轮询节点
+func (d *LoopNodeExecutor) Polling(ctx context.Context, nodeInst *entity.NodeInst) error {
+	return nil
+}
+
+// Cancel 取消执行节点
+func (d *LoopNodeExecutor) Cancel(ctx context.Context, nodeInst *entity.NodeInst) error {
+	nodeInst.Status = entity.NodeInstCancelled
+	return nil
+}
+
+// AsyncComplete 是否异步完成
+func (d *LoopNodeExecutor) AsyncComplete(inst *entity.NodeInst) bool {
+	return false
+}
+
+// AsyncByTrigger 通过触发器实现异步
+func (d *LoopNodeExecutor) AsyncByTrigger(inst *entity.NodeInst) bool {
+	return false
+}
+
+// AsyncByPolling 通过轮询实现异步
+func (d *LoopNodeExecutor) AsyncByPolling(inst *entity.NodeInst) bool {
+	return false
+}
+
+// Type 获取是哪种节点类型的处理器
+func (d *LoopNodeExecutor) Type() entity.NodeType {
+	return entity.LoopNode
+}
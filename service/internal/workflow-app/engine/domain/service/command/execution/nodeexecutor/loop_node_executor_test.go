@@ -0,0 +1,55 @@
+package nodeexecutor
+
+import (
+	"testing"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/pkg/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoopNodeExecutor_evaluate(t *testing.T) {
+	bodyOutput := map[string]interface{}{"count": 3}
+	schedNodeInsts := []*entity.NodeInst{
+		{NodeInstID: "3", BasicNodeDef: entity.BasicNodeDef{RefName: "loop", Type: entity.LoopNode}},
+		{NodeInstID: "2", BasicNodeDef: entity.BasicNodeDef{RefName: "body"}, Output: bodyOutput},
+		{NodeInstID: "1", BasicNodeDef: entity.BasicNodeDef{RefName: "before"}, Output: map[string]interface{}{}},
+	}
+	tests := []struct {
+		name          string
+		state         *entity.LoopState
+		condition     string
+		maxIterations int
+		want          *entity.LoopOutput
+	}{
+		{"首次进入循环", nil, "${loop.index < 2}", 2,
+			&entity.LoopOutput{Index: 0, Last: map[string]interface{}{}, Continue: true}},
+		{"继续下一次迭代并获取上一次迭代的输出", &entity.LoopState{Index: 0, NodeInstID: "1", Running: true},
+			"${loop.last.body.count < 5}", 2,
+			&entity.LoopOutput{Index: 1, Last: map[string]interface{}{"body": bodyOutput}, Continue: true}},
+		{"循环条件不满足时退出", &entity.LoopState{Index: 0, NodeInstID: "1", Running: true},
+			"${loop.last.body.count > 5}", 2,
+			&entity.LoopOutput{Index: 1, Last: map[string]interface{}{"body": bodyOutput}}},
+		{"达到最大迭代次数时退出", &entity.LoopState{Index: 1, NodeInstID: "1", Running: true}, "", 2,
+			&entity.LoopOutput{Index: 2, Last: map[string]interface{}{"body": bodyOutput}, MaxIterationsReached: true}},
+		{"上一次循环已经退出时重新计数", &entity.LoopState{Index: 2, NodeInstID: "1"}, "", 0,
+			&entity.LoopOutput{Index: 0, Last: map[string]interface{}{}, Continue: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := &entity.WorkflowInst{SchedNodeInsts: schedNodeInsts}
+			if tt.state != nil {
+				inst.LoopStates = map[string]*entity.LoopState{"loop": tt.state}
+			}
+			nodeDef := entity.LoopNodeDef{
+				BasicNodeDef:  entity.BasicNodeDef{RefName: "loop", Type: entity.LoopNode, Condition: tt.condition},
+				Body:          "body",
+				MaxIterations: tt.maxIterations,
+			}
+			d := &LoopNodeExecutor{exprEvaluator: expr.NewDefaultEvaluator()}
+			got, err := d.evaluate(inst, schedNodeInsts[0], nodeDef)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -17,6 +17,39 @@ type NodeExecutor interface {
 	Type() entity.NodeType
 }
 
+// SubworkflowAwareExecutor 需要自行处理子流程状态变化的节点执行器
+// 子流程状态变化时默认直接将子流程的状态同步给父流程的节点, 实现该接口的执行器可以自行决定节点状态
+type SubworkflowAwareExecutor interface {
+	// AwareOfSubworkflow 是否由执行器处理节点对应的子流程状态变化, 返回 false 时使用默认的处理
+	AwareOfSubworkflow(nodeInst *entity.NodeInst) bool
+	// OnSubworkflowUpdated 子流程状态变化时更新节点实例, 返回 false 表示忽略此次变化
+	OnSubworkflowUpdated(ctx context.Context, nodeInst *entity.NodeInst, subworkflowInst *entity.WorkflowInst) (
+		bool, error)
+}
+
+// PollingAwareExecutor 需要自行决定轮询何时结束的节点执行器
+// 轮询的节点默认根据 pollCondition 判断是否继续轮询, 实现该接口的执行器在轮询中直接设置节点的结束状态
+type PollingAwareExecutor interface {
+	// AwareOfPolling 是否由执行器决定轮询何时结束, 返回 false 时使用默认的处理
+	AwareOfPolling(nodeInst *entity.NodeInst) bool
+}
+
+// CompensableExecutor 支持补偿的节点执行器
+// 流程补偿时按执行路径逆序对执行成功的节点实例调用补偿
+type CompensableExecutor interface {
+	// CanCompensate 节点是否配置了补偿
+	CanCompensate(nodeInst *entity.NodeInst) bool
+	// Compensate 执行补偿, nodeInst 为补偿节点实例, 其中保留了被补偿节点实例的输出
+	Compensate(ctx context.Context, nodeInst *entity.NodeInst) error
+}
+
+// EscalatableExecutor 支持升级的节点执行器
+// 节点接近超时时调用升级, 比如审批节点在接近超时时追加升级的审批人
+type EscalatableExecutor interface {
+	// Escalate 升级节点实例, 返回 false 表示没有升级
+	Escalate(ctx context.Context, nodeInst *entity.NodeInst) (bool, error)
+}
+
 // Registry 节点执行器注册中心
 type Registry interface {
 	Register(e NodeExecutor)
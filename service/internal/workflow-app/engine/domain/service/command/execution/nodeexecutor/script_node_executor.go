@@ -0,0 +1,126 @@
+package nodeexecutor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/common"
+	"github.com/fflow-tech/fflow/service/pkg/expr"
+	"github.com/fflow-tech/fflow/service/pkg/remote"
+)
+
+const (
+	defaultScriptTimeout = 10 * time.Second // 脚本默认的执行超时时间
+	scriptResultKey      = "result"         // 脚本返回值不是对象时, 输出中对应的 key
+)
+
+// ScriptNodeExecutor 脚本节点执行器实现
+// 在引擎内直接执行内联的 JS/Go 脚本, 适用于简单的数据处理, 不需要发布 FAAS 函数
+type ScriptNodeExecutor struct {
+	remoteRepo        ports.RemoteRepository
+	instExprEvaluator *common.InstExprEvaluator
+}
+
+// NewScriptNodeExecutor 初始化执行器
+func NewScriptNodeExecutor(remoteRepo ports.RemoteRepository,
+	instExprEvaluator *common.InstExprEvaluator) *ScriptNodeExecutor {
+	return &ScriptNodeExecutor{remoteRepo: remoteRepo, instExprEvaluator: instExprEvaluator}
+}
+
+// AsyncComplete 是否异步完成
+func (d *ScriptNodeExecutor) AsyncComplete(inst *entity.NodeInst) bool {
+	return false
+}
+
+// AsyncByTrigger 通过触发器异步完成
+func (d *ScriptNodeExecutor) AsyncByTrigger(nodeInst *entity.NodeInst) bool {
+	return false
+}
+
+// AsyncByPolling 通过轮询异步完成
+func (d *ScriptNodeExecutor) AsyncByPolling(nodeInst *entity.NodeInst) bool {
+	return false
+}
+
+// Execute 执行节点, 计算入参后执行脚本, 脚本的返回值作为节点的输出
+func (d *ScriptNodeExecutor) Execute(ctx context.Context, nodeInst *entity.NodeInst) error {
+	nodeDef, err := entity.ToActualNodeDef(nodeInst.BasicNodeDef.Type, nodeInst.NodeDef)
+	if err != nil {
+		return err
+	}
+	actualNodeDef := nodeDef.(entity.ScriptNodeDef)
+
+	timeout, err := getScriptTimeout(actualNodeDef)
+	if err != nil {
+		return err
+	}
+
+	query := dto.NewGetWorkflowInstDTO(nodeInst.InstID, nodeInst.DefID, "")
+	input, secrets, err := d.instExprEvaluator.EvaluateArgsByInstCtx(query, nodeInst.Namespace, actualNodeDef.Input)
+	if err != nil {
+		return err
+	}
+	nodeInst.Input = input
+
+	result, err := d.remoteRepo.RunScript(ctx, &remote.RunScriptReqDTO{
+		Namespace: nodeInst.Namespace,
+		Name:      nodeInst.BasicNodeDef.RefName,
+		Language:  actualNodeDef.Language,
+		Code:      actualNodeDef.Code,
+		Input:     input,
+		Timeout:   timeout,
+	})
+	if err != nil {
+		secrets.RedactNodeInst(nodeInst)
+		return secrets.RedactError(fmt.Errorf("failed to run script of node [%s]: %w",
+			nodeInst.BasicNodeDef.RefName, err))
+	}
+
+	nodeInst.Output = toScriptOutput(result)
+	secrets.RedactNodeInst(nodeInst)
+	return nil
+}
+
+// getScriptTimeout 获取脚本的执行超时时间, 使用节点的超时配置, 超出范围时报错
+func getScriptTimeout(nodeDef entity.ScriptNodeDef) (time.Duration, error) {
+	if nodeDef.Timeout.Duration == "" {
+		return defaultScriptTimeout, nil
+	}
+	timeout, err := expr.ParseDuration(nodeDef.Timeout.Duration)
+	if err != nil {
+		return 0, err
+	}
+	if timeout <= 0 || timeout > entity.MaxScriptTimeout {
+		return 0, fmt.Errorf("script node [%s] timeout [%s] must be greater than 0 and not exceed %s",
+			nodeDef.RefName, nodeDef.Timeout.Duration, entity.MaxScriptTimeout)
+	}
+	return timeout, nil
+}
+
+// toScriptOutput 转换脚本的返回值, 不是对象时放到 result 中
+func toScriptOutput(result interface{}) map[string]interface{} {
+	if output, ok := result.(map[string]interface{}); ok {
+		return output
+	}
+	return map[string]interface{}{scriptResultKey: result}
+}
+
+// Polling 轮询节点
+func (d *ScriptNodeExecutor) Polling(ctx context.Context, nodeInst *entity.NodeInst) error {
+	return nil
+}
+
+// Cancel 取消执行节点
+func (d *ScriptNodeExecutor) Cancel(ctx context.Context, nodeInst *entity.NodeInst) error {
+	nodeInst.Status = entity.NodeInstCancelled
+	return nil
+}
+
+// Type 获取是哪种节点类型的处理器
+func (d *ScriptNodeExecutor) Type() entity.NodeType {
+	return entity.ScriptNode
+}
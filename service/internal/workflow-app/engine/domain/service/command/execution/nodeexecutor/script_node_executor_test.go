@@ -0,0 +1,56 @@
+package nodeexecutor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScriptNodeExecutor_getScriptTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration string
+		want     time.Duration
+		wantErr  bool
+	}{
+		{"没有配置超时时间时使用默认值", "", defaultScriptTimeout, false},
+		{"使用节点配置的超时时间", "3s", 3 * time.Second, false},
+		{"使用最大值", "60s", entity.MaxScriptTimeout, false},
+		{"超过最大值", "10m", 0, true},
+		{"超时时间为 0", "0s", 0, true},
+		{"超时时间格式错误", "abc", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeDef := entity.ScriptNodeDef{
+				BasicNodeDef: entity.BasicNodeDef{Timeout: entity.NodeTimeout{Duration: tt.duration}},
+			}
+			got, err := getScriptTimeout(nodeDef)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getScriptTimeout() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScriptNodeExecutor_toScriptOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		result interface{}
+		want   map[string]interface{}
+	}{
+		{"返回值是对象时直接作为输出", map[string]interface{}{"total": 3},
+			map[string]interface{}{"total": 3}},
+		{"返回值不是对象时放到 result 中", "ok", map[string]interface{}{"result": "ok"}},
+		{"返回值为空", nil, map[string]interface{}{"result": nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, toScriptOutput(tt.result))
+		})
+	}
+}
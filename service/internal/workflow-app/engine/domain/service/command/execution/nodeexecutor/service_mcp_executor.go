@@ -4,8 +4,13 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/pkg/errors"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/convertor"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/pkg/mcp"
+	"github.com/fflow-tech/fflow/service/pkg/remote"
 	"github.com/fflow-tech/fflow/service/pkg/utils"
 )
 
@@ -30,26 +35,98 @@ func (d *ServiceMCPNodeExecutor) Execute(ctx context.Context,
 	}
 
 	rsp, err := d.call(ctx, args)
-	if err != nil {
-		return err
+	if rsp != nil {
+		nodeInst.Output = rsp
 	}
-
-	nodeInst.Output = rsp
-	return nil
+	return err
 }
 
 // Polling 轮询节点
 func (d *ServiceMCPNodeExecutor) Polling(ctx context.Context,
 	nodeInst *entity.NodeInst, originArgs interface{}) error {
-	return fmt.Errorf("not implemented")
+	args := originArgs.(*entity.MCPArgs)
+	nodeInst.PollInput = args.Body
+	rsp, err := d.call(ctx, args)
+	if err != nil {
+		nodeInst.PollFailedCount += 1
+		nodeInst.Reason.PollFailedReason = err.Error()
+		return err
+	}
+
+	nodeInst.PollOutput = rsp
+	return nil
 }
 
 // Cancel 取消执行节点
 func (d *ServiceMCPNodeExecutor) Cancel(ctx context.Context, nodeInst *entity.NodeInst, originArgs interface{}) error {
-	return fmt.Errorf("not implemented")
+	args := originArgs.(*entity.MCPArgs)
+	nodeInst.CancelInput = args.Body
+	rsp, err := d.call(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	nodeInst.CancelOutput = rsp
+	nodeInst.Status = entity.NodeInstCancelled
+	return nil
 }
 
-// call 组装 request 并发送 rpc 请求
+// call 组装 request 并调用 mcp 工具, 工具返回错误时同时返回输出和错误
 func (d *ServiceMCPNodeExecutor) call(ctx context.Context, args *entity.MCPArgs) (map[string]interface{}, error) {
-	return map[string]interface{}{}, nil
+	if err := d.validateArgs(args); err != nil {
+		return nil, fmt.Errorf("illegal args: %w", err)
+	}
+
+	req := convertor.AbilityArgsConvertor.ConvertEntityToCallMCPDTO(args)
+	if err := d.validateArguments(ctx, req); err != nil {
+		return nil, err
+	}
+
+	result, err := d.remoteRepo.CallMCP(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	output := convertCallToolResultToOutput(result)
+	if result.IsError {
+		return output, fmt.Errorf("call mcp tool [%s] failed: %s", args.Tool, result.Text())
+	}
+	return output, nil
+}
+
+// convertCallToolResultToOutput 将工具调用结果转换为节点输出
+func convertCallToolResultToOutput(result *mcp.CallToolResult) map[string]interface{} {
+	content := make([]interface{}, 0, len(result.Content))
+	for _, block := range result.Content {
+		content = append(content, block)
+	}
+	output := map[string]interface{}{
+		"content": content,
+		"text":    result.Text(),
+		"isError": result.IsError,
+	}
+	if result.StructuredContent != nil {
+		output["structuredContent"] = result.StructuredContent
+	}
+	return output
+}
+
+// validateArguments 使用工具的输入 schema 校验计算后的参数
+func (d *ServiceMCPNodeExecutor) validateArguments(ctx context.Context, req *remote.CallMCPReqDTO) error {
+	tool, err := d.remoteRepo.GetMCPTool(ctx, req)
+	if err != nil {
+		return err
+	}
+	return mcp.ValidateArguments(tool, req.Arguments)
+}
+
+// validateArgs 入参检查
+func (d *ServiceMCPNodeExecutor) validateArgs(args *entity.MCPArgs) error {
+	if args.URL == "" {
+		return errors.New("url is empty")
+	}
+	if args.Tool == "" {
+		return errors.New("tool is empty")
+	}
+	return nil
 }
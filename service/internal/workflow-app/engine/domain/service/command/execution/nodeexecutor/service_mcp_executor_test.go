@@ -0,0 +1,30 @@
+package nodeexecutor
+
+import (
+	"testing"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+)
+
+func TestServiceMCPNodeExecutor_validateArgs(t *testing.T) {
+	type args struct {
+		args *entity.MCPArgs
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{"URL 和 Tool 没有的情况", args{args: &entity.MCPArgs{}}, true},
+		{"Tool 没有的情况", args{args: &entity.MCPArgs{URL: "test"}}, true},
+		{"都有的情况", args{args: &entity.MCPArgs{URL: "test", Tool: "test"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &ServiceMCPNodeExecutor{}
+			if err := d.validateArgs(tt.args.args); (err != nil) != tt.wantErr {
+				t.Errorf("validateArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
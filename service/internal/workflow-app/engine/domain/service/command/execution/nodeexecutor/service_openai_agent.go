@@ -0,0 +1,265 @@
+package nodeexecutor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/convertor"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/pkg/expr"
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultAgentMaxIterations = 10               // 智能体模式默认的最大模型调用轮数
+	agentToolTimeout          = 60 * time.Second // 单次工具调用的超时时间
+)
+
+// callAgent 智能体模式: 循环调用模型并执行模型选择的工具, 直到模型给出最终答案或者达到最大轮数
+// 无论成功与否都会返回输出, 方便审计每一次工具调用
+func (d *ServiceOpenAINodeExecutor) callAgent(ctx context.Context, nodeInst *entity.NodeInst,
+	args *entity.OpenAIArgs) (map[string]interface{}, error) {
+	provider, req, timeout, err := d.newProviderAndRequest(args)
+	if err != nil {
+		return nil, err
+	}
+
+	maxIterations := args.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = defaultAgentMaxIterations
+	}
+	agentTimeout, err := getAgentTimeout(nodeInst, timeout, maxIterations)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, agentTimeout)
+	defer cancel()
+
+	toolMap := make(map[string]*entity.OpenAITool, len(args.Tools))
+	for i := range args.Tools {
+		tool := &args.Tools[i]
+		toolMap[tool.Name] = tool
+		definition, err := d.buildToolDefinition(ctx, tool)
+		if err != nil {
+			return nil, err
+		}
+		req.Tools = append(req.Tools, definition)
+	}
+
+	usage := openai.Usage{}
+	toolCallRecords := []interface{}{}
+	lastMessage := openai.ChatCompletionMessage{}
+	schemaRetry := 0
+	for iteration := 1; iteration <= maxIterations; iteration++ {
+		resp, err := provider.CreateChatCompletion(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call OpenAI API: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return nil, fmt.Errorf("failed to get response from OpenAI API")
+		}
+		addUsage(&usage, resp.Usage)
+
+		lastMessage = resp.Choices[0].Message
+		if len(lastMessage.ToolCalls) == 0 {
+			output, err := buildAgentOutput(lastMessage, usage, toolCallRecords, iteration)
+			if err != nil {
+				return nil, err
+			}
+			// 最终答案不满足 Schema 时把错误反馈给模型重新回答, 重试同样占用模型调用轮数
+			err = appendStructuredOutput(args, output, lastMessage.Content)
+			if err == nil || schemaRetry >= args.SchemaRetries || iteration >= maxIterations {
+				return output, err
+			}
+			schemaRetry++
+			req.Messages = append(req.Messages, lastMessage, buildSchemaRetryMessage(err))
+			continue
+		}
+
+		req.Messages = append(req.Messages, lastMessage)
+		for _, toolCall := range lastMessage.ToolCalls {
+			record, content := d.executeToolCall(ctx, toolMap, toolCall)
+			record["iteration"] = iteration
+			toolCallRecords = append(toolCallRecords, record)
+			req.Messages = append(req.Messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				Content:    content,
+				ToolCallID: toolCall.ID,
+			})
+		}
+	}
+
+	output, err := buildAgentOutput(lastMessage, usage, toolCallRecords, maxIterations)
+	if err != nil {
+		return nil, err
+	}
+	return output, fmt.Errorf("agent reached max iterations %d without final answer", maxIterations)
+}
+
+// getAgentTimeout 获取智能体模式整体的超时时间, 优先使用节点的超时配置,
+// 没有配置时每一轮按照一次模型请求和一次工具调用的超时时间计算
+func getAgentTimeout(nodeInst *entity.NodeInst, requestTimeout time.Duration,
+	maxIterations int) (time.Duration, error) {
+	if nodeInst.BasicNodeDef.Timeout.Duration != "" {
+		timeout, err := expr.ParseDuration(nodeInst.BasicNodeDef.Timeout.Duration)
+		if err != nil {
+			return 0, err
+		}
+		if timeout > 0 {
+			return timeout, nil
+		}
+	}
+	return (requestTimeout + agentToolTimeout) * time.Duration(maxIterations), nil
+}
+
+// buildToolDefinition 构建提供给模型的工具定义
+func (d *ServiceOpenAINodeExecutor) buildToolDefinition(ctx context.Context,
+	tool *entity.OpenAITool) (openai.Tool, error) {
+	if tool.Name == "" {
+		return openai.Tool{}, fmt.Errorf("agent tool name must not be empty")
+	}
+
+	parameters := tool.Parameters
+	if parameters == nil && tool.MCP != nil && strings.EqualFold(tool.Protocol, string(entity.MCPService)) {
+		mcpTool, err := d.remoteRepo.GetMCPTool(ctx, convertor.AbilityArgsConvertor.ConvertEntityToCallMCPDTO(tool.MCP))
+		if err != nil {
+			return openai.Tool{}, fmt.Errorf("failed to get schema of agent tool [%s]: %w", tool.Name, err)
+		}
+		parameters = mcpTool.InputSchema
+		if tool.Description == "" {
+			tool.Description = mcpTool.Description
+		}
+	}
+	if parameters == nil {
+		parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+
+	return openai.Tool{
+		Type: openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  parameters,
+		},
+	}, nil
+}
+
+// executeToolCall 执行模型选择的工具, 返回调用记录以及回传给模型的内容
+// 工具执行失败时把错误信息回传给模型, 由模型决定下一步
+func (d *ServiceOpenAINodeExecutor) executeToolCall(ctx context.Context, toolMap map[string]*entity.OpenAITool,
+	toolCall openai.ToolCall) (map[string]interface{}, string) {
+	record := map[string]interface{}{
+		"id":        toolCall.ID,
+		"name":      toolCall.Function.Name,
+		"arguments": toolCall.Function.Arguments,
+	}
+
+	result, err := d.callTool(ctx, toolMap, toolCall)
+	if err != nil {
+		record["error"] = err.Error()
+		return record, fmt.Sprintf("error: %s", err)
+	}
+
+	record["result"] = result
+	content, err := json.Marshal(result)
+	if err != nil {
+		return record, fmt.Sprint(result)
+	}
+	return record, string(content)
+}
+
+// callTool 根据工具协议调用对应的远程能力
+func (d *ServiceOpenAINodeExecutor) callTool(ctx context.Context, toolMap map[string]*entity.OpenAITool,
+	toolCall openai.ToolCall) (interface{}, error) {
+	tool, ok := toolMap[toolCall.Function.Name]
+	if !ok {
+		return nil, fmt.Errorf("tool [%s] not found", toolCall.Function.Name)
+	}
+
+	arguments := map[string]interface{}{}
+	if toolCall.Function.Arguments != "" {
+		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &arguments); err != nil {
+			return nil, fmt.Errorf("illegal arguments of tool [%s]: %w", tool.Name, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, agentToolTimeout)
+	defer cancel()
+
+	switch entity.ServiceType(strings.ToUpper(tool.Protocol)) {
+	case entity.HTTPService:
+		if tool.HTTP == nil {
+			return nil, fmt.Errorf("http args of tool [%s] must not be empty", tool.Name)
+		}
+		return d.remoteRepo.CallHTTP(ctx,
+			convertor.AbilityArgsConvertor.ConvertEntityToCallHTTPDTO(buildToolHTTPArgs(tool.HTTP, arguments)))
+	case entity.FAASService:
+		if tool.FAAS == nil {
+			return nil, fmt.Errorf("faas args of tool [%s] must not be empty", tool.Name)
+		}
+		faasArgs := *tool.FAAS
+		faasArgs.Body = arguments
+		return d.remoteRepo.CallFAAS(ctx, convertor.AbilityArgsConvertor.ConvertEntityToCallFAASDTO(&faasArgs))
+	case entity.MCPService:
+		if tool.MCP == nil {
+			return nil, fmt.Errorf("mcp args of tool [%s] must not be empty", tool.Name)
+		}
+		mcpArgs := *tool.MCP
+		mcpArgs.Body = arguments
+		result, err := d.remoteRepo.CallMCP(ctx, convertor.AbilityArgsConvertor.ConvertEntityToCallMCPDTO(&mcpArgs))
+		if err != nil {
+			return nil, err
+		}
+		if result.IsError {
+			return nil, fmt.Errorf("%s", result.Text())
+		}
+		if result.StructuredContent != nil {
+			return result.StructuredContent, nil
+		}
+		return result.Text(), nil
+	default:
+		return nil, fmt.Errorf("illegal protocol [%s] of tool [%s]", tool.Protocol, tool.Name)
+	}
+}
+
+// buildToolHTTPArgs GET 请求将参数放到 query 中, 其它请求放到 body 中
+func buildToolHTTPArgs(originArgs *entity.HTTPArgs, arguments map[string]interface{}) *entity.HTTPArgs {
+	httpArgs := *originArgs
+	if !strings.EqualFold(httpArgs.Method, http.MethodGet) {
+		httpArgs.Body = arguments
+		return &httpArgs
+	}
+
+	parameters := map[string]string{}
+	for k, v := range originArgs.Parameters {
+		parameters[k] = v
+	}
+	for k, v := range arguments {
+		parameters[k] = fmt.Sprint(v)
+	}
+	httpArgs.Parameters = parameters
+	return &httpArgs
+}
+
+// buildAgentOutput 组装智能体模式的节点输出
+func buildAgentOutput(message openai.ChatCompletionMessage, usage openai.Usage,
+	toolCallRecords []interface{}, iterations int) (map[string]interface{}, error) {
+	output, err := buildOpenAIOutput(message, usage)
+	if err != nil {
+		return nil, err
+	}
+	output["toolCalls"] = toolCallRecords
+	output["iterations"] = iterations
+	return output, nil
+}
+
+// addUsage 累加 token 用量
+func addUsage(total *openai.Usage, usage openai.Usage) {
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+}
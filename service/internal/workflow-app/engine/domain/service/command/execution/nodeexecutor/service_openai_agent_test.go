@@ -0,0 +1,174 @@
+package nodeexecutor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/pkg/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRemoteRepo 记录 HTTP 调用请求
+type fakeRemoteRepo struct {
+	ports.RemoteRepository
+	httpReqs []*remote.CallHTTPReqDTO
+}
+
+func (f *fakeRemoteRepo) CallHTTP(ctx context.Context, req *remote.CallHTTPReqDTO) (map[string]interface{}, error) {
+	f.httpReqs = append(f.httpReqs, req)
+	return map[string]interface{}{"temperature": 20}, nil
+}
+
+// newFakeAgentServer 第一次返回工具调用, 收到工具结果后返回最终答案
+func newFakeAgentServer(alwaysCallTool bool) *httptest.Server {
+	toolCallRsp := `{"choices":[{"index":0,"message":{"role":"assistant","tool_calls":[{"id":"call_1",` +
+		`"type":"function","function":{"name":"weather","arguments":"{\"city\":\"shenzhen\"}"}}]}}],` +
+		`"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`
+	finalRsp := `{"choices":[{"index":0,"message":{"role":"assistant","content":"20 degrees"}}],` +
+		`"usage":{"prompt_tokens":20,"completion_tokens":3,"total_tokens":23}}`
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			Messages []map[string]interface{} `json:"messages"`
+			Tools    []interface{}            `json:"tools"`
+		}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if alwaysCallTool || req.Messages[len(req.Messages)-1]["role"] != "tool" {
+			fmt.Fprint(w, toolCallRsp)
+			return
+		}
+		fmt.Fprint(w, finalRsp)
+	}))
+}
+
+func newAgentArgs(baseURL string) *entity.OpenAIArgs {
+	return &entity.OpenAIArgs{
+		BaseURL:       baseURL,
+		APIKey:        "test-api-key",
+		Model:         "gpt-4o",
+		Prompt:        "What's the weather in shenzhen?",
+		MaxIterations: 3,
+		Tools: []entity.OpenAITool{
+			{
+				Name:     "weather",
+				Protocol: "http",
+				HTTP:     &entity.HTTPArgs{Method: "GET", URL: "http://weather.com/query"},
+			},
+		},
+	}
+}
+
+func TestServiceOpenAINodeExecutor_ExecuteAgent(t *testing.T) {
+	server := newFakeAgentServer(false)
+	defer server.Close()
+
+	remoteRepo := &fakeRemoteRepo{}
+	executor := NewServiceOpenAINodeExecutor(remoteRepo, nil)
+	nodeInst := &entity.NodeInst{}
+	err := executor.Execute(context.Background(), nodeInst, newAgentArgs(server.URL))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "20 degrees", nodeInst.Output["content"])
+	assert.Equal(t, 2, nodeInst.Output["iterations"])
+	assert.Equal(t, 38, nodeInst.Output["usage"].(map[string]interface{})["total_tokens"])
+	assert.Equal(t, 1, len(remoteRepo.httpReqs))
+	assert.Equal(t, "shenzhen", remoteRepo.httpReqs[0].Query["city"])
+	toolCalls := nodeInst.Output["toolCalls"].([]interface{})
+	assert.Equal(t, 1, len(toolCalls))
+	assert.Equal(t, "weather", toolCalls[0].(map[string]interface{})["name"])
+}
+
+func TestServiceOpenAINodeExecutor_ExecuteAgentReachMaxIterations(t *testing.T) {
+	server := newFakeAgentServer(true)
+	defer server.Close()
+
+	remoteRepo := &fakeRemoteRepo{}
+	executor := NewServiceOpenAINodeExecutor(remoteRepo, nil)
+	nodeInst := &entity.NodeInst{}
+	err := executor.Execute(context.Background(), nodeInst, newAgentArgs(server.URL))
+
+	assert.Error(t, err)
+	assert.Equal(t, 3, len(remoteRepo.httpReqs))
+	assert.Equal(t, 3, len(nodeInst.Output["toolCalls"].([]interface{})))
+}
+
+func TestServiceOpenAINodeExecutor_ExecuteAgentStructuredWithRetry(t *testing.T) {
+	toolCallRsp := `{"choices":[{"index":0,"message":{"role":"assistant","tool_calls":[{"id":"call_1",` +
+		`"type":"function","function":{"name":"weather","arguments":"{\"city\":\"shenzhen\"}"}}]}}]}`
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		w.Header().Set("Content-Type", "application/json")
+		switch requestCount {
+		case 1:
+			fmt.Fprint(w, toolCallRsp)
+		case 2:
+			fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"{\"approved\":\"yes\"}"}}]}`)
+		default:
+			fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"{\"approved\":true}"}}]}`)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		schemaRetries int
+		maxIterations int
+		wantErr       bool
+		wantRequests  int
+	}{
+		{"不重试的情况", 0, 3, true, 2},
+		{"重试后成功的情况", 1, 3, false, 3},
+		{"达到最大轮数后不再重试", 1, 2, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestCount = 0
+			args := newAgentArgs(server.URL)
+			args.JSONSchema = approvalSchema
+			args.SchemaRetries = tt.schemaRetries
+			args.MaxIterations = tt.maxIterations
+			nodeInst := &entity.NodeInst{}
+			err := NewServiceOpenAINodeExecutor(&fakeRemoteRepo{}, nil).Execute(context.Background(), nodeInst, args)
+
+			assert.Equal(t, tt.wantRequests, requestCount)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, true, nodeInst.Output["json"].(map[string]interface{})["approved"])
+			assert.Equal(t, 3, nodeInst.Output["iterations"])
+		})
+	}
+}
+
+func TestGetAgentTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"使用节点的超时配置", "10m", 10 * time.Minute, false},
+		{"没有配置时按照轮数计算", "", 3 * (30*time.Second + agentToolTimeout), false},
+		{"超时配置不合法", "abc", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInst := &entity.NodeInst{
+				BasicNodeDef: entity.BasicNodeDef{Timeout: entity.NodeTimeout{Duration: tt.timeout}},
+			}
+			got, err := getAgentTimeout(nodeInst, 30*time.Second, 3)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -3,22 +3,36 @@ package nodeexecutor
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/convertor"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/common"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/llm"
+	"github.com/fflow-tech/fflow/service/pkg/log"
 	"github.com/fflow-tech/fflow/service/pkg/utils"
 	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cast"
+)
+
+const (
+	openAIStreamFlushSize     = 64                     // 流式输出累计多少字符后发送一次进度事件
+	openAIStreamFlushInterval = 500 * time.Millisecond // 流式输出最长多久发送一次进度事件
 )
 
 // ServiceOpenAINodeExecutor implements a node executor for OpenAI API calls
 type ServiceOpenAINodeExecutor struct {
-	remoteRepo ports.RemoteRepository
+	remoteRepo   ports.RemoteRepository
+	eventBusRepo ports.EventBusRepository
 }
 
 // NewServiceOpenAINodeExecutor creates a new instance of ServiceOpenAINodeExecutor
-func NewServiceOpenAINodeExecutor(remoteRepo ports.RemoteRepository) *ServiceOpenAINodeExecutor {
-	return &ServiceOpenAINodeExecutor{remoteRepo: remoteRepo}
+func NewServiceOpenAINodeExecutor(remoteRepo ports.RemoteRepository,
+	eventBusRepo ports.EventBusRepository) *ServiceOpenAINodeExecutor {
+	return &ServiceOpenAINodeExecutor{remoteRepo: remoteRepo, eventBusRepo: eventBusRepo}
 }
 
 // Execute 执行节点
@@ -27,13 +41,22 @@ func (d *ServiceOpenAINodeExecutor) Execute(ctx context.Context,
 	args := originArgs.(*entity.OpenAIArgs)
 	nodeInst.Input = map[string]interface{}{"prompt": args.Prompt}
 
-	rsp, err := d.call(ctx, args)
-	if err != nil {
-		return err
+	var rsp map[string]interface{}
+	var err error
+	switch {
+	case len(args.Tools) > 0:
+		// 智能体模式下失败时也保留输出, 用于审计工具调用
+		rsp, err = d.callAgent(ctx, nodeInst, args)
+	case args.Stream:
+		rsp, err = d.callStream(ctx, nodeInst, args)
+	default:
+		rsp, err = d.call(ctx, args)
 	}
-
-	nodeInst.Output = rsp
-	return nil
+	if rsp != nil {
+		nodeInst.Output = rsp
+		nodeInst.TokenUsage = buildTokenUsage(args.Model, rsp)
+	}
+	return err
 }
 
 // Polling 轮询节点
@@ -50,20 +73,104 @@ func (d *ServiceOpenAINodeExecutor) Cancel(ctx context.Context,
 
 // call 组装 request 并发送 OpenAI API 请求
 func (d *ServiceOpenAINodeExecutor) call(ctx context.Context, args *entity.OpenAIArgs) (map[string]interface{}, error) {
-	if args.APIKey == "" {
-		return nil, fmt.Errorf("OpenAI API Key is required")
+	provider, req, timeout, err := d.newProviderAndRequest(args)
+	if err != nil {
+		return nil, err
 	}
 
-	config := openai.DefaultConfig(args.APIKey)
-	config.BaseURL = args.BaseURL
+	// 设置超时上下文
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// 输出不满足 Schema 时重新请求模型, 最多请求 SchemaRetries+1 次
+	usage := openai.Usage{}
+	for retry := 0; ; retry++ {
+		// 发送请求
+		resp, err := provider.CreateChatCompletion(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to call OpenAI API: %w", err)
+		}
+
+		if len(resp.Choices) == 0 {
+			return nil, fmt.Errorf("failed to get response from OpenAI API")
+		}
+		addUsage(&usage, resp.Usage)
+
+		message := resp.Choices[0].Message
+		output, err := buildOpenAIOutput(message, usage)
+		if err != nil {
+			return nil, err
+		}
+		err = appendStructuredOutput(args, output, message.Content)
+		if err == nil || retry >= args.SchemaRetries {
+			return output, err
+		}
+		req.Messages = append(req.Messages, message, buildSchemaRetryMessage(err))
+	}
+}
 
-	// 为每个请求创建新的 client
-	client := openai.NewClientWithConfig(config)
+// callStream 以流式方式发送 OpenAI API 请求, 并将增量内容作为进度事件发送出去
+// 输出不满足 Schema 时在完整的内容组装完成后重新以流式方式请求模型, 每次请求的增量内容都会依次发送
+func (d *ServiceOpenAINodeExecutor) callStream(ctx context.Context, nodeInst *entity.NodeInst,
+	args *entity.OpenAIArgs) (map[string]interface{}, error) {
+	provider, req, timeout, err := d.newProviderAndRequest(args)
+	if err != nil {
+		return nil, err
+	}
 
 	// 设置超时上下文
-	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	publisher := newOpenAIProgressPublisher(ctx, d.eventBusRepo, nodeInst)
+	usage := openai.Usage{}
+	for retry := 0; ; retry++ {
+		resp, err := provider.CreateChatCompletionStream(ctx, req, func(delta string) {
+			publisher.append(ctx, delta)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to call OpenAI API stream: %w", err)
+		}
+		publisher.flush(ctx)
+		if len(resp.Choices) == 0 {
+			return nil, fmt.Errorf("failed to get response from OpenAI API")
+		}
+		addUsage(&usage, resp.Usage)
+
+		message := resp.Choices[0].Message
+		output, err := buildOpenAIOutput(message, usage)
+		if err != nil {
+			return nil, err
+		}
+		err = appendStructuredOutput(args, output, message.Content)
+		if err == nil || retry >= args.SchemaRetries {
+			return output, err
+		}
+		req.Messages = append(req.Messages, message, buildSchemaRetryMessage(err))
+	}
+}
+
+// newProviderAndRequest 获取大模型服务并构建请求, 同时返回单次请求的超时时间
+func (d *ServiceOpenAINodeExecutor) newProviderAndRequest(args *entity.OpenAIArgs) (
+	llm.Provider, openai.ChatCompletionRequest, time.Duration, error) {
+	providerConfig, err := getLLMProviderConfig(args)
+	if err != nil {
+		return nil, openai.ChatCompletionRequest{}, 0, err
+	}
+	timeout, err := providerConfig.GetTimeout()
+	if err != nil {
+		return nil, openai.ChatCompletionRequest{}, 0, err
+	}
+	provider, err := llm.NewProvider(providerConfig)
+	if err != nil {
+		return nil, openai.ChatCompletionRequest{}, 0, err
+	}
+
+	// 节点没有指定模型时使用服务配置的默认模型, 回写到参数中方便统计用量
+	if args.Model == "" {
+		args.Model = providerConfig.DefaultModel
+	}
+
 	// 转换消息格式
 	messages := make([]openai.ChatCompletionMessage, 0, len(args.Messages))
 	for _, msg := range args.Messages {
@@ -81,24 +188,137 @@ func (d *ServiceOpenAINodeExecutor) call(ctx context.Context, args *entity.OpenA
 		})
 	}
 
+	responseFormat, err := buildResponseFormat(args)
+	if err != nil {
+		return nil, openai.ChatCompletionRequest{}, 0, err
+	}
+
 	// 构建请求
 	req := openai.ChatCompletionRequest{
-		Model:       args.Model,
-		Messages:    messages,
-		Temperature: float32(args.Temperature),
-		MaxTokens:   args.MaxTokens,
-		Stream:      args.Stream,
+		Model:          args.Model,
+		Messages:       messages,
+		Temperature:    float32(args.Temperature),
+		MaxTokens:      args.MaxTokens,
+		ResponseFormat: responseFormat,
+	}
+	return provider, req, timeout, nil
+}
+
+// getLLMProviderConfig 节点指定了服务名称时使用引擎配置中的服务, 否则使用节点上的 apiKey 和 baseURL
+func getLLMProviderConfig(args *entity.OpenAIArgs) (llm.ProviderConfig, error) {
+	if args.Provider == "" {
+		if args.APIKey == "" {
+			return llm.ProviderConfig{}, fmt.Errorf("OpenAI API Key is required")
+		}
+		return llm.ProviderConfig{Type: llm.OpenAI, BaseURL: args.BaseURL, APIKey: args.APIKey}, nil
 	}
 
-	// 发送请求
-	resp, err := client.CreateChatCompletion(ctx, req)
+	providerConfig, ok := config.GetLLMProviderConfig().Providers[args.Provider]
+	if !ok {
+		return llm.ProviderConfig{}, fmt.Errorf("llm provider [%s] not found", args.Provider)
+	}
+	return providerConfig, nil
+}
+
+// buildOpenAIOutput 组装节点输出, 在消息的基础上追加 token 用量
+func buildOpenAIOutput(message openai.ChatCompletionMessage, usage openai.Usage) (map[string]interface{}, error) {
+	output, err := utils.StructToMap(message)
 	if err != nil {
-		return nil, fmt.Errorf("failed to call OpenAI API: %w", err)
+		return nil, err
+	}
+	output["usage"] = map[string]interface{}{
+		"prompt_tokens":     usage.PromptTokens,
+		"completion_tokens": usage.CompletionTokens,
+		"total_tokens":      usage.TotalTokens,
 	}
+	return output, nil
+}
+
+// buildTokenUsage 根据节点输出中的 usage 构建 token 用量, 并按照配置的单价计算费用
+func buildTokenUsage(model string, output map[string]interface{}) *entity.TokenUsage {
+	usage, ok := output["usage"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	tokenUsage := &entity.TokenUsage{
+		Model:            model,
+		PromptTokens:     cast.ToInt(usage["prompt_tokens"]),
+		CompletionTokens: cast.ToInt(usage["completion_tokens"]),
+		TotalTokens:      cast.ToInt(usage["total_tokens"]),
+	}
+	tokenUsage.Cost = config.GetLLMPricingConfig().GetCost(model,
+		tokenUsage.PromptTokens, tokenUsage.CompletionTokens)
+	return tokenUsage
+}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("failed to get response from OpenAI API")
+// appendStructuredOutput 需要结构化输出时将解析后的内容追加到输出中
+func appendStructuredOutput(args *entity.OpenAIArgs, output map[string]interface{}, content string) error {
+	if !isStructuredOutput(args) {
+		return nil
+	}
+	result, err := parseStructuredOutput(args, content)
+	if result != nil {
+		output[structuredOutputKey] = result
 	}
+	return err
+}
 
-	return utils.StructToMap(resp.Choices[0].Message)
+// openAIProgressPublisher 将流式增量内容攒批并脱敏后作为节点进度事件发送
+type openAIProgressPublisher struct {
+	eventBusRepo ports.EventBusRepository
+	nodeInst     *entity.NodeInst
+	secrets      *common.SecretResolver
+	buffer       strings.Builder
+	seq          int
+	lastFlush    time.Time
+}
+
+func newOpenAIProgressPublisher(ctx context.Context, eventBusRepo ports.EventBusRepository,
+	nodeInst *entity.NodeInst) *openAIProgressPublisher {
+	return &openAIProgressPublisher{eventBusRepo: eventBusRepo, nodeInst: nodeInst,
+		secrets: common.GetSecretResolver(ctx), lastFlush: time.Now()}
+}
+
+// append 追加增量内容, 达到阈值时发送
+func (p *openAIProgressPublisher) append(ctx context.Context, delta string) {
+	p.buffer.WriteString(delta)
+	if p.buffer.Len() >= openAIStreamFlushSize || time.Since(p.lastFlush) >= openAIStreamFlushInterval {
+		p.send(ctx, false)
+	}
+}
+
+// flush 发送缓存中的全部增量内容
+func (p *openAIProgressPublisher) flush(ctx context.Context) {
+	p.send(ctx, true)
+}
+
+// send 脱敏后发送缓存中的增量内容, 发送失败不影响节点执行
+// 非最终发送时末尾可能是密钥值的开头部分, 保留在缓存中等后续内容到达后再脱敏
+func (p *openAIProgressPublisher) send(ctx context.Context, final bool) {
+	p.lastFlush = time.Now()
+	if p.buffer.Len() == 0 || p.eventBusRepo == nil {
+		return
+	}
+
+	content := p.buffer.String()
+	pending := ""
+	if !final {
+		n := p.secrets.PartialSecretLen(content)
+		content, pending = content[:len(content)-n], content[len(content)-n:]
+	}
+	p.buffer.Reset()
+	p.buffer.WriteString(pending)
+	delta := p.secrets.Redact(content)
+	if delta == "" {
+		return
+	}
+	progressEvent, err := convertor.EventConvertor.ConvertEntityToNodeProgressEvent(p.nodeInst, p.seq, delta)
+	if err != nil {
+		log.Warnf("Failed to convert node progress event, caused by %s", err)
+		return
+	}
+	p.seq++
+	if err := p.eventBusRepo.SendExternalEvent(ctx, progressEvent); err != nil {
+		log.Warnf("Failed to send node progress event, caused by %s", err)
+	}
 }
@@ -2,13 +2,61 @@ package nodeexecutor
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/event"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/common"
+	"github.com/fflow-tech/fflow/service/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/provider"
 	"github.com/stretchr/testify/assert"
 )
 
+var testConfigProvider = &fakeConfigProvider{configs: map[string]string{
+	"LLM_PRICING": `{"models":{"gpt-3.5-turbo":{"promptPrice":1000,"completionPrice":2000}}}`,
+}}
+
+func TestMain(m *testing.M) {
+	provider.InjectConfigProvider(testConfigProvider)
+	os.Exit(m.Run())
+}
+
+// fakeConfigProvider 按照配置 key 返回固定的配置
+type fakeConfigProvider struct {
+	configs map[string]string
+}
+
+func (f *fakeConfigProvider) GetAny(ctx context.Context, k config.Key, t interface{}) error {
+	conf, ok := f.configs[k.Key]
+	if !ok {
+		return fmt.Errorf("config %s not found", k.Key)
+	}
+	return json.Unmarshal([]byte(conf), t)
+}
+
+func (f *fakeConfigProvider) GetString(ctx context.Context, k config.Key) (string, error) {
+	return f.configs[k.Key], nil
+}
+
+// fakeEventBusRepo 记录发送的外部事件
+type fakeEventBusRepo struct {
+	ports.EventBusRepository
+	externalEvents []interface{}
+}
+
+func (f *fakeEventBusRepo) SendExternalEvent(ctx context.Context, msg interface{}) error {
+	f.externalEvents = append(f.externalEvents, msg)
+	return nil
+}
+
 func TestServiceOpenAINodeExecutor_SuccessExecute(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -186,3 +234,125 @@ func TestServiceOpenAINodeExecutor_Cancel(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "not implemented")
 }
+
+func TestServiceOpenAINodeExecutor_ExecuteStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		chunks := []string{
+			`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"}}]}`,
+			`{"choices":[{"index":0,"delta":{"content":", world"}}]}`,
+			`{"choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`,
+		}
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	eventBusRepo := &fakeEventBusRepo{}
+	executor := NewServiceOpenAINodeExecutor(nil, eventBusRepo)
+	nodeInst := &entity.NodeInst{NodeInstID: "1"}
+	err := executor.Execute(context.Background(), nodeInst, &entity.OpenAIArgs{
+		BaseURL: server.URL,
+		APIKey:  "test-api-key",
+		Model:   "gpt-3.5-turbo",
+		Prompt:  "Hello",
+		Stream:  true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello, world", nodeInst.Output["content"])
+	assert.Equal(t, "assistant", nodeInst.Output["role"])
+	assert.Equal(t, 5, nodeInst.Output["usage"].(map[string]interface{})["total_tokens"])
+	assert.Equal(t, &entity.TokenUsage{Model: "gpt-3.5-turbo", PromptTokens: 3, CompletionTokens: 2,
+		TotalTokens: 5, Cost: 0.007}, nodeInst.TokenUsage)
+	assert.Equal(t, 1, len(eventBusRepo.externalEvents))
+	progressEvent := eventBusRepo.externalEvents[0].(event.NodeProgressEvent)
+	assert.Equal(t, "Hello, world", progressEvent.Delta)
+	assert.Equal(t, event.NodeProgress.String(), progressEvent.EventType)
+}
+
+// fakeSecretRepo 返回固定值的密钥仓储
+type fakeSecretRepo struct {
+	ports.SecretRepository
+	value string
+}
+
+func (r *fakeSecretRepo) Get(d *dto.GetSecretDTO) (*entity.Secret, error) {
+	return &entity.Secret{Namespace: d.Namespace, Name: d.Name, Value: r.value}, nil
+}
+
+// TestServiceOpenAINodeExecutor_ExecuteStreamWithSecrets 测试流式输出中的密钥值在发送进度事件前被脱敏
+func TestServiceOpenAINodeExecutor_ExecuteStreamWithSecrets(t *testing.T) {
+	// 第一段内容超过发送阈值, 且密钥值被拆分到两段内容中
+	padding := strings.Repeat("a", openAIStreamFlushSize)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, content := range []string{padding + " key: sk-", "abc done"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", content)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	secrets := common.NewSecretResolver(&fakeSecretRepo{value: "sk-abc"}, "ns")
+	_, err := secrets.Resolve("${secrets.token}")
+	assert.NoError(t, err)
+
+	eventBusRepo := &fakeEventBusRepo{}
+	executor := NewServiceOpenAINodeExecutor(nil, eventBusRepo)
+	err = executor.Execute(common.WithSecretResolver(context.Background(), secrets),
+		&entity.NodeInst{NodeInstID: "1"}, &entity.OpenAIArgs{
+			BaseURL: server.URL,
+			APIKey:  "test-api-key",
+			Model:   "gpt-3.5-turbo",
+			Prompt:  "Hello",
+			Stream:  true,
+		})
+
+	assert.NoError(t, err)
+	var deltas []string
+	for _, e := range eventBusRepo.externalEvents {
+		deltas = append(deltas, e.(event.NodeProgressEvent).Delta)
+	}
+	assert.Equal(t, []string{padding + " key: ", "${secrets.token} done"}, deltas)
+}
+
+func TestServiceOpenAINodeExecutor_ExecuteWithProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("x-api-key"))
+		fmt.Fprint(w, `{"id":"msg_1","model":"claude-default","stop_reason":"end_turn",`+
+			`"content":[{"type":"text","text":"Hi"}],"usage":{"input_tokens":4,"output_tokens":1}}`)
+	}))
+	defer server.Close()
+
+	testConfigProvider.configs["LLM_PROVIDERS"] = fmt.Sprintf(`{"providers":{"claude":`+
+		`{"type":"anthropic","baseURL":"%s","apiKey":"secret","defaultModel":"claude-default"}}}`, server.URL)
+	defer delete(testConfigProvider.configs, "LLM_PROVIDERS")
+
+	tests := []struct {
+		name      string
+		args      *entity.OpenAIArgs
+		wantModel string
+		wantErr   bool
+	}{
+		{"使用服务的默认模型", &entity.OpenAIArgs{Provider: "claude", Prompt: "Hello"}, "claude-default", false},
+		{"节点指定模型", &entity.OpenAIArgs{Provider: "claude", Model: "claude-x", Prompt: "Hello"}, "claude-x", false},
+		{"服务不存在", &entity.OpenAIArgs{Provider: "unknown", Prompt: "Hello"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInst := &entity.NodeInst{}
+			err := NewServiceOpenAINodeExecutor(nil, nil).Execute(context.Background(), nodeInst, tt.args)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, "Hi", nodeInst.Output["content"])
+			assert.Equal(t, tt.wantModel, nodeInst.TokenUsage.Model)
+			assert.Equal(t, 5, nodeInst.TokenUsage.TotalTokens)
+		})
+	}
+}
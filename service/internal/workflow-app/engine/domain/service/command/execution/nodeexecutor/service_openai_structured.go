@@ -0,0 +1,117 @@
+package nodeexecutor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/sashabaranov/go-openai"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	structuredOutputKey  = "json"   // 结构化输出在节点输出中的字段名
+	structuredSchemaName = "output" // 请求模型时 JSON Schema 的名称
+)
+
+// jsonSchema 实现 json.Marshaler, 用于设置请求中的 JSON Schema
+type jsonSchema map[string]interface{}
+
+// MarshalJSON 序列化
+func (s jsonSchema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}(s))
+}
+
+// isStructuredOutput 是否需要结构化输出
+func isStructuredOutput(args *entity.OpenAIArgs) bool {
+	return args.JSONSchema != nil || getResponseFormatType(args) != openai.ChatCompletionResponseFormatTypeText
+}
+
+// getResponseFormatType 获取返回格式, 配置了 Schema 且没有指定格式时使用 json_schema
+func getResponseFormatType(args *entity.OpenAIArgs) openai.ChatCompletionResponseFormatType {
+	if args.ResponseFormat != "" {
+		return openai.ChatCompletionResponseFormatType(strings.ToLower(args.ResponseFormat))
+	}
+	if args.JSONSchema != nil {
+		return openai.ChatCompletionResponseFormatTypeJSONSchema
+	}
+	return openai.ChatCompletionResponseFormatTypeText
+}
+
+// buildResponseFormat 构建请求的返回格式, 不需要结构化输出时返回 nil
+func buildResponseFormat(args *entity.OpenAIArgs) (*openai.ChatCompletionResponseFormat, error) {
+	if !isStructuredOutput(args) {
+		return nil, nil
+	}
+
+	formatType := getResponseFormatType(args)
+	switch formatType {
+	case openai.ChatCompletionResponseFormatTypeJSONObject:
+		return &openai.ChatCompletionResponseFormat{Type: formatType}, nil
+	case openai.ChatCompletionResponseFormatTypeJSONSchema:
+		if args.JSONSchema == nil {
+			return nil, fmt.Errorf("jsonSchema is required when responseFormat is %s", formatType)
+		}
+		return &openai.ChatCompletionResponseFormat{
+			Type: formatType,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   structuredSchemaName,
+				Schema: jsonSchema(args.JSONSchema),
+			},
+		}, nil
+	case openai.ChatCompletionResponseFormatTypeText:
+		// 文本格式下仍然可以通过 Schema 校验模型返回的内容
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("illegal responseFormat [%s]", args.ResponseFormat)
+	}
+}
+
+// parseStructuredOutput 将模型返回的内容解析成 map, 并使用 Schema 校验
+func parseStructuredOutput(args *entity.OpenAIArgs, content string) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(trimCodeFence(content)), &result); err != nil {
+		return nil, fmt.Errorf("output is not a valid json object: %w", err)
+	}
+	if args.JSONSchema == nil {
+		return result, nil
+	}
+
+	validateResult, err := gojsonschema.Validate(gojsonschema.NewGoLoader(args.JSONSchema),
+		gojsonschema.NewGoLoader(result))
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate output: %w", err)
+	}
+	if validateResult.Valid() {
+		return result, nil
+	}
+
+	errMsgs := []string{}
+	for _, resultErr := range validateResult.Errors() {
+		errMsgs = append(errMsgs, fmt.Sprintf("[%s]", resultErr.String()))
+	}
+	return result, fmt.Errorf("output does not match json schema: %s", strings.Join(errMsgs, ""))
+}
+
+// trimCodeFence 去掉模型有时会包在 JSON 外面的 markdown 代码块
+func trimCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	content = strings.TrimPrefix(content, "```")
+	if index := strings.Index(content, "\n"); index >= 0 {
+		content = content[index+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(content), "```"))
+}
+
+// buildSchemaRetryMessage 构建输出不满足 Schema 时重新请求模型的提示
+func buildSchemaRetryMessage(err error) openai.ChatCompletionMessage {
+	return openai.ChatCompletionMessage{
+		Role: openai.ChatMessageRoleUser,
+		Content: fmt.Sprintf("Your previous response is invalid: %s. "+
+			"Please respond again with only a JSON object that matches the required schema.", err),
+	}
+}
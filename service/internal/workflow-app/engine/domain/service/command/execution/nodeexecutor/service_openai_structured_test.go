@@ -0,0 +1,139 @@
+package nodeexecutor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+var approvalSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []interface{}{"approved"},
+	"properties": map[string]interface{}{
+		"approved": map[string]interface{}{"type": "boolean"},
+	},
+}
+
+func Test_parseStructuredOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    *entity.OpenAIArgs
+		content string
+		wantErr bool
+	}{
+		{"JSON 模式合法的情况", &entity.OpenAIArgs{ResponseFormat: "json_object"}, `{"a":1}`, false},
+		{"不是 JSON 的情况", &entity.OpenAIArgs{ResponseFormat: "json_object"}, `hello`, true},
+		{"包含代码块的情况", &entity.OpenAIArgs{JSONSchema: approvalSchema}, "```json\n{\"approved\":true}\n```", false},
+		{"不满足 Schema 的情况", &entity.OpenAIArgs{JSONSchema: approvalSchema}, `{"approved":"yes"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseStructuredOutput(tt.args, tt.content); (err != nil) != tt.wantErr {
+				t.Errorf("parseStructuredOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServiceOpenAINodeExecutor_ExecuteStructuredWithRetry(t *testing.T) {
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		content := `{\"approved\":\"yes\"}`
+		if requestCount > 1 {
+			content = `{\"approved\":true}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"%s"}}]}`, content)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		schemaRetries int
+		wantErr       bool
+	}{
+		{"不重试的情况", 0, true},
+		{"重试后成功的情况", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestCount = 0
+			executor := NewServiceOpenAINodeExecutor(nil, nil)
+			nodeInst := &entity.NodeInst{}
+			err := executor.Execute(context.Background(), nodeInst, &entity.OpenAIArgs{
+				BaseURL:       server.URL,
+				APIKey:        "test-api-key",
+				Model:         "gpt-4o",
+				Prompt:        "approve?",
+				JSONSchema:    approvalSchema,
+				SchemaRetries: tt.schemaRetries,
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, true, nodeInst.Output["json"].(map[string]interface{})["approved"])
+		})
+	}
+}
+
+func TestServiceOpenAINodeExecutor_ExecuteStreamStructuredWithRetry(t *testing.T) {
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		content := `{\"approved\":\"yes\"}`
+		if requestCount > 1 {
+			content = `{\"approved\":true}`
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: %s\n\n", fmt.Sprintf(
+			`{"choices":[{"index":0,"delta":{"role":"assistant","content":"%s"}}]}`, content))
+		fmt.Fprintf(w, "data: %s\n\n",
+			`{"choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name             string
+		schemaRetries    int
+		wantErr          bool
+		wantRequestCount int
+	}{
+		{"不重试的情况", 0, true, 1},
+		{"组装完成后重试成功的情况", 1, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestCount = 0
+			eventBusRepo := &fakeEventBusRepo{}
+			executor := NewServiceOpenAINodeExecutor(nil, eventBusRepo)
+			nodeInst := &entity.NodeInst{NodeInstID: "1"}
+			err := executor.Execute(context.Background(), nodeInst, &entity.OpenAIArgs{
+				BaseURL:       server.URL,
+				APIKey:        "test-api-key",
+				Model:         "gpt-4o",
+				Prompt:        "approve?",
+				Stream:        true,
+				JSONSchema:    approvalSchema,
+				SchemaRetries: tt.schemaRetries,
+			})
+			assert.Equal(t, tt.wantRequestCount, requestCount)
+			assert.Equal(t, tt.wantRequestCount, len(eventBusRepo.externalEvents))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, true, nodeInst.Output["json"].(map[string]interface{})["approved"])
+			assert.Equal(t, 10, nodeInst.Output["usage"].(map[string]interface{})["total_tokens"])
+		})
+	}
+}
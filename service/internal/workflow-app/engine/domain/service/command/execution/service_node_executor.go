@@ -10,6 +10,7 @@ import (
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/common"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/nodeexecutor"
 	"github.com/fflow-tech/fflow/service/pkg/expr"
 	"github.com/fflow-tech/fflow/service/pkg/log"
@@ -21,6 +22,7 @@ import (
 type ServiceNodeExecutor struct {
 	workflowInstRepo ports.WorkflowInstRepository
 	remoteRepository ports.RemoteRepository
+	secretRepo       ports.SecretRepository
 	executor         map[entity.ServiceType]ServiceNodeRemoteExecutor
 	exprEvaluator    expr.Evaluator
 }
@@ -37,12 +39,14 @@ func NewServiceNodeExecutor(repoProviderSet *ports.RepoProviderSet,
 	workflowProviderSet *WorkflowProviderSet) *ServiceNodeExecutor {
 	return &ServiceNodeExecutor{
 		workflowInstRepo: repoProviderSet.WorkflowInstRepo(),
+		secretRepo:       repoProviderSet.SecretRepo(),
 		exprEvaluator:    workflowProviderSet.ExprEvaluator(),
 		executor: map[entity.ServiceType]ServiceNodeRemoteExecutor{
-			entity.HTTPService:   nodeexecutor.NewServiceHTTPNodeExecutor(repoProviderSet.RemoteRepo()),
-			entity.FAASService:   nodeexecutor.NewServiceFAASNodeExecutor(repoProviderSet.RemoteRepo()),
-			entity.MCPService:    nodeexecutor.NewServiceMCPNodeExecutor(repoProviderSet.RemoteRepo()),
-			entity.OpenAIService: nodeexecutor.NewServiceOpenAINodeExecutor(repoProviderSet.RemoteRepo()),
+			entity.HTTPService: nodeexecutor.NewServiceHTTPNodeExecutor(repoProviderSet.RemoteRepo()),
+			entity.FAASService: nodeexecutor.NewServiceFAASNodeExecutor(repoProviderSet.RemoteRepo()),
+			entity.MCPService:  nodeexecutor.NewServiceMCPNodeExecutor(repoProviderSet.RemoteRepo()),
+			entity.OpenAIService: nodeexecutor.NewServiceOpenAINodeExecutor(repoProviderSet.RemoteRepo(),
+				repoProviderSet.EventBusRepo()),
 		},
 	}
 }
@@ -71,12 +75,14 @@ func (d *ServiceNodeExecutor) AsyncByPolling(nodeInst *entity.NodeInst) bool {
 
 // Execute 执行节点
 func (d *ServiceNodeExecutor) Execute(ctx context.Context, nodeInst *entity.NodeInst) error {
-	executor, args, err := d.getExecutorAndArgs(nodeInst, entity.NormalArgs)
+	executor, args, secrets, err := d.getExecutorAndArgs(nodeInst, entity.NormalArgs)
 	if err != nil {
 		return err
 	}
-	if err := executor.Execute(ctx, nodeInst, args); err != nil {
-		return err
+	err = executor.Execute(common.WithSecretResolver(ctx, secrets), nodeInst, args)
+	secrets.RedactNodeInst(nodeInst)
+	if err != nil {
+		return secrets.RedactError(err)
 	}
 
 	return d.setNodeInstStatusIfExecuteFailed(nodeInst)
@@ -252,11 +258,13 @@ func generateNewMapByPath(paths []string, v interface{}) map[string]interface{}
 
 // Polling 轮询节点
 func (d *ServiceNodeExecutor) Polling(ctx context.Context, nodeInst *entity.NodeInst) error {
-	executor, args, err := d.getExecutorAndArgs(nodeInst, entity.PollingArgs)
+	executor, args, secrets, err := d.getExecutorAndArgs(nodeInst, entity.PollingArgs)
 	if err != nil {
 		return err
 	}
-	return executor.Polling(ctx, nodeInst, args)
+	err = executor.Polling(common.WithSecretResolver(ctx, secrets), nodeInst, args)
+	secrets.RedactNodeInst(nodeInst)
+	return secrets.RedactError(err)
 }
 
 // Cancel 取消执行节点
@@ -268,11 +276,29 @@ func (d *ServiceNodeExecutor) Cancel(ctx context.Context, nodeInst *entity.NodeI
 		return nil
 	}
 
-	executor, args, err := d.getExecutorAndArgs(nodeInst, entity.CancelArgs)
+	executor, args, secrets, err := d.getExecutorAndArgs(nodeInst, entity.CancelArgs)
 	if err != nil {
 		return err
 	}
-	return executor.Cancel(ctx, nodeInst, args)
+	err = executor.Cancel(common.WithSecretResolver(ctx, secrets), nodeInst, args)
+	secrets.RedactNodeInst(nodeInst)
+	return secrets.RedactError(err)
+}
+
+// CanCompensate 是否配置了补偿参数
+func (d *ServiceNodeExecutor) CanCompensate(nodeInst *entity.NodeInst) bool {
+	return !d.argsNotExists(nodeInst, entity.CompensateArgs)
+}
+
+// Compensate 补偿执行节点, 补偿的请求和响应记录在补偿节点实例的 Input 和 Output 中
+func (d *ServiceNodeExecutor) Compensate(ctx context.Context, nodeInst *entity.NodeInst) error {
+	executor, args, secrets, err := d.getExecutorAndArgs(nodeInst, entity.CompensateArgs)
+	if err != nil {
+		return err
+	}
+	err = executor.Execute(common.WithSecretResolver(ctx, secrets), nodeInst, args)
+	secrets.RedactNodeInst(nodeInst)
+	return secrets.RedactError(err)
 }
 
 func (d *ServiceNodeExecutor) argsNotExists(nodeInst *entity.NodeInst, argsType entity.ServiceNodeArgsType) bool {
@@ -284,15 +310,16 @@ func (d *ServiceNodeExecutor) argsNotExists(nodeInst *entity.NodeInst, argsType
 	return nodeDef.Protocol == ""
 }
 
+// getExecutorAndArgs 获取执行器和参数, 参数中的密钥引用会被替换为密钥值, 执行后需要通过 SecretResolver 脱敏
 func (d *ServiceNodeExecutor) getExecutorAndArgs(nodeInst *entity.NodeInst, argsType entity.ServiceNodeArgsType) (
-	ServiceNodeRemoteExecutor, interface{}, error) {
+	ServiceNodeRemoteExecutor, interface{}, *common.SecretResolver, error) {
 	args, err := entity.GetServiceNodeArgs(nodeInst.NodeDef, argsType)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	executor, err := d.getExecutor(nodeInst.NodeDef, argsType)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	workflowInst, err := d.workflowInstRepo.Get(&dto.GetWorkflowInstDTO{
@@ -300,18 +327,25 @@ func (d *ServiceNodeExecutor) getExecutorAndArgs(nodeInst *entity.NodeInst, args
 		DefID:  nodeInst.DefID,
 	})
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	reqBody, err := d.buildReqBody(workflowInst, nodeInst, argsType)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	d.setServiceNodeIsMockMode(workflowInst, nodeInst, args)
 
 	d.setReqBodyToArgs(args, reqBody)
-	return executor, args, nil
+
+	secrets := common.NewSecretResolver(d.secretRepo, nodeInst.Namespace)
+	resolvedArgs, err := secrets.Resolve(args)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("[%s]failed to resolve secrets for argsType=%s: %w",
+			logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), argsType, err)
+	}
+	return executor, resolvedArgs, secrets, nil
 }
 
 // Type 获取是哪种节点类型的处理器
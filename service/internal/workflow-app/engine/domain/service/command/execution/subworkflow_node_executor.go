@@ -3,40 +3,58 @@ package execution
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"github.com/fflow-tech/fflow/service/pkg/log"
 
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/convertor"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/common"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/constants"
+	"github.com/fflow-tech/fflow/service/pkg/expr"
+	"github.com/fflow-tech/fflow/service/pkg/logs"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
 )
 
-// SubWorkflowExecutor 等待节点执行器实现
+// fanOutCtxKey 动态子流程的表达式中引用当前元素的 key
+const fanOutCtxKey = "fanout"
+
+// SubWorkflowExecutor 子流程节点执行器实现
+// 配置 items 时为每个元素启动一个子流程, 按照完成策略汇总所有子流程的结果
 type SubWorkflowExecutor struct {
 	workflowRunner    WorkflowRunner
 	workflowDefRepo   ports.WorkflowDefRepository
 	workflowInstRepo  ports.WorkflowInstRepository
+	nodeInstRepo      ports.NodeInstRepository
 	instExprEvaluator *common.InstExprEvaluator
+	exprEvaluator     expr.Evaluator
 }
 
 // NewSubWorkflowExecutor 新建
 func NewSubWorkflowExecutor(workflowRunner WorkflowRunner, workflowDefRepo ports.WorkflowDefRepository,
-	repoProviderSet *ports.RepoProviderSet, instExprEvaluator *common.InstExprEvaluator) *SubWorkflowExecutor {
+	repoProviderSet *ports.RepoProviderSet, instExprEvaluator *common.InstExprEvaluator,
+	exprEvaluator expr.Evaluator) *SubWorkflowExecutor {
 	return &SubWorkflowExecutor{
 		workflowRunner:    workflowRunner,
 		workflowDefRepo:   workflowDefRepo,
 		workflowInstRepo:  repoProviderSet.WorkflowInstRepo(),
+		nodeInstRepo:      repoProviderSet.NodeInstRepo(),
 		instExprEvaluator: instExprEvaluator,
+		exprEvaluator:     exprEvaluator,
 	}
 }
 
 // Execute 执行节点
 func (d *SubWorkflowExecutor) Execute(ctx context.Context, nodeInst *entity.NodeInst) error {
-	nodeDef, err := entity.ToActualNodeDef(nodeInst.BasicNodeDef.Type, nodeInst.NodeDef)
+	actualNodeDef, err := d.getNodeDef(nodeInst)
 	if err != nil {
 		return err
 	}
-	actualNodeDef := nodeDef.(entity.SubworkflowNodeDef)
+	if actualNodeDef.FanOut() {
+		return d.executeFanOut(nodeInst, actualNodeDef)
+	}
 
 	// 获取子流程定义并启动流程实例
 	subWorkflowDef, err := d.getSubworkflowDef(nodeInst, actualNodeDef)
@@ -140,15 +158,21 @@ func (d *SubWorkflowExecutor) Polling(ctx context.Context, nodeInst *entity.Node
 	return nil
 }
 
-// Cancel 取消执行节点
+// Cancel 取消执行节点, 动态子流程时取消所有正在运行的子流程
 func (d *SubWorkflowExecutor) Cancel(ctx context.Context, nodeInst *entity.NodeInst) error {
+	nodeDef, err := d.getNodeDef(nodeInst)
+	if err != nil {
+		return err
+	}
+	if nodeDef.FanOut() {
+		return d.cancelFanOut(ctx, nodeInst)
+	}
 	cancelReqDTO := &dto.CancelWorkflowInstDTO{
 		DefID:    nodeInst.SubworkflowDefID,
 		InstID:   nodeInst.SubworkflowInstID,
 		Operator: nodeInst.Operator.CancelledOperator,
 	}
-	err := d.workflowRunner.Cancel(ctx, cancelReqDTO)
-	if err != nil {
+	if err := d.workflowRunner.Cancel(ctx, cancelReqDTO); err != nil {
 		return err
 	}
 
@@ -176,3 +200,314 @@ func (d *SubWorkflowExecutor) AsyncByPolling(nodeInst *entity.NodeInst) bool {
 func (d *SubWorkflowExecutor) Type() entity.NodeType {
 	return entity.SubWorkflowNode
 }
+
+// getNodeDef 获取节点定义
+func (d *SubWorkflowExecutor) getNodeDef(nodeInst *entity.NodeInst) (entity.SubworkflowNodeDef, error) {
+	nodeDef, err := entity.ToActualNodeDef(entity.SubWorkflowNode, nodeInst.NodeDef)
+	if err != nil {
+		return entity.SubworkflowNodeDef{}, err
+	}
+	return nodeDef.(entity.SubworkflowNodeDef), nil
+}
+
+// fanOutState 动态子流程的执行状态, 保存在节点实例的输出中
+type fanOutState struct {
+	Results    []interface{}        `json:"results"`  // 按元素顺序汇总的子流程输出, 没有成功的子流程为空
+	Children   []*foreachItemDetail `json:"children"` // 每个元素对应的子流程的执行情况
+	Total      int                  `json:"total"`
+	Required   int                  `json:"required"` // 需要成功的子流程数量
+	Succeeded  int                  `json:"succeeded"`
+	Failed     int                  `json:"failed"`
+	Cancelling bool                 `json:"cancelling,omitempty"` // 正在取消子流程, 取消引起的子流程状态变化不需要处理
+}
+
+// newFanOutState 新建
+func newFanOutState(total, required int) *fanOutState {
+	s := &fanOutState{
+		Results:  make([]interface{}, total),
+		Children: make([]*foreachItemDetail, 0, total),
+		Total:    total,
+		Required: required,
+	}
+	for i := 0; i < total; i++ {
+		s.Children = append(s.Children, &foreachItemDetail{Index: i, Status: entity.NodeInstScheduled})
+	}
+	return s
+}
+
+// getFanOutState 从节点实例的输出中获取执行状态
+func getFanOutState(nodeInst *entity.NodeInst) (*fanOutState, error) {
+	s := &fanOutState{}
+	if err := utils.ToOtherInterfaceValue(s, nodeInst.Output); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// save 保存执行状态到节点实例的输出中
+func (s *fanOutState) save(nodeInst *entity.NodeInst) error {
+	output, err := utils.StructToMap(s)
+	if err != nil {
+		return err
+	}
+	nodeInst.Output = output
+	return nil
+}
+
+// complete 记录子流程的执行结果
+func (s *fanOutState) complete(index int, status entity.NodeInstStatus, output map[string]interface{},
+	errMsg string) {
+	child := s.Children[index]
+	child.Status, child.Output, child.Error = status, output, errMsg
+	if status == entity.NodeInstSucceed {
+		s.Results[index] = output
+		s.Succeeded++
+		return
+	}
+	s.Failed++
+}
+
+// getChildByInstID 根据子流程实例 ID 获取子流程的执行情况
+func (s *fanOutState) getChildByInstID(instID string) *foreachItemDetail {
+	for _, child := range s.Children {
+		if child.InstID == instID {
+			return child
+		}
+	}
+	return nil
+}
+
+// hasRunningChild 是否有正在运行的子流程
+func (s *fanOutState) hasRunningChild() bool {
+	for _, child := range s.Children {
+		if child.Status == entity.NodeInstRunning {
+			return true
+		}
+	}
+	return false
+}
+
+// decide 根据成功和失败的数量判断节点是否可以结束, 成功数量达到要求时成功, 剩余的子流程都成功也达不到要求时失败
+func (s *fanOutState) decide() (entity.NodeInstStatus, bool) {
+	if s.Succeeded >= s.Required {
+		return entity.NodeInstSucceed, true
+	}
+	pending := s.Total - s.Succeeded - s.Failed
+	if s.Succeeded+pending < s.Required {
+		return entity.NodeInstFailed, true
+	}
+	return entity.NodeInstStatus{}, false
+}
+
+// executeFanOut 为数组中的每个元素启动一个子流程
+func (d *SubWorkflowExecutor) executeFanOut(nodeInst *entity.NodeInst, nodeDef entity.SubworkflowNodeDef) error {
+	subworkflowDef, err := d.getSubworkflowDef(nodeInst, nodeDef)
+	if err != nil {
+		return err
+	}
+	inst, err := d.workflowInstRepo.Get(&dto.GetWorkflowInstDTO{InstID: nodeInst.InstID, DefID: nodeInst.DefID})
+	if err != nil {
+		return err
+	}
+	baseCtx, err := entity.ConvertToCtx(inst)
+	if err != nil {
+		return err
+	}
+	if err := entity.AppendNodeInfoToCtxKey(baseCtx, nodeInst, constants.ThisNode); err != nil {
+		return err
+	}
+	r, err := d.exprEvaluator.EvaluateMap(baseCtx, map[string]interface{}{"items": nodeDef.Items})
+	if err != nil {
+		return fmt.Errorf("failed to evaluate subworkflow items: %w", err)
+	}
+	items, err := toItemArray(r["items"])
+	if err != nil {
+		return fmt.Errorf("subworkflow %w", err)
+	}
+
+	nodeInst.SubworkflowDefID = subworkflowDef.DefID
+	nodeInst.ChildInstIDs = nil
+	state := newFanOutState(len(items), nodeDef.RequiredSucceeded(len(items)))
+	for i, item := range items {
+		// 已经可以确定结果时不再启动后续的子流程
+		if _, decided := state.decide(); decided {
+			break
+		}
+		itemCtx := getFanOutItemCtx(baseCtx, item, i, len(items))
+		instID, err := d.startFanOutSubworkflow(nodeInst, nodeDef, itemCtx, i)
+		if err != nil {
+			log.Errorf("[%s]Failed to start subworkflow for item [%d], caused by %s",
+				logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), i, err)
+			state.complete(i, entity.NodeInstFailed, nil, err.Error())
+			continue
+		}
+		state.Children[i].Status, state.Children[i].InstID = entity.NodeInstRunning, instID
+		nodeInst.ChildInstIDs = append(nodeInst.ChildInstIDs, instID)
+	}
+
+	if status, decided := state.decide(); decided {
+		if err := d.finishFanOut(context.Background(), nodeInst, nodeDef, state, status); err != nil {
+			return err
+		}
+	}
+	return state.save(nodeInst)
+}
+
+// getFanOutItemCtx 获取计算单个元素表达式的上下文, 通过 fanout.item 和 fanout.index 引用当前元素
+func getFanOutItemCtx(baseCtx map[string]interface{}, item interface{}, index, total int) map[string]interface{} {
+	ctx := make(map[string]interface{}, len(baseCtx)+1)
+	for k, v := range baseCtx {
+		ctx[k] = v
+	}
+	ctx[fanOutCtxKey] = map[string]interface{}{
+		"item":  item,
+		"index": index,
+		"total": total,
+	}
+	return ctx
+}
+
+// startFanOutSubworkflow 为单个元素启动子流程
+func (d *SubWorkflowExecutor) startFanOutSubworkflow(nodeInst *entity.NodeInst, nodeDef entity.SubworkflowNodeDef,
+	itemCtx map[string]interface{}, index int) (string, error) {
+	input, err := d.exprEvaluator.EvaluateMap(itemCtx, nodeDef.Args.Input)
+	if err != nil {
+		return "", fmt.Errorf("failed to evaluate subworkflow input: %w", err)
+	}
+	return d.workflowRunner.Start(context.Background(), &dto.StartWorkflowInstDTO{
+		DefID:            nodeInst.SubworkflowDefID,
+		ParentInstID:     nodeInst.InstID,
+		ParentNodeInstID: nodeInst.NodeInstID,
+		Name:             nodeDef.Args.Name,
+		Creator:          nodeDef.Args.Operator,
+		Input:            input,
+		Reason: fmt.Sprintf("Start subworkflow [subworkflowDefID:%s] for item [%d] of "+
+			"defID:[%s]|instID:[%s]|nodeInstID:[%s]", nodeInst.SubworkflowDefID, index,
+			nodeInst.DefID, nodeInst.InstID, nodeInst.NodeInstID),
+	})
+}
+
+// AwareOfSubworkflow 动态子流程由执行器根据完成策略处理, 单个子流程使用默认的处理
+// 根据节点定义判断, 子流程可能在节点保存子流程实例 ID 之前就已经结束
+func (d *SubWorkflowExecutor) AwareOfSubworkflow(nodeInst *entity.NodeInst) bool {
+	nodeDef, err := d.getNodeDef(nodeInst)
+	return err == nil && nodeDef.FanOut()
+}
+
+// OnSubworkflowUpdated 子流程结束时记录执行结果, 满足完成策略时结束节点并取消其他正在运行的子流程
+func (d *SubWorkflowExecutor) OnSubworkflowUpdated(ctx context.Context, nodeInst *entity.NodeInst,
+	subworkflowInst *entity.WorkflowInst) (bool, error) {
+	if nodeInst.Status.IsTerminal() || !subworkflowInst.Status.IsTerminal() {
+		return false, nil
+	}
+	state, err := getFanOutState(nodeInst)
+	if err != nil || state.Cancelling {
+		return false, err
+	}
+	child := state.getChildByInstID(subworkflowInst.InstID)
+	if child == nil || child.Status.IsTerminal() {
+		return false, nil
+	}
+	status, errMsg := getForeachItemStatus(subworkflowInst)
+	state.complete(child.Index, status, subworkflowInst.Output, errMsg)
+
+	if status, decided := state.decide(); decided {
+		nodeDef, err := d.getNodeDef(nodeInst)
+		if err != nil {
+			return false, err
+		}
+		if err := d.finishFanOut(ctx, nodeInst, nodeDef, state, status); err != nil {
+			return false, err
+		}
+	}
+	return true, state.save(nodeInst)
+}
+
+// finishFanOut 结束节点, 取消还在运行的子流程, 没有启动的子流程标记为取消
+func (d *SubWorkflowExecutor) finishFanOut(ctx context.Context, nodeInst *entity.NodeInst,
+	nodeDef entity.SubworkflowNodeDef, state *fanOutState, status entity.NodeInstStatus) error {
+	if err := d.cancelFanOutChildren(ctx, nodeInst, state); err != nil {
+		return err
+	}
+
+	nodeInst.Status = status
+	nodeInst.CompletedAt = time.Now()
+	if status == entity.NodeInstSucceed {
+		nodeInst.Reason.SucceedReason = fmt.Sprintf("subworkflows join [%s] completed, %d of %d succeed",
+			getJoinPolicy(nodeDef), state.Succeeded, state.Total)
+		return nil
+	}
+	nodeInst.Reason.FailedReason = fmt.Sprintf("subworkflows join [%s] failed, succeed %d < required %d, "+
+		"first error: %s", getJoinPolicy(nodeDef), state.Succeeded, state.Required, getFirstFanOutError(state))
+	return nil
+}
+
+// cancelFanOut 取消节点时取消所有正在运行的子流程
+func (d *SubWorkflowExecutor) cancelFanOut(ctx context.Context, nodeInst *entity.NodeInst) error {
+	state, err := getFanOutState(nodeInst)
+	if err != nil {
+		return err
+	}
+	if err := d.cancelFanOutChildren(ctx, nodeInst, state); err != nil {
+		return err
+	}
+	nodeInst.Status = entity.NodeInstCancelled
+	return state.save(nodeInst)
+}
+
+// cancelFanOutChildren 取消正在运行的子流程, 取消之前先保存取消标记, 其他进程处理子流程的状态变化时也可以忽略
+func (d *SubWorkflowExecutor) cancelFanOutChildren(ctx context.Context, nodeInst *entity.NodeInst,
+	state *fanOutState) error {
+	if state.hasRunningChild() {
+		state.Cancelling = true
+		if err := state.save(nodeInst); err != nil {
+			return err
+		}
+		if err := saveNodeInst(d.nodeInstRepo, nodeInst); err != nil {
+			return err
+		}
+	}
+	for _, child := range state.Children {
+		if child.Status == entity.NodeInstRunning {
+			if err := d.workflowRunner.Cancel(ctx, &dto.CancelWorkflowInstDTO{
+				DefID:    nodeInst.SubworkflowDefID,
+				InstID:   child.InstID,
+				Operator: nodeInst.Operator.CancelledOperator,
+			}); err != nil {
+				log.Errorf("[%s]Failed to cancel subworkflow [%s], caused by %s",
+					logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), child.InstID, err)
+			}
+		}
+		if !child.Status.IsTerminal() {
+			child.Status = entity.NodeInstCancelled
+		}
+	}
+	return nil
+}
+
+// saveNodeInst 立即保存节点实例
+func saveNodeInst(nodeInstRepo ports.NodeInstRepository, nodeInst *entity.NodeInst) error {
+	updateNodeInstDTO, err := convertor.NodeInstConvertor.ConvertEntityToUpdateDTO(nodeInst)
+	if err != nil {
+		return err
+	}
+	return nodeInstRepo.UpdateWithDefID(updateNodeInstDTO)
+}
+
+// getFirstFanOutError 获取第一个失败的子流程的原因
+func getFirstFanOutError(state *fanOutState) string {
+	for _, child := range state.Children {
+		if child.Error != "" {
+			return fmt.Sprintf("[%d]%s", child.Index, child.Error)
+		}
+	}
+	return ""
+}
+
+// getJoinPolicy 获取完成策略, 为空时为 ALL
+func getJoinPolicy(nodeDef entity.SubworkflowNodeDef) entity.JoinPolicy {
+	if nodeDef.Join == "" {
+		return entity.JoinAll
+	}
+	return nodeDef.Join
+}
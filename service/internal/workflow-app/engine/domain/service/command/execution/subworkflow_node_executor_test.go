@@ -0,0 +1,297 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/cache"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/nodeexecutor"
+	"github.com/fflow-tech/fflow/service/pkg/expr"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCancelWorkflowRunner 记录取消的子流程实例
+type fakeCancelWorkflowRunner struct {
+	WorkflowRunner
+	cancelled []string
+}
+
+func (r *fakeCancelWorkflowRunner) Cancel(ctx context.Context, req *dto.CancelWorkflowInstDTO) error {
+	r.cancelled = append(r.cancelled, req.InstID)
+	return nil
+}
+
+// TestFanOutState_Decide 测试根据完成策略判断动态子流程节点是否可以结束
+func TestFanOutState_Decide(t *testing.T) {
+	tests := []struct {
+		name        string
+		join        entity.JoinPolicy
+		quorum      int
+		total       int
+		succeeded   int
+		failed      int
+		wantStatus  entity.NodeInstStatus
+		wantDecided bool
+	}{
+		{"ALL 全部成功", entity.JoinAll, 0, 3, 3, 0, entity.NodeInstSucceed, true},
+		{"ALL 还有运行中的子流程", entity.JoinAll, 0, 3, 2, 0, entity.NodeInstStatus{}, false},
+		{"ALL 任意一个失败", entity.JoinAll, 0, 3, 1, 1, entity.NodeInstFailed, true},
+		{"ALL 没有元素", "", 0, 0, 0, 0, entity.NodeInstSucceed, true},
+		{"ANY 任意一个成功", entity.JoinAny, 0, 3, 1, 0, entity.NodeInstSucceed, true},
+		{"ANY 部分失败", entity.JoinAny, 0, 3, 0, 2, entity.NodeInstStatus{}, false},
+		{"ANY 全部失败", entity.JoinAny, 0, 3, 0, 3, entity.NodeInstFailed, true},
+		{"ANY 没有元素", entity.JoinAny, 0, 0, 0, 0, entity.NodeInstSucceed, true},
+		{"QUORUM 达到数量", entity.JoinQuorum, 2, 3, 2, 0, entity.NodeInstSucceed, true},
+		{"QUORUM 还可能达到数量", entity.JoinQuorum, 2, 3, 1, 1, entity.NodeInstStatus{}, false},
+		{"QUORUM 不可能达到数量", entity.JoinQuorum, 2, 3, 0, 2, entity.NodeInstFailed, true},
+		{"QUORUM 大于元素数量", entity.JoinQuorum, 4, 3, 0, 0, entity.NodeInstFailed, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeDef := entity.SubworkflowNodeDef{Join: tt.join, Quorum: tt.quorum}
+			state := newFanOutState(tt.total, nodeDef.RequiredSucceeded(tt.total))
+			state.Succeeded, state.Failed = tt.succeeded, tt.failed
+			status, decided := state.decide()
+			assert.Equal(t, tt.wantDecided, decided)
+			assert.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
+
+// TestSubWorkflowExecutor_OnSubworkflowUpdated 测试子流程结束时按照完成策略结束节点并取消其他子流程
+func TestSubWorkflowExecutor_OnSubworkflowUpdated(t *testing.T) {
+	tests := []struct {
+		name          string
+		join          entity.JoinPolicy
+		childStatus   entity.InstStatus
+		wantUpdated   bool
+		wantStatus    entity.NodeInstStatus
+		wantCancelled []string
+	}{
+		{"ANY 第一个子流程成功后结束", "ANY", entity.InstSucceed, true, entity.NodeInstSucceed,
+			[]string{"child-1", "child-2"}},
+		{"ALL 第一个子流程失败后结束", "ALL", entity.InstFailed, true, entity.NodeInstFailed,
+			[]string{"child-1", "child-2"}},
+		{"ALL 第一个子流程成功后继续等待", "ALL", entity.InstSucceed, true, entity.NodeInstRunning, nil},
+		{"子流程没有结束时忽略", "ALL", entity.InstRunning, false, entity.NodeInstRunning, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeCancelWorkflowRunner{}
+			nodeInstRepo := &fakeNodeInstRepo{}
+			executor := &SubWorkflowExecutor{workflowRunner: runner, nodeInstRepo: nodeInstRepo}
+			nodeInst := &entity.NodeInst{
+				NodeDef: map[string]interface{}{
+					"refName": "deploy", "type": "SUB_WORKFLOW", "subworkflow": "deployRegion",
+					"items": "${w.input.regions}", "join": string(tt.join),
+				},
+				Status:       entity.NodeInstRunning,
+				ChildInstIDs: []string{"child-0", "child-1", "child-2"},
+				Operator:     &entity.NodeOperator{},
+				Reason:       &entity.NodeReason{},
+			}
+			state := newFanOutState(3, entity.SubworkflowNodeDef{Join: tt.join}.RequiredSucceeded(3))
+			for i, child := range state.Children {
+				child.Status, child.InstID = entity.NodeInstRunning, nodeInst.ChildInstIDs[i]
+			}
+			assert.Nil(t, state.save(nodeInst))
+
+			updated, err := executor.OnSubworkflowUpdated(context.Background(), nodeInst, &entity.WorkflowInst{
+				InstID: "child-0", Status: tt.childStatus, Output: map[string]interface{}{"region": "a"},
+				Reason: &entity.InstReason{FailedRootCause: entity.InstFailedRootCause{FailedReason: "boom"}},
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantUpdated, updated)
+			assert.Equal(t, tt.wantStatus, nodeInst.Status)
+			assert.Equal(t, tt.wantCancelled, runner.cancelled)
+			if tt.wantUpdated && tt.childStatus == entity.InstSucceed {
+				got, err := getFanOutState(nodeInst)
+				assert.Nil(t, err)
+				assert.Equal(t, map[string]interface{}{"region": "a"}, got.Results[0])
+			}
+			if tt.wantCancelled == nil {
+				assert.Empty(t, nodeInstRepo.updated)
+				return
+			}
+
+			// 取消子流程之前已经保存了取消标记, 其他进程重新加载节点实例后忽略取消引起的状态变化
+			assert.Len(t, nodeInstRepo.updated, 1)
+			savedNodeInst := &entity.NodeInst{}
+			assert.Nil(t, json.Unmarshal([]byte(nodeInstRepo.updated[0].Context), savedNodeInst))
+			assert.Equal(t, entity.NodeInstRunning, savedNodeInst.Status)
+			updated, err = executor.OnSubworkflowUpdated(context.Background(), savedNodeInst, &entity.WorkflowInst{
+				InstID: "child-1", Status: entity.InstCancelled, Reason: &entity.InstReason{},
+			})
+			assert.Nil(t, err)
+			assert.False(t, updated)
+		})
+	}
+}
+
+// fakeMutexCacheRepo 使用同一个互斥锁模拟流程实例锁
+type fakeMutexCacheRepo struct {
+	ports.CacheRepository
+	mu *sync.Mutex
+}
+
+func (r *fakeMutexCacheRepo) GetDistributeLockWithRetry(name string, expireTime time.Duration,
+	trys int, retryDelay time.Duration) cache.DistributeLock {
+	return &fakeMutexLock{mu: r.mu}
+}
+
+type fakeMutexLock struct {
+	mu *sync.Mutex
+}
+
+func (l *fakeMutexLock) Lock() error {
+	l.mu.Lock()
+	return nil
+}
+
+func (l *fakeMutexLock) Unlock() (bool, error) {
+	l.mu.Unlock()
+	return true, nil
+}
+
+// fakeSavedNodeInstRepo 保存最近一次更新的节点实例, 第一次读取时通知 firstGet
+type fakeSavedNodeInstRepo struct {
+	ports.NodeInstRepository
+	mu       sync.Mutex
+	saved    string
+	firstGet chan struct{}
+	gotOnce  sync.Once
+}
+
+func (r *fakeSavedNodeInstRepo) Get(req *dto.GetNodeInstDTO) (*entity.NodeInst, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer r.gotOnce.Do(func() { close(r.firstGet) })
+	nodeInst := &entity.NodeInst{}
+	if err := json.Unmarshal([]byte(r.saved), nodeInst); err != nil {
+		return nil, err
+	}
+	return nodeInst, nil
+}
+
+func (r *fakeSavedNodeInstRepo) UpdateWithDefID(req *dto.UpdateNodeInstDTO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved = req.Context
+	return nil
+}
+
+// fakeFanOutWorkflowRunner 启动子流程, 第一个子流程启动后立即结束
+type fakeFanOutWorkflowRunner struct {
+	WorkflowRunner
+	started      int
+	onFirstStart func(instID string)
+}
+
+func (r *fakeFanOutWorkflowRunner) Start(ctx context.Context, req *dto.StartWorkflowInstDTO) (string, error) {
+	instID := fmt.Sprintf("child-%d", r.started)
+	r.started++
+	if r.started == 1 {
+		r.onFirstStart(instID)
+	}
+	return instID, nil
+}
+
+type fakeSubworkflowDefRepo struct {
+	ports.WorkflowDefRepository
+}
+
+func (r *fakeSubworkflowDefRepo) GetSubworkflowLastVersion(req *dto.GetSubworkflowDefDTO) (*entity.WorkflowDef,
+	error) {
+	return &entity.WorkflowDef{DefID: "20"}, nil
+}
+
+type fakeParentInstRepo struct {
+	ports.WorkflowInstRepository
+}
+
+func (r *fakeParentInstRepo) Get(req *dto.GetWorkflowInstDTO) (*entity.WorkflowInst, error) {
+	return &entity.WorkflowInst{InstID: req.InstID, WorkflowDef: &entity.WorkflowDef{DefID: req.DefID}}, nil
+}
+
+// TestSubWorkflowExecutor_ChildCompletedBeforeExecuteReturns 测试子流程在节点执行返回之前结束时仍然按照完成策略处理
+func TestSubWorkflowExecutor_ChildCompletedBeforeExecuteReturns(t *testing.T) {
+	instLock := &sync.Mutex{}
+	nodeInstRepo := &fakeSavedNodeInstRepo{firstGet: make(chan struct{})}
+	runner := &fakeFanOutWorkflowRunner{}
+	executor := &SubWorkflowExecutor{
+		workflowRunner:   runner,
+		workflowDefRepo:  &fakeSubworkflowDefRepo{},
+		workflowInstRepo: &fakeParentInstRepo{},
+		nodeInstRepo:     nodeInstRepo,
+		exprEvaluator:    expr.NewDefaultEvaluator(),
+	}
+	registry := nodeexecutor.NewDefaultRegistry()
+	registry.Register(executor)
+	updater := &DefaultWorkflowUpdater{
+		workflowInstRepo:     &fakeParentInstRepo{},
+		nodeInstRepo:         nodeInstRepo,
+		cacheRepo:            &fakeMutexCacheRepo{mu: instLock},
+		exprEvaluator:        expr.NewDefaultEvaluator(),
+		nodeExecutorRegistry: registry,
+	}
+	nodeInst := &entity.NodeInst{
+		NodeDef: map[string]interface{}{
+			"refName": "deploy", "type": "SUB_WORKFLOW", "subworkflow": "deployRegion",
+			"items": []interface{}{"a", "b"},
+		},
+		BasicNodeDef: entity.BasicNodeDef{RefName: "deploy", Type: entity.SubWorkflowNode},
+		DefID:        "10",
+		InstID:       "100",
+		NodeInstID:   "1000",
+		Status:       entity.NodeInstRunning,
+		Operator:     &entity.NodeOperator{},
+		Reason:       &entity.NodeReason{},
+	}
+	// 节点执行之前保存的节点实例中还没有子流程信息
+	assert.Nil(t, saveNodeInst(nodeInstRepo, nodeInst))
+
+	done := make(chan error, 1)
+	runner.onFirstStart = func(instID string) {
+		go func() {
+			done <- updater.updateNodeInstForSubWorkflow(&entity.WorkflowInst{
+				WorkflowDef:      &entity.WorkflowDef{ParentDefID: "10"},
+				InstID:           instID,
+				ParentInstID:     "100",
+				ParentNodeInstID: "1000",
+				Status:           entity.InstSucceed,
+				Output:           map[string]interface{}{"region": "a"},
+				Reason:           &entity.InstReason{},
+			})
+		}()
+		// 等待子流程结束的处理读取到还没有子流程信息的节点实例
+		<-nodeInstRepo.firstGet
+	}
+
+	// 节点执行和保存都在父流程实例锁内完成
+	instLock.Lock()
+	assert.Nil(t, executor.Execute(context.Background(), nodeInst))
+	assert.Nil(t, saveNodeInst(nodeInstRepo, nodeInst))
+	instLock.Unlock()
+
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("subworkflow update not finished")
+	}
+	saved, err := nodeInstRepo.Get(&dto.GetNodeInstDTO{})
+	assert.Nil(t, err)
+	assert.Equal(t, entity.NodeInstRunning, saved.Status)
+	state, err := getFanOutState(saved)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, state.Succeeded)
+	assert.Equal(t, entity.NodeInstSucceed, state.Children[0].Status)
+	assert.Equal(t, entity.NodeInstRunning, state.Children[1].Status)
+}
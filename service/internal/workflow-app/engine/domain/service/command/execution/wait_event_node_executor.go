@@ -0,0 +1,112 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/pkg/log"
+	"github.com/fflow-tech/fflow/service/pkg/logs"
+)
+
+// WaitEventNodeExecutor 等待事件节点执行器
+// 节点执行时注册实例级别的事件触发器, 收到匹配关联表达式的事件后以事件内容作为输出完成节点
+type WaitEventNodeExecutor struct {
+	triggerRepo ports.TriggerRepository
+}
+
+// NewWaitEventNodeExecutor 初始化执行器
+func NewWaitEventNodeExecutor(repoProviderSet *ports.RepoProviderSet) *WaitEventNodeExecutor {
+	return &WaitEventNodeExecutor{triggerRepo: repoProviderSet.TriggerRepo()}
+}
+
+// Execute 执行节点, 注册等待事件的触发器
+func (d *WaitEventNodeExecutor) Execute(ctx context.Context, nodeInst *entity.NodeInst) error {
+	nodeDef, err := entity.ToActualNodeDef(nodeInst.BasicNodeDef.Type, nodeInst.NodeDef)
+	if err != nil {
+		return err
+	}
+	actualNodeDef := nodeDef.(entity.WaitEventNodeDef)
+	if actualNodeDef.Event == "" {
+		return fmt.Errorf("[%s]wait event node [%s] event must not be empty",
+			logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), nodeInst.BasicNodeDef.RefName)
+	}
+
+	createTriggerDTO := &dto.CreateTriggerDTO{}
+	createTriggerDTO.RefName = nodeInst.BasicNodeDef.RefName
+	createTriggerDTO.Type = entity.Event
+	createTriggerDTO.Event = actualNodeDef.Event
+	createTriggerDTO.Action = entity.Action{
+		Name:       nodeInst.BasicNodeDef.RefName,
+		Condition:  actualNodeDef.CorrelationExpr(),
+		ActionType: entity.ReceiveEvent,
+		Args: map[string]interface{}{
+			"node":       nodeInst.BasicNodeDef.RefName,
+			"nodeInstID": nodeInst.NodeInstID,
+			"event":      "${event}",
+		},
+	}
+	createTriggerDTO.Namespace = nodeInst.Namespace
+	createTriggerDTO.DefID = nodeInst.DefID
+	createTriggerDTO.DefVersion = nodeInst.DefVersion
+	createTriggerDTO.InstID = nodeInst.InstID
+	createTriggerDTO.Level = entity.InstTrigger
+	createTriggerDTO.Status = entity.EnabledTrigger
+
+	nodeInst.TriggerID, err = d.triggerRepo.Create(createTriggerDTO)
+	return err
+}
+
+// Polling 轮询节点
+func (d *WaitEventNodeExecutor) Polling(ctx context.Context, nodeInst *entity.NodeInst) error {
+	return nil
+}
+
+// Cancel 取消执行节点, 同时取消等待事件的触发器
+func (d *WaitEventNodeExecutor) Cancel(ctx context.Context, nodeInst *entity.NodeInst) error {
+	if err := DisableWaitEventTrigger(d.triggerRepo, nodeInst.DefID, nodeInst.DefVersion,
+		nodeInst.InstID, nodeInst.TriggerID); err != nil {
+		log.Warnf("[%s]Failed to disable trigger of node %s, caused by %s",
+			logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), nodeInst.BasicNodeDef.RefName, err)
+	}
+	nodeInst.Status = entity.NodeInstCancelled
+	return nil
+}
+
+// DisableWaitEventTrigger 取消等待事件节点注册的触发器
+func DisableWaitEventTrigger(triggerRepo ports.TriggerRepository,
+	defID string, defVersion int, instID, triggerID string) error {
+	if triggerID == "" {
+		return nil
+	}
+	return triggerRepo.Update(&dto.UpdateTriggerDTO{
+		DefID:      defID,
+		DefVersion: defVersion,
+		InstID:     instID,
+		TriggerID:  triggerID,
+		Level:      entity.InstTrigger,
+		Status:     entity.DisabledTrigger,
+	})
+}
+
+// AsyncComplete 是否异步完成
+func (d *WaitEventNodeExecutor) AsyncComplete(inst *entity.NodeInst) bool {
+	return true
+}
+
+// AsyncByTrigger 通过触发器完成节点
+func (d *WaitEventNodeExecutor) AsyncByTrigger(inst *entity.NodeInst) bool {
+	return true
+}
+
+// AsyncByPolling 通过轮询实现异步
+func (d *WaitEventNodeExecutor) AsyncByPolling(inst *entity.NodeInst) bool {
+	return false
+}
+
+// Type 获取是哪种节点类型的处理器
+func (d *WaitEventNodeExecutor) Type() entity.NodeType {
+	return entity.WaitEventNode
+}
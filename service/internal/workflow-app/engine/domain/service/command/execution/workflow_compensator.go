@@ -0,0 +1,192 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/convertor"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/nodeexecutor"
+	"github.com/fflow-tech/fflow/service/pkg/constants"
+	"github.com/fflow-tech/fflow/service/pkg/log"
+	"github.com/fflow-tech/fflow/service/pkg/logs"
+)
+
+// WorkflowCompensator 流程补偿者
+type WorkflowCompensator interface {
+	// Compensate 按执行路径逆序补偿已经执行成功的节点, 调用方需要持有流程实例的锁并保证流程实例已经结束
+	Compensate(ctx context.Context, inst *entity.WorkflowInst, req *dto.CompensateWorkflowInstDTO) error
+}
+
+// DefaultWorkflowCompensator 默认的流程补偿者
+type DefaultWorkflowCompensator struct {
+	nodeInstRepo         ports.NodeInstRepository
+	nodeExecutorRegistry nodeexecutor.Registry
+	workflowUpdater      WorkflowUpdater
+}
+
+// NewDefaultWorkflowCompensator 新建流程补偿者
+func NewDefaultWorkflowCompensator(repoProviderSet *ports.RepoProviderSet,
+	workflowProviderSet *WorkflowProviderSet,
+	workflowUpdater WorkflowUpdater) *DefaultWorkflowCompensator {
+	return &DefaultWorkflowCompensator{
+		nodeInstRepo:         repoProviderSet.NodeInstRepo(),
+		nodeExecutorRegistry: workflowProviderSet.NodeExecutorRegistry(),
+		workflowUpdater:      workflowUpdater,
+	}
+}
+
+// Compensate 补偿流程实例, 遇到补偿失败的节点时停止, 再次补偿时会跳过已经补偿成功的节点
+func (c *DefaultWorkflowCompensator) Compensate(ctx context.Context, inst *entity.WorkflowInst,
+	req *dto.CompensateWorkflowInstDTO) error {
+	if inst.Compensation != nil && inst.Compensation.Status == entity.CompensationSucceed {
+		return nil
+	}
+
+	nodeInsts, err := c.getAllNodeInsts(inst)
+	if err != nil {
+		return err
+	}
+
+	startCompensation(inst, req)
+	if err := c.workflowUpdater.UpdateWorkflowInst(inst); err != nil {
+		return err
+	}
+
+	for _, nodeInst := range entity.GetNodeInstsToBeCompensated(inst.ExecutePath, nodeInsts) {
+		executor, ok := c.getCompensableExecutor(nodeInst)
+		if !ok || !executor.CanCompensate(nodeInst) {
+			continue
+		}
+
+		compensateNodeInst, err := c.compensateNodeInst(ctx, executor, nodeInst, req)
+		if compensateNodeInst != nil {
+			inst.Compensation.Records = append(inst.Compensation.Records, &entity.CompensateRecord{
+				RefName:              nodeInst.BasicNodeDef.RefName,
+				NodeInstID:           nodeInst.NodeInstID,
+				CompensateNodeInstID: compensateNodeInst.NodeInstID,
+				Status:               compensateNodeInst.Status.String(),
+			})
+		}
+		if err != nil {
+			log.Warnf("[%s]Failed to compensate node %s, caused by %s",
+				logs.GetFlowTraceID(inst.WorkflowDef.DefID, inst.InstID), nodeInst.BasicNodeDef.RefName, err)
+			inst.Compensation.Status = entity.CompensationFailed
+			inst.Compensation.FailedReason = fmt.Sprintf("failed to compensate node %s: %s",
+				nodeInst.BasicNodeDef.RefName, err)
+			inst.Compensation.CompletedAt = time.Now()
+			if updateErr := c.workflowUpdater.UpdateWorkflowInst(inst); updateErr != nil {
+				return updateErr
+			}
+			return fmt.Errorf("[%s]%s", logs.GetFlowTraceID(inst.WorkflowDef.DefID, inst.InstID),
+				inst.Compensation.FailedReason)
+		}
+	}
+
+	inst.Compensation.Status = entity.CompensationSucceed
+	inst.Compensation.CompletedAt = time.Now()
+	return c.workflowUpdater.UpdateWorkflowInst(inst)
+}
+
+// startCompensation 标记补偿开始, 保留之前补偿的记录
+func startCompensation(inst *entity.WorkflowInst, req *dto.CompensateWorkflowInstDTO) {
+	if inst.Compensation == nil {
+		inst.Compensation = &entity.Compensation{}
+	}
+	inst.Compensation.Status = entity.CompensationRunning
+	inst.Compensation.Operator = req.Operator
+	inst.Compensation.Reason = req.Reason
+	inst.Compensation.FailedReason = ""
+	inst.Compensation.StartAt = time.Now()
+	inst.Compensation.CompletedAt = time.Time{}
+}
+
+// getAllNodeInsts 获取流程实例所有的节点实例, 包括之前的补偿节点实例
+func (c *DefaultWorkflowCompensator) getAllNodeInsts(inst *entity.WorkflowInst) ([]*entity.NodeInst, error) {
+	pageQueryNodeInsts := &dto.PageQueryNodeInstDTO{
+		DefID:     inst.WorkflowDef.DefID,
+		InstID:    inst.InstID,
+		PageQuery: constants.NewPageQuery(defaultInitPageIndex, defaultPageSize),
+	}
+
+	var r []*entity.NodeInst
+	for i := defaultInitPageIndex; i <= maxQueryTimes; i++ {
+		pageQueryNodeInsts.PageIndex = i
+		nodeInsts, err := c.nodeInstRepo.PageQuery(pageQueryNodeInsts)
+		if err != nil {
+			return nil, err
+		}
+		if len(nodeInsts) == 0 {
+			break
+		}
+		r = append(r, nodeInsts...)
+	}
+	return r, nil
+}
+
+func (c *DefaultWorkflowCompensator) getCompensableExecutor(
+	nodeInst *entity.NodeInst) (nodeexecutor.CompensableExecutor, bool) {
+	executor, ok := c.nodeExecutorRegistry.GetExecutor(nodeInst.BasicNodeDef.Type)
+	if !ok {
+		return nil, false
+	}
+	compensableExecutor, ok := executor.(nodeexecutor.CompensableExecutor)
+	return compensableExecutor, ok
+}
+
+// compensateNodeInst 创建补偿节点实例并执行补偿
+func (c *DefaultWorkflowCompensator) compensateNodeInst(ctx context.Context,
+	executor nodeexecutor.CompensableExecutor, nodeInst *entity.NodeInst,
+	req *dto.CompensateWorkflowInstDTO) (*entity.NodeInst, error) {
+	compensateNodeInst := newCompensateNodeInst(nodeInst, req)
+	createNodeInstDTO, err := convertor.NodeInstConvertor.ConvertEntityToCreateDTO(compensateNodeInst)
+	if err != nil {
+		return nil, err
+	}
+	compensateNodeInst.NodeInstID, err = c.nodeInstRepo.Create(createNodeInstDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	compensateErr := executor.Compensate(ctx, compensateNodeInst)
+	if compensateErr != nil {
+		compensateNodeInst.Status = entity.NodeInstFailed
+		compensateNodeInst.Reason.FailedReason = compensateErr.Error()
+	} else {
+		compensateNodeInst.Status = entity.NodeInstSucceed
+	}
+	compensateNodeInst.CompletedAt = time.Now()
+
+	updateNodeInstDTO, err := convertor.NodeInstConvertor.ConvertEntityToUpdateDTO(compensateNodeInst)
+	if err != nil {
+		return compensateNodeInst, err
+	}
+	if err := c.nodeInstRepo.UpdateWithDefID(updateNodeInstDTO); err != nil {
+		return compensateNodeInst, err
+	}
+	return compensateNodeInst, compensateErr
+}
+
+// newCompensateNodeInst 新建补偿节点实例, 保留被补偿节点实例的输出, 补偿参数中可以通过 this.output 引用
+func newCompensateNodeInst(nodeInst *entity.NodeInst, req *dto.CompensateWorkflowInstDTO) *entity.NodeInst {
+	now := time.Now()
+	return &entity.NodeInst{
+		NodeDef:       nodeInst.NodeDef,
+		BasicNodeDef:  nodeInst.BasicNodeDef,
+		Namespace:     nodeInst.Namespace,
+		DefID:         nodeInst.DefID,
+		DefVersion:    nodeInst.DefVersion,
+		InstID:        nodeInst.InstID,
+		Status:        entity.NodeInstRunning,
+		Owner:         nodeInst.Owner,
+		Output:        nodeInst.Output,
+		ScheduledAt:   now,
+		ExecuteAt:     now,
+		Operator:      &entity.NodeOperator{RunOperator: req.Operator},
+		Reason:        &entity.NodeReason{RunReason: req.Reason},
+		CompensateFor: nodeInst.NodeInstID,
+	}
+}
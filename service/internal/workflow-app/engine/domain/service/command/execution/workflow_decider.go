@@ -29,9 +29,11 @@ func NewDefaultWorkflowDecider(exprEvaluator *expr.DefaultEvaluator) *DefaultWor
 	}
 
 	r.searchNextsFuncMap = map[entity.NodeType]func(inst *entity.WorkflowInst, nodeDef interface{}) ([]string, error){
-		entity.SwitchNode: r.searchSwitchNodeNexts,
-		entity.ForkNode:   r.searchForkNodeNexts,
-		entity.JoinNode:   r.searchJoinNodeNexts,
+		entity.SwitchNode:  r.searchSwitchNodeNexts,
+		entity.ForkNode:    r.searchForkNodeNexts,
+		entity.JoinNode:    r.searchJoinNodeNexts,
+		entity.ForeachNode: r.searchForeachNodeNexts,
+		entity.LoopNode:    r.searchLoopNodeNexts,
 	}
 	return r
 }
@@ -88,6 +90,11 @@ func (d *DefaultWorkflowDecider) decideByCurNodeInst(inst *entity.WorkflowInst)
 		if err := d.decideByFailedPolicy(inst, curNodeInst, result); err != nil {
 			return nil, err
 		}
+	} else if curNodeInst.Status == entity.NodeInstTimeout || curNodeInst.Status == entity.NodeInstCancelled {
+		// 节点超时或者取消只有配置了错误路由时才会驱动流程
+		if err := d.decideByErrorRoute(inst, curNodeInst, result); err != nil {
+			return nil, err
+		}
 	}
 	return result, nil
 }
@@ -98,8 +105,12 @@ func (d *DefaultWorkflowDecider) notNeedScheduleNext(curNodeInst *entity.NodeIns
 	return curNodeInst.BasicNodeDef.Schedule.SchedulePolicy == entity.ScheduleNextIfNotComplete || curNodeInst.FromRerun
 }
 
+// decideByFailedPolicy 节点失败时优先根据错误路由执行处理节点, 没有匹配的路由再根据失败策略决定
 func (d *DefaultWorkflowDecider) decideByFailedPolicy(inst *entity.WorkflowInst,
 	curNodeInst *entity.NodeInst, result *entity.DecideResult) error {
+	if _, ok := entity.MatchErrorRoute(curNodeInst); ok {
+		return d.decideByErrorRoute(inst, curNodeInst, result)
+	}
 	if curNodeInst.BasicNodeDef.Schedule.FailedPolicy == entity.Ignore {
 		if d.notNeedScheduleNext(curNodeInst) {
 			return nil
@@ -114,6 +125,19 @@ func (d *DefaultWorkflowDecider) decideByFailedPolicy(inst *entity.WorkflowInst,
 	return nil
 }
 
+// decideByErrorRoute 根据错误路由调度对应的处理节点
+func (d *DefaultWorkflowDecider) decideByErrorRoute(inst *entity.WorkflowInst,
+	curNodeInst *entity.NodeInst, result *entity.DecideResult) error {
+	next, ok := entity.MatchErrorRoute(curNodeInst)
+	if !ok {
+		return nil
+	}
+	if next == entity.EndNode {
+		return d.setInstStatusForArriveEndNode(inst, result)
+	}
+	return d.appendNextNodeInst(inst, next, result)
+}
+
 func (d *DefaultWorkflowDecider) decideForStart(inst *entity.WorkflowInst) (*entity.DecideResult, error) {
 	result := entity.NewDecideResult()
 	nodeIndexMap, err := entity.GetNodeIndexDefMap(inst.WorkflowDef)
@@ -222,7 +246,8 @@ func (d *DefaultWorkflowDecider) needSkipNode(inst *entity.WorkflowInst, nodeIns
 		}
 	}
 
-	if nodeInst.BasicNodeDef.Condition != "" {
+	// 循环节点的 condition 为循环条件, 由执行器计算
+	if nodeInst.BasicNodeDef.Condition != "" && nodeInst.BasicNodeDef.Type != entity.LoopNode {
 		match, err := matchCondition(d.exprEvaluator, inst, nodeInst.BasicNodeDef.Condition)
 		if err != nil {
 			return false, err
@@ -468,6 +493,36 @@ func (d *DefaultWorkflowDecider) searchForkNodeNexts(inst *entity.WorkflowInst,
 	return nodeDef.Fork, nil
 }
 
+// searchForeachNodeNexts 遍历节点在所有元素执行完成后才会完成, 元素的执行不会产生新的节点实例, 直接调度下一个节点
+func (d *DefaultWorkflowDecider) searchForeachNodeNexts(inst *entity.WorkflowInst,
+	curNodeDef interface{}) ([]string, error) {
+	nodeDef := curNodeDef.(entity.ForeachNodeDef)
+	next, err := entity.GetNextNode(inst.WorkflowDef, &nodeDef.BasicNodeDef)
+	if err != nil {
+		return nil, err
+	}
+	return []string{next}, nil
+}
+
+// searchLoopNodeNexts 循环节点根据执行结果选择循环体或者下一个节点, 每次迭代都会产生新的节点实例
+func (d *DefaultWorkflowDecider) searchLoopNodeNexts(inst *entity.WorkflowInst,
+	curNodeDef interface{}) ([]string, error) {
+	nodeDef := curNodeDef.(entity.LoopNodeDef)
+	output := entity.LoopOutput{}
+	if err := utils.ToOtherInterfaceValue(&output, inst.CurNodeInst.Output); err != nil {
+		return nil, err
+	}
+	if output.Continue && inst.CurNodeInst.Status == entity.NodeInstSucceed {
+		return []string{nodeDef.Body}, nil
+	}
+
+	next, err := entity.GetNextNode(inst.WorkflowDef, &nodeDef.BasicNodeDef)
+	if err != nil {
+		return nil, err
+	}
+	return []string{next}, nil
+}
+
 func (d *DefaultWorkflowDecider) searchJoinNodeNexts(inst *entity.WorkflowInst,
 	curNodeDef interface{}) ([]string, error) {
 	nodeDef := curNodeDef.(entity.JoinNodeDef)
@@ -137,6 +137,36 @@ func TestDefaultWorkflowDecider_BasicDecide(t *testing.T) {
 			[]string{"t2"},
 			false,
 		},
+		{
+			"遍历节点完成后调度下一个节点的情况",
+			args{inst: &entity.WorkflowInst{
+				WorkflowDef: &entity.WorkflowDef{
+					Nodes: []map[string]interface{}{
+						{"t1": map[string]interface{}{
+							"type":  "FOREACH",
+							"items": "${w.i.docs}",
+							"node":  map[string]interface{}{"type": "TRANSFORM"},
+							"next":  "t3",
+						}},
+						{"t2": map[string]interface{}{
+							"type": "ASSIGN",
+							"next": "end",
+						}},
+						{"t3": map[string]interface{}{
+							"type": "ASSIGN",
+							"next": "end",
+						}},
+					},
+				},
+				CurNodeInst: &entity.NodeInst{
+					NodeDef:      entity.BasicNodeDef{RefName: "t1", Type: entity.ForeachNode, Next: "t3"},
+					Status:       entity.NodeInstSucceed,
+					BasicNodeDef: entity.BasicNodeDef{RefName: "t1", Type: entity.ForeachNode, Next: "t3"},
+				},
+			}},
+			[]string{"t3"},
+			false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -153,6 +183,89 @@ func TestDefaultWorkflowDecider_BasicDecide(t *testing.T) {
 	}
 }
 
+// TestDefaultWorkflowDecider_LoopDecide 测试
+func TestDefaultWorkflowDecider_LoopDecide(t *testing.T) {
+	nodes := []map[string]interface{}{
+		{"t1": map[string]interface{}{
+			"type":      "LOOP",
+			"condition": "${w.i.n > 1}",
+			"body":      "t2",
+			"next":      "t3",
+		}},
+		{"t2": map[string]interface{}{
+			"type": "ASSIGN",
+			"next": "t1",
+		}},
+		{"t3": map[string]interface{}{
+			"type": "ASSIGN",
+			"next": "end",
+		}},
+	}
+	loopNodeDef := entity.BasicNodeDef{RefName: "t1", Type: entity.LoopNode, Next: "t3"}
+	tests := []struct {
+		name    string
+		inst    *entity.WorkflowInst
+		want    []string
+		wantErr bool
+	}{
+		{
+			"循环节点继续迭代时调度循环体",
+			&entity.WorkflowInst{
+				WorkflowDef: &entity.WorkflowDef{Nodes: nodes},
+				CurNodeInst: &entity.NodeInst{
+					NodeDef:      loopNodeDef,
+					Status:       entity.NodeInstSucceed,
+					BasicNodeDef: loopNodeDef,
+					Output:       map[string]interface{}{"index": 1, "continue": true},
+				},
+			},
+			[]string{"t2"},
+			false,
+		},
+		{
+			"循环节点退出时调度下一个节点",
+			&entity.WorkflowInst{
+				WorkflowDef: &entity.WorkflowDef{Nodes: nodes},
+				CurNodeInst: &entity.NodeInst{
+					NodeDef:      loopNodeDef,
+					Status:       entity.NodeInstSucceed,
+					BasicNodeDef: loopNodeDef,
+					Output:       map[string]interface{}{"index": 2, "continue": false},
+				},
+			},
+			[]string{"t3"},
+			false,
+		},
+		{
+			"循环体执行完成后回到循环节点, 循环条件不作为跳过条件",
+			&entity.WorkflowInst{
+				WorkflowDef: &entity.WorkflowDef{Nodes: nodes},
+				Input:       map[string]interface{}{"n": 0},
+				CurNodeInst: &entity.NodeInst{
+					NodeDef:      entity.BasicNodeDef{RefName: "t2", Type: entity.AssignNode, Next: "t1"},
+					Status:       entity.NodeInstSucceed,
+					BasicNodeDef: entity.BasicNodeDef{RefName: "t2", Type: entity.AssignNode, Next: "t1"},
+				},
+			},
+			[]string{"t1"},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDefaultWorkflowDecider(expr.NewDefaultEvaluator())
+			got, err := d.Decide(tt.inst)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decide() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(entity.GetNodeRefNames(got.NodesToBeScheduled), tt.want) {
+				t.Errorf("Decide() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // TestDefaultWorkflowDecider_SwitchDecide 测试
 func TestDefaultWorkflowDecider_SwitchDecide(t *testing.T) {
 	type args struct {
@@ -1333,3 +1446,62 @@ func TestDefaultWorkflowDecider_SkipNode(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultWorkflowDecider_ErrorRouteDecide(t *testing.T) {
+	nodes := []map[string]interface{}{
+		{"t1": map[string]interface{}{"type": "ASSIGN", "next": "end"}},
+		{"h1": map[string]interface{}{"type": "ASSIGN", "next": "end"}},
+		{"h2": map[string]interface{}{"type": "ASSIGN", "next": "end"}},
+		{"h3": map[string]interface{}{"type": "ASSIGN", "next": "end"}},
+	}
+	t1 := entity.BasicNodeDef{RefName: "t1", Type: entity.AssignNode, Next: "end", OnError: []entity.ErrorRoute{
+		{Errors: []entity.ErrorClass{entity.ErrorClassHTTP}, HTTPStatus: []string{"5xx", "429"}, Next: "h1"},
+		{Errors: []entity.ErrorClass{entity.ErrorClassTimeout, entity.ErrorClassExpression}, Next: "h2"},
+		{Errors: []entity.ErrorClass{entity.ErrorClassError}, Next: "h3"},
+	}}
+	tests := []struct {
+		name       string
+		status     entity.NodeInstStatus
+		nodeErr    *entity.NodeError
+		want       []string
+		wantStatus entity.InstStatus
+	}{
+		{"HTTP 状态码匹配范围", entity.NodeInstFailed,
+			&entity.NodeError{Class: entity.ErrorClassHTTP, StatusCode: 503}, []string{"h1"}, entity.InstRunning},
+		{"HTTP 状态码精确匹配", entity.NodeInstFailed,
+			&entity.NodeError{Class: entity.ErrorClassHTTP, StatusCode: 429}, []string{"h1"}, entity.InstRunning},
+		{"HTTP 状态码不匹配时流程失败", entity.NodeInstFailed,
+			&entity.NodeError{Class: entity.ErrorClassHTTP, StatusCode: 404}, []string{}, entity.InstFailed},
+		{"表达式错误", entity.NodeInstFailed,
+			&entity.NodeError{Class: entity.ErrorClassExpression}, []string{"h2"}, entity.InstRunning},
+		{"没有记录错误类型的失败", entity.NodeInstFailed, nil, []string{"h3"}, entity.InstRunning},
+		{"节点超时", entity.NodeInstTimeout, nil, []string{"h2"}, entity.InstRunning},
+		{"节点取消没有匹配的路由时不调度", entity.NodeInstCancelled, nil, []string{}, entity.InstRunning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := &entity.WorkflowInst{
+				WorkflowDef: &entity.WorkflowDef{Nodes: nodes},
+				CurNodeInst: &entity.NodeInst{
+					NodeDef:      t1,
+					BasicNodeDef: t1,
+					Status:       tt.status,
+					Error:        tt.nodeErr,
+					Reason:       &entity.NodeReason{},
+				},
+			}
+			d := NewDefaultWorkflowDecider(expr.NewDefaultEvaluator())
+			got, err := d.Decide(inst)
+			if err != nil {
+				t.Errorf("Decide() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(entity.GetNodeRefNames(got.NodesToBeScheduled), tt.want) {
+				t.Errorf("Decide() got = %v, want %v", entity.GetNodeRefNames(got.NodesToBeScheduled), tt.want)
+			}
+			if got.InstStatus != tt.wantStatus {
+				t.Errorf("Decide() got status = %v, want %v", got.InstStatus, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -8,10 +8,12 @@ import (
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/convertor"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/validator"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/config"
 	"github.com/fflow-tech/fflow/service/pkg/errno"
 	"github.com/fflow-tech/fflow/service/pkg/log"
 	"github.com/fflow-tech/fflow/service/pkg/logs"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -75,14 +77,22 @@ func (e *DefaultWorkflowExecutor) doDriveNext(ctx context.Context, req *dto.Driv
 			config.GetValidationRulesConfig().MaxNodeInstsForOneFlow)
 	}
 
+	// 流程失败后并行分支的节点结束时还会驱动流程, 最后一个分支结束时开始自动补偿
 	if inst.Status.IsTerminal() {
-		return nil
+		if !needAutoCompensate(inst) {
+			return nil
+		}
+		return e.workflowUpdater.SendWorkflowCompensateDriveEvent(inst)
 	}
 
 	if inst.Status == entity.InstPaused {
 		return e.handleForInstAlreadyPaused(inst)
 	}
 
+	if !inst.TokenBudgetExceeded && inst.ExceedTokenBudget() {
+		return e.handleForTokenBudgetExceeded(inst)
+	}
+
 	// 1. 决策器决策
 	decideResult, err := e.workflowDecider.Decide(inst)
 	if err != nil {
@@ -111,6 +121,30 @@ func (e *DefaultWorkflowExecutor) handleForInstAlreadyPaused(inst *entity.Workfl
 	return e.workflowUpdater.UpdateWorkflowInst(inst)
 }
 
+// handleForTokenBudgetExceeded 超出 token 预算时按照策略暂停或者失败流程, 暂停后恢复的流程不再重复处理
+func (e *DefaultWorkflowExecutor) handleForTokenBudgetExceeded(inst *entity.WorkflowInst) error {
+	reason := fmt.Sprintf("token usage exceeds budget, total tokens: %d, cost: %g",
+		inst.TokenUsage.TotalTokens, inst.TokenUsage.Cost)
+	log.Warnf("[%s]%s", logs.GetFlowTraceID(inst.WorkflowDef.DefID, inst.InstID), reason)
+
+	inst.TokenBudgetExceeded = true
+	if inst.WorkflowDef.TokenBudget.Policy == entity.TokenBudgetPause {
+		inst.Status = entity.InstPaused
+		inst.Reason.PauseReason = reason
+		if inst.CurNodeInst != nil {
+			inst.RunCompletedNodeInstIDsAfterPaused = buildRunCompletedNodeInstIDsAfterPaused(inst)
+		}
+		return e.workflowUpdater.UpdateWorkflowInstWithStatus(inst)
+	}
+
+	inst.Status = entity.InstFailed
+	inst.Reason.FailedRootCause.FailedReason = reason
+	if inst.CurNodeInst != nil {
+		inst.Reason.FailedRootCause.FailedNodeRefNames = []string{inst.CurNodeInst.BasicNodeDef.RefName}
+	}
+	return e.workflowUpdater.UpdateWorkflowInstWithStatus(inst)
+}
+
 func buildRunCompletedNodeInstIDsAfterPaused(inst *entity.WorkflowInst) []string {
 	if len(inst.RunCompletedNodeInstIDsAfterPaused) == 0 {
 		return []string{inst.CurNodeInst.NodeInstID}
@@ -138,10 +172,56 @@ func (e *DefaultWorkflowExecutor) updateWorkflowInstByDecideResult(inst *entity.
 	if decideResult.InstStatus == entity.InstFailed {
 		inst.Reason.FailedRootCause = *decideResult.InstFailedRootCause
 	}
+	if decideResult.InstStatus == entity.InstSucceed {
+		checkInstOutput(inst)
+	}
+	if err := updateLoopState(inst); err != nil {
+		return err
+	}
 
 	return e.workflowUpdater.UpdateWorkflowInstWithStatus(inst)
 }
 
+// updateLoopState 根据完成的循环节点的输出记录循环的执行状态, 和决策结果一起保存到流程实例中
+func updateLoopState(inst *entity.WorkflowInst) error {
+	nodeInst := inst.CurNodeInst
+	if nodeInst == nil || nodeInst.BasicNodeDef.Type != entity.LoopNode {
+		return nil
+	}
+	output := entity.LoopOutput{}
+	if err := utils.ToOtherInterfaceValue(&output, nodeInst.Output); err != nil {
+		return err
+	}
+
+	if inst.LoopStates == nil {
+		inst.LoopStates = map[string]*entity.LoopState{}
+	}
+	inst.LoopStates[nodeInst.BasicNodeDef.RefName] = &entity.LoopState{
+		Index:      output.Index,
+		NodeInstID: nodeInst.NodeInstID,
+		Running:    output.Continue && nodeInst.Status == entity.NodeInstSucceed,
+	}
+	return nil
+}
+
+// checkInstOutput 流程成功时校验输出是否符合流程定义, 不符合时按照策略让流程失败或者只记录原因
+// 手动标记流程成功时同样需要校验
+func checkInstOutput(inst *entity.WorkflowInst) {
+	err := validator.ValidateInstOutput(inst.WorkflowDef, inst.Output)
+	if err == nil {
+		return
+	}
+	log.Warnf("[%s]Workflow inst output does not match the output def, caused by %s",
+		logs.GetFlowTraceID(inst.WorkflowDef.DefID, inst.InstID), err)
+
+	inst.OutputViolation = err.Error()
+	if inst.WorkflowDef.OutputPolicy == entity.OutputWarn {
+		return
+	}
+	inst.Status = entity.InstFailed
+	inst.Reason.FailedRootCause.FailedReason = fmt.Sprintf("illegal workflow output, caused by %s", err)
+}
+
 func (e *DefaultWorkflowExecutor) creatNodeInsts(result *entity.DecideResult) error {
 	for _, nodeInst := range result.NodesToBeScheduled {
 		createNodeInstDTO, err := convertor.NodeInstConvertor.ConvertEntityToCreateDTO(nodeInst)
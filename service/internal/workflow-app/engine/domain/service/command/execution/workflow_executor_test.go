@@ -0,0 +1,75 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCheckInstOutput 测试流程成功时按照输出定义校验输出
+func TestCheckInstOutput(t *testing.T) {
+	outputDef := []map[string]entity.OutputDef{
+		{"count": {Type: entity.OutputInteger, Required: true}},
+		{"level": {Type: entity.OutputString, Options: []interface{}{"high", "low"}}},
+	}
+	tests := []struct {
+		name          string
+		policy        entity.OutputPolicy
+		output        map[string]interface{}
+		wantStatus    entity.InstStatus
+		wantViolation bool
+	}{
+		{"符合输出定义", "", map[string]interface{}{"count": 3, "level": "high"}, entity.InstSucceed, false},
+		{"缺少必须的输出", "", map[string]interface{}{"level": "high"}, entity.InstFailed, true},
+		{"类型不符", entity.OutputFail, map[string]interface{}{"count": "3"}, entity.InstFailed, true},
+		{"不在可选值中", "", map[string]interface{}{"count": 3, "level": "mid"}, entity.InstFailed, true},
+		{"只记录原因", entity.OutputWarn, map[string]interface{}{"count": 1.5}, entity.InstSucceed, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := &entity.WorkflowInst{
+				WorkflowDef: &entity.WorkflowDef{Output: outputDef, OutputPolicy: tt.policy},
+				Status:      entity.InstSucceed,
+				Output:      tt.output,
+				Reason:      &entity.InstReason{},
+			}
+			checkInstOutput(inst)
+			assert.Equal(t, tt.wantStatus, inst.Status)
+			assert.Equal(t, tt.wantViolation, inst.OutputViolation != "")
+		})
+	}
+}
+
+// TestUpdateLoopState 测试根据完成的循环节点记录循环的执行状态
+func TestUpdateLoopState(t *testing.T) {
+	loopNodeInst := func(status entity.NodeInstStatus, output map[string]interface{}) *entity.NodeInst {
+		return &entity.NodeInst{
+			NodeInstID:   "5",
+			Status:       status,
+			Output:       output,
+			BasicNodeDef: entity.BasicNodeDef{RefName: "loop", Type: entity.LoopNode},
+		}
+	}
+	tests := []struct {
+		name        string
+		curNodeInst *entity.NodeInst
+		want        map[string]*entity.LoopState
+	}{
+		{"继续执行循环体", loopNodeInst(entity.NodeInstSucceed, map[string]interface{}{"index": 1, "continue": true}),
+			map[string]*entity.LoopState{"loop": {Index: 1, NodeInstID: "5", Running: true}}},
+		{"退出循环", loopNodeInst(entity.NodeInstSucceed, map[string]interface{}{"index": 2, "continue": false}),
+			map[string]*entity.LoopState{"loop": {Index: 2, NodeInstID: "5"}}},
+		{"循环节点失败时不再执行循环体", loopNodeInst(entity.NodeInstFailed, map[string]interface{}{"continue": true}),
+			map[string]*entity.LoopState{"loop": {NodeInstID: "5"}}},
+		{"不是循环节点", &entity.NodeInst{BasicNodeDef: entity.BasicNodeDef{RefName: "body", Type: entity.TransformNode}},
+			nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := &entity.WorkflowInst{CurNodeInst: tt.curNodeInst}
+			assert.Nil(t, updateLoopState(inst))
+			assert.Equal(t, tt.want, inst.LoopStates)
+		})
+	}
+}
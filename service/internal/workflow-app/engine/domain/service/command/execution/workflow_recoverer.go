@@ -0,0 +1,155 @@
+package execution
+
+import (
+	"context"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/event"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/nodeexecutor"
+	"github.com/fflow-tech/fflow/service/pkg/constants"
+	"github.com/fflow-tech/fflow/service/pkg/log"
+	"github.com/fflow-tech/fflow/service/pkg/logs"
+)
+
+// WorkflowRecoverer 流程恢复者, 用于进程退出导致驱动事件丢失后继续推进流程
+type WorkflowRecoverer interface {
+	// Recover 根据节点实例的状态重新发送驱动事件, 调用方需要持有流程实例的锁并保证流程实例在执行中
+	Recover(ctx context.Context, inst *entity.WorkflowInst) error
+}
+
+// DefaultWorkflowRecoverer 默认的流程恢复者
+type DefaultWorkflowRecoverer struct {
+	nodeInstRepo         ports.NodeInstRepository
+	nodeExecutorRegistry nodeexecutor.Registry
+	workflowUpdater      WorkflowUpdater
+}
+
+// NewDefaultWorkflowRecoverer 新建流程恢复者
+func NewDefaultWorkflowRecoverer(repoProviderSet *ports.RepoProviderSet,
+	workflowProviderSet *WorkflowProviderSet,
+	workflowUpdater WorkflowUpdater) *DefaultWorkflowRecoverer {
+	return &DefaultWorkflowRecoverer{
+		nodeInstRepo:         repoProviderSet.NodeInstRepo(),
+		nodeExecutorRegistry: workflowProviderSet.NodeExecutorRegistry(),
+		workflowUpdater:      workflowUpdater,
+	}
+}
+
+// Recover 恢复流程实例
+func (r *DefaultWorkflowRecoverer) Recover(ctx context.Context, inst *entity.WorkflowInst) error {
+	nodeInsts, err := r.getAllNodeInsts(inst)
+	if err != nil {
+		return err
+	}
+	nodeInsts = entity.FilterOutCompensationNodeInsts(nodeInsts)
+
+	// 还没有创建任何节点实例, 说明流程启动事件丢失
+	if len(nodeInsts) == 0 {
+		log.Infof("[%s]Recover workflow inst by start drive event",
+			logs.GetFlowTraceID(inst.WorkflowDef.DefID, inst.InstID))
+		return r.workflowUpdater.SendWorkflowStartDriveEvent(inst.WorkflowDef.Namespace,
+			inst.WorkflowDef.DefID, inst.InstID, false)
+	}
+
+	var scheduled []string
+	var lastCompleted *entity.NodeInst
+	hasUnfinished := false
+	for _, nodeInst := range nodeInsts {
+		if nodeInst.Status.IsCompleted() &&
+			(lastCompleted == nil || nodeInst.CompletedAt.After(lastCompleted.CompletedAt)) {
+			lastCompleted = nodeInst
+		}
+		if nodeInst.Status.IsTerminal() {
+			continue
+		}
+
+		hasUnfinished = true
+		if nodeInst.Status == entity.NodeInstScheduled {
+			scheduled = append(scheduled, nodeInst.NodeInstID)
+			continue
+		}
+		if err := r.recoverNodeInst(nodeInst); err != nil {
+			return err
+		}
+	}
+
+	if len(scheduled) > 0 {
+		if err := r.workflowUpdater.SendNodeScheduleDriveEvent(inst, scheduled); err != nil {
+			return err
+		}
+	}
+
+	// 所有的节点都已经结束但流程还在执行中, 说明节点完成事件丢失
+	if !hasUnfinished && lastCompleted != nil {
+		return r.workflowUpdater.SendNodeCompleteDriveEvent(lastCompleted, false)
+	}
+	return nil
+}
+
+// recoverNodeInst 根据节点实例的状态重新发送驱动事件
+func (r *DefaultWorkflowRecoverer) recoverNodeInst(nodeInst *entity.NodeInst) error {
+	log.Infof("[%s]Recover node inst [%s][%d] with status %s",
+		logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID),
+		nodeInst.BasicNodeDef.RefName, nodeInst.NodeInstID, nodeInst.Status)
+
+	switch nodeInst.Status {
+	case entity.NodeInstWaiting:
+		return r.workflowUpdater.SendWaitNodeExecuteDriveEvent(nodeInst)
+	case entity.NodeInstRunning:
+		return r.recoverRunningNodeInst(nodeInst)
+	default:
+		// 暂停的节点需要用户手动恢复
+		return nil
+	}
+}
+
+// recoverRunningNodeInst 恢复执行中的节点实例
+func (r *DefaultWorkflowRecoverer) recoverRunningNodeInst(nodeInst *entity.NodeInst) error {
+	if nodeInst.Retrying {
+		return r.workflowUpdater.SendNodeDriveEvent(nodeInst, event.NodeExecuteDrive)
+	}
+
+	executor, ok := r.nodeExecutorRegistry.GetExecutor(nodeInst.BasicNodeDef.Type)
+	if !ok {
+		return nil
+	}
+	if executor.AsyncByPolling(nodeInst) {
+		return r.workflowUpdater.SendNodeDriveEvent(nodeInst, event.NodePollDrive)
+	}
+	// 等待外部回调完成的节点不需要处理
+	if executor.AsyncComplete(nodeInst) {
+		return nil
+	}
+
+	// 同步执行的节点在执行过程中被中断, 按照重试重新执行
+	nodeInst.Retrying = true
+	if err := r.workflowUpdater.UpdateNodeInstWithStatus(nodeInst); err != nil {
+		return err
+	}
+	return r.workflowUpdater.SendNodeDriveEvent(nodeInst, event.NodeExecuteDrive)
+}
+
+// getAllNodeInsts 获取流程实例所有的节点实例
+func (r *DefaultWorkflowRecoverer) getAllNodeInsts(inst *entity.WorkflowInst) ([]*entity.NodeInst, error) {
+	pageQueryNodeInsts := &dto.PageQueryNodeInstDTO{
+		DefID:     inst.WorkflowDef.DefID,
+		InstID:    inst.InstID,
+		PageQuery: constants.NewPageQuery(defaultInitPageIndex, defaultPageSize),
+	}
+
+	var all []*entity.NodeInst
+	for i := defaultInitPageIndex; i <= maxQueryTimes; i++ {
+		pageQueryNodeInsts.PageIndex = i
+		nodeInsts, err := r.nodeInstRepo.PageQuery(pageQueryNodeInsts)
+		if err != nil {
+			return nil, err
+		}
+		if len(nodeInsts) == 0 {
+			break
+		}
+		all = append(all, nodeInsts...)
+	}
+	return all, nil
+}
@@ -0,0 +1,155 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/event"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/nodeexecutor"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeNodeInstRepo 分页返回固定的节点实例, 记录更新的节点实例
+type fakeNodeInstRepo struct {
+	ports.NodeInstRepository
+	nodeInsts []*entity.NodeInst
+	updated   []*dto.UpdateNodeInstDTO
+}
+
+func (r *fakeNodeInstRepo) UpdateWithDefID(req *dto.UpdateNodeInstDTO) error {
+	r.updated = append(r.updated, req)
+	return nil
+}
+
+func (r *fakeNodeInstRepo) PageQuery(req *dto.PageQueryNodeInstDTO) ([]*entity.NodeInst, error) {
+	if req.PageIndex > 1 {
+		return nil, nil
+	}
+	return r.nodeInsts, nil
+}
+
+// fakeRecoverUpdater 记录恢复时发送的驱动事件
+type fakeRecoverUpdater struct {
+	WorkflowUpdater
+	events []string
+}
+
+func (u *fakeRecoverUpdater) SendWorkflowStartDriveEvent(namespace, defID, instID string, fromResume bool) error {
+	u.events = append(u.events, "start")
+	return nil
+}
+
+func (u *fakeRecoverUpdater) SendNodeScheduleDriveEvent(inst *entity.WorkflowInst, nodeInstIDs []string) error {
+	u.events = append(u.events, fmt.Sprintf("schedule:%v", nodeInstIDs))
+	return nil
+}
+
+func (u *fakeRecoverUpdater) SendNodeCompleteDriveEvent(nodeInst *entity.NodeInst, fromResume bool) error {
+	u.events = append(u.events, "complete:"+nodeInst.NodeInstID)
+	return nil
+}
+
+func (u *fakeRecoverUpdater) SendWaitNodeExecuteDriveEvent(nodeInst *entity.NodeInst) error {
+	u.events = append(u.events, "wait:"+nodeInst.NodeInstID)
+	return nil
+}
+
+func (u *fakeRecoverUpdater) SendNodeDriveEvent(nodeInst *entity.NodeInst, eventType event.DriveEventType) error {
+	u.events = append(u.events, fmt.Sprintf("%s:%s", eventType, nodeInst.NodeInstID))
+	return nil
+}
+
+func (u *fakeRecoverUpdater) UpdateNodeInstWithStatus(nodeInst *entity.NodeInst) error {
+	u.events = append(u.events, fmt.Sprintf("update:%s:%v", nodeInst.NodeInstID, nodeInst.Retrying))
+	return nil
+}
+
+// fakeAsyncExecutor 可以指定异步方式的节点执行器
+type fakeAsyncExecutor struct {
+	nodeexecutor.NodeExecutor
+	nodeType  entity.NodeType
+	polling   bool
+	completed bool
+}
+
+func (e *fakeAsyncExecutor) Type() entity.NodeType                { return e.nodeType }
+func (e *fakeAsyncExecutor) AsyncByPolling(*entity.NodeInst) bool { return e.polling }
+func (e *fakeAsyncExecutor) AsyncComplete(*entity.NodeInst) bool  { return e.completed }
+func (e *fakeAsyncExecutor) AsyncByTrigger(*entity.NodeInst) bool { return false }
+
+// TestDefaultWorkflowRecoverer_Recover 测试根据节点实例状态重新发送驱动事件
+func TestDefaultWorkflowRecoverer_Recover(t *testing.T) {
+	now := time.Now()
+	newNodeInst := func(id string, nodeType entity.NodeType, status entity.NodeInstStatus) *entity.NodeInst {
+		return &entity.NodeInst{
+			NodeInstID:   id,
+			BasicNodeDef: entity.BasicNodeDef{Type: nodeType},
+			Status:       status,
+		}
+	}
+	tests := []struct {
+		name       string
+		nodeInsts  func() []*entity.NodeInst
+		wantEvents []string
+	}{
+		{"没有节点实例时重新启动", func() []*entity.NodeInst { return nil }, []string{"start"}},
+		{"重新调度已调度的节点", func() []*entity.NodeInst {
+			return []*entity.NodeInst{
+				newNodeInst("1", entity.AssignNode, entity.NodeInstSucceed),
+				newNodeInst("2", entity.AssignNode, entity.NodeInstScheduled),
+				newNodeInst("3", entity.AssignNode, entity.NodeInstScheduled),
+			}
+		}, []string{"schedule:[2 3]"}},
+		{"等待中的节点重新计算等待时间", func() []*entity.NodeInst {
+			return []*entity.NodeInst{newNodeInst("1", entity.AssignNode, entity.NodeInstWaiting)}
+		}, []string{"wait:1"}},
+		{"轮询节点继续轮询", func() []*entity.NodeInst {
+			return []*entity.NodeInst{newNodeInst("1", entity.ServiceNode, entity.NodeInstRunning)}
+		}, []string{"NodePollDriveEvent:1"}},
+		{"等待回调的节点不处理", func() []*entity.NodeInst {
+			return []*entity.NodeInst{newNodeInst("1", entity.ApprovalNode, entity.NodeInstRunning)}
+		}, nil},
+		{"同步节点中断后重试", func() []*entity.NodeInst {
+			return []*entity.NodeInst{newNodeInst("1", entity.AssignNode, entity.NodeInstRunning)}
+		}, []string{"update:1:true", "NodeExecuteDriveEvent:1"}},
+		{"重试中的节点直接执行", func() []*entity.NodeInst {
+			nodeInst := newNodeInst("1", entity.ServiceNode, entity.NodeInstRunning)
+			nodeInst.Retrying = true
+			return []*entity.NodeInst{nodeInst}
+		}, []string{"NodeExecuteDriveEvent:1"}},
+		{"节点都已结束时重新发送最后完成的节点", func() []*entity.NodeInst {
+			first := newNodeInst("1", entity.AssignNode, entity.NodeInstSucceed)
+			first.CompletedAt = now.Add(-time.Minute)
+			last := newNodeInst("2", entity.AssignNode, entity.NodeInstFailed)
+			last.CompletedAt = now
+			return []*entity.NodeInst{last, first}
+		}, []string{"complete:2"}},
+		{"忽略补偿节点实例", func() []*entity.NodeInst {
+			compensation := newNodeInst("2", entity.AssignNode, entity.NodeInstRunning)
+			compensation.CompensateFor = "1"
+			return []*entity.NodeInst{newNodeInst("1", entity.AssignNode, entity.NodeInstScheduled), compensation}
+		}, []string{"schedule:[1]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := nodeexecutor.NewDefaultRegistry()
+			registry.Register(&fakeAsyncExecutor{nodeType: entity.AssignNode})
+			registry.Register(&fakeAsyncExecutor{nodeType: entity.ServiceNode, polling: true})
+			registry.Register(&fakeAsyncExecutor{nodeType: entity.ApprovalNode, completed: true})
+			updater := &fakeRecoverUpdater{}
+			recoverer := &DefaultWorkflowRecoverer{
+				nodeInstRepo:         &fakeNodeInstRepo{nodeInsts: tt.nodeInsts()},
+				nodeExecutorRegistry: registry,
+				workflowUpdater:      updater,
+			}
+			inst := &entity.WorkflowInst{InstID: "100", WorkflowDef: &entity.WorkflowDef{DefID: "10"}}
+			assert.Nil(t, recoverer.Recover(context.Background(), inst))
+			assert.Equal(t, tt.wantEvents, updater.events)
+		})
+	}
+}
@@ -27,6 +27,8 @@ type WorkflowRunner interface {
 	Resume(ctx context.Context, req *dto.ResumeWorkflowInstDTO) error            // 恢复流程
 	Complete(ctx context.Context, req *dto.CompleteWorkflowInstDTO) error        // 标记流程结束
 	Cancel(ctx context.Context, req *dto.CancelWorkflowInstDTO) error            // 取消流程
+	Compensate(ctx context.Context, req *dto.CompensateWorkflowInstDTO) error    // 补偿流程
+	Recover(ctx context.Context, req *dto.RecoverWorkflowInstDTO) error          // 恢复中断的流程
 	SetTimeout(ctx context.Context, req *dto.SetWorkflowInstTimeoutDTO) error    // 标记流程超时
 	UpdateCtx(ctx context.Context, req *dto.UpdateWorkflowInstCtxDTO) error      // 更新流程上下文
 	Debug(ctx context.Context, req *dto.DebugWorkflowInstDTO) error              // 更新调试信息
@@ -42,6 +44,8 @@ type DefaultWorkflowRunner struct {
 	msgSender        common.MsgSender
 	workflowExecutor WorkflowExecutor
 	workflowUpdater  WorkflowUpdater
+	compensator      WorkflowCompensator
+	recoverer        WorkflowRecoverer
 }
 
 // NewDefaultWorkflowRunner 初始化
@@ -58,11 +62,16 @@ func NewDefaultWorkflowRunner(repoProviderSet *ports.RepoProviderSet,
 		msgSender:        workflowProviderSet.MsgSender(),
 		workflowExecutor: workflowExecutor,
 		workflowUpdater:  workflowUpdater,
+		compensator:      NewDefaultWorkflowCompensator(repoProviderSet, workflowProviderSet, workflowUpdater),
+		recoverer:        NewDefaultWorkflowRecoverer(repoProviderSet, workflowProviderSet, workflowUpdater),
 	}
-	instExprEvaluator := common.NewInstExprEvaluator(repoProviderSet.WorkflowInstRepo(),
+	instExprEvaluator := common.NewInstExprEvaluator(repoProviderSet.WorkflowInstRepo(), repoProviderSet.SecretRepo(),
 		workflowProviderSet.ExprEvaluator())
-	nodeRunner.nodeExecutorRegistry.Register(NewSubWorkflowExecutor(
-		r, r.workflowDefRepo, repoProviderSet, instExprEvaluator))
+	subworkflowExecutor := NewSubWorkflowExecutor(r, r.workflowDefRepo, repoProviderSet, instExprEvaluator,
+		workflowProviderSet.ExprEvaluator())
+	nodeRunner.nodeExecutorRegistry.Register(subworkflowExecutor)
+	nodeRunner.nodeExecutorRegistry.Register(NewForeachNodeExecutor(
+		r, repoProviderSet, workflowProviderSet, subworkflowExecutor))
 	r.nodeRunner = nodeRunner
 	return r
 }
@@ -203,6 +212,8 @@ func (e *DefaultWorkflowRunner) rescheduleInst(ctx context.Context,
 	// 如果流程被重启，之前记录的暂停相关信息需要被清空
 	inst.RunCompletedNodeInstIDsAfterPaused = []string{}
 	inst.WaitCompletedNodeInstIDsAfterPaused = []string{}
+	// 重启后循环节点重新开始计数
+	inst.LoopStates = nil
 	if len(req.Input) > 0 {
 		inst.Input = req.Input
 	}
@@ -379,8 +390,11 @@ func (e *DefaultWorkflowRunner) doComplete(ctx context.Context, req *dto.Complet
 		return fmt.Errorf(instTerminalErrFormat, logs.GetFlowTraceID(inst.WorkflowDef.DefID, inst.InstID))
 	}
 
-	// 3. 更新流程的状态
+	// 3. 更新流程的状态, 标记成功时和自动完成一样校验输出
 	inst.Status = req.Status
+	if req.Status == entity.InstSucceed {
+		checkInstOutput(inst)
+	}
 	return e.workflowUpdater.UpdateWorkflowInstWithStatus(inst)
 }
 
@@ -423,6 +437,61 @@ func (e *DefaultWorkflowRunner) doCancel(ctx context.Context, req *dto.CancelWor
 	return e.workflowUpdater.UpdateWorkflowInstWithStatus(inst)
 }
 
+// Compensate 补偿流程, 流程还在执行中时会先取消流程
+func (e *DefaultWorkflowRunner) Compensate(ctx context.Context, req *dto.CompensateWorkflowInstDTO) error {
+	lock, err := GetInstDistributeLock(e.cacheRepo, req.InstID)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	return e.doCompensate(ctx, req)
+}
+
+func (e *DefaultWorkflowRunner) doCompensate(ctx context.Context, req *dto.CompensateWorkflowInstDTO) error {
+	inst, err := e.workflowInstRepo.Get(dto.NewGetWorkflowInstDTO(req.InstID, req.DefID, ""))
+	if err != nil {
+		return err
+	}
+
+	if !inst.Status.IsTerminal() {
+		cancelReq := &dto.CancelWorkflowInstDTO{
+			DefID:    req.DefID,
+			InstID:   req.InstID,
+			Operator: req.Operator,
+			Reason:   fmt.Sprintf("cancelled before compensate, reason: %s", req.Reason),
+		}
+		if err := e.doCancel(ctx, cancelReq); err != nil {
+			return err
+		}
+		if inst, err = e.workflowInstRepo.Get(dto.NewGetWorkflowInstDTO(req.InstID, req.DefID, "")); err != nil {
+			return err
+		}
+	}
+
+	return e.compensator.Compensate(ctx, inst, req)
+}
+
+// Recover 恢复因为进程退出而中断的流程, 重新投递丢失的驱动事件
+func (e *DefaultWorkflowRunner) Recover(ctx context.Context, req *dto.RecoverWorkflowInstDTO) error {
+	lock, err := GetInstDistributeLock(e.cacheRepo, req.InstID)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	inst, err := e.workflowInstRepo.Get(dto.NewGetWorkflowInstDTO(req.InstID, req.DefID, ""))
+	if err != nil {
+		return err
+	}
+
+	// 只有执行中的流程需要恢复, 暂停的流程通过 Resume 恢复
+	if inst.Status != entity.InstRunning {
+		return nil
+	}
+	return e.recoverer.Recover(ctx, inst)
+}
+
 func (e *DefaultWorkflowRunner) cancelAllRunningNodeInsts(ctx context.Context,
 	inst *entity.WorkflowInst, operator, reason string) error {
 	for _, nodeInst := range inst.SchedNodeInsts {
@@ -2,6 +2,8 @@ package execution
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -11,12 +13,14 @@ import (
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/event"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/common"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/execution/nodeexecutor"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/command/trigger"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/constants"
 	"github.com/fflow-tech/fflow/service/pkg/expr"
 	"github.com/fflow-tech/fflow/service/pkg/log"
 	"github.com/fflow-tech/fflow/service/pkg/logs"
-	"github.com/fflow-tech/fflow/service/pkg/utils"
+	"github.com/fflow-tech/fflow/service/pkg/remote"
 )
 
 // WorkflowUpdater 流程更新者
@@ -25,9 +29,11 @@ type WorkflowUpdater interface {
 	SendWorkflowInstExternalEvent(inst *entity.WorkflowInst, eventType event.ExternalEventType) error
 	SendNodeExternalEvent(nodeInst *entity.NodeInst, eventType event.ExternalEventType) error
 	SendWorkflowStartDriveEvent(namespace string, defID string, instID string, fromResumeInst bool) error
+	SendWorkflowCompensateDriveEvent(inst *entity.WorkflowInst) error
 	SendNodeScheduleDriveEvent(inst *entity.WorkflowInst, nodesToBeScheduled []string) error
 	SendNodeCompleteDriveEvent(nodeInst *entity.NodeInst, fromResumeInst bool) error
 	SendDelayNodeExecuteDriveEvent(nodeInst *entity.NodeInst, deliverAfter time.Duration) error
+	SendWaitNodeExecuteDriveEvent(nodeInst *entity.NodeInst) error
 	SendPresetNodeExecuteDriveEvent(nodeInst *entity.NodeInst, deliverAt time.Time) error
 	SendNodeDriveEvent(nodeInst *entity.NodeInst, eventType event.DriveEventType) error
 	SendDelayNodeDriveEvent(nodeInst *entity.NodeInst, deliverAfter time.Duration, eventType event.DriveEventType) error
@@ -42,11 +48,14 @@ type WorkflowUpdater interface {
 
 // DefaultWorkflowUpdater 流程更新
 type DefaultWorkflowUpdater struct {
-	workflowInstRepo ports.WorkflowInstRepository
-	triggerRegistry  trigger.Registry
-	nodeInstRepo     ports.NodeInstRepository
-	eventBusRepo     ports.EventBusRepository
-	exprEvaluator    expr.Evaluator
+	workflowInstRepo     ports.WorkflowInstRepository
+	triggerRegistry      trigger.Registry
+	nodeInstRepo         ports.NodeInstRepository
+	eventBusRepo         ports.EventBusRepository
+	cacheRepo            ports.CacheRepository
+	remoteRepo           ports.RemoteRepository
+	exprEvaluator        expr.Evaluator
+	nodeExecutorRegistry nodeexecutor.Registry
 }
 
 // NewDefaultWorkflowUpdater 新建更新者
@@ -54,11 +63,14 @@ func NewDefaultWorkflowUpdater(repoProviderSet *ports.RepoProviderSet,
 	workflowProviderSet *WorkflowProviderSet,
 	triggerRegistry *trigger.DefaultRegistry) *DefaultWorkflowUpdater {
 	return &DefaultWorkflowUpdater{
-		workflowInstRepo: repoProviderSet.WorkflowInstRepo(),
-		nodeInstRepo:     repoProviderSet.NodeInstRepo(),
-		eventBusRepo:     repoProviderSet.EventBusRepo(),
-		exprEvaluator:    workflowProviderSet.ExprEvaluator(),
-		triggerRegistry:  triggerRegistry,
+		workflowInstRepo:     repoProviderSet.WorkflowInstRepo(),
+		nodeInstRepo:         repoProviderSet.NodeInstRepo(),
+		eventBusRepo:         repoProviderSet.EventBusRepo(),
+		cacheRepo:            repoProviderSet.CacheRepo(),
+		remoteRepo:           repoProviderSet.RemoteRepo(),
+		exprEvaluator:        workflowProviderSet.ExprEvaluator(),
+		nodeExecutorRegistry: workflowProviderSet.NodeExecutorRegistry(),
+		triggerRegistry:      triggerRegistry,
 	}
 }
 
@@ -73,6 +85,17 @@ func (w *DefaultWorkflowUpdater) SendWorkflowStartDriveEvent(namespace, defID, i
 	return w.eventBusRepo.SendDriveEvent(context.Background(), driveEvent)
 }
 
+// SendWorkflowCompensateDriveEvent 发送流程补偿驱动事件
+func (w *DefaultWorkflowUpdater) SendWorkflowCompensateDriveEvent(inst *entity.WorkflowInst) error {
+	driveEvent := event.WorkflowCompensateDriveEvent{
+		BasicEvent: event.NewDriveBasicEvent(event.WorkflowCompensateDrive, inst.WorkflowDef.Namespace,
+			inst.WorkflowDef.DefID, inst.InstID),
+		DefID:  inst.WorkflowDef.DefID,
+		InstID: inst.InstID,
+	}
+	return w.eventBusRepo.SendDriveEvent(context.Background(), driveEvent)
+}
+
 // SendWorkflowDefExternalEvent 发送外部事件 现在定义的结构相同 为了后续的处理还是分开处理
 func (w *DefaultWorkflowUpdater) SendWorkflowDefExternalEvent(namespace string,
 	defID string, eventType event.ExternalEventType) error {
@@ -234,7 +257,7 @@ func (w *DefaultWorkflowUpdater) UpdateWorkflowInstWithStatus(inst *entity.Workf
 	case entity.InstSucceed:
 		return w.updateWorkflowInstWithExternalEventType(inst, event.WorkflowSuccess)
 	case entity.InstFailed:
-		return w.updateWorkflowInstWithExternalEventType(inst, event.WorkflowFail)
+		return w.updateFailedWorkflowInst(inst)
 	case entity.InstRunning:
 		return w.UpdateWorkflowInst(inst)
 	case entity.InstPaused:
@@ -248,6 +271,18 @@ func (w *DefaultWorkflowUpdater) UpdateWorkflowInstWithStatus(inst *entity.Workf
 	}
 }
 
+// updateFailedWorkflowInst 更新失败的流程实例, 流程配置了自动补偿时发送补偿驱动事件
+// 还有并行分支在执行时先不补偿, 等分支结束驱动流程时再发送补偿驱动事件
+func (w *DefaultWorkflowUpdater) updateFailedWorkflowInst(inst *entity.WorkflowInst) error {
+	if err := w.updateWorkflowInstWithExternalEventType(inst, event.WorkflowFail); err != nil {
+		return err
+	}
+	if inst.PreStatus == inst.Status || !needAutoCompensate(inst) {
+		return nil
+	}
+	return w.SendWorkflowCompensateDriveEvent(inst)
+}
+
 // CreateWorkflowInst 创建流程实例
 func (w *DefaultWorkflowUpdater) CreateWorkflowInst(req *dto.StartWorkflowInstDTO,
 	workflowDef *entity.WorkflowDef) (string, error) {
@@ -344,11 +379,16 @@ func (w *DefaultWorkflowUpdater) updateWorkflowInstWithExternalEventType(inst *e
 
 // updateNodeInstForSubWorkflow 根据流程状态更新对应的父流程中节点状态
 func (w *DefaultWorkflowUpdater) updateNodeInstForSubWorkflow(inst *entity.WorkflowInst) error {
-	// 获取更新后的节点实例和对应的事件类型
-	nodeInst, nodeEventType, err := w.getNodeInstAndEventTypeForUpdate(inst)
+	parentNodeInst, err := w.getParentNodeInst(inst)
 	if err != nil {
 		return err
 	}
+	// 节点执行器需要自行处理子流程状态变化时, 交给执行器决定节点的状态
+	if executor, ok := w.getSubworkflowAwareExecutor(parentNodeInst); ok {
+		return w.updateNodeInstBySubworkflowAwareExecutor(executor, inst)
+	}
+	// 获取更新后的节点实例和对应的事件类型
+	nodeInst, nodeEventType := w.getNodeInstAndEventTypeForUpdate(parentNodeInst, inst)
 	// 节点实例为空时直接返回不需要发送事件
 	if nodeInst == nil {
 		return nil
@@ -357,25 +397,70 @@ func (w *DefaultWorkflowUpdater) updateNodeInstForSubWorkflow(inst *entity.Workf
 	if err := w.updateNodeInstWithExternalEventType(nodeInst, nodeEventType); err != nil {
 		return err
 	}
-	// 节点状态为成功或失败时，发送节点完成驱动事件
+	// 节点状态为成功或失败, 或者超时、取消时配置了匹配的错误路由, 发送节点完成驱动事件
 	if inst.Status == entity.InstSucceed || inst.Status == entity.InstFailed {
 		return w.SendNodeDriveEvent(nodeInst, event.NodeCompleteDrive)
 	}
+	if _, ok := entity.MatchErrorRoute(nodeInst); ok {
+		return w.SendNodeDriveEvent(nodeInst, event.NodeCompleteDrive)
+	}
 	return nil
 }
 
-// getNodeInstAndEventTypeForUpdate 获取节点实例和事件类型
-func (w *DefaultWorkflowUpdater) getNodeInstAndEventTypeForUpdate(inst *entity.WorkflowInst) (*entity.NodeInst,
-	event.ExternalEventType, error) {
-	// 获取子流程对应的父流程中的节点实例
-	nodeInst, err := w.nodeInstRepo.Get(&dto.GetNodeInstDTO{
+// updateNodeInstBySubworkflowAwareExecutor 由节点执行器根据子流程状态更新父流程中的节点
+// 同一个节点可能对应多个子流程, 需要加父流程实例的锁避免并发更新节点实例
+func (w *DefaultWorkflowUpdater) updateNodeInstBySubworkflowAwareExecutor(
+	executor nodeexecutor.SubworkflowAwareExecutor, inst *entity.WorkflowInst) error {
+	lock, err := GetInstDistributeLock(w.cacheRepo, inst.ParentInstID)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	// 加锁之后重新获取节点实例
+	nodeInst, err := w.getParentNodeInst(inst)
+	if err != nil {
+		return err
+	}
+	updated, err := executor.OnSubworkflowUpdated(context.Background(), nodeInst, inst)
+	if err != nil || !updated {
+		return err
+	}
+	if !nodeInst.Status.IsTerminal() {
+		return w.updateNodeInst(nodeInst)
+	}
+	if err := w.UpdateNodeInstWithStatus(nodeInst); err != nil {
+		return err
+	}
+	if !nodeInst.Status.IsCompleted() {
+		return nil
+	}
+	return w.SendNodeDriveEvent(nodeInst, event.NodeCompleteDrive)
+}
+
+// getSubworkflowAwareExecutor 获取需要自行处理子流程状态变化的节点执行器
+func (w *DefaultWorkflowUpdater) getSubworkflowAwareExecutor(nodeInst *entity.NodeInst) (
+	nodeexecutor.SubworkflowAwareExecutor, bool) {
+	executor, exists := w.nodeExecutorRegistry.GetExecutor(nodeInst.BasicNodeDef.Type)
+	if !exists {
+		return nil, false
+	}
+	awareExecutor, ok := executor.(nodeexecutor.SubworkflowAwareExecutor)
+	return awareExecutor, ok && awareExecutor.AwareOfSubworkflow(nodeInst)
+}
+
+// getParentNodeInst 获取子流程对应的父流程中的节点实例
+func (w *DefaultWorkflowUpdater) getParentNodeInst(inst *entity.WorkflowInst) (*entity.NodeInst, error) {
+	return w.nodeInstRepo.Get(&dto.GetNodeInstDTO{
 		DefID:      inst.WorkflowDef.ParentDefID,
 		InstID:     inst.ParentInstID,
 		NodeInstID: inst.ParentNodeInstID,
 	})
-	if err != nil {
-		return nil, "", err
-	}
+}
+
+// getNodeInstAndEventTypeForUpdate 获取节点实例和事件类型
+func (w *DefaultWorkflowUpdater) getNodeInstAndEventTypeForUpdate(nodeInst *entity.NodeInst,
+	inst *entity.WorkflowInst) (*entity.NodeInst, event.ExternalEventType) {
 	// 将流程状态和节点状态做映射
 	var nodeEventType event.ExternalEventType
 	// 子流程的实例 ID 和定义 ID
@@ -408,10 +493,10 @@ func (w *DefaultWorkflowUpdater) getNodeInstAndEventTypeForUpdate(inst *entity.W
 	default:
 		// 对于其他的流程状态，不需要更新节点，直接忽略
 		log.Infof("ignored inst status to node inst status:%s", inst.Status)
-		return nil, "", nil
+		return nil, ""
 	}
 
-	return nodeInst, nodeEventType, nil
+	return nodeInst, nodeEventType
 }
 
 // UpdateWorkflowInst 更新流程实例
@@ -515,6 +600,11 @@ func (w *DefaultWorkflowUpdater) updateNodeInstForWaitStatus(nodeInst *entity.No
 		return nil
 	}
 
+	return w.SendWaitNodeExecuteDriveEvent(nodeInst)
+}
+
+// SendWaitNodeExecuteDriveEvent 按照等待的配置发送节点执行事件, 等待时间从进入等待状态开始计算
+func (w *DefaultWorkflowUpdater) SendWaitNodeExecuteDriveEvent(nodeInst *entity.NodeInst) error {
 	// 如果是因为调试设置的等待, 必须等待用户使用 Resume 操作恢复
 	if nodeInst.WaitForDebug {
 		return nil
@@ -525,20 +615,89 @@ func (w *DefaultWorkflowUpdater) updateNodeInstForWaitStatus(nodeInst *entity.No
 		return nil
 	}
 
+	waitAt := nodeInst.WaitAt
+	if waitAt.IsZero() {
+		waitAt = time.Now()
+	}
+
 	// 优先使用 duration 的配置
 	if nodeInst.BasicNodeDef.Wait.Duration != "" {
 		deliveryAfter, err := expr.ParseDuration(nodeInst.BasicNodeDef.Wait.Duration)
 		if err != nil {
 			return err
 		}
-		return w.SendDelayNodeExecuteDriveEvent(nodeInst, deliveryAfter)
+		return w.sendDurableNodeExecuteDriveEvent(nodeInst, waitAt.Add(deliveryAfter))
+	}
+
+	until, err := w.evaluateWaitUntil(nodeInst)
+	if err != nil {
+		return err
+	}
+	allowDaysChecker, err := common.NewDefaultAllowDaysChecker()
+	if err != nil {
+		return err
+	}
+	deliveryAt, err := getWaitDeliverAt(nodeInst.BasicNodeDef.Wait, until, waitAt, allowDaysChecker)
+	if err != nil {
+		return err
+	}
+	return w.sendDurableNodeExecuteDriveEvent(nodeInst, deliveryAt)
+}
+
+// evaluateWaitUntil 计算 until 中的表达式
+func (w *DefaultWorkflowUpdater) evaluateWaitUntil(nodeInst *entity.NodeInst) (string, error) {
+	until := nodeInst.BasicNodeDef.Wait.Until
+	if !w.exprEvaluator.IsExpression(until) {
+		return until, nil
+	}
+
+	inst, err := w.workflowInstRepo.Get(dto.NewGetWorkflowInstDTO(nodeInst.InstID, nodeInst.DefID, ""))
+	if err != nil {
+		return "", err
+	}
+	ctx, err := entity.ConvertToCtx(inst)
+	if err != nil {
+		return "", err
+	}
+	if err := entity.AppendNodeInfoToCtxKey(ctx, nodeInst, constants.ThisNode); err != nil {
+		return "", err
+	}
+	r, err := w.exprEvaluator.Evaluate(ctx, until)
+	if err != nil {
+		return "", err
 	}
+	return fmt.Sprintf("%v", r), nil
+}
 
-	deliveryAt, err := utils.GetNextTimeByExpr(nodeInst.BasicNodeDef.Wait.Expr, time.Now())
+// sendDurableNodeExecuteDriveEvent 通过定时器服务恢复等待的节点, 引擎重启后不会丢失
+// 没有配置定时器服务或者注册失败时, 降级为发送定时执行驱动事件
+func (w *DefaultWorkflowUpdater) sendDurableNodeExecuteDriveEvent(nodeInst *entity.NodeInst,
+	deliverAt time.Time) error {
+	params, err := json.Marshal(&dto.ResumeNodeDTO{
+		Namespace:  nodeInst.Namespace,
+		DefID:      nodeInst.DefID,
+		InstID:     nodeInst.InstID,
+		NodeInstID: nodeInst.NodeInstID,
+		Operator:   waitTimerOperator,
+		Reason:     fmt.Sprintf("wait until %s", deliverAt.Format(time.RFC3339)),
+	})
 	if err != nil {
 		return err
 	}
-	return w.SendPresetNodeExecuteDriveEvent(nodeInst, deliveryAt)
+
+	err = w.remoteRepo.AddDelayJob(context.Background(), &remote.AddDelayJobReqDTO{
+		Name:      fmt.Sprintf("%s:%s", waitTimerNamePrefix, nodeInst.NodeInstID),
+		Params:    string(params),
+		DeliverAt: deliverAt,
+	})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, remote.ErrTimerNotEnabled) {
+		log.Warnf("[%s]Failed to add delay job for node inst [%s], use preset drive event instead, caused by %s",
+			logs.GetFlowTraceID(nodeInst.DefID, nodeInst.InstID), nodeInst.NodeInstID, err)
+	}
+	return w.SendPresetNodeExecuteDriveEvent(nodeInst, deliverAt)
 }
 
 // UpdateWorkflowInstFailed 更新流程实例为失败
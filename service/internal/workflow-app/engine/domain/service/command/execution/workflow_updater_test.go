@@ -0,0 +1,94 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/pkg/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTimerRemoteRepo 记录注册到定时器服务的延时任务
+type fakeTimerRemoteRepo struct {
+	ports.RemoteRepository
+	jobs []*remote.AddDelayJobReqDTO
+	err  error
+}
+
+func (r *fakeTimerRemoteRepo) AddDelayJob(ctx context.Context, req *remote.AddDelayJobReqDTO) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.jobs = append(r.jobs, req)
+	return nil
+}
+
+// fakePresetEventBusRepo 记录定时驱动事件的投递时间
+type fakePresetEventBusRepo struct {
+	ports.EventBusRepository
+	deliverAts []time.Time
+}
+
+func (r *fakePresetEventBusRepo) SendPresetDriveEvent(ctx context.Context, deliverAt time.Time, msg interface{}) error {
+	r.deliverAts = append(r.deliverAts, deliverAt)
+	return nil
+}
+
+// TestDefaultWorkflowUpdater_SendWaitNodeExecuteDriveEvent 测试等待节点通过定时器服务注册恢复任务
+func TestDefaultWorkflowUpdater_SendWaitNodeExecuteDriveEvent(t *testing.T) {
+	waitAt := time.Date(2030, 1, 1, 8, 0, 0, 0, time.UTC)
+	newNodeInst := func(duration string) *entity.NodeInst {
+		return &entity.NodeInst{
+			Namespace:    "default",
+			DefID:        "1",
+			InstID:       "2",
+			NodeInstID:   "3",
+			WaitAt:       waitAt,
+			BasicNodeDef: entity.BasicNodeDef{RefName: "wait", Wait: entity.Wait{Duration: duration}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		nodeInst      *entity.NodeInst
+		timerErr      error
+		wantJobs      int
+		wantPresetAts []time.Time
+	}{
+		{"duration 等待注册到定时器服务", newNodeInst("10m"), nil, 1, nil},
+		{"没有配置定时器服务时降级为定时驱动事件", newNodeInst("10m"), remote.ErrTimerNotEnabled, 0,
+			[]time.Time{waitAt.Add(10 * time.Minute)}},
+		{"注册失败时降级为定时驱动事件", newNodeInst("10m"), fmt.Errorf("timer unavailable"), 0,
+			[]time.Time{waitAt.Add(10 * time.Minute)}},
+		{"max 需要手动恢复", newNodeInst("max"), nil, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remoteRepo := &fakeTimerRemoteRepo{err: tt.timerErr}
+			eventBusRepo := &fakePresetEventBusRepo{}
+			updater := &DefaultWorkflowUpdater{remoteRepo: remoteRepo, eventBusRepo: eventBusRepo}
+
+			assert.NoError(t, updater.SendWaitNodeExecuteDriveEvent(tt.nodeInst))
+			assert.Equal(t, tt.wantPresetAts, eventBusRepo.deliverAts)
+			if !assert.Len(t, remoteRepo.jobs, tt.wantJobs) || tt.wantJobs == 0 {
+				return
+			}
+
+			// 定时器到期后回调的请求体可以直接用于恢复节点
+			job := remoteRepo.jobs[0]
+			assert.Equal(t, "nodeWait:3", job.Name)
+			assert.Equal(t, waitAt.Add(10*time.Minute), job.DeliverAt)
+			resumeReq := &dto.ResumeNodeDTO{}
+			assert.NoError(t, json.Unmarshal([]byte(job.Params), resumeReq))
+			assert.Equal(t, "2", resumeReq.InstID)
+			assert.Equal(t, "3", resumeReq.NodeInstID)
+			assert.Equal(t, waitTimerOperator, resumeReq.Operator)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
@@ -16,6 +17,7 @@ import (
 	"github.com/fflow-tech/fflow/service/pkg/log"
 	"github.com/fflow-tech/fflow/service/pkg/logs"
 	"github.com/fflow-tech/fflow/service/pkg/utils"
+	"github.com/xeipuuv/gojsonschema"
 )
 
 // NodeInstCommandService 写服务
@@ -39,6 +41,7 @@ func NewNodeInstCommandService(repoProviderSet *ports.RepoProviderSet,
 		workflowInstRepo: repoProviderSet.WorkflowInstRepo(),
 		nodeInstRepo:     repoProviderSet.NodeInstRepo(),
 		cacheRepo:        repoProviderSet.CacheRepo(),
+		remoteRepo:       repoProviderSet.RemoteRepo(),
 		exprEvaluator:    workflowProviderSet.ExprEvaluator(),
 		nodeRunner:       nodeRunner,
 	}
@@ -225,6 +228,151 @@ func (m *NodeInstCommandService) CompleteNode(ctx context.Context, req *dto.Comp
 	return m.nodeRunner.Complete(ctx, req)
 }
 
+// ApproveNode 审批通过节点, 提交的表单会按照节点的表单 schema 校验后作为节点的输出
+// 状态和审批人的校验需要在实例锁内完成, 避免和其它审批操作并发
+func (m *NodeInstCommandService) ApproveNode(ctx context.Context, req *dto.ApproveNodeDTO) error {
+	lock, err := execution.GetInstDistributeLock(m.cacheRepo, req.InstID)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	nodeInst, err := m.getApprovalNodeInst(ctx, req.InstID, req.NodeInstID, req.NodeRefName, req.Operator)
+	if err != nil {
+		return err
+	}
+	if err := validateApprovalForm(nodeInst, req.Form); err != nil {
+		return err
+	}
+
+	return m.nodeRunner.CompleteWithinLock(ctx, &dto.CompleteNodeDTO{
+		Namespace:   req.Namespace,
+		DefID:       req.DefID,
+		InstID:      req.InstID,
+		NodeInstID:  nodeInst.NodeInstID,
+		NodeRefName: req.NodeRefName,
+		Status:      entity.NodeInstSucceed,
+		Output:      req.Form,
+		Operator:    req.Operator,
+		Reason:      req.Reason,
+	})
+}
+
+// RejectNode 审批拒绝节点, 节点会被标记为失败, 可以通过 onError 配置拒绝后的处理节点
+func (m *NodeInstCommandService) RejectNode(ctx context.Context, req *dto.RejectNodeDTO) error {
+	lock, err := execution.GetInstDistributeLock(m.cacheRepo, req.InstID)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	nodeInst, err := m.getApprovalNodeInst(ctx, req.InstID, req.NodeInstID, req.NodeRefName, req.Operator)
+	if err != nil {
+		return err
+	}
+
+	return m.nodeRunner.CompleteWithinLock(ctx, &dto.CompleteNodeDTO{
+		Namespace:   req.Namespace,
+		DefID:       req.DefID,
+		InstID:      req.InstID,
+		NodeInstID:  nodeInst.NodeInstID,
+		NodeRefName: req.NodeRefName,
+		Status:      entity.NodeInstFailed,
+		Output:      req.Form,
+		Operator:    req.Operator,
+		Reason:      req.Reason,
+	})
+}
+
+// DelegateNode 转交审批节点
+func (m *NodeInstCommandService) DelegateNode(ctx context.Context, req *dto.DelegateNodeDTO) error {
+	if req.Assignee == "" {
+		return fmt.Errorf("the assignee to delegate must not be empty")
+	}
+
+	lock, err := execution.GetInstDistributeLock(m.cacheRepo, req.InstID)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	nodeInst, err := m.getApprovalNodeInst(ctx, req.InstID, req.NodeInstID, req.NodeRefName, req.Operator)
+	if err != nil {
+		return err
+	}
+
+	nodeInst.Approval.Delegate(req.Operator, req.Assignee, req.Reason)
+	updateDTO, err := convertor.NodeInstConvertor.ConvertEntityToUpdateDTO(nodeInst)
+	if err != nil {
+		return err
+	}
+	return m.nodeInstRepo.UpdateWithDefID(updateDTO)
+}
+
+// getApprovalNodeInst 获取运行中的审批节点实例, 并校验操作人是否为审批人, 调用方需要持有实例锁
+func (m *NodeInstCommandService) getApprovalNodeInst(ctx context.Context, instID, nodeInstID, refName,
+	operator string) (*entity.NodeInst, error) {
+	inst, err := m.workflowInstRepo.Get(dto.NewGetWorkflowInstDTO(instID, "", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	var nodeInst *entity.NodeInst
+	for _, schedNodeInst := range inst.SchedNodeInsts {
+		if schedNodeInst.BasicNodeDef.RefName == refName {
+			nodeInst = schedNodeInst
+			break
+		}
+	}
+	if nodeInst == nil || (nodeInstID != "" && nodeInst.NodeInstID != nodeInstID) {
+		return nil, fmt.Errorf("nodeInst refname [%s] in workflowInst: [%s] not found", refName, instID)
+	}
+	if nodeInst.BasicNodeDef.Type != entity.ApprovalNode || nodeInst.Approval == nil {
+		return nil, fmt.Errorf("node [%s] is not a waiting approval node", refName)
+	}
+	if nodeInst.Status != entity.NodeInstRunning {
+		return nil, fmt.Errorf("approval node [%s] is %s, can not be operated", refName, nodeInst.Status)
+	}
+
+	// 操作人不是审批人时, 通过鉴权服务查询操作人在流程命名空间下的角色
+	var roles []string
+	if !utils.StrContains(nodeInst.Approval.Assignees, operator) && len(nodeInst.Approval.Roles) > 0 {
+		if roles, err = m.remoteRepo.GetRolesForUserInDomain(ctx, inst.WorkflowDef.Namespace, operator); err != nil {
+			return nil, err
+		}
+	}
+	if !nodeInst.Approval.IsAssignee(operator, roles) {
+		return nil, fmt.Errorf("operator [%s] is not the assignee of approval node [%s]", operator, refName)
+	}
+	return nodeInst, nil
+}
+
+// validateApprovalForm 使用审批节点的表单 schema 校验提交的表单
+func validateApprovalForm(nodeInst *entity.NodeInst, form map[string]interface{}) error {
+	if len(nodeInst.Approval.Form) == 0 {
+		return nil
+	}
+	if form == nil {
+		form = map[string]interface{}{}
+	}
+
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(nodeInst.Approval.Form),
+		gojsonschema.NewGoLoader(form))
+	if err != nil {
+		return fmt.Errorf("failed to validate form of approval node [%s]: %w", nodeInst.BasicNodeDef.RefName, err)
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	errMsgs := []string{}
+	for _, resultErr := range result.Errors() {
+		errMsgs = append(errMsgs, fmt.Sprintf("[%s]", resultErr.String()))
+	}
+	return fmt.Errorf("illegal form of approval node [%s]: %s",
+		nodeInst.BasicNodeDef.RefName, strings.Join(errMsgs, ""))
+}
+
 // SetTimeout 标记节点超时
 func (m *NodeInstCommandService) SetTimeout(ctx context.Context, req *dto.SetNodeTimeoutDTO) error {
 	return m.nodeRunner.SetTimeout(ctx, req)
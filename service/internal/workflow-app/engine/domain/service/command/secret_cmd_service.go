@@ -0,0 +1,51 @@
+package command
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/pkg/log"
+)
+
+// SecretCommandService 密钥写服务
+type SecretCommandService struct {
+	secretRepo ports.SecretRepository
+}
+
+// NewSecretCommandService 新建服务
+func NewSecretCommandService(repoProviderSet *ports.RepoProviderSet) *SecretCommandService {
+	return &SecretCommandService{secretRepo: repoProviderSet.SecretRepo()}
+}
+
+// CreateSecret 创建密钥
+func (m *SecretCommandService) CreateSecret(ctx context.Context, req *dto.CreateSecretDTO) (string, error) {
+	if !entity.IsValidSecretName(req.Name) {
+		return "", fmt.Errorf("invalid secret name [%s], only letters, digits and underscores are allowed", req.Name)
+	}
+
+	id, err := m.secretRepo.Create(req)
+	if err != nil {
+		log.Errorf("Failed to create secret, namespace:[%s] name:[%s], caused by %s", req.Namespace, req.Name, err)
+		return "", err
+	}
+
+	return id, nil
+}
+
+// UpdateSecret 更新密钥
+func (m *SecretCommandService) UpdateSecret(ctx context.Context, req *dto.UpdateSecretDTO) error {
+	if _, err := m.secretRepo.Get(&dto.GetSecretDTO{Namespace: req.Namespace, Name: req.Name}); err != nil {
+		log.Errorf("Failed to get secret, namespace:[%s] name:[%s], caused by %s", req.Namespace, req.Name, err)
+		return err
+	}
+
+	return m.secretRepo.Update(req)
+}
+
+// DeleteSecret 删除密钥
+func (m *SecretCommandService) DeleteSecret(ctx context.Context, req *dto.DeleteSecretDTO) error {
+	return m.secretRepo.Delete(req)
+}
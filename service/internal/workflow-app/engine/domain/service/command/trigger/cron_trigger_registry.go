@@ -88,6 +88,11 @@ func (t *DefaultCronTriggerRegistry) Unregister(d *dto.UnregisterTriggerDTO) err
 	return t.triggerRepo.Update(updateTriggerDTO)
 }
 
+// Schedule 重新调度已经注册的定时触发器, 进程重启后等待投递的定时事件丢失时使用
+func (t *DefaultCronTriggerRegistry) Schedule(trigger *entity.Trigger) error {
+	return t.createCronTask(trigger.Expr, trigger.TriggerID, trigger.DefID, trigger.DefVersion)
+}
+
 // validateCronTriggerIntervalTime  校验定时触发器间隔时间
 func (t *DefaultCronTriggerRegistry) validateCronTriggerIntervalTime(cronExpr string) error {
 	// 定时触发器触发间隔必须在一分钟和一个月之间
@@ -2,19 +2,27 @@
 package validator
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/bitly/go-simplejson"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/expr"
+	"github.com/fflow-tech/fflow/service/pkg/mcp"
 	"github.com/fflow-tech/fflow/service/pkg/utils"
 	"github.com/xeipuuv/gojsonschema"
 )
 
 const (
-	maxSubWorkflowCount = 10 // 最大子流程数量
+	maxSubWorkflowCount    = 10               // 最大子流程数量
+	mcpToolDiscoverTimeout = 10 * time.Second // 获取 MCP 工具定义的超时时间
 )
 
 // ValidateDefJson 检查传入内容格式
@@ -53,22 +61,97 @@ func ValidateSubworkflowDefJson(subWorkflows []interface{}) error {
 	return nil
 }
 
+// defJsonValidators 通过 schema 校验后依次执行的校验
+var defJsonValidators = []func(defJson string) error{
+	ValidateDuplicateName,
+	ValidateInfiniteLoop,
+	ValidateDefJsonSize,
+	validateNodeConfig,
+	ValidateForeachNodes,
+	ValidateLoopNodes,
+	ValidateApprovalNodes,
+	ValidateScriptNodes,
+	ValidateWaitEventNodes,
+	ValidateNodeWaits,
+	ValidateSubworkflowNodes,
+	ValidateOutputDef,
+	ValidateErrorRoutes,
+	ValidateMCPNodes,
+	ValidateOpenAINodes,
+}
+
 // ValidateWorkflowDefJson 检查流程定义格式
 func ValidateWorkflowDefJson(defJson string) error {
 	if err := ValidateJsonSchema(defJson); err != nil {
 		return err
 	}
-	if err := ValidateDuplicateName(defJson); err != nil {
-		return err
+	for _, validate := range defJsonValidators {
+		if err := validate(defJson); err != nil {
+			return err
+		}
 	}
-	if err := ValidateInfiniteLoop(defJson); err != nil {
-		return err
+	return nil
+}
+
+// ValidateDefJsonErrors 执行所有的校验并返回全部的错误, 用于一次性展示定义中的所有问题
+// schema 校验失败时其他校验没有意义, 只返回 schema 的错误
+func ValidateDefJsonErrors(defJson string) []error {
+	errs := validateWorkflowDefJsonErrors(defJson)
+
+	valueJson, err := simplejson.NewJson([]byte(defJson))
+	if err != nil {
+		return append(errs, err)
 	}
-	if err := ValidateDefJsonSize(defJson); err != nil {
-		return err
+	for _, subWorkflow := range valueJson.GetPath("subworkflows").MustArray() {
+		subWorkflowMap, ok := subWorkflow.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		names := make([]string, 0, len(subWorkflowMap))
+		for name := range subWorkflowMap {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			def := subWorkflowMap[name]
+			subDefJson, err := json.Marshal(def)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("subworkflow [%s] def is not a valid json: %v", name, def))
+				continue
+			}
+			for _, err := range validateWorkflowDefJsonErrors(string(subDefJson)) {
+				errs = append(errs, fmt.Errorf("subworkflow [%s] %w", name, err))
+			}
+		}
+	}
+	return errs
+}
+
+// validateWorkflowDefJsonErrors 执行单个流程定义的所有校验, 合并的错误会拆分开
+func validateWorkflowDefJsonErrors(defJson string) []error {
+	if err := ValidateJsonSchema(defJson); err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, validate := range defJsonValidators {
+		err := validate(defJson)
+		if err == nil {
+			continue
+		}
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			errs = append(errs, err)
+			continue
+		}
+		errs = append(errs, joined.Unwrap()...)
 	}
+	return errs
+}
 
-	return validateNodeConfig(defJson)
+// joinSortedErrors 按错误信息排序后合并, 节点按 map 遍历时也能得到稳定的错误信息
+func joinSortedErrors(errs []error) error {
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
 }
 
 // ValidateJsonSchema 检查传入内容格式的schema格式
@@ -204,6 +287,145 @@ func hasInputOption(options []interface{}, inputOption interface{}) bool {
 	return false
 }
 
+// ValidateInstOutput 检测流程实例的输出是否符合流程定义中的输出定义
+func ValidateInstOutput(def *entity.WorkflowDef, output map[string]interface{}) error {
+	if len(def.Output) == 0 {
+		return nil
+	}
+
+	// 按照 json 解析后的值校验, 和调用方拿到的输出保持一致
+	outputBytes, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+	jsonOutput := map[string]interface{}{}
+	if err := json.Unmarshal(outputBytes, &jsonOutput); err != nil {
+		return err
+	}
+
+	for _, outputKeyMap := range def.Output {
+		for name, outputDef := range outputKeyMap {
+			if err := validateOutputValue(name, outputDef, jsonOutput); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateOutputValue 检查单个输出字段
+func validateOutputValue(name string, outputDef entity.OutputDef, output map[string]interface{}) error {
+	value, ok := output[name]
+	if !ok || value == nil {
+		if outputDef.Required {
+			return fmt.Errorf("output field `%s` is required", name)
+		}
+		return nil
+	}
+
+	if !outputDef.Type.Match(value) {
+		return fmt.Errorf("output field `%s` value %v must be %s", name, value, outputDef.Type)
+	}
+	if outputDef.Options != nil && !hasOutputOption(outputDef.Options, value) {
+		return fmt.Errorf("output field `%s` value %v must in options:%v", name, value, outputDef.Options)
+	}
+	return nil
+}
+
+// hasOutputOption 检测是否包含输出选项, 输出的值可能是对象或者数组, 需要深度比较
+func hasOutputOption(options []interface{}, value interface{}) bool {
+	for _, option := range options {
+		if reflect.DeepEqual(option, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateOutputDef 校验流程的输出定义, 带有 return 的节点需要符合输出定义
+// return 中的表达式只有在执行时才能计算, 在流程结束时再校验
+func ValidateOutputDef(defJson string) error {
+	workflowDefEntity := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), workflowDefEntity); err != nil {
+		return err
+	}
+	switch workflowDefEntity.OutputPolicy {
+	case "", entity.OutputFail, entity.OutputWarn:
+	default:
+		return fmt.Errorf("output policy [%s] is illegal, must be one of FAIL, WARN", workflowDefEntity.OutputPolicy)
+	}
+	if len(workflowDefEntity.Output) == 0 {
+		return nil
+	}
+
+	outputDefs := map[string]entity.OutputDef{}
+	hasRequired := false
+	for _, outputKeyMap := range workflowDefEntity.Output {
+		for name, outputDef := range outputKeyMap {
+			if !outputDef.Type.IsValid() {
+				return fmt.Errorf("output field `%s` type [%s] is illegal", name, outputDef.Type)
+			}
+			outputDefs[name] = outputDef
+			hasRequired = hasRequired || outputDef.Required
+		}
+	}
+
+	nodes, err := entity.GetNodeRefNameDefMap(workflowDefEntity)
+	if err != nil {
+		return err
+	}
+	hasReturn := false
+	var errs []error
+	for refName := range nodes {
+		nodeDef, err := entity.GetBasicNodeDefByRefName(workflowDefEntity, refName)
+		if err != nil {
+			return err
+		}
+		if len(nodeDef.Return) > 0 {
+			hasReturn = true
+			if err := validateNodeReturn(refName, nodeDef.Return, outputDefs); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if hasRequired && nodeDef.Next == entity.EndNode {
+			errs = append(errs,
+				fmt.Errorf("node [%s] ends the workflow without return, but output has required field", refName))
+		}
+	}
+	if hasRequired && !hasReturn {
+		errs = append(errs, fmt.Errorf("output has required field, but no node configures return"))
+	}
+	return joinSortedErrors(errs)
+}
+
+// validateNodeReturn 校验节点的 return 是否符合输出定义
+func validateNodeReturn(refName string, nodeReturn map[string]interface{},
+	outputDefs map[string]entity.OutputDef) error {
+	for name := range nodeReturn {
+		if _, ok := outputDefs[name]; !ok {
+			return fmt.Errorf("node [%s] returns undeclared output field `%s`", refName, name)
+		}
+	}
+
+	staticReturn, _ := splitStaticArguments(nodeReturn)
+	for name, outputDef := range outputDefs {
+		if _, ok := nodeReturn[name]; !ok {
+			if outputDef.Required {
+				return fmt.Errorf("node [%s] return misses required output field `%s`", refName, name)
+			}
+			continue
+		}
+		if _, ok := staticReturn[name]; !ok {
+			continue
+		}
+		if err := validateOutputValue(name, outputDef, staticReturn); err != nil {
+			return fmt.Errorf("node [%s] %w", refName, err)
+		}
+	}
+	return nil
+}
+
 // ValidateInfiniteLoop 死循环校验
 func ValidateInfiniteLoop(defJson string) error {
 	workflowDef := &entity.WorkflowDef{}
@@ -298,6 +520,10 @@ func getNextNodesName(curNode *entity.BasicNodeDef, workflowDef *entity.Workflow
 		{
 			return getSwitchNodeTypeNextNodes(workflowDef, schedNodes, curNode.RefName)
 		}
+	case entity.LoopNode:
+		{
+			return getLoopNodeTypeNextNodes(workflowDef, schedNodes, curNode)
+		}
 	default:
 		return getCommonTypeNextNodes(workflowDef, schedNodes, curNode)
 	}
@@ -339,6 +565,24 @@ func getSwitchNodeTypeNextNodes(workflowDef *entity.WorkflowDef, schedNodes []st
 	return nextNodes, nil
 }
 
+// getLoopNodeTypeNextNodes 获取 loop 类型的后续执行节点, 包括循环体和循环结束后的节点
+func getLoopNodeTypeNextNodes(workflowDef *entity.WorkflowDef, schedNodes []string, curNode *entity.BasicNodeDef) (
+	[]string, error) {
+	nodeDef, err := entity.GetNodeDefByRefName(workflowDef, curNode.RefName)
+	if err != nil {
+		return nil, err
+	}
+	nextNodes, err := getCommonTypeNextNodes(workflowDef, schedNodes, curNode)
+	if err != nil {
+		return nil, err
+	}
+	loopNodeDef := nodeDef.(entity.LoopNodeDef)
+	if loopNodeDef.Body != "" && !isSchedNode(loopNodeDef.Body, schedNodes) {
+		nextNodes = append([]string{loopNodeDef.Body}, nextNodes...)
+	}
+	return nextNodes, nil
+}
+
 // getCommonTypeNextNodes 获取普通类型的后续执行节点
 func getCommonTypeNextNodes(workflowDef *entity.WorkflowDef, schedNodes []string, curNode *entity.BasicNodeDef) (
 	[]string, error) {
@@ -440,3 +684,574 @@ func validateRefNodeMustConfigNextField(nodeDef *entity.BasicNodeDef) error {
 	}
 	return nil
 }
+
+// ValidateForeachNodes 校验遍历节点的配置
+func ValidateForeachNodes(defJson string) error {
+	workflowDefEntity := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), workflowDefEntity); err != nil {
+		return err
+	}
+	nodes, err := entity.GetNodeRefNameDefMap(workflowDefEntity)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for refName := range nodes {
+		nodeDef, err := entity.GetNodeDefByRefName(workflowDefEntity, refName)
+		if err != nil {
+			return err
+		}
+		foreachNodeDef, ok := nodeDef.(entity.ForeachNodeDef)
+		if !ok {
+			continue
+		}
+		if err := validateForeachNode(workflowDefEntity, foreachNodeDef); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinSortedErrors(errs)
+}
+
+// validateForeachNode 校验单个遍历节点, 必须配置遍历的数组, 并且内联节点和子流程只能配置一个
+func validateForeachNode(workflowDef *entity.WorkflowDef, nodeDef entity.ForeachNodeDef) error {
+	if nodeDef.Items == nil || nodeDef.Items == "" {
+		return fmt.Errorf("foreach node [%s] must configure items", nodeDef.RefName)
+	}
+	if nodeDef.MaxConcurrency < 0 {
+		return fmt.Errorf("foreach node [%s] maxConcurrency must >= 0", nodeDef.RefName)
+	}
+	hasNode := len(nodeDef.Node) > 0
+	if hasNode == nodeDef.RunSubworkflow() {
+		return fmt.Errorf("foreach node [%s] must configure one of node and subworkflow", nodeDef.RefName)
+	}
+	if hasNode {
+		return validateForeachInlineNode(nodeDef)
+	}
+	if nodeDef.Subworkflow != "" && !hasSubworkflow(workflowDef, nodeDef.Subworkflow) {
+		return fmt.Errorf("foreach node [%s] subworkflow [%s] not exists", nodeDef.RefName, nodeDef.Subworkflow)
+	}
+	return nil
+}
+
+// validateForeachInlineNode 校验遍历节点的内联节点, 内联节点只支持同步完成的服务节点和转换节点
+func validateForeachInlineNode(nodeDef entity.ForeachNodeDef) error {
+	inlineNodeDef, err := entity.NewNodeDef(map[string]interface{}{nodeDef.RefName: nodeDef.Node}, 0)
+	if err != nil {
+		return fmt.Errorf("illegal foreach node [%s] inline node: %w", nodeDef.RefName, err)
+	}
+	switch def := inlineNodeDef.(type) {
+	case entity.ServiceNodeDef:
+		if def.AsyncComplete || def.PollArgs != nil {
+			return fmt.Errorf("foreach node [%s] inline node must complete synchronously", nodeDef.RefName)
+		}
+		return nil
+	case entity.TransformNodeDef:
+		return nil
+	default:
+		return fmt.Errorf("foreach node [%s] inline node type must be %s or %s",
+			nodeDef.RefName, entity.ServiceNode, entity.TransformNode)
+	}
+}
+
+// hasSubworkflow 流程定义中是否包含指定的子流程
+func hasSubworkflow(workflowDef *entity.WorkflowDef, refName string) bool {
+	for _, subworkflow := range workflowDef.Subworkflows {
+		if _, ok := subworkflow[refName]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateLoopNodes 校验循环节点配置
+func ValidateLoopNodes(defJson string) error {
+	workflowDefEntity := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), workflowDefEntity); err != nil {
+		return err
+	}
+	nodes, err := entity.GetNodeRefNameDefMap(workflowDefEntity)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for refName := range nodes {
+		nodeDef, err := entity.GetNodeDefByRefName(workflowDefEntity, refName)
+		if err != nil {
+			return err
+		}
+		loopNodeDef, ok := nodeDef.(entity.LoopNodeDef)
+		if !ok {
+			continue
+		}
+		// 避免节点引用覆盖上下文中的循环信息
+		if _, ok := nodes[entity.LoopCtxKey]; ok {
+			return fmt.Errorf("workflow with loop node must not have node named [%s]", entity.LoopCtxKey)
+		}
+		if err := validateLoopNode(workflowDefEntity, nodes, loopNodeDef); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinSortedErrors(errs)
+}
+
+// validateLoopNode 校验单个循环节点, 循环体必须存在并且最终回到循环节点
+func validateLoopNode(workflowDef *entity.WorkflowDef, nodes map[string]map[string]interface{},
+	nodeDef entity.LoopNodeDef) error {
+	if nodeDef.MaxIterations < 0 {
+		return fmt.Errorf("loop node [%s] maxIterations must >= 0", nodeDef.RefName)
+	}
+	if _, ok := nodes[nodeDef.Body]; !ok || nodeDef.Body == nodeDef.RefName {
+		return fmt.Errorf("loop node [%s] body [%s] not exists", nodeDef.RefName, nodeDef.Body)
+	}
+	back, err := canReachNode(nodeDef.Body, nodeDef.RefName, workflowDef, nil)
+	if err != nil {
+		return err
+	}
+	if !back {
+		return fmt.Errorf("loop node [%s] body [%s] must go back to the loop node", nodeDef.RefName, nodeDef.Body)
+	}
+	return nil
+}
+
+// ValidateApprovalNodes 校验审批节点配置
+func ValidateApprovalNodes(defJson string) error {
+	workflowDefEntity := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), workflowDefEntity); err != nil {
+		return err
+	}
+	nodes, err := entity.GetNodeRefNameDefMap(workflowDefEntity)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for refName := range nodes {
+		nodeDef, err := entity.GetNodeDefByRefName(workflowDefEntity, refName)
+		if err != nil {
+			return err
+		}
+		approvalNodeDef, ok := nodeDef.(entity.ApprovalNodeDef)
+		if !ok {
+			continue
+		}
+		if err := validateApprovalNode(approvalNodeDef); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinSortedErrors(errs)
+}
+
+// validateApprovalNode 校验单个审批节点, 必须配置审批人或者审批角色, 表单必须是合法的 JSON Schema
+func validateApprovalNode(nodeDef entity.ApprovalNodeDef) error {
+	if len(nodeDef.Assignees) == 0 && len(nodeDef.Roles) == 0 {
+		return fmt.Errorf("approval node [%s] must have assignees or roles", nodeDef.RefName)
+	}
+	if len(nodeDef.Form) == 0 {
+		return nil
+	}
+	if _, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(nodeDef.Form)); err != nil {
+		return fmt.Errorf("approval node [%s] form is not a valid json schema: %w", nodeDef.RefName, err)
+	}
+	return nil
+}
+
+// ValidateScriptNodes 校验脚本节点配置
+func ValidateScriptNodes(defJson string) error {
+	workflowDefEntity := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), workflowDefEntity); err != nil {
+		return err
+	}
+	nodes, err := entity.GetNodeRefNameDefMap(workflowDefEntity)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for refName := range nodes {
+		nodeDef, err := entity.GetNodeDefByRefName(workflowDefEntity, refName)
+		if err != nil {
+			return err
+		}
+		scriptNodeDef, ok := nodeDef.(entity.ScriptNodeDef)
+		if !ok {
+			continue
+		}
+		if err := validateScriptNode(scriptNodeDef); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinSortedErrors(errs)
+}
+
+// validateScriptNode 校验单个脚本节点, 语言必须是支持的语言, 代码不能为空, 超时时间不能超出范围
+func validateScriptNode(nodeDef entity.ScriptNodeDef) error {
+	if !entity.IsSupportedScriptLanguage(nodeDef.Language) {
+		return fmt.Errorf("script node [%s] language [%s] is not supported, must be one of %v",
+			nodeDef.RefName, nodeDef.Language, entity.ScriptLanguages)
+	}
+	if strings.TrimSpace(nodeDef.Code) == "" {
+		return fmt.Errorf("script node [%s] code must not be empty", nodeDef.RefName)
+	}
+	if nodeDef.Timeout.Duration == "" {
+		return nil
+	}
+	timeout, err := expr.ParseDuration(nodeDef.Timeout.Duration)
+	if err != nil {
+		return fmt.Errorf("script node [%s] timeout [%s] is illegal: %w",
+			nodeDef.RefName, nodeDef.Timeout.Duration, err)
+	}
+	if timeout <= 0 || timeout > entity.MaxScriptTimeout {
+		return fmt.Errorf("script node [%s] timeout [%s] must be greater than 0 and not exceed %s",
+			nodeDef.RefName, nodeDef.Timeout.Duration, entity.MaxScriptTimeout)
+	}
+	return nil
+}
+
+// ValidateWaitEventNodes 校验等待事件节点配置, 等待的事件不能为空
+func ValidateWaitEventNodes(defJson string) error {
+	workflowDefEntity := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), workflowDefEntity); err != nil {
+		return err
+	}
+	nodes, err := entity.GetNodeRefNameDefMap(workflowDefEntity)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for refName := range nodes {
+		nodeDef, err := entity.GetNodeDefByRefName(workflowDefEntity, refName)
+		if err != nil {
+			return err
+		}
+		waitEventNodeDef, ok := nodeDef.(entity.WaitEventNodeDef)
+		if !ok {
+			continue
+		}
+		if strings.TrimSpace(waitEventNodeDef.Event) == "" {
+			errs = append(errs, fmt.Errorf("wait event node [%s] event must not be empty", waitEventNodeDef.RefName))
+		}
+	}
+	return joinSortedErrors(errs)
+}
+
+// ValidateSubworkflowNodes 校验子流程节点的动态子流程配置
+func ValidateSubworkflowNodes(defJson string) error {
+	workflowDefEntity := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), workflowDefEntity); err != nil {
+		return err
+	}
+	nodes, err := entity.GetNodeRefNameDefMap(workflowDefEntity)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for refName := range nodes {
+		nodeDef, err := entity.GetNodeDefByRefName(workflowDefEntity, refName)
+		if err != nil {
+			return err
+		}
+		subworkflowNodeDef, ok := nodeDef.(entity.SubworkflowNodeDef)
+		if !ok || !subworkflowNodeDef.FanOut() {
+			continue
+		}
+		if err := validateSubworkflowJoin(subworkflowNodeDef); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinSortedErrors(errs)
+}
+
+// validateSubworkflowJoin 校验动态子流程的完成策略
+func validateSubworkflowJoin(nodeDef entity.SubworkflowNodeDef) error {
+	switch nodeDef.Join {
+	case "", entity.JoinAll, entity.JoinAny:
+		return nil
+	case entity.JoinQuorum:
+		if nodeDef.Quorum <= 0 {
+			return fmt.Errorf("subworkflow node [%s] quorum must be greater than 0", nodeDef.RefName)
+		}
+		return nil
+	default:
+		return fmt.Errorf("subworkflow node [%s] join [%s] is illegal, must be one of ALL, ANY, QUORUM",
+			nodeDef.RefName, nodeDef.Join)
+	}
+}
+
+// ValidateNodeWaits 校验节点等待配置的时区和 until 时间
+func ValidateNodeWaits(defJson string) error {
+	workflowDefEntity := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), workflowDefEntity); err != nil {
+		return err
+	}
+	nodes, err := entity.GetNodeRefNameDefMap(workflowDefEntity)
+	if err != nil {
+		return err
+	}
+	evaluator := expr.NewDefaultEvaluator()
+	var errs []error
+	for refName := range nodes {
+		nodeDef, err := entity.GetBasicNodeDefByRefName(workflowDefEntity, refName)
+		if err != nil {
+			return err
+		}
+		wait := nodeDef.Wait
+		if _, err := wait.Location(); err != nil {
+			errs = append(errs, fmt.Errorf("node [%s] wait timezone [%s] is illegal: %w", refName, wait.Timezone, err))
+			continue
+		}
+		// 表达式在执行时再计算
+		if wait.Until == "" || evaluator.IsExpression(wait.Until) {
+			continue
+		}
+		if _, err := wait.ParseUntil(wait.Until); err != nil {
+			errs = append(errs, fmt.Errorf("node [%s] %w", refName, err))
+		}
+	}
+	return joinSortedErrors(errs)
+}
+
+// canReachNode 从指定节点出发是否可以到达目标节点
+func canReachNode(node, target string, workflowDef *entity.WorkflowDef, runNodes []string) (bool, error) {
+	runNodes = append(runNodes, node)
+	nodeDef, err := entity.GetBasicNodeDefByRefName(workflowDef, node)
+	if err != nil {
+		return false, err
+	}
+	nextNodes, err := getNextNodesName(nodeDef, workflowDef, runNodes)
+	if err != nil {
+		return false, err
+	}
+	for _, nextNode := range nextNodes {
+		if nextNode == target {
+			return true, nil
+		}
+		if nextNode == entity.EndNode {
+			continue
+		}
+		reach, err := canReachNode(nextNode, target, workflowDef, runNodes)
+		if err != nil {
+			return false, err
+		}
+		if reach {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ValidateErrorRoutes 校验节点的错误路由配置, 以及节点名称不能和上下文中的 secrets 冲突
+func ValidateErrorRoutes(defJson string) error {
+	workflowDefEntity := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), workflowDefEntity); err != nil {
+		return err
+	}
+	nodes, err := entity.GetNodeRefNameDefMap(workflowDefEntity)
+	if err != nil {
+		return err
+	}
+	// 避免节点引用覆盖上下文中的密钥引用
+	if _, ok := nodes[entity.SecretsCtxKey]; ok {
+		return fmt.Errorf("workflow must not have node named [%s]", entity.SecretsCtxKey)
+	}
+	var errs []error
+	for refName := range nodes {
+		nodeDef, err := entity.GetBasicNodeDefByRefName(workflowDefEntity, refName)
+		if err != nil {
+			return err
+		}
+		if len(nodeDef.OnError) == 0 {
+			continue
+		}
+		// 避免节点引用覆盖上下文中的错误信息
+		if _, ok := nodes[entity.ErrorCtxKey]; ok {
+			return fmt.Errorf("workflow with onError routes must not have node named [%s]", entity.ErrorCtxKey)
+		}
+		for _, route := range nodeDef.OnError {
+			if err := validateErrorRoute(nodes, refName, route); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return joinSortedErrors(errs)
+}
+
+// validateErrorRoute 校验单个错误路由, 处理节点必须存在, 错误类型和状态码必须合法
+func validateErrorRoute(nodes map[string]map[string]interface{}, refName string, route entity.ErrorRoute) error {
+	if _, ok := nodes[route.Next]; (!ok && route.Next != entity.EndNode) || route.Next == refName {
+		return fmt.Errorf("node [%s] onError next [%s] not exists", refName, route.Next)
+	}
+	for _, class := range route.Errors {
+		if !entity.ContainsErrorClass(entity.ErrorClasses, class) {
+			return fmt.Errorf("node [%s] onError has illegal error [%s]", refName, class)
+		}
+	}
+	if len(route.HTTPStatus) == 0 {
+		return nil
+	}
+	if len(route.Errors) > 0 && !entity.ContainsErrorClass(route.Errors, entity.ErrorClassHTTP) {
+		return fmt.Errorf("node [%s] onError httpStatus only works with error [%s]", refName, entity.ErrorClassHTTP)
+	}
+	for _, status := range route.HTTPStatus {
+		if _, _, err := entity.ParseHTTPStatusRange(status); err != nil {
+			return fmt.Errorf("node [%s] onError has %w", refName, err)
+		}
+	}
+	return nil
+}
+
+// ValidateOpenAINodes 校验 OpenAI 节点的参数, 智能体模式需要多轮调用模型, 不支持流式响应
+func ValidateOpenAINodes(defJson string) error {
+	workflowDefEntity := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), workflowDefEntity); err != nil {
+		return err
+	}
+	nodes, err := entity.GetNodeRefNameDefMap(workflowDefEntity)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for refName := range nodes {
+		nodeDef, err := entity.GetNodeDefByRefName(workflowDefEntity, refName)
+		if err != nil {
+			return err
+		}
+		if _, ok := nodeDef.(entity.ServiceNodeDef); !ok {
+			continue
+		}
+		args, err := entity.GetServiceNodeArgs(nodeDef, entity.NormalArgs)
+		if err != nil {
+			continue
+		}
+		openAIArgs, ok := args.(*entity.OpenAIArgs)
+		if !ok {
+			continue
+		}
+		if len(openAIArgs.Tools) > 0 && openAIArgs.Stream {
+			errs = append(errs, fmt.Errorf("openai node [%s] does not support stream when tools are configured", refName))
+		}
+	}
+	return joinSortedErrors(errs)
+}
+
+// ValidateMCPNodes 校验 MCP 节点的参数, 没有配置 skipMCPToolsValidation 时获取 MCP 服务端的工具定义,
+// 校验工具是否存在以及静态参数是否符合工具的输入 schema
+func ValidateMCPNodes(defJson string) error {
+	workflowDefEntity := &entity.WorkflowDef{}
+	if err := json.Unmarshal([]byte(defJson), workflowDefEntity); err != nil {
+		return err
+	}
+	nodes, err := entity.GetNodeRefNameDefMap(workflowDefEntity)
+	if err != nil {
+		return err
+	}
+
+	validateTools := !config.GetValidationRulesConfig().SkipMCPToolsValidation
+	refreshedServers := map[string]bool{}
+	var errs []error
+	for refName := range nodes {
+		nodeDef, err := entity.GetNodeDefByRefName(workflowDefEntity, refName)
+		if err != nil {
+			return err
+		}
+		if _, ok := nodeDef.(entity.ServiceNodeDef); !ok {
+			continue
+		}
+		args, err := entity.GetServiceNodeArgs(nodeDef, entity.NormalArgs)
+		if err != nil {
+			// 协议不合法等问题在执行时再报错
+			continue
+		}
+		mcpArgs, ok := args.(*entity.MCPArgs)
+		if !ok {
+			continue
+		}
+		if err := validateMCPArgs(refName, mcpArgs, validateTools, refreshedServers); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinSortedErrors(errs)
+}
+
+// validateMCPArgs 校验单个 MCP 节点的参数, 同一个服务端在一次校验中只获取一次工具定义
+// 地址或者请求头中有表达式时只有在执行时才能确定服务端, MOCK 的节点不会请求服务端, 都不获取工具定义
+func validateMCPArgs(refName string, args *entity.MCPArgs, validateTools bool,
+	refreshedServers map[string]bool) error {
+	if args.URL == "" || args.Tool == "" {
+		return fmt.Errorf("mcp node [%s] must configure url and tool", refName)
+	}
+	if !validateTools || args.MockMode || hasMCPServerExpression(args) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mcpToolDiscoverTimeout)
+	defer cancel()
+	opts := []mcp.Option{mcp.WithTransport(mcp.Transport(args.Transport)), mcp.WithHeaders(args.Headers)}
+	server := utils.StructToJsonStr([]interface{}{args.URL, args.Headers})
+	if !refreshedServers[server] {
+		if _, err := mcp.DefaultToolCache.Refresh(ctx, args.URL, opts...); err != nil {
+			return fmt.Errorf("failed to list tools of mcp node [%s]: %w", refName, err)
+		}
+		refreshedServers[server] = true
+	}
+	tool, err := mcp.DefaultToolCache.GetTool(ctx, args.URL, args.Tool, opts...)
+	if err != nil {
+		return fmt.Errorf("illegal mcp node [%s]: %w", refName, err)
+	}
+
+	arguments := args.Body
+	if arguments == nil {
+		arguments = utils.StringMapToInterfaceMap(args.Parameters)
+	}
+	staticArguments, exprFields := splitStaticArguments(arguments)
+	if err := mcp.ValidateArguments(tool, staticArguments, exprFields...); err != nil {
+		return fmt.Errorf("illegal mcp node [%s]: %w", refName, err)
+	}
+	return nil
+}
+
+// hasMCPServerExpression 判断 MCP 服务端的地址和请求头中是否包含表达式, 比如 `Bearer ${secrets.token}`
+func hasMCPServerExpression(args *entity.MCPArgs) bool {
+	if strings.Contains(args.URL, "${") {
+		return true
+	}
+	for name, value := range args.Headers {
+		if strings.Contains(name, "${") || strings.Contains(value, "${") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitStaticArguments 拆分出不含表达式的参数, 含表达式的字段只有在执行时才能校验
+func splitStaticArguments(arguments map[string]interface{}) (map[string]interface{}, []string) {
+	evaluator := expr.NewDefaultEvaluator()
+	staticArguments := map[string]interface{}{}
+	exprFields := []string{}
+	for k, v := range arguments {
+		if containsExpression(evaluator, v) {
+			exprFields = append(exprFields, k)
+			continue
+		}
+		staticArguments[k] = v
+	}
+	return staticArguments, exprFields
+}
+
+// containsExpression 判断值中是否包含表达式
+func containsExpression(evaluator *expr.DefaultEvaluator, v interface{}) bool {
+	switch value := v.(type) {
+	case string:
+		return evaluator.IsExpression(value)
+	case map[string]interface{}:
+		for _, item := range value {
+			if containsExpression(evaluator, item) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if containsExpression(evaluator, item) {
+				return true
+			}
+		}
+	}
+	return false
+}
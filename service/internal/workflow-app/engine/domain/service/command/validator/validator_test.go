@@ -0,0 +1,190 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/provider"
+)
+
+func TestMain(m *testing.M) {
+	provider.InjectConfigProvider(&fakeConfigProvider{configs: map[string]string{}})
+	os.Exit(m.Run())
+}
+
+// fakeConfigProvider 按照配置 key 返回固定的配置, 没有配置时使用默认的 schema 和校验规则
+type fakeConfigProvider struct {
+	configs map[string]string
+}
+
+func (f *fakeConfigProvider) GetAny(ctx context.Context, k config.Key, t interface{}) error {
+	conf, ok := f.configs[k.Key]
+	if !ok {
+		return fmt.Errorf("config %s not found", k.Key)
+	}
+	return json.Unmarshal([]byte(conf), t)
+}
+
+func (f *fakeConfigProvider) GetString(ctx context.Context, k config.Key) (string, error) {
+	return f.configs[k.Key], nil
+}
+
+// invalidScriptDefJson 两个脚本节点和一个子流程的脚本节点都有错误
+const invalidScriptDefJson = `{"name":"demo","start":"a","nodes":[
+	{"b":{"type":"SCRIPT","language":"javascript","code":" ","next":"end"}},
+	{"a":{"type":"SCRIPT","language":"lua","code":"x","next":"b"}}],
+	"subworkflows":[{
+		"second":{"name":"second","start":"d","nodes":[{"d":{"type":"SCRIPT","language":"golang","code":"","next":"end"}}]},
+		"first":{"name":"first","start":"c","nodes":[{"c":{"type":"SCRIPT","language":"python","code":"1","next":"end"}}]}
+	}]}`
+
+// TestValidateDefJsonErrors 测试一次返回所有节点和子流程的错误, 错误按稳定的顺序返回
+func TestValidateDefJsonErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		defJson string
+		want    []string
+	}{
+		{"没有错误", `{"name":"demo","start":"a","nodes":[
+			{"a":{"type":"SCRIPT","language":"javascript","code":"x","next":"end"}}]}`, nil},
+		{"多个节点和子流程都有错误", invalidScriptDefJson, []string{
+			"script node [a] language [lua] is not supported, must be one of [javascript golang]",
+			"script node [b] code must not be empty",
+			"subworkflow [first] script node [c] language [python] is not supported, must be one of [javascript golang]",
+			"subworkflow [second] script node [d] code must not be empty",
+		}},
+		{"schema 校验失败时只返回 schema 的错误", `{"start":"a","nodes":[
+			{"a":{"type":"SCRIPT","language":"lua","code":"x","next":"end"}}]}`, []string{
+			"illegal def json：[(root): name is required]",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range ValidateDefJsonErrors(tt.defJson) {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateDefJsonErrors() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateWorkflowDefJson 测试多个节点的错误合并后顺序稳定
+func TestValidateWorkflowDefJson(t *testing.T) {
+	want := "script node [a] language [lua] is not supported, must be one of [javascript golang]\n" +
+		"script node [b] code must not be empty"
+	for i := 0; i < 20; i++ {
+		err := ValidateWorkflowDefJson(invalidScriptDefJson)
+		if err == nil || err.Error() != want {
+			t.Fatalf("ValidateWorkflowDefJson() error = %v, want %q", err, want)
+		}
+	}
+}
+
+// TestValidateMCPArgs 测试没有跳过校验且服务端确定时才获取工具定义
+func TestValidateMCPArgs(t *testing.T) {
+	// 没有服务监听的地址, 获取工具定义时会报错
+	unreachableURL := "http://127.0.0.1:1/mcp"
+	tests := []struct {
+		name          string
+		args          *entity.MCPArgs
+		validateTools bool
+		wantErr       bool
+	}{
+		{"没有配置工具", &entity.MCPArgs{URL: unreachableURL}, false, true},
+		{"跳过校验", &entity.MCPArgs{URL: unreachableURL, Tool: "search"}, false, false},
+		{"没有跳过校验时获取工具定义", &entity.MCPArgs{URL: unreachableURL, Tool: "search"}, true, true},
+		{"地址中有表达式", &entity.MCPArgs{URL: "${w.i.mcpURL}", Tool: "search"}, true, false},
+		{"请求头中有表达式", &entity.MCPArgs{URL: unreachableURL, Tool: "search",
+			Headers: map[string]string{"Authorization": "Bearer ${secrets.mcpToken}"}}, true, false},
+		{"MOCK 节点", &entity.MCPArgs{ServiceNodeBasicArgs: entity.ServiceNodeBasicArgs{MockMode: true},
+			URL: unreachableURL, Tool: "search"}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMCPArgs("search", tt.args, tt.validateTools, map[string]bool{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateMCPArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateOpenAINodes 测试智能体模式不能开启流式响应
+func TestValidateOpenAINodes(t *testing.T) {
+	newDefJson := func(args string) string {
+		return `{"name":"demo","start":"ask","nodes":[{"ask":{"type":"SERVICE","next":"end",` +
+			`"args":{"protocol":"OPENAI","provider":"default","prompt":"hi"` + args + `}}}]}`
+	}
+	tests := []struct {
+		name    string
+		defJson string
+		wantErr bool
+	}{
+		{"普通模式开启流式响应", newDefJson(`,"stream":true`), false},
+		{"智能体模式", newDefJson(`,"tools":[{"name":"weather","protocol":"HTTP"}]`), false},
+		{"智能体模式开启流式响应", newDefJson(`,"stream":true,"tools":[{"name":"weather","protocol":"HTTP"}]`), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateOpenAINodes(tt.defJson); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOpenAINodes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateErrorRoutes 测试节点名称不能和上下文中的 secrets 冲突
+func TestValidateErrorRoutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		defJson string
+		wantErr bool
+	}{
+		{"普通节点名称", `{"name":"demo","start":"a","nodes":[
+			{"a":{"type":"SCRIPT","language":"javascript","code":"x","next":"end"}}]}`, false},
+		{"名为 secrets 的节点", `{"name":"demo","start":"secrets","nodes":[
+			{"secrets":{"type":"SCRIPT","language":"javascript","code":"x","next":"end"}}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateErrorRoutes(tt.defJson); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateErrorRoutes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateScriptNodes 测试脚本节点的语言、代码和超时时间
+func TestValidateScriptNodes(t *testing.T) {
+	newDefJson := func(script string) string {
+		return `{"name":"demo","start":"a","nodes":[{"a":{"type":"SCRIPT","next":"end"` + script + `}}]}`
+	}
+	tests := []struct {
+		name    string
+		defJson string
+		wantErr bool
+	}{
+		{"没有配置超时时间", newDefJson(`,"language":"javascript","code":"x"`), false},
+		{"超时时间等于最大值", newDefJson(`,"language":"javascript","code":"x","timeout":{"duration":"60s"}`), false},
+		{"超时时间超过最大值", newDefJson(`,"language":"javascript","code":"x","timeout":{"duration":"10m"}`), true},
+		{"超时时间为 0", newDefJson(`,"language":"javascript","code":"x","timeout":{"duration":"0s"}`), true},
+		{"不支持的语言", newDefJson(`,"language":"python","code":"x"`), true},
+		{"代码为空", newDefJson(`,"language":"golang","code":" "`), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateScriptNodes(tt.defJson); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateScriptNodes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -102,6 +102,27 @@ func (m *WorkflowInstCommandService) ConsumeNodeCompleteDriveEvent(ctx context.C
 	return m.workflowRunner.DriveNext(ctx, driveReq)
 }
 
+// ConsumeWorkflowCompensateDriveEvent 消费流程补偿驱动事件
+func (m *WorkflowInstCommandService) ConsumeWorkflowCompensateDriveEvent(ctx context.Context,
+	d *dto.DriveEventDTO) error {
+	driveEvent := event.WorkflowCompensateDriveEvent{}
+	if err := json.Unmarshal(d.Message.Payload(), &driveEvent); err != nil {
+		return err
+	}
+	req := &dto.CompensateWorkflowInstDTO{
+		DefID:    driveEvent.DefID,
+		InstID:   driveEvent.InstID,
+		Operator: driveEvent.Operator,
+		Reason:   "compensate on workflow failure",
+	}
+	// 补偿失败的结果已经记录在流程实例中, 可以通过接口再次发起补偿, 这里不再重复消费
+	if err := m.workflowRunner.Compensate(ctx, req); err != nil {
+		log.Errorf("[%s]Failed to compensate workflow inst, caused by %s",
+			logs.GetFlowTraceID(driveEvent.DefID, driveEvent.InstID), err)
+	}
+	return nil
+}
+
 func eventNeedIgnore(inst *entity.WorkflowInst, eventTime time.Time) bool {
 	// 如果流程已经不在执行态了, 直接忽略掉消息
 	if inst.Status != entity.InstRunning {
@@ -132,6 +153,12 @@ func (m *WorkflowInstCommandService) CancelWorkflowInst(ctx context.Context, req
 	return m.workflowRunner.Cancel(ctx, req)
 }
 
+// CompensateWorkflowInst 补偿
+func (m *WorkflowInstCommandService) CompensateWorkflowInst(ctx context.Context,
+	req *dto.CompensateWorkflowInstDTO) error {
+	return m.workflowRunner.Compensate(ctx, req)
+}
+
 // PauseWorkflowInst 暂停
 func (m *WorkflowInstCommandService) PauseWorkflowInst(ctx context.Context, req *dto.PauseWorkflowInstDTO) error {
 	return m.workflowRunner.Pause(ctx, req)
@@ -142,6 +169,11 @@ func (m *WorkflowInstCommandService) ResumeWorkflowInst(ctx context.Context, req
 	return m.workflowRunner.Resume(ctx, req)
 }
 
+// RecoverWorkflowInst 恢复因为进程退出而中断的流程实例
+func (m *WorkflowInstCommandService) RecoverWorkflowInst(ctx context.Context, req *dto.RecoverWorkflowInstDTO) error {
+	return m.workflowRunner.Recover(ctx, req)
+}
+
 // CheckTimeout 检查超时
 func (m *WorkflowInstCommandService) CheckTimeout(ctx context.Context) error {
 	return m.timeoutChecker.CheckAll()
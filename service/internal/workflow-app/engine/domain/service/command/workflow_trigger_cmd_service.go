@@ -46,10 +46,12 @@ func NewWorkflowTriggerCommandService(workflowProviderSet *execution.WorkflowPro
 		exprEvaluator:    workflowProviderSet.ExprEvaluator(),
 		triggerActorMap: map[entity.ActionType]func(ctx context.Context,
 			trigger *entity.Trigger, actionArgs interface{}) error{
-			entity.StartWorkflow: actor.OnStartWorkflow,
-			entity.RerunNode:     actor.OnRerunNode,
-			entity.ResumeNode:    actor.OnResumeNode,
-			entity.CompleteNode:  actor.OnCompleteNode,
+			entity.StartWorkflow:      actor.OnStartWorkflow,
+			entity.RerunNode:          actor.OnRerunNode,
+			entity.ResumeNode:         actor.OnResumeNode,
+			entity.CompleteNode:       actor.OnCompleteNode,
+			entity.CompensateWorkflow: actor.OnCompensateWorkflow,
+			entity.ReceiveEvent:       actor.OnReceiveEvent,
 		},
 	}
 }
@@ -6,15 +6,18 @@ type Adapters struct {
 	*WorkflowDefQueryService
 	*WorkflowInstQueryService
 	*NodeInstQueryService
+	*SecretQueryService
 }
 
 // NewQueryAdapters 初始化查询适配器
 func NewQueryAdapters(workflowDefQueryService *WorkflowDefQueryService,
 	workflowInstQueryService *WorkflowInstQueryService,
-	nodeInstQueryService *NodeInstQueryService) *Adapters {
+	nodeInstQueryService *NodeInstQueryService,
+	secretQueryService *SecretQueryService) *Adapters {
 	return &Adapters{
 		WorkflowDefQueryService:  workflowDefQueryService,
 		WorkflowInstQueryService: workflowInstQueryService,
 		NodeInstQueryService:     nodeInstQueryService,
+		SecretQueryService:       secretQueryService,
 	}
 }
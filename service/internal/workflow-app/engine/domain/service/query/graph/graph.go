@@ -0,0 +1,419 @@
+// Package graph 将流程定义渲染为 Mermaid 或者 Graphviz DOT 格式的流程图
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+)
+
+// Format 流程图的格式
+type Format string
+
+const (
+	Mermaid Format = "mermaid" // Mermaid 流程图
+	DOT     Format = "dot"     // Graphviz DOT
+)
+
+// GetFormat 根据名称获取流程图的格式, 为空时默认为 Mermaid
+func GetFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return Mermaid, nil
+	case Mermaid, DOT:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported graph format %s, only mermaid and dot are supported", s)
+	}
+}
+
+// NodeKind 流程图中节点的种类
+type NodeKind string
+
+const (
+	TaskNode     NodeKind = "task"     // 流程定义中的节点
+	StartNode    NodeKind = "start"    // 开始
+	EndNode      NodeKind = "end"      // 结束
+	TriggerNode  NodeKind = "trigger"  // 触发器
+	WorkflowNode NodeKind = "workflow" // 通过流程定义 ID 引用的外部流程
+)
+
+// EdgeKind 流程图中边的种类
+type EdgeKind string
+
+const (
+	NextEdge        EdgeKind = "next"        // 节点执行完成后调度的下一个节点
+	ErrorEdge       EdgeKind = "error"       // 节点失败时的错误路由
+	TriggerEdge     EdgeKind = "trigger"     // 触发器执行的动作
+	SubworkflowEdge EdgeKind = "subworkflow" // 节点启动的子流程
+)
+
+// SkippedStatus 被标记跳过的节点的状态
+const SkippedStatus = "skipped"
+
+// Node 流程图中的节点
+type Node struct {
+	ID       string          // 流程图中的唯一标识
+	Label    string          // 展示的名称
+	Kind     NodeKind        // 节点的种类
+	Type     entity.NodeType // 流程定义中节点的类型
+	Status   string          // 叠加实例后节点实例的状态
+	Executed bool            // 叠加实例后是否执行过
+}
+
+// Edge 流程图中的边
+type Edge struct {
+	From     string   // 开始节点的唯一标识
+	To       string   // 结束节点的唯一标识
+	Label    string   // 展示的条件或者动作
+	Kind     EdgeKind // 边的种类
+	Executed bool     // 叠加实例后是否执行过
+}
+
+// Graph 流程图, 内部定义的子流程作为子图展示
+type Graph struct {
+	Name      string
+	Nodes     []*Node
+	Edges     []*Edge
+	Subgraphs []*Graph
+
+	prefix  string
+	refs    map[string]*Node // 节点引用名称和节点的映射
+	start   *Node
+	end     *Node
+	subRefs map[string]*Graph // 子流程引用名称和子图的映射
+}
+
+// New 根据流程定义生成流程图, 覆盖节点的 next、SWITCH 分支、FORK/JOIN、错误路由、子流程和触发器
+func New(def *entity.WorkflowDef) (*Graph, error) {
+	if def == nil {
+		return nil, fmt.Errorf("workflow def must not be nil")
+	}
+	return newGraph(def, "")
+}
+
+func newGraph(def *entity.WorkflowDef, prefix string) (*Graph, error) {
+	g := &Graph{
+		Name:    def.Name,
+		prefix:  prefix,
+		refs:    map[string]*Node{},
+		subRefs: map[string]*Graph{},
+	}
+	g.start = g.addNode(&Node{ID: prefix + "n_start", Label: "start", Kind: StartNode})
+	for _, subworkflows := range def.Subworkflows {
+		for refName, subDef := range subworkflows {
+			subDef := subDef
+			sub, err := newGraph(&subDef, fmt.Sprintf("%ss%d_", prefix, len(g.Subgraphs)))
+			if err != nil {
+				return nil, err
+			}
+			if sub.Name == "" {
+				sub.Name = refName
+			}
+			g.Subgraphs = append(g.Subgraphs, sub)
+			g.subRefs[refName] = sub
+		}
+	}
+
+	nodeDefs, err := g.addTaskNodes(def)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodeDefs) > 0 {
+		g.addEdge(g.start.ID, nodeDefs[0].Node.ID, "", NextEdge)
+	}
+	for _, nodeDef := range nodeDefs {
+		if err := g.addNodeEdges(def, nodeDef); err != nil {
+			return nil, err
+		}
+	}
+	g.addTriggers(def)
+	return g, nil
+}
+
+// taskNodeDef 节点和对应的流程定义
+type taskNodeDef struct {
+	Node  *Node
+	Basic *entity.BasicNodeDef
+	Def   interface{}
+}
+
+// addTaskNodes 添加流程定义中的所有节点
+func (g *Graph) addTaskNodes(def *entity.WorkflowDef) ([]*taskNodeDef, error) {
+	var r []*taskNodeDef
+	for i, node := range def.Nodes {
+		nodeDef, err := entity.NewNodeDef(node, i)
+		if err != nil {
+			return nil, err
+		}
+		basic, err := entity.GetBasicNodeDefFromNodeDef(nodeDef)
+		if err != nil {
+			return nil, err
+		}
+		n := g.addNode(&Node{
+			ID:    fmt.Sprintf("%sn%d", g.prefix, i),
+			Label: basic.RefName,
+			Kind:  TaskNode,
+			Type:  basic.Type,
+		})
+		g.refs[basic.RefName] = n
+		r = append(r, &taskNodeDef{Node: n, Basic: basic, Def: nodeDef})
+	}
+	return r, nil
+}
+
+// addNodeEdges 添加节点出发的所有边
+func (g *Graph) addNodeEdges(def *entity.WorkflowDef, nodeDef *taskNodeDef) error {
+	from := nodeDef.Node.ID
+	switch d := nodeDef.Def.(type) {
+	case entity.SwitchNodeDef:
+		for _, c := range d.Switch {
+			g.addRefEdge(from, c.Next, c.Condition, NextEdge)
+		}
+		g.addRefEdge(from, d.Next, "default", NextEdge)
+	case entity.ForkNodeDef:
+		for _, next := range d.Fork {
+			g.addRefEdge(from, next, "", NextEdge)
+		}
+	case entity.LoopNodeDef:
+		g.addRefEdge(from, d.Body, "loop", NextEdge)
+		g.addNextEdge(def, nodeDef.Basic, from, "done")
+	case entity.SubworkflowNodeDef:
+		label := "subworkflow"
+		if d.FanOut() {
+			label = fmt.Sprintf("for each, join %s", strings.ToLower(orDefault(string(d.Join), "all")))
+		}
+		g.addSubworkflowEdge(from, d.Subworkflow, d.ID, d.Version, label)
+		g.addNextEdge(def, nodeDef.Basic, from, "")
+	case entity.ForeachNodeDef:
+		if d.RunSubworkflow() {
+			g.addSubworkflowEdge(from, d.Subworkflow, d.ID, d.Version, "for each")
+		}
+		g.addNextEdge(def, nodeDef.Basic, from, "")
+	default:
+		g.addNextEdge(def, nodeDef.Basic, from, "")
+	}
+
+	for _, route := range nodeDef.Basic.OnError {
+		g.addRefEdge(from, route.Next, getErrorRouteLabel(route), ErrorEdge)
+	}
+	return nil
+}
+
+// addNextEdge 添加到下一个节点的边, 没有配置 next 时为定义中的下一个节点
+func (g *Graph) addNextEdge(def *entity.WorkflowDef, basic *entity.BasicNodeDef, from, label string) {
+	next, err := entity.GetNextNode(def, basic)
+	if err != nil {
+		// 最后一个节点没有配置 next, 流程在这里结束
+		next = entity.EndNode
+	}
+	g.addRefEdge(from, next, label, NextEdge)
+}
+
+// addSubworkflowEdge 添加到子流程的边, 内部定义的子流程指向子图的开始节点
+func (g *Graph) addSubworkflowEdge(from, refName, defID string, version int, label string) {
+	if sub, ok := g.subRefs[refName]; ok {
+		g.addEdge(from, sub.start.ID, label, SubworkflowEdge)
+		return
+	}
+	name := refName
+	if name == "" && defID != "" {
+		name = "workflow " + defID
+		if version > 0 {
+			name += fmt.Sprintf(" v%d", version)
+		}
+	}
+	if name == "" {
+		return
+	}
+	to := g.addNode(&Node{ID: fmt.Sprintf("%sw%d", g.prefix, len(g.Nodes)), Label: name, Kind: WorkflowNode})
+	g.addEdge(from, to.ID, label, SubworkflowEdge)
+}
+
+// addTriggers 添加触发器以及触发器动作指向的节点
+func (g *Graph) addTriggers(def *entity.WorkflowDef) {
+	i := 0
+	for _, triggers := range def.Triggers {
+		for refName, trigger := range triggers {
+			label := orDefault(trigger.RefName, refName)
+			if source := orDefault(trigger.Expr, trigger.Event); source != "" {
+				label = fmt.Sprintf("%s\n%s: %s", label, trigger.Type, source)
+			}
+			t := g.addNode(&Node{ID: fmt.Sprintf("%st%d", g.prefix, i), Label: label, Kind: TriggerNode})
+			i++
+			for _, actions := range trigger.Actions {
+				for _, action := range actions {
+					g.addEdge(t.ID, g.getActionTarget(action), getActionLabel(action), TriggerEdge)
+				}
+			}
+		}
+	}
+}
+
+// getActionTarget 获取触发器动作指向的节点, 流程级别的动作指向开始节点
+func (g *Graph) getActionTarget(action entity.Action) string {
+	if args, ok := action.Args.(map[string]interface{}); ok {
+		if refName, ok := args["node"].(string); ok {
+			if n, ok := g.refs[refName]; ok {
+				return n.ID
+			}
+		}
+	}
+	return g.start.ID
+}
+
+func getActionLabel(action entity.Action) string {
+	label := strings.ToLower(string(action.ActionType))
+	if action.Condition != "" {
+		label = fmt.Sprintf("%s if %s", label, action.Condition)
+	}
+	return label
+}
+
+func getErrorRouteLabel(route entity.ErrorRoute) string {
+	var matches []string
+	for _, class := range route.Errors {
+		matches = append(matches, class.String())
+	}
+	matches = append(matches, route.HTTPStatus...)
+	if len(matches) == 0 {
+		return "on error"
+	}
+	return "on " + strings.Join(matches, ", ")
+}
+
+// addRefEdge 添加到引用名称对应节点的边
+func (g *Graph) addRefEdge(from, refName, label string, kind EdgeKind) {
+	if refName == "" {
+		return
+	}
+	if refName == entity.EndNode {
+		g.addEdge(from, g.getEnd().ID, label, kind)
+		return
+	}
+	to, ok := g.refs[refName]
+	if !ok {
+		// 引用不存在的节点时同样展示出来, 方便发现定义中的错误
+		to = g.addNode(&Node{ID: fmt.Sprintf("%sx%d", g.prefix, len(g.Nodes)), Label: refName + "?", Kind: TaskNode})
+		g.refs[refName] = to
+	}
+	g.addEdge(from, to.ID, label, kind)
+}
+
+func (g *Graph) getEnd() *Node {
+	if g.end == nil {
+		g.end = g.addNode(&Node{ID: g.prefix + "n_end", Label: "end", Kind: EndNode})
+	}
+	return g.end
+}
+
+func (g *Graph) addNode(n *Node) *Node {
+	g.Nodes = append(g.Nodes, n)
+	return n
+}
+
+func (g *Graph) addEdge(from, to, label string, kind EdgeKind) {
+	g.Edges = append(g.Edges, &Edge{From: from, To: to, Label: label, Kind: kind})
+}
+
+// Overlay 在流程图上叠加流程实例的执行路径和节点实例的状态
+func (g *Graph) Overlay(inst *entity.WorkflowInst) {
+	if inst == nil {
+		return
+	}
+	for _, nodeInst := range inst.SchedNodeInsts {
+		if n, ok := g.refs[nodeInst.BasicNodeDef.RefName]; ok && nodeInst.CompensateFor == "" {
+			n.Status = nodeInst.Status.String()
+			n.Executed = true
+		}
+	}
+
+	// 被标记跳过的节点不会创建节点实例
+	for _, refName := range inst.SkipNodes {
+		if n, ok := g.refs[refName]; ok && n.Status == "" {
+			n.Status = SkippedStatus
+		}
+	}
+
+	// 记录节点第一次和最后一次出现在执行路径中的位置
+	first, last := map[string]int{}, map[string]int{}
+	for i, step := range inst.ExecutePath {
+		for _, refName := range step {
+			n, ok := g.refs[refName]
+			if !ok {
+				continue
+			}
+			n.Executed = true
+			if _, ok := first[n.ID]; !ok {
+				first[n.ID] = i
+			}
+			last[n.ID] = i
+		}
+	}
+	if len(first) > 0 {
+		g.start.Executed = true
+	}
+	if g.end != nil && inst.Status == entity.InstSucceed {
+		g.end.Executed = true
+	}
+
+	lastStep := len(inst.ExecutePath) - 1
+	for _, e := range g.Edges {
+		switch {
+		case e.From == g.start.ID:
+			e.Executed = hasKey(first, e.To) && first[e.To] == 0
+		case g.end != nil && e.To == g.end.ID:
+			e.Executed = g.end.Executed && hasKey(last, e.From) && last[e.From] == lastStep
+		default:
+			// 执行路径只记录每一轮调度的节点, 两个节点都执行过并且先后出现时认为经过了这条边
+			e.Executed = hasKey(first, e.From) && hasKey(last, e.To) && first[e.From] < last[e.To]
+		}
+	}
+}
+
+func hasKey(m map[string]int, k string) bool {
+	_, ok := m[k]
+	return ok
+}
+
+func orDefault(s, d string) string {
+	if s == "" {
+		return d
+	}
+	return s
+}
+
+// Render 按照格式渲染流程图
+func (g *Graph) Render(format Format) (string, error) {
+	switch format {
+	case Mermaid:
+		return renderMermaid(g), nil
+	case DOT:
+		return renderDOT(g), nil
+	default:
+		return "", fmt.Errorf("unsupported graph format %s", format)
+	}
+}
+
+// RenderDef 渲染流程定义的流程图
+func RenderDef(def *entity.WorkflowDef, format Format) (string, error) {
+	g, err := New(def)
+	if err != nil {
+		return "", err
+	}
+	return g.Render(format)
+}
+
+// RenderInst 渲染流程实例的流程图, 叠加执行路径和节点状态
+func RenderInst(inst *entity.WorkflowInst, format Format) (string, error) {
+	if inst == nil {
+		return "", fmt.Errorf("workflow inst must not be nil")
+	}
+	g, err := New(inst.WorkflowDef)
+	if err != nil {
+		return "", err
+	}
+	g.Overlay(inst)
+	return g.Render(format)
+}
@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDefJson = `{
+  "name": "order",
+  "triggers": [{"retry": {"type": "event", "event": "order_retry",
+    "actions": [{"rerun": {"action": "RERUN_NODE", "args": {"node": "pay"}}}]}}],
+  "nodes": [
+    {"check": {"type": "SWITCH", "switch": [{"condition": "${input.amount > 100}", "next": "split"}], "next": "pay"}},
+    {"split": {"type": "FORK", "fork": ["pay", "notify"]}},
+    {"pay": {"type": "SERVICE", "next": "merge", "onError": [{"errors": ["TIMEOUT"], "next": "end"}]}},
+    {"notify": {"type": "SUB_WORKFLOW", "subworkflow": "notice", "next": "merge"}},
+    {"merge": {"type": "JOIN"}}
+  ],
+  "subworkflows": [{"notice": {"name": "notice", "nodes": [{"send": {"type": "ASSIGN", "next": "end"}}]}}]
+}`
+
+func newTestDef(t *testing.T) *entity.WorkflowDef {
+	def := &entity.WorkflowDef{}
+	assert.Nil(t, json.Unmarshal([]byte(testDefJson), def))
+	return def
+}
+
+// edgeStrings 获取流程图中所有边的描述
+func edgeStrings(g *Graph) []string {
+	var r []string
+	for _, e := range g.allEdges() {
+		r = append(r, fmt.Sprintf("%s->%s:%s:%s:%v", e.From, e.To, e.Kind, e.Label, e.Executed))
+	}
+	return r
+}
+
+// TestNew 测试根据流程定义生成节点和边
+func TestNew(t *testing.T) {
+	g, err := New(newTestDef(t))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"n_start->n0:next::false",
+		"n0->n1:next:${input.amount > 100}:false",
+		"n0->n2:next:default:false",
+		"n1->n2:next::false",
+		"n1->n3:next::false",
+		"n2->n4:next::false",
+		"n2->n_end:error:on TIMEOUT:false",
+		"n3->s0_n_start:subworkflow:subworkflow:false",
+		"n3->n4:next::false",
+		"n4->n_end:next::false",
+		"t0->n2:trigger:rerun_node:false",
+		"s0_n_start->s0_n0:next::false",
+		"s0_n0->s0_n_end:next::false",
+	}, edgeStrings(g))
+}
+
+// TestGraph_Overlay 测试叠加流程实例的执行路径和节点状态
+func TestGraph_Overlay(t *testing.T) {
+	def := newTestDef(t)
+	g, err := New(def)
+	assert.Nil(t, err)
+	g.Overlay(&entity.WorkflowInst{
+		WorkflowDef: def,
+		Status:      entity.InstFailed,
+		ExecutePath: [][]string{{"check"}, {"pay"}},
+		SkipNodes:   []string{"notify"},
+		SchedNodeInsts: []*entity.NodeInst{
+			{BasicNodeDef: entity.BasicNodeDef{RefName: "check"}, Status: entity.NodeInstSucceed},
+			{BasicNodeDef: entity.BasicNodeDef{RefName: "pay"}, Status: entity.NodeInstFailed},
+		},
+	})
+
+	var executed []string
+	for _, e := range g.allEdges() {
+		if e.Executed {
+			executed = append(executed, e.From+"->"+e.To)
+		}
+	}
+	assert.Equal(t, []string{"n_start->n0", "n0->n2"}, executed)
+	assert.Equal(t, "succeed", g.refs["check"].Status)
+	assert.Equal(t, "failed", g.refs["pay"].Status)
+	assert.Equal(t, SkippedStatus, g.refs["notify"].Status)
+	assert.False(t, g.refs["notify"].Executed)
+	assert.False(t, g.refs["split"].Executed)
+	assert.False(t, g.end.Executed)
+}
+
+// TestGraph_Render 测试渲染为不同格式
+func TestGraph_Render(t *testing.T) {
+	def := newTestDef(t)
+	inst := &entity.WorkflowInst{
+		WorkflowDef: def,
+		Status:      entity.InstRunning,
+		ExecutePath: [][]string{{"check"}},
+		SchedNodeInsts: []*entity.NodeInst{
+			{BasicNodeDef: entity.BasicNodeDef{RefName: "check"}, Status: entity.NodeInstSucceed},
+		},
+	}
+	tests := []struct {
+		name     string
+		format   string
+		inst     *entity.WorkflowInst
+		contains []string
+		wantErr  bool
+	}{
+		{"默认为 Mermaid", "", nil, []string{
+			"flowchart TD",
+			`n0{"check<br/>SWITCH"}`,
+			`n0 -->|"${input.amount #gt; 100}"| n1`,
+			`n2 -.->|"on TIMEOUT"| n_end`,
+			`subgraph s0["subworkflow: notice"]`,
+			`t0>"retry<br/>event: order_retry"]`,
+		}, false},
+		{"Mermaid 叠加实例状态", "mermaid", inst, []string{
+			`n0{"check<br/>SWITCH<br/>succeed"}`,
+			"classDef succeed fill:#d4edda,stroke:#28a745,stroke-width:2px",
+			"class n0 succeed",
+			"linkStyle 0 stroke:#28a745,stroke-width:3px",
+		}, false},
+		{"DOT", "DOT", nil, []string{
+			`digraph "order" {`,
+			`n0 [label="check\nSWITCH", shape=diamond];`,
+			`n2 -> n_end [label="on TIMEOUT", style=dashed, color="#dc3545"];`,
+			"subgraph cluster_s0 {",
+		}, false},
+		{"DOT 叠加实例状态", "dot", inst, []string{
+			`n0 [label="check\nSWITCH\nsucceed", shape=diamond, fillcolor="#d4edda", color="#28a745", penwidth=2];`,
+			`n_start -> n0 [color="#28a745", penwidth=2.5];`,
+		}, false},
+		{"不支持的格式", "png", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, err := GetFormat(tt.format)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			var got string
+			if tt.inst != nil {
+				got, err = RenderInst(tt.inst, format)
+			} else {
+				got, err = RenderDef(def, format)
+			}
+			assert.Nil(t, err)
+			for _, s := range tt.contains {
+				assert.True(t, strings.Contains(got, s), "%s not in:\n%s", s, got)
+			}
+		})
+	}
+}
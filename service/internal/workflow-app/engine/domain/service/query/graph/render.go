@@ -0,0 +1,275 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+)
+
+// style 节点的填充色和边框色
+type style struct {
+	fill   string
+	stroke string
+}
+
+const (
+	executedClass = "executed" // 执行过但是没有节点实例状态的节点, 例如开始和结束
+	executedColor = "#28a745"  // 执行过的边的颜色
+)
+
+// statusStyles 节点实例状态对应的样式
+var statusStyles = map[string]style{
+	entity.NodeInstSucceed.String():   {"#d4edda", "#28a745"},
+	entity.NodeInstFailed.String():    {"#f8d7da", "#dc3545"},
+	entity.NodeInstRunning.String():   {"#cce5ff", "#007bff"},
+	entity.NodeInstScheduled.String(): {"#fff3cd", "#ffc107"},
+	entity.NodeInstWaiting.String():   {"#fff3cd", "#ffc107"},
+	entity.NodeInstPaused.String():    {"#e2d9f3", "#6f42c1"},
+	entity.NodeInstCancelled.String(): {"#e2e3e5", "#6c757d"},
+	entity.NodeInstTimeout.String():   {"#ffe5d0", "#fd7e14"},
+	SkippedStatus:                     {"#f8f9fa", "#adb5bd"},
+	executedClass:                     {"#ffffff", "#28a745"},
+}
+
+// getStyleClass 获取节点对应的样式名称, 没有叠加实例或者没有执行过时为空
+func getStyleClass(n *Node) string {
+	if _, ok := statusStyles[n.Status]; ok && n.Status != "" {
+		return n.Status
+	}
+	if n.Executed {
+		return executedClass
+	}
+	return ""
+}
+
+// getLabelLines 获取节点展示的多行内容
+func getLabelLines(n *Node) []string {
+	lines := strings.Split(n.Label, "\n")
+	if n.Kind == TaskNode && n.Type != "" {
+		lines = append(lines, n.Type.String())
+	}
+	if n.Status != "" {
+		lines = append(lines, n.Status)
+	}
+	return lines
+}
+
+// allNodes 获取流程图和所有子图中的节点
+func (g *Graph) allNodes() []*Node {
+	nodes := append([]*Node{}, g.Nodes...)
+	for _, sub := range g.Subgraphs {
+		nodes = append(nodes, sub.allNodes()...)
+	}
+	return nodes
+}
+
+// allEdges 获取流程图和所有子图中的边
+func (g *Graph) allEdges() []*Edge {
+	edges := append([]*Edge{}, g.Edges...)
+	for _, sub := range g.Subgraphs {
+		edges = append(edges, sub.allEdges()...)
+	}
+	return edges
+}
+
+// renderMermaid 渲染为 Mermaid 流程图
+func renderMermaid(g *Graph) string {
+	b := &strings.Builder{}
+	b.WriteString("flowchart TD\n")
+	if g.Name != "" {
+		fmt.Fprintf(b, "    %%%% %s\n", g.Name)
+	}
+	writeMermaidNodes(b, g, "    ")
+
+	var executed []string
+	for i, e := range g.allEdges() {
+		arrow := "-->"
+		if e.Kind != NextEdge {
+			arrow = "-.->"
+		}
+		if e.Label != "" {
+			arrow = fmt.Sprintf("%s|\"%s\"|", arrow, escapeMermaid(e.Label))
+		}
+		fmt.Fprintf(b, "    %s %s %s\n", e.From, arrow, e.To)
+		if e.Executed {
+			executed = append(executed, fmt.Sprint(i))
+		}
+	}
+
+	classes := map[string][]string{}
+	for _, n := range g.allNodes() {
+		if class := getStyleClass(n); class != "" {
+			classes[class] = append(classes[class], n.ID)
+		}
+	}
+	for _, class := range sortedKeys(classes) {
+		s := statusStyles[class]
+		fmt.Fprintf(b, "    classDef %s fill:%s,stroke:%s,stroke-width:2px\n", class, s.fill, s.stroke)
+		fmt.Fprintf(b, "    class %s %s\n", strings.Join(classes[class], ","), class)
+	}
+	if len(executed) > 0 {
+		fmt.Fprintf(b, "    linkStyle %s stroke:%s,stroke-width:3px\n", strings.Join(executed, ","), executedColor)
+	}
+	return b.String()
+}
+
+// writeMermaidNodes 输出节点的定义, 子图中的节点定义在子图内
+func writeMermaidNodes(b *strings.Builder, g *Graph, indent string) {
+	for _, n := range g.Nodes {
+		var lines []string
+		for _, line := range getLabelLines(n) {
+			lines = append(lines, escapeMermaid(line))
+		}
+		open, closing := getMermaidShape(n)
+		fmt.Fprintf(b, "%s%s%s\"%s\"%s\n", indent, n.ID, open, strings.Join(lines, "<br/>"), closing)
+	}
+	for _, sub := range g.Subgraphs {
+		fmt.Fprintf(b, "%ssubgraph %s[\"%s\"]\n", indent, strings.TrimSuffix(sub.prefix, "_"),
+			escapeMermaid("subworkflow: "+sub.Name))
+		writeMermaidNodes(b, sub, indent+"    ")
+		fmt.Fprintf(b, "%send\n", indent)
+	}
+}
+
+// getMermaidShape 获取节点在 Mermaid 中的形状
+func getMermaidShape(n *Node) (string, string) {
+	switch n.Kind {
+	case StartNode, EndNode:
+		return "((", "))"
+	case TriggerNode:
+		return ">", "]"
+	case WorkflowNode:
+		return "[[", "]]"
+	}
+	switch n.Type {
+	case entity.SwitchNode:
+		return "{", "}"
+	case entity.ForkNode, entity.JoinNode, entity.ExclusiveJoinNode:
+		return "{{", "}}"
+	case entity.SubWorkflowNode, entity.ForeachNode, entity.LoopNode:
+		return "[[", "]]"
+	case entity.WaitNode, entity.WaitEventNode, entity.ApprovalNode, entity.EventNode:
+		return "([", "])"
+	default:
+		return "[", "]"
+	}
+}
+
+// escapeMermaid 转义 Mermaid 文本中的特殊字符
+func escapeMermaid(s string) string {
+	return strings.NewReplacer(
+		"#", "#35;",
+		"\"", "#quot;",
+		"<", "#lt;",
+		">", "#gt;",
+		"|", "#124;",
+		"\n", " ",
+	).Replace(s)
+}
+
+// renderDOT 渲染为 Graphviz DOT
+func renderDOT(g *Graph) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "digraph \"%s\" {\n", escapeDOT(g.Name))
+	b.WriteString("    rankdir=TB;\n")
+	b.WriteString("    node [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\", fontname=\"Helvetica\"];\n")
+	b.WriteString("    edge [fontname=\"Helvetica\", fontsize=10];\n")
+	writeDOTNodes(b, g, "    ")
+
+	for _, e := range g.allEdges() {
+		var attrs []string
+		if e.Label != "" {
+			attrs = append(attrs, fmt.Sprintf("label=\"%s\"", escapeDOT(e.Label)))
+		}
+		switch e.Kind {
+		case ErrorEdge:
+			attrs = append(attrs, "style=dashed", "color=\"#dc3545\"")
+		case TriggerEdge:
+			attrs = append(attrs, "style=dotted")
+		case SubworkflowEdge:
+			attrs = append(attrs, "style=dashed")
+		}
+		if e.Executed {
+			attrs = append(attrs, fmt.Sprintf("color=\"%s\"", executedColor), "penwidth=2.5")
+		}
+		fmt.Fprintf(b, "    %s -> %s%s;\n", e.From, e.To, formatDOTAttrs(attrs))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// writeDOTNodes 输出节点的定义, 子图使用 cluster 展示
+func writeDOTNodes(b *strings.Builder, g *Graph, indent string) {
+	for _, n := range g.Nodes {
+		attrs := []string{fmt.Sprintf("label=\"%s\"", escapeDOT(strings.Join(getLabelLines(n), "\n")))}
+		if shape := getDOTShape(n); shape != "" {
+			attrs = append(attrs, "shape="+shape)
+		}
+		if class := getStyleClass(n); class != "" {
+			s := statusStyles[class]
+			attrs = append(attrs, fmt.Sprintf("fillcolor=\"%s\"", s.fill), fmt.Sprintf("color=\"%s\"", s.stroke),
+				"penwidth=2")
+		}
+		fmt.Fprintf(b, "%s%s%s;\n", indent, n.ID, formatDOTAttrs(attrs))
+	}
+	for _, sub := range g.Subgraphs {
+		fmt.Fprintf(b, "%ssubgraph cluster_%s {\n", indent, strings.TrimSuffix(sub.prefix, "_"))
+		fmt.Fprintf(b, "%s    label=\"%s\";\n", indent, escapeDOT("subworkflow: "+sub.Name))
+		fmt.Fprintf(b, "%s    style=dashed;\n", indent)
+		writeDOTNodes(b, sub, indent+"    ")
+		fmt.Fprintf(b, "%s}\n", indent)
+	}
+}
+
+// getDOTShape 获取节点在 DOT 中的形状, 为空时使用默认的圆角矩形
+func getDOTShape(n *Node) string {
+	switch n.Kind {
+	case StartNode:
+		return "circle"
+	case EndNode:
+		return "doublecircle"
+	case TriggerNode:
+		return "cds"
+	case WorkflowNode:
+		return "component"
+	}
+	switch n.Type {
+	case entity.SwitchNode:
+		return "diamond"
+	case entity.ForkNode, entity.JoinNode, entity.ExclusiveJoinNode:
+		return "hexagon"
+	case entity.SubWorkflowNode, entity.ForeachNode, entity.LoopNode:
+		return "component"
+	case entity.WaitNode, entity.WaitEventNode, entity.ApprovalNode, entity.EventNode:
+		return "ellipse"
+	default:
+		return ""
+	}
+}
+
+func formatDOTAttrs(attrs []string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(attrs, ", ") + "]"
+}
+
+// escapeDOT 转义 DOT 字符串中的特殊字符
+func escapeDOT(s string) string {
+	return strings.NewReplacer(
+		"\\", "\\\\",
+		"\"", "\\\"",
+		"\n", "\\n",
+	).Replace(s)
+}
+
+func sortedKeys(m map[string][]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
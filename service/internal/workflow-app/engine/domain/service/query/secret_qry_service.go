@@ -0,0 +1,39 @@
+package query
+
+import (
+	"context"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/convertor"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/repository/repo"
+	"github.com/fflow-tech/fflow/service/pkg/log"
+)
+
+// SecretQueryService 密钥查询服务
+type SecretQueryService struct {
+	secretRepo ports.SecretRepository
+}
+
+// NewSecretQueryService 新建查询服务
+func NewSecretQueryService(r *repo.SecretRepo) *SecretQueryService {
+	return &SecretQueryService{secretRepo: r}
+}
+
+// GetSecretList 分页查询密钥, 不返回密钥值
+func (m *SecretQueryService) GetSecretList(ctx context.Context, d *dto.PageQuerySecretDTO) (
+	[]*dto.SecretDTO, int64, error) {
+	secrets, err := m.secretRepo.PageQuery(d)
+	if err != nil {
+		log.Errorf("Failed to page query secrets, namespace:[%s], caused by %s", d.Namespace, err)
+		return nil, 0, err
+	}
+
+	total, err := m.secretRepo.Count(d)
+	if err != nil {
+		log.Errorf("Failed to count secrets, namespace:[%s], caused by %s", d.Namespace, err)
+		return nil, 0, err
+	}
+
+	return convertor.SecretConvertor.ConvertEntitiesToDTOs(secrets), total, nil
+}
@@ -5,7 +5,9 @@ import (
 
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/convertor"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/query/graph"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/repository/repo"
 	"github.com/fflow-tech/fflow/service/pkg/log"
 )
@@ -58,3 +60,26 @@ func (m *WorkflowDefQueryService) GetWorkflowDefList(ctx context.Context, d *dto
 	defDTOList, err := convertor.DefConvertor.ConvertEntitiesToDTOs(defEntityList)
 	return defDTOList, total, err
 }
+
+// GetWorkflowDefGraph 查询流程定义的流程图, 没有指定版本时使用最新版本
+func (m *WorkflowDefQueryService) GetWorkflowDefGraph(ctx context.Context,
+	d *dto.GetWorkflowDefGraphDTO) (string, error) {
+	format, err := graph.GetFormat(d.Format)
+	if err != nil {
+		return "", err
+	}
+
+	getDef := &dto.GetWorkflowDefDTO{Namespace: d.Namespace, Creator: d.Creator, DefID: d.DefID, Version: d.Version}
+	var def *entity.WorkflowDef
+	if d.Version > 0 {
+		def, err = m.workflowDefRepo.Get(getDef)
+	} else {
+		def, err = m.workflowDefRepo.GetLastVersion(getDef)
+	}
+	if err != nil {
+		log.Errorf("Failed to get workflow def, defID:[%s], caused by %s", d.DefID, err)
+		return "", err
+	}
+
+	return graph.RenderDef(def, format)
+}
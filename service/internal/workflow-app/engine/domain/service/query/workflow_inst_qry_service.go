@@ -6,6 +6,7 @@ import (
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/convertor"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/service/query/graph"
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/repository/repo"
 	"github.com/fflow-tech/fflow/service/pkg/log"
 	"github.com/fflow-tech/fflow/service/pkg/utils"
@@ -52,3 +53,25 @@ func (m *WorkflowInstQueryService) GetWorkflowInstList(ctx context.Context, req
 
 	return r, total, nil
 }
+
+// GetWorkflowInstGraph 查询流程实例的流程图, 叠加执行路径和节点状态
+func (m *WorkflowInstQueryService) GetWorkflowInstGraph(ctx context.Context,
+	req *dto.GetWorkflowInstGraphDTO) (string, error) {
+	format, err := graph.GetFormat(req.Format)
+	if err != nil {
+		return "", err
+	}
+
+	inst, err := m.workflowInstRepo.Get(&dto.GetWorkflowInstDTO{
+		Namespace: req.Namespace,
+		Operator:  req.Operator,
+		InstID:    req.InstID,
+		DefID:     req.DefID,
+	})
+	if err != nil {
+		log.Errorf("Failed to GetWorkflowInstGraph, caused by %s, req:%s", err, utils.StructToJsonStr(req))
+		return "", err
+	}
+
+	return graph.RenderInst(inst, format)
+}
@@ -0,0 +1,27 @@
+// Package config 提供常用的配置
+package config
+
+import (
+	"context"
+
+	"github.com/fflow-tech/fflow/service/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/provider"
+)
+
+var (
+	holidayCalendarGroupKey = config.NewGroupKey("engine", "HolidayCalendar") // 节假日日历配置
+)
+
+// HolidayCalendarConfig 节假日日历配置
+type HolidayCalendarConfig struct {
+	Source   string   `json:"source,omitempty"`   // 日历来源, 支持 builtin-cn、本地文件路径和 http 地址, 为空时不使用日历
+	Holidays []string `json:"holidays,omitempty"` // 额外的节假日, 格式为 2006-01-02 或者 2006-01-02~2006-01-07
+	Workdays []string `json:"workdays,omitempty"` // 额外的调休工作日, 格式同上
+}
+
+// GetHolidayCalendarConfig 获取默认配置
+func GetHolidayCalendarConfig() *HolidayCalendarConfig {
+	conf := HolidayCalendarConfig{}
+	provider.GetConfigProvider().GetAny(context.Background(), holidayCalendarGroupKey, &conf)
+	return &conf
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"context"
+
+	"github.com/fflow-tech/fflow/service/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/provider"
+)
+
+var (
+	llmPricingGroupKey = config.NewGroupKey("engine", "LLM_PRICING") // LLMPricing 大模型计费配置
+)
+
+// LLMPricingConfig 大模型计费配置
+type LLMPricingConfig struct {
+	Models map[string]LLMModelPrice `json:"models"` // 模型名称到单价的映射
+}
+
+// LLMModelPrice 模型单价, 单位为每百万 token 的价格
+type LLMModelPrice struct {
+	PromptPrice     float64 `json:"promptPrice"`     // 输入 token 单价
+	CompletionPrice float64 `json:"completionPrice"` // 输出 token 单价
+}
+
+// GetLLMPricingConfig 获取默认配置
+func GetLLMPricingConfig() LLMPricingConfig {
+	conf := LLMPricingConfig{
+		Models: map[string]LLMModelPrice{},
+	}
+	provider.GetConfigProvider().GetAny(context.Background(), llmPricingGroupKey, &conf)
+	return conf
+}
+
+// GetCost 计算费用, 未配置单价的模型费用为 0
+func (c LLMPricingConfig) GetCost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := c.Models[model]
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.PromptPrice + float64(completionTokens)*price.CompletionPrice) / 1000000
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"context"
+
+	"github.com/fflow-tech/fflow/service/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/llm"
+	"github.com/fflow-tech/fflow/service/pkg/provider"
+)
+
+var (
+	llmProviderGroupKey = config.NewGroupKey("engine", "LLM_PROVIDERS") // LLMProviders 大模型服务配置
+)
+
+// LLMProviderConfig 大模型服务配置, 节点通过名称引用, 鉴权信息只保存在配置中
+type LLMProviderConfig struct {
+	Providers map[string]llm.ProviderConfig `json:"providers"` // 服务名称到服务配置的映射
+}
+
+// GetLLMProviderConfig 获取默认配置
+func GetLLMProviderConfig() LLMProviderConfig {
+	conf := LLMProviderConfig{
+		Providers: map[string]llm.ProviderConfig{},
+	}
+	provider.GetConfigProvider().GetAny(context.Background(), llmProviderGroupKey, &conf)
+	return conf
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"context"
+
+	"github.com/fflow-tech/fflow/service/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/provider"
+)
+
+var (
+	secretGroupKey = config.NewGroupKey("engine", "SECRET") // Secret 密钥配置
+)
+
+// SecretConfig 密钥配置
+type SecretConfig struct {
+	EncryptKey string `json:"encryptKey"` // 密钥值落库时的加密密钥
+}
+
+// GetSecretConfig 获取默认配置
+func GetSecretConfig() SecretConfig {
+	conf := SecretConfig{}
+	provider.GetConfigProvider().GetAny(context.Background(), secretGroupKey, &conf)
+	return conf
+}
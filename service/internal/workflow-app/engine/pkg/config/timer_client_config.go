@@ -0,0 +1,24 @@
+// Package config 提供常用的配置
+package config
+
+import (
+	"context"
+
+	"github.com/fflow-tech/fflow/service/pkg/config"
+	"github.com/fflow-tech/fflow/service/pkg/provider"
+	"github.com/fflow-tech/fflow/service/pkg/remote"
+)
+
+var (
+	timerClientGroupKey = config.NewGroupKey("engine", "TimerClient") // 定时器服务配置
+)
+
+// GetDefaultTimerClientConfig 获取默认配置, 没有配置定时器服务地址时不启用
+func GetDefaultTimerClientConfig() *remote.DefaultTimerClientConfig {
+	conf := remote.DefaultTimerClientConfig{
+		App:                 "fflow-engine",
+		LoadBalancingPolicy: "round_robin",
+	}
+	provider.GetConfigProvider().GetAny(context.Background(), timerClientGroupKey, &conf)
+	return &conf
+}
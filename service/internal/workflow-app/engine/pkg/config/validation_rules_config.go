@@ -21,6 +21,8 @@ type ValidationRulesConfig struct {
 	MaxNodeInstsForOneFlow    int `json:"maxNodeInstsForOneFlow"`    // 一个流程最大的节点实例数量
 	MaxWorkflowInstsForOneDef int `json:"maxWorkflowInstsForOneDef"` // 一个流程定义最大的流程实例数量
 	MaxInputArgsSize          int `json:"maxInputArgsSize"`          // 启动流程input最大字节数
+	// SkipMCPToolsValidation 保存定义时是否跳过连接 MCP 服务端校验工具是否存在以及参数是否合法, 默认校验
+	SkipMCPToolsValidation bool `json:"skipMCPToolsValidation"`
 }
 
 // GetValidationRulesConfig 获取默认配置
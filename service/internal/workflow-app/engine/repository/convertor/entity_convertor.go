@@ -15,6 +15,7 @@ var (
 	InstConvertor     = &instConvertorImpl{}
 	NodeInstConvertor = &nodeInstConvertorImpl{}
 	TriggerConvertor  = &triggerConvertorImpl{}
+	SecretConvertor   = &secretConvertorImpl{}
 )
 
 type defConvertorImpl struct {
@@ -29,6 +30,9 @@ type nodeInstConvertorImpl struct {
 type triggerConvertorImpl struct {
 }
 
+type secretConvertorImpl struct {
+}
+
 // ConvertPOToEntity 转换成实体
 func (*defConvertorImpl) ConvertPOToEntity(p *po.WorkflowDefPO) (*entity.WorkflowDef, error) {
 	def := &entity.WorkflowDef{}
@@ -63,6 +67,10 @@ func (*instConvertorImpl) ConvertPOToEntity(p *po.WorkflowInstPO,
 	e.InstID = utils.UintToStr(p.ID)
 	e.SchedNodeInsts = filterSchedNodeInsts(e, schedNodeInsts)
 	e.CurNodeInst = getNodeInstByID(schedNodeInsts, curNodeInstID)
+	// 查询了节点实例时实时汇总 token 用量, 否则使用上一次持久化的值
+	if len(schedNodeInsts) > 0 {
+		e.TokenUsage = entity.SumTokenUsage(schedNodeInsts)
+	}
 	if e.Operator == nil {
 		e.Operator = &entity.InstOperator{}
 	}
@@ -74,6 +82,8 @@ func (*instConvertorImpl) ConvertPOToEntity(p *po.WorkflowInstPO,
 }
 
 func filterSchedNodeInsts(inst *entity.WorkflowInst, nodeInsts []*entity.NodeInst) []*entity.NodeInst {
+	// 补偿节点实例不参与调度
+	nodeInsts = entity.FilterOutCompensationNodeInsts(nodeInsts)
 	// 0. 没有重启过, 只需要把所有最新的节点拿出来
 	if inst.BeforeLastRestartMaxNodeInstID == "" {
 		return entity.GetLastNodeInsts(nodeInsts)
@@ -166,3 +176,17 @@ func (*triggerConvertorImpl) ConvertPOToEntity(p *po.TriggerPO) (*entity.Trigger
 	t.Level = entity.TriggerLevel(p.Level)
 	return t, nil
 }
+
+// ConvertPOToEntity 转换, 不包含密钥值
+func (*secretConvertorImpl) ConvertPOToEntity(p *po.SecretPO) *entity.Secret {
+	return &entity.Secret{
+		ID:          utils.UintToStr(p.ID),
+		Namespace:   p.Namespace,
+		Name:        p.Name,
+		Description: p.Description,
+		Creator:     p.Creator,
+		Updater:     p.Updater,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
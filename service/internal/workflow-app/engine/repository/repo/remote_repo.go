@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/constants"
+	"github.com/fflow-tech/fflow/service/pkg/mcp"
 	"github.com/fflow-tech/fflow/service/pkg/remote"
 )
 
@@ -15,18 +16,27 @@ type RemoteRepo struct {
 	cronClient       remote.CronClient
 	chatOpsClient    remote.ChatOpsClient
 	cloudEventClient remote.CloudEventClient
+	rbacClient       *remote.DefaultRbacClient
+	scriptRunner     remote.ScriptRunner
+	timerClient      *remote.DefaultTimerClient
 }
 
 // NewRemoteRepo 实体构造函数
 func NewRemoteRepo(abilityCaller *remote.DefaultAbilityCaller,
 	cronClient *remote.DefaultCronClient,
 	chatOpsClient *remote.DefaultChatOpsClient,
-	cloudEventClient *remote.DefaultCloudEventClient) *RemoteRepo {
+	cloudEventClient *remote.DefaultCloudEventClient,
+	rbacClient *remote.DefaultRbacClient,
+	scriptRunner remote.ScriptRunner,
+	timerClient *remote.DefaultTimerClient) *RemoteRepo {
 	return &RemoteRepo{
 		abilityCaller:    abilityCaller,
 		cronClient:       cronClient,
 		chatOpsClient:    chatOpsClient,
 		cloudEventClient: cloudEventClient,
+		rbacClient:       rbacClient,
+		scriptRunner:     scriptRunner,
+		timerClient:      timerClient,
 	}
 }
 
@@ -40,6 +50,21 @@ func (t *RemoteRepo) CallHTTP(ctx context.Context, req *remote.CallHTTPReqDTO) (
 	return t.abilityCaller.CallHTTP(ctx, req)
 }
 
+// CallMCP 调用 mcp 工具
+func (t *RemoteRepo) CallMCP(ctx context.Context, req *remote.CallMCPReqDTO) (*mcp.CallToolResult, error) {
+	return t.abilityCaller.CallMCP(ctx, req)
+}
+
+// GetMCPTool 获取 mcp 工具定义
+func (t *RemoteRepo) GetMCPTool(ctx context.Context, req *remote.CallMCPReqDTO) (*mcp.Tool, error) {
+	return t.abilityCaller.GetMCPTool(ctx, req)
+}
+
+// RunScript 执行内联脚本
+func (t *RemoteRepo) RunScript(ctx context.Context, req *remote.RunScriptReqDTO) (interface{}, error) {
+	return t.scriptRunner.RunScript(ctx, req)
+}
+
 // SendMsgToUser 发送消息给用户
 func (t *RemoteRepo) SendMsgToUser(userID string, msg string) error {
 	return t.chatOpsClient.SendMsgToUser(userID, msg)
@@ -71,7 +96,17 @@ func (t *RemoteRepo) CancelCronJob(jobName string) error {
 	return t.cronClient.CancelCronJob(&remote.CancelCronJobReqDTO{Name: jobName})
 }
 
+// AddDelayJob 添加延时任务
+func (t *RemoteRepo) AddDelayJob(ctx context.Context, req *remote.AddDelayJobReqDTO) error {
+	return t.timerClient.AddDelayJob(ctx, req)
+}
+
 // SendCloudEvent 发送事件
 func (t *RemoteRepo) SendCloudEvent(ctx context.Context, req *remote.SendCloudEventDTO) error {
 	return t.cloudEventClient.Send(ctx, req)
 }
+
+// GetRolesForUserInDomain 获取用户在指定域下的角色
+func (t *RemoteRepo) GetRolesForUserInDomain(ctx context.Context, domain, user string) ([]string, error) {
+	return t.rbacClient.GetRolesForUserInDomain(ctx, domain, user)
+}
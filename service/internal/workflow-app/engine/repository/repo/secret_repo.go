@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/storage"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/dao/storage/sql"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/pkg/config"
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/repository/convertor"
+	"github.com/fflow-tech/fflow/service/pkg/constants"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
+)
+
+// SecretRepo 密钥仓储层, 密钥值加密后落库
+type SecretRepo struct {
+	secretDAO storage.SecretDAO
+}
+
+// NewSecretRepo 初始化密钥仓储层
+func NewSecretRepo(d *sql.SecretDAO) *SecretRepo {
+	return &SecretRepo{secretDAO: d}
+}
+
+// Create 创建
+func (r *SecretRepo) Create(d *dto.CreateSecretDTO) (string, error) {
+	value, err := encryptSecret(d.Value)
+	if err != nil {
+		return "", err
+	}
+
+	req := *d
+	req.Value = value
+	secret, err := r.secretDAO.Create(&req)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.UintToStr(secret.ID), nil
+}
+
+// Update 更新
+func (r *SecretRepo) Update(d *dto.UpdateSecretDTO) error {
+	req := *d
+	if d.Value != "" {
+		value, err := encryptSecret(d.Value)
+		if err != nil {
+			return err
+		}
+		req.Value = value
+	}
+
+	return r.secretDAO.Update(&req)
+}
+
+// Delete 删除
+func (r *SecretRepo) Delete(d *dto.DeleteSecretDTO) error {
+	return r.secretDAO.Delete(d)
+}
+
+// Get 获取, 返回解密后的密钥值
+func (r *SecretRepo) Get(d *dto.GetSecretDTO) (*entity.Secret, error) {
+	p, err := r.secretDAO.Get(d)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := convertor.SecretConvertor.ConvertPOToEntity(p)
+	secret.Value, err = utils.AESDecrypt(config.GetSecretConfig().EncryptKey, p.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret [%s/%s]: %w", d.Namespace, d.Name, err)
+	}
+
+	return secret, nil
+}
+
+// Count 统计
+func (r *SecretRepo) Count(d *dto.PageQuerySecretDTO) (int64, error) {
+	return r.secretDAO.Count(d)
+}
+
+// PageQuery 分页查询, 不返回密钥值
+func (r *SecretRepo) PageQuery(d *dto.PageQuerySecretDTO) ([]*entity.Secret, error) {
+	if d.PageQuery == nil {
+		d.PageQuery = constants.NewDefaultPageQuery()
+	}
+
+	ps, err := r.secretDAO.PageQuery(d)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]*entity.Secret, 0, len(ps))
+	for _, p := range ps {
+		secrets = append(secrets, convertor.SecretConvertor.ConvertPOToEntity(p))
+	}
+
+	return secrets, nil
+}
+
+func encryptSecret(value string) (string, error) {
+	encrypted, err := utils.AESEncrypt(config.GetSecretConfig().EncryptKey, value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	return encrypted, nil
+}
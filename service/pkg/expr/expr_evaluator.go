@@ -22,6 +22,22 @@ type Evaluator interface {
 	EvaluateMap(ctx map[string]interface{}, exprMap map[string]interface{}) (map[string]interface{}, error)
 }
 
+// EvalError 表达式计算错误
+type EvalError struct {
+	Expr string
+	Err  error
+}
+
+// Error 实现 error 接口, 保持原始的错误信息
+func (e *EvalError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 返回原始错误
+func (e *EvalError) Unwrap() error {
+	return e.Err
+}
+
 // DefaultEvaluator 计算器
 type DefaultEvaluator struct {
 }
@@ -60,7 +76,11 @@ func (c *DefaultEvaluator) Evaluate(ctx map[string]interface{}, expr string) (in
 		return false, err
 	}
 
-	return gval.Evaluate(realExpr, ctx, jsonpath.Language(), curTimeFormatFunc, sprintfFunc)
+	result, err := gval.Evaluate(realExpr, ctx, jsonpath.Language(), curTimeFormatFunc, sprintfFunc)
+	if err != nil {
+		return nil, &EvalError{Expr: expr, Err: err}
+	}
+	return result, nil
 }
 
 // Match 是否匹配
@@ -72,7 +92,7 @@ func (c *DefaultEvaluator) Match(ctx map[string]interface{}, expr string) (bool,
 
 	result, err := gval.Evaluate(realExpr, ctx, jsonpath.Language())
 	if err != nil {
-		return false, err
+		return false, &EvalError{Expr: expr, Err: err}
 	}
 
 	return cast.ToBool(result), nil
@@ -131,7 +151,7 @@ func (c *DefaultEvaluator) evaluateInterface(ctx map[string]interface{},
 // getRealExpr 获取实际的表达式
 func (c *DefaultEvaluator) getRealExpr(expr string) (string, error) {
 	if !c.IsExpression(expr) {
-		return "", fmt.Errorf("illegal expr=[%s]", expr)
+		return "", &EvalError{Expr: expr, Err: fmt.Errorf("illegal expr=[%s]", expr)}
 	}
 
 	return expr[2 : len(expr)-1], nil
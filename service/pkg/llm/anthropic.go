@@ -0,0 +1,333 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultAnthropicBaseURL   = "https://api.anthropic.com/v1"
+	defaultAnthropicVersion   = "2023-06-01"
+	defaultAnthropicMaxTokens = 4096 // Anthropic 接口必须指定 max_tokens
+)
+
+// anthropicProvider Anthropic Messages 接口, 负责和 OpenAI 格式互相转换
+type anthropicProvider struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// newAnthropicProvider 创建 Anthropic 服务, 默认使用 x-api-key 鉴权
+func newAnthropicProvider(conf ProviderConfig) (Provider, error) {
+	if conf.BaseURL == "" {
+		conf.BaseURL = defaultAnthropicBaseURL
+	}
+	headers := map[string]string{"anthropic-version": defaultAnthropicVersion}
+	for k, v := range conf.Headers {
+		headers[k] = v
+	}
+	conf.Headers = headers
+	return &anthropicProvider{
+		baseURL:    strings.TrimSuffix(conf.BaseURL, "/"),
+		httpClient: newHTTPClient(conf, "x-api-key", ""),
+	}, nil
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float32            `json:"temperature,omitempty"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+type anthropicContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema interface{} `json:"input_schema"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	ID         string                  `json:"id"`
+	Model      string                  `json:"model"`
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      anthropicUsage          `json:"usage"`
+}
+
+// anthropicStreamEvent 流式返回的事件, 只解析用到的字段
+type anthropicStreamEvent struct {
+	Type    string            `json:"type"`
+	Message anthropicResponse `json:"message"`
+	Delta   struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// CreateChatCompletion 发送对话请求
+func (p *anthropicProvider) CreateChatCompletion(ctx context.Context,
+	req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	body, err := p.post(ctx, req, false)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	defer body.Close()
+
+	rsp := anthropicResponse{}
+	if err := json.NewDecoder(body).Decode(&rsp); err != nil {
+		return openai.ChatCompletionResponse{}, fmt.Errorf("failed to decode anthropic response: %w", err)
+	}
+	return convertAnthropicResponse(rsp), nil
+}
+
+// CreateChatCompletionStream 以流式方式发送对话请求
+func (p *anthropicProvider) CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest,
+	onDelta func(delta string)) (openai.ChatCompletionResponse, error) {
+	body, err := p.post(ctx, req, true)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	defer body.Close()
+
+	rsp := anthropicResponse{}
+	content := strings.Builder{}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		event := anthropicStreamEvent{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			return openai.ChatCompletionResponse{}, fmt.Errorf("failed to decode anthropic stream event: %w", err)
+		}
+		switch event.Type {
+		case "message_start":
+			rsp.ID, rsp.Model = event.Message.ID, event.Message.Model
+			rsp.Usage.InputTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" {
+				continue
+			}
+			content.WriteString(event.Delta.Text)
+			if onDelta != nil {
+				onDelta(event.Delta.Text)
+			}
+		case "message_delta":
+			rsp.StopReason = event.Delta.StopReason
+			rsp.Usage.OutputTokens = event.Usage.OutputTokens
+		case "error":
+			return openai.ChatCompletionResponse{}, fmt.Errorf("anthropic stream error: %s: %s",
+				event.Error.Type, event.Error.Message)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return openai.ChatCompletionResponse{}, fmt.Errorf("failed to receive stream: %w", err)
+	}
+
+	rsp.Content = []anthropicContentBlock{{Type: "text", Text: content.String()}}
+	return convertAnthropicResponse(rsp), nil
+}
+
+// post 发送请求, 返回成功时的 body
+func (p *anthropicProvider) post(ctx context.Context, req openai.ChatCompletionRequest,
+	stream bool) (io.ReadCloser, error) {
+	anthropicReq, err := convertToAnthropicRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	anthropicReq.Stream = stream
+	reqBody, err := json.Marshal(anthropicReq)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/messages", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpRsp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if httpRsp.StatusCode < http.StatusOK || httpRsp.StatusCode >= http.StatusMultipleChoices {
+		defer httpRsp.Body.Close()
+		errBody, _ := io.ReadAll(httpRsp.Body)
+		return nil, fmt.Errorf("anthropic api error, status code: %d, body: %s", httpRsp.StatusCode, errBody)
+	}
+	return httpRsp.Body, nil
+}
+
+// convertToAnthropicRequest 将 OpenAI 格式的请求转换为 Anthropic 格式
+func convertToAnthropicRequest(req openai.ChatCompletionRequest) (*anthropicRequest, error) {
+	r := &anthropicRequest{Model: req.Model, MaxTokens: req.MaxTokens, Temperature: req.Temperature}
+	if r.MaxTokens <= 0 {
+		r.MaxTokens = defaultAnthropicMaxTokens
+	}
+
+	systems := []string{}
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case openai.ChatMessageRoleSystem:
+			systems = append(systems, msg.Content)
+		case openai.ChatMessageRoleTool:
+			// 工具结果以 user 消息返回, 连续的工具结果合并到同一条消息中
+			block := anthropicContentBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content}
+			if last := len(r.Messages) - 1; last >= 0 && isToolResultMessage(r.Messages[last]) {
+				r.Messages[last].Content = append(r.Messages[last].Content, block)
+				continue
+			}
+			r.Messages = append(r.Messages, anthropicMessage{
+				Role: openai.ChatMessageRoleUser, Content: []anthropicContentBlock{block}})
+		default:
+			message, err := convertToAnthropicMessage(msg)
+			if err != nil {
+				return nil, err
+			}
+			r.Messages = append(r.Messages, message)
+		}
+	}
+
+	// Anthropic 不支持 response_format, 通过系统提示词约束输出格式
+	if instruction := buildResponseFormatInstruction(req.ResponseFormat); instruction != "" {
+		systems = append(systems, instruction)
+	}
+	r.System = strings.Join(systems, "\n\n")
+
+	for _, tool := range req.Tools {
+		if tool.Function == nil {
+			continue
+		}
+		r.Tools = append(r.Tools, anthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: tool.Function.Parameters,
+		})
+	}
+	return r, nil
+}
+
+func isToolResultMessage(message anthropicMessage) bool {
+	return message.Role == openai.ChatMessageRoleUser && len(message.Content) > 0 &&
+		message.Content[0].Type == "tool_result"
+}
+
+func convertToAnthropicMessage(msg openai.ChatCompletionMessage) (anthropicMessage, error) {
+	message := anthropicMessage{Role: msg.Role, Content: []anthropicContentBlock{}}
+	if msg.Content != "" {
+		message.Content = append(message.Content, anthropicContentBlock{Type: "text", Text: msg.Content})
+	}
+	for _, toolCall := range msg.ToolCalls {
+		input := json.RawMessage(toolCall.Function.Arguments)
+		if len(input) == 0 {
+			input = json.RawMessage("{}")
+		}
+		if !json.Valid(input) {
+			return anthropicMessage{}, fmt.Errorf("illegal arguments of tool call [%s]", toolCall.ID)
+		}
+		message.Content = append(message.Content, anthropicContentBlock{
+			Type: "tool_use", ID: toolCall.ID, Name: toolCall.Function.Name, Input: input})
+	}
+	return message, nil
+}
+
+func buildResponseFormatInstruction(format *openai.ChatCompletionResponseFormat) string {
+	if format == nil {
+		return ""
+	}
+	switch format.Type {
+	case openai.ChatCompletionResponseFormatTypeJSONObject:
+		return "Respond only with a valid JSON object."
+	case openai.ChatCompletionResponseFormatTypeJSONSchema:
+		if format.JSONSchema == nil || format.JSONSchema.Schema == nil {
+			return "Respond only with a valid JSON object."
+		}
+		schema, err := format.JSONSchema.Schema.MarshalJSON()
+		if err != nil {
+			return "Respond only with a valid JSON object."
+		}
+		return fmt.Sprintf("Respond only with a JSON object that matches this JSON schema: %s", schema)
+	}
+	return ""
+}
+
+// convertAnthropicResponse 将 Anthropic 格式的返回转换为 OpenAI 格式
+func convertAnthropicResponse(rsp anthropicResponse) openai.ChatCompletionResponse {
+	message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	texts := []string{}
+	for _, block := range rsp.Content {
+		switch block.Type {
+		case "text":
+			texts = append(texts, block.Text)
+		case "tool_use":
+			message.ToolCalls = append(message.ToolCalls, openai.ToolCall{
+				ID:       block.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: block.Name, Arguments: string(block.Input)},
+			})
+		}
+	}
+	message.Content = strings.Join(texts, "")
+
+	return openai.ChatCompletionResponse{
+		ID:      rsp.ID,
+		Model:   rsp.Model,
+		Choices: []openai.ChatCompletionChoice{{Message: message, FinishReason: convertStopReason(rsp.StopReason)}},
+		Usage: openai.Usage{
+			PromptTokens:     rsp.Usage.InputTokens,
+			CompletionTokens: rsp.Usage.OutputTokens,
+			TotalTokens:      rsp.Usage.InputTokens + rsp.Usage.OutputTokens,
+		},
+	}
+}
+
+func convertStopReason(stopReason string) openai.FinishReason {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return openai.FinishReasonStop
+	case "max_tokens":
+		return openai.FinishReasonLength
+	case "tool_use":
+		return openai.FinishReasonToolCalls
+	}
+	return openai.FinishReasonNull
+}
@@ -0,0 +1,125 @@
+// Package llm 大模型服务的统一抽象, 以 OpenAI 的请求和返回结构作为通用格式, 由不同的 Provider 适配各家接口
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ProviderType 大模型服务类型
+type ProviderType string
+
+const (
+	OpenAI    ProviderType = "openai"    // OpenAI 及兼容 OpenAI 接口的服务
+	Anthropic ProviderType = "anthropic" // Anthropic Messages 接口
+	Ollama    ProviderType = "ollama"    // 本地 Ollama 服务
+)
+
+const (
+	defaultTimeout = 60 * time.Second // 默认的请求超时时间
+)
+
+// ProviderConfig 大模型服务配置
+type ProviderConfig struct {
+	Type         ProviderType      `json:"type"`                   // 服务类型, 为空时为 openai
+	BaseURL      string            `json:"baseURL,omitempty"`      // 接口地址, 为空时使用各类型的默认地址
+	APIKey       string            `json:"apiKey,omitempty"`       // 鉴权使用的 Key
+	AuthHeader   string            `json:"authHeader,omitempty"`   // 鉴权请求头, 为空时使用各类型的默认请求头
+	AuthScheme   string            `json:"authScheme,omitempty"`   // 鉴权请求头的值前缀, 如 Bearer
+	Headers      map[string]string `json:"headers,omitempty"`      // 额外的请求头
+	DefaultModel string            `json:"defaultModel,omitempty"` // 节点未指定模型时使用的模型
+	Timeout      string            `json:"timeout,omitempty"`      // 单次请求超时时间, 如 60s
+}
+
+// GetTimeout 获取请求超时时间
+func (c ProviderConfig) GetTimeout() (time.Duration, error) {
+	if c.Timeout == "" {
+		return defaultTimeout, nil
+	}
+	timeout, err := time.ParseDuration(c.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("illegal timeout [%s] of llm provider: %w", c.Timeout, err)
+	}
+	return timeout, nil
+}
+
+// Provider 大模型服务
+type Provider interface {
+	// CreateChatCompletion 发送对话请求
+	CreateChatCompletion(ctx context.Context,
+		req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+	// CreateChatCompletionStream 以流式方式发送对话请求, 每收到一段增量内容回调一次 onDelta, 结束后返回完整的结果
+	CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest,
+		onDelta func(delta string)) (openai.ChatCompletionResponse, error)
+}
+
+// Builder 根据配置创建大模型服务
+type Builder func(conf ProviderConfig) (Provider, error)
+
+var (
+	buildersMu sync.RWMutex
+	builders   = map[ProviderType]Builder{
+		OpenAI:    newOpenAIProvider,
+		Ollama:    newOllamaProvider,
+		Anthropic: newAnthropicProvider,
+	}
+)
+
+// Register 注册大模型服务类型, 已存在时覆盖
+func Register(t ProviderType, builder Builder) {
+	buildersMu.Lock()
+	defer buildersMu.Unlock()
+	builders[t] = builder
+}
+
+// NewProvider 根据配置创建大模型服务
+func NewProvider(conf ProviderConfig) (Provider, error) {
+	if conf.Type == "" {
+		conf.Type = OpenAI
+	}
+
+	buildersMu.RLock()
+	builder, ok := builders[ProviderType(strings.ToLower(string(conf.Type)))]
+	buildersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("llm provider type [%s] not supported", conf.Type)
+	}
+	return builder(conf)
+}
+
+// headerTransport 为请求统一加上鉴权和额外的请求头
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+// RoundTrip 发送请求
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// newHTTPClient 创建带有鉴权请求头的 http client, defaultAuthHeader 和 defaultAuthScheme 为配置为空时使用的值
+func newHTTPClient(conf ProviderConfig, defaultAuthHeader, defaultAuthScheme string) *http.Client {
+	headers := map[string]string{}
+	for k, v := range conf.Headers {
+		headers[k] = v
+	}
+	authHeader, authScheme := conf.AuthHeader, conf.AuthScheme
+	if authHeader == "" {
+		authHeader, authScheme = defaultAuthHeader, defaultAuthScheme
+	}
+	if conf.APIKey != "" && authHeader != "" {
+		headers[authHeader] = strings.TrimSpace(authScheme + " " + conf.APIKey)
+	}
+	return &http.Client{Transport: &headerTransport{base: http.DefaultTransport, headers: headers}}
+}
@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    ProviderConfig
+		wantErr bool
+	}{
+		{"类型为空时使用 openai", ProviderConfig{}, false},
+		{"openai 类型", ProviderConfig{Type: OpenAI}, false},
+		{"大写的 anthropic 类型", ProviderConfig{Type: "ANTHROPIC"}, false},
+		{"ollama 类型", ProviderConfig{Type: Ollama}, false},
+		{"不支持的类型", ProviderConfig{Type: "unknown"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewProvider(tt.conf)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		conf       ProviderConfig
+		wantHeader string
+		wantValue  string
+	}{
+		{"默认使用 Bearer 鉴权", ProviderConfig{Type: OpenAI, APIKey: "key"}, "Authorization", "Bearer key"},
+		{"自定义鉴权请求头", ProviderConfig{Type: OpenAI, APIKey: "key", AuthHeader: "api-key"}, "api-key", "key"},
+		{"ollama 默认不鉴权", ProviderConfig{Type: Ollama, APIKey: "key"}, "Authorization", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.wantValue, r.Header.Get(tt.wantHeader))
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"hi"}}],`+
+					`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+			}))
+			defer server.Close()
+
+			tt.conf.BaseURL = server.URL
+			provider, err := NewProvider(tt.conf)
+			assert.Nil(t, err)
+			rsp, err := provider.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{Model: "m"})
+			assert.Nil(t, err)
+			assert.Equal(t, "hi", rsp.Choices[0].Message.Content)
+			assert.Equal(t, 2, rsp.Usage.TotalTokens)
+		})
+	}
+}
+
+func TestAnthropicProvider_CreateChatCompletion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages", r.URL.Path)
+		assert.Equal(t, "key", r.Header.Get("x-api-key"))
+		assert.Equal(t, defaultAnthropicVersion, r.Header.Get("anthropic-version"))
+
+		req := anthropicRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, "be helpful", req.System)
+		assert.Equal(t, defaultAnthropicMaxTokens, req.MaxTokens)
+		assert.Equal(t, 3, len(req.Messages))
+		assert.Equal(t, "tool_use", req.Messages[1].Content[0].Type)
+		assert.Equal(t, 2, len(req.Messages[2].Content))
+		assert.Equal(t, "get_weather", req.Tools[0].Name)
+
+		fmt.Fprint(w, `{"id":"msg_1","model":"claude","stop_reason":"tool_use",`+
+			`"content":[{"type":"text","text":"checking"},`+
+			`{"type":"tool_use","id":"call_3","name":"get_weather","input":{"city":"sz"}}],`+
+			`"usage":{"input_tokens":10,"output_tokens":5}}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(ProviderConfig{Type: Anthropic, BaseURL: server.URL, APIKey: "key"})
+	assert.Nil(t, err)
+	rsp, err := provider.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model: "claude",
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "be helpful"},
+			{Role: openai.ChatMessageRoleUser, Content: "weather?"},
+			{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{
+				{ID: "call_1", Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city":"bj"}`}},
+				{ID: "call_2", Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city":"sh"}`}},
+			}},
+			{Role: openai.ChatMessageRoleTool, ToolCallID: "call_1", Content: "sunny"},
+			{Role: openai.ChatMessageRoleTool, ToolCallID: "call_2", Content: "rainy"},
+		},
+		Tools: []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{
+			Name: "get_weather", Parameters: map[string]interface{}{"type": "object"}}}},
+	})
+	assert.Nil(t, err)
+
+	message := rsp.Choices[0].Message
+	assert.Equal(t, "checking", message.Content)
+	assert.Equal(t, openai.FinishReasonToolCalls, rsp.Choices[0].FinishReason)
+	assert.Equal(t, 1, len(message.ToolCalls))
+	assert.Equal(t, `{"city":"sz"}`, message.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, rsp.Usage)
+}
+
+func TestAnthropicProvider_CreateChatCompletionStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{"id":"msg_1","model":"claude","usage":{"input_tokens":3}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":", world"}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, event := range events {
+			fmt.Fprintf(w, "event: x\ndata: %s\n\n", event)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(ProviderConfig{Type: Anthropic, BaseURL: server.URL})
+	assert.Nil(t, err)
+	deltas := []string{}
+	rsp, err := provider.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{Model: "claude"},
+		func(delta string) { deltas = append(deltas, delta) })
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Hello", ", world"}, deltas)
+	assert.Equal(t, "Hello, world", rsp.Choices[0].Message.Content)
+	assert.Equal(t, openai.FinishReasonStop, rsp.Choices[0].FinishReason)
+	assert.Equal(t, 5, rsp.Usage.TotalTokens)
+}
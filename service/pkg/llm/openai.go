@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOllamaBaseURL = "http://localhost:11434/v1"
+)
+
+// openAIProvider 兼容 OpenAI 接口的服务
+type openAIProvider struct {
+	client *openai.Client
+}
+
+// newOpenAIProvider 创建 OpenAI 服务, 默认使用 Authorization: Bearer 鉴权
+func newOpenAIProvider(conf ProviderConfig) (Provider, error) {
+	if conf.BaseURL == "" {
+		conf.BaseURL = defaultOpenAIBaseURL
+	}
+	return newOpenAICompatibleProvider(conf, "Authorization", "Bearer"), nil
+}
+
+// newOllamaProvider 创建 Ollama 服务, 使用 Ollama 兼容 OpenAI 的接口, 默认不需要鉴权
+func newOllamaProvider(conf ProviderConfig) (Provider, error) {
+	if conf.BaseURL == "" {
+		conf.BaseURL = defaultOllamaBaseURL
+	}
+	return newOpenAICompatibleProvider(conf, "", ""), nil
+}
+
+func newOpenAICompatibleProvider(conf ProviderConfig, defaultAuthHeader, defaultAuthScheme string) *openAIProvider {
+	// 鉴权请求头由 http client 统一设置, 这里不再传入 token
+	clientConfig := openai.DefaultConfig("")
+	clientConfig.BaseURL = strings.TrimSuffix(conf.BaseURL, "/")
+	clientConfig.HTTPClient = newHTTPClient(conf, defaultAuthHeader, defaultAuthScheme)
+	return &openAIProvider{client: openai.NewClientWithConfig(clientConfig)}
+}
+
+// CreateChatCompletion 发送对话请求
+func (p *openAIProvider) CreateChatCompletion(ctx context.Context,
+	req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	return p.client.CreateChatCompletion(ctx, req)
+}
+
+// CreateChatCompletionStream 以流式方式发送对话请求
+func (p *openAIProvider) CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest,
+	onDelta func(delta string)) (openai.ChatCompletionResponse, error) {
+	req.Stream = true
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := p.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	defer stream.Close()
+
+	rsp := openai.ChatCompletionResponse{Model: req.Model}
+	message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+	finishReason := openai.FinishReasonNull
+	content := strings.Builder{}
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return openai.ChatCompletionResponse{}, fmt.Errorf("failed to receive stream: %w", err)
+		}
+		rsp.ID, rsp.Model = chunk.ID, chunk.Model
+		if chunk.Usage != nil {
+			rsp.Usage = *chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		delta := chunk.Choices[0].Delta
+		if delta.Role != "" {
+			message.Role = delta.Role
+		}
+		if chunk.Choices[0].FinishReason != "" {
+			finishReason = chunk.Choices[0].FinishReason
+		}
+		content.WriteString(delta.Content)
+		if delta.Content != "" && onDelta != nil {
+			onDelta(delta.Content)
+		}
+	}
+
+	message.Content = content.String()
+	rsp.Choices = []openai.ChatCompletionChoice{{Message: message, FinishReason: finishReason}}
+	return rsp, nil
+}
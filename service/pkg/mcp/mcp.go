@@ -0,0 +1,553 @@
+// Package mcp 提供 Model Context Protocol 客户端实现, 支持 Streamable HTTP 和 SSE 两种传输方式
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	ProtocolVersion       = "2025-03-26"           // 客户端支持的协议版本
+	jsonRPCVersion        = "2.0"                  // JSON-RPC 版本
+	sessionIDHeader       = "Mcp-Session-Id"       // 会话 ID 请求头
+	protocolVersionHeader = "MCP-Protocol-Version" // 协议版本请求头
+	clientName            = "fflow"                // 客户端名称
+	clientVersion         = "1.0.0"                // 客户端版本
+	maxErrBodySize        = 1024                   // 错误时读取响应体的最大长度
+)
+
+// Transport 传输方式
+type Transport string
+
+const (
+	StreamableHTTP Transport = "STREAMABLE_HTTP" // Streamable HTTP 传输
+	SSE            Transport = "SSE"             // HTTP+SSE 传输
+)
+
+// Tool 工具定义
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema,omitempty"`
+}
+
+// CallToolResult 工具调用结果
+type CallToolResult struct {
+	Content           []map[string]interface{} `json:"content"`
+	StructuredContent map[string]interface{}   `json:"structuredContent,omitempty"`
+	IsError           bool                     `json:"isError,omitempty"`
+}
+
+// Text 拼接结果中所有文本类型的内容块
+func (r *CallToolResult) Text() string {
+	texts := []string{}
+	for _, block := range r.Content {
+		if block["type"] != "text" {
+			continue
+		}
+		if text, ok := block["text"].(string); ok {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// InitializeResult 初始化结果
+type InitializeResult struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities,omitempty"`
+	ServerInfo      map[string]interface{} `json:"serverInfo,omitempty"`
+	Instructions    string                 `json:"instructions,omitempty"`
+}
+
+// RPCError JSON-RPC 错误
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error 实现 error 接口
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp rpc error, code=%d, message=%s", e.Code, e.Message)
+}
+
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      *int64      `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// Client MCP 客户端, 一个客户端对应一个会话
+type Client struct {
+	url        string
+	transport  Transport
+	headers    map[string]string
+	httpClient *http.Client
+	nextID     int64
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+
+	// 以下字段仅在 SSE 传输方式下使用
+	endpoint  string
+	pending   map[string]chan *response
+	cancel    context.CancelFunc
+	done      chan struct{}
+	streamErr error
+}
+
+// Option 客户端选项
+type Option func(*Client)
+
+// WithTransport 指定传输方式, 为空时根据地址自动判断
+func WithTransport(t Transport) Option {
+	return func(c *Client) {
+		if t != "" {
+			c.transport = Transport(strings.ToUpper(string(t)))
+		}
+	}
+}
+
+// WithHeaders 设置额外的请求头
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Client) {
+		c.headers = headers
+	}
+}
+
+// WithHTTPClient 设置 HTTP 客户端
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewClient 新建客户端
+func NewClient(serverURL string, opts ...Option) *Client {
+	c := &Client{
+		url:        serverURL,
+		transport:  guessTransport(serverURL),
+		httpClient: &http.Client{},
+		pending:    map[string]chan *response{},
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// guessTransport 约定以 /sse 结尾的地址使用 SSE 传输, 其余使用 Streamable HTTP
+func guessTransport(serverURL string) Transport {
+	u, err := url.Parse(serverURL)
+	if err == nil && strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), "/sse") {
+		return SSE
+	}
+	return StreamableHTTP
+}
+
+// Initialize 初始化会话, 调用其他方法之前必须先调用
+func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
+	if c.transport == SSE {
+		if err := c.connectSSE(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	params := map[string]interface{}{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]interface{}{"name": clientName, "version": clientVersion},
+	}
+	result := &InitializeResult{}
+	if err := c.call(ctx, "initialize", params, result); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.protocolVersion = result.ProtocolVersion
+	c.mu.Unlock()
+
+	if err := c.notify(ctx, "notifications/initialized", nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListTools 获取服务端提供的所有工具
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	tools := []Tool{}
+	cursor := ""
+	for {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		result := &struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor,omitempty"`
+		}{}
+		if err := c.call(ctx, "tools/list", params, result); err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// CallTool 调用工具
+func (c *Client) CallTool(ctx context.Context, name string,
+	arguments map[string]interface{}) (*CallToolResult, error) {
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	params := map[string]interface{}{"name": name, "arguments": arguments}
+	result := &CallToolResult{}
+	if err := c.call(ctx, "tools/call", params, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Close 关闭会话
+func (c *Client) Close() error {
+	if c.transport == SSE {
+		if c.cancel != nil {
+			c.cancel()
+		}
+		return nil
+	}
+
+	sessionID := c.getSessionID()
+	if sessionID == "" {
+		return nil
+	}
+	httpReq, err := http.NewRequest(http.MethodDelete, c.url, nil)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(httpReq)
+	httpRsp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	return httpRsp.Body.Close()
+}
+
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := atomic.AddInt64(&c.nextID, 1)
+	req := &request{JSONRPC: jsonRPCVersion, ID: &id, Method: method, Params: params}
+
+	var rsp *response
+	var err error
+	if c.transport == SSE {
+		rsp, err = c.callBySSE(ctx, req)
+	} else {
+		rsp, err = c.callByStreamableHTTP(ctx, req)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to call mcp method %s: %w", method, err)
+	}
+	if rsp.Error != nil {
+		return rsp.Error
+	}
+	if result == nil || len(rsp.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(rsp.Result, result)
+}
+
+func (c *Client) notify(ctx context.Context, method string, params interface{}) error {
+	req := &request{JSONRPC: jsonRPCVersion, Method: method, Params: params}
+	postURL := c.url
+	if c.transport == SSE {
+		postURL = c.endpoint
+	}
+	httpRsp, err := c.post(ctx, postURL, req)
+	if err != nil {
+		return fmt.Errorf("failed to send mcp notification %s: %w", method, err)
+	}
+	defer httpRsp.Body.Close()
+	return checkStatus(postURL, httpRsp)
+}
+
+func (c *Client) callByStreamableHTTP(ctx context.Context, req *request) (*response, error) {
+	httpRsp, err := c.post(ctx, c.url, req)
+	if err != nil {
+		return nil, err
+	}
+	defer httpRsp.Body.Close()
+	if err := checkStatus(c.url, httpRsp); err != nil {
+		return nil, err
+	}
+	if sessionID := httpRsp.Header.Get(sessionIDHeader); sessionID != "" {
+		c.mu.Lock()
+		c.sessionID = sessionID
+		c.mu.Unlock()
+	}
+
+	idKey := strconv.FormatInt(*req.ID, 10)
+	if isEventStream(httpRsp) {
+		return readResponseFromStream(bufio.NewReader(httpRsp.Body), idKey)
+	}
+
+	rsp := &response{}
+	if err := json.NewDecoder(httpRsp.Body).Decode(rsp); err != nil {
+		return nil, fmt.Errorf("failed to decode mcp response: %w", err)
+	}
+	return rsp, nil
+}
+
+// readResponseFromStream 从 SSE 流中读取到指定 ID 的响应为止
+func readResponseFromStream(reader *bufio.Reader, idKey string) (*response, error) {
+	for {
+		ev, err := readEvent(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read mcp response stream: %w", err)
+		}
+		if ev.data == "" {
+			continue
+		}
+		rsp := &response{}
+		if err := json.Unmarshal([]byte(ev.data), rsp); err != nil {
+			return nil, fmt.Errorf("failed to decode mcp response: %w", err)
+		}
+		if string(rsp.ID) == idKey {
+			return rsp, nil
+		}
+	}
+}
+
+// connectSSE 建立 SSE 长连接, 并等待服务端下发消息发送地址
+func (c *Client) connectSSE(ctx context.Context) error {
+	streamCtx, cancel := context.WithCancel(context.Background())
+	httpReq, err := http.NewRequestWithContext(streamCtx, http.MethodGet, c.url, nil)
+	if err != nil {
+		cancel()
+		return err
+	}
+	c.setHeaders(httpReq)
+	httpReq.Header.Set("Accept", "text/event-stream")
+	httpRsp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to connect mcp sse %s: %w", c.url, err)
+	}
+	if err := checkStatus(c.url, httpRsp); err != nil {
+		httpRsp.Body.Close()
+		cancel()
+		return err
+	}
+
+	c.cancel = cancel
+	endpointCh := make(chan string, 1)
+	go c.readSSEStream(httpRsp.Body, endpointCh)
+
+	select {
+	case endpoint := <-endpointCh:
+		base, err := url.Parse(c.url)
+		if err != nil {
+			return err
+		}
+		ref, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("illegal mcp sse endpoint %s: %w", endpoint, err)
+		}
+		c.endpoint = base.ResolveReference(ref).String()
+		return nil
+	case <-c.done:
+		return c.getStreamErr()
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	}
+}
+
+// readSSEStream 持续读取 SSE 流, 将响应分发给等待中的请求
+func (c *Client) readSSEStream(body io.ReadCloser, endpointCh chan string) {
+	defer close(c.done)
+	defer body.Close()
+
+	reader := bufio.NewReader(body)
+	for {
+		ev, err := readEvent(reader)
+		if err != nil {
+			c.mu.Lock()
+			c.streamErr = fmt.Errorf("mcp sse stream closed: %w", err)
+			c.mu.Unlock()
+			return
+		}
+		switch ev.name {
+		case "endpoint":
+			select {
+			case endpointCh <- ev.data:
+			default:
+			}
+		case "", "message":
+			c.dispatch(ev.data)
+		}
+	}
+}
+
+func (c *Client) dispatch(data string) {
+	rsp := &response{}
+	if err := json.Unmarshal([]byte(data), rsp); err != nil || len(rsp.ID) == 0 || rsp.Method != "" {
+		// 忽略服务端主动发起的请求和通知
+		return
+	}
+	c.mu.Lock()
+	ch, ok := c.pending[string(rsp.ID)]
+	c.mu.Unlock()
+	if ok {
+		ch <- rsp
+	}
+}
+
+func (c *Client) callBySSE(ctx context.Context, req *request) (*response, error) {
+	idKey := strconv.FormatInt(*req.ID, 10)
+	ch := make(chan *response, 1)
+	c.mu.Lock()
+	c.pending[idKey] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, idKey)
+		c.mu.Unlock()
+	}()
+
+	httpRsp, err := c.post(ctx, c.endpoint, req)
+	if err != nil {
+		return nil, err
+	}
+	httpRsp.Body.Close()
+	if err := checkStatus(c.endpoint, httpRsp); err != nil {
+		return nil, err
+	}
+
+	select {
+	case rsp := <-ch:
+		return rsp, nil
+	case <-c.done:
+		return nil, c.getStreamErr()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *Client) post(ctx context.Context, postURL string, req *request) (*http.Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, postURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	c.setHeaders(httpReq)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	return c.httpClient.Do(httpReq)
+}
+
+func (c *Client) setHeaders(httpReq *http.Request) {
+	for k, v := range c.headers {
+		httpReq.Header.Set(k, v)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sessionID != "" {
+		httpReq.Header.Set(sessionIDHeader, c.sessionID)
+	}
+	if c.protocolVersion != "" {
+		httpReq.Header.Set(protocolVersionHeader, c.protocolVersion)
+	}
+}
+
+func (c *Client) getSessionID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sessionID
+}
+
+func (c *Client) getStreamErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.streamErr
+}
+
+func isEventStream(httpRsp *http.Response) bool {
+	return strings.HasPrefix(httpRsp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+func checkStatus(reqURL string, httpRsp *http.Response) error {
+	if httpRsp.StatusCode < http.StatusMultipleChoices {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(httpRsp.Body, maxErrBodySize))
+	return fmt.Errorf("call %s failed, status: %d, body: %s", reqURL, httpRsp.StatusCode, string(body))
+}
+
+// event SSE 事件
+type event struct {
+	name string
+	data string
+}
+
+// readEvent 读取一个完整的 SSE 事件
+func readEvent(reader *bufio.Reader) (*event, error) {
+	ev := &event{}
+	data := []string{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && len(data) > 0 {
+				ev.data = strings.Join(data, "\n")
+				return ev, nil
+			}
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if ev.name == "" && len(data) == 0 {
+				continue
+			}
+			ev.data = strings.Join(data, "\n")
+			return ev, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+}
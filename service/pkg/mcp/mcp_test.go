@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// handleRPC 模拟服务端处理 JSON-RPC 请求
+func handleRPC(req *response) interface{} {
+	var params struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{"protocolVersion": ProtocolVersion}
+	case "tools/list":
+		return map[string]interface{}{"tools": []Tool{{Name: "echo", InputSchema: map[string]interface{}{"type": "object"}}}}
+	case "tools/call":
+		_ = json.Unmarshal(req.Result, &params)
+		if params.Name != "echo" {
+			return map[string]interface{}{"isError": true,
+				"content": []interface{}{map[string]interface{}{"type": "text", "text": "unknown tool"}}}
+		}
+		return map[string]interface{}{
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": params.Arguments["msg"]}},
+		}
+	}
+	return nil
+}
+
+// decodeRequest 解析请求, 为了复用 response 结构体把 params 放在 Result 字段
+func decodeRequest(r *http.Request) *response {
+	raw := struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}{}
+	_ = json.NewDecoder(r.Body).Decode(&raw)
+	return &response{ID: raw.ID, Method: raw.Method, Result: raw.Params}
+}
+
+func encodeResponse(req *response) []byte {
+	result, _ := json.Marshal(handleRPC(req))
+	rsp, _ := json.Marshal(&response{JSONRPC: jsonRPCVersion, ID: req.ID, Result: result})
+	return rsp
+}
+
+func newStreamableHTTPServer(useSSE bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			return
+		}
+		req := decodeRequest(r)
+		if len(req.ID) == 0 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		if req.Method != "initialize" && r.Header.Get(sessionIDHeader) != "session-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set(sessionIDHeader, "session-1")
+		if useSSE {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, ": ping\n\nevent: message\ndata: %s\n\n", encodeResponse(req))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(encodeResponse(req))
+	}))
+}
+
+func newSSEServer() *httptest.Server {
+	messages := make(chan []byte, 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		fmt.Fprint(w, "event: endpoint\ndata: /messages?sessionId=1\n\n")
+		flusher.Flush()
+		for {
+			select {
+			case msg := <-messages:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", msg)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		req := decodeRequest(r)
+		if len(req.ID) > 0 {
+			messages <- encodeResponse(req)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestClient(t *testing.T) {
+	streamableServer := newStreamableHTTPServer(false)
+	defer streamableServer.Close()
+	streamableSSEServer := newStreamableHTTPServer(true)
+	defer streamableSSEServer.Close()
+	sseServer := newSSEServer()
+	defer sseServer.Close()
+
+	tests := []struct {
+		name          string
+		url           string
+		wantTransport Transport
+	}{
+		{"Streamable HTTP 返回 JSON 的情况", streamableServer.URL + "/mcp", StreamableHTTP},
+		{"Streamable HTTP 返回 SSE 流的情况", streamableSSEServer.URL + "/mcp", StreamableHTTP},
+		{"SSE 传输的情况", sseServer.URL + "/sse", SSE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			c := NewClient(tt.url)
+			defer c.Close()
+			assert.Equal(t, tt.wantTransport, c.transport)
+
+			_, err := c.Initialize(ctx)
+			assert.Nil(t, err)
+
+			tools, err := c.ListTools(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(tools))
+			assert.Equal(t, "echo", tools[0].Name)
+
+			result, err := c.CallTool(ctx, "echo", map[string]interface{}{"msg": "hello"})
+			assert.Nil(t, err)
+			assert.False(t, result.IsError)
+			assert.Equal(t, "hello", result.Text())
+
+			result, err = c.CallTool(ctx, "unknown", nil)
+			assert.Nil(t, err)
+			assert.True(t, result.IsError)
+			assert.Equal(t, "unknown tool", result.Text())
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const (
+	defaultToolCacheTTL = 10 * time.Minute // 工具定义默认缓存时间
+)
+
+var (
+	DefaultToolCache = NewToolCache(defaultToolCacheTTL) // 进程内默认的工具定义缓存
+)
+
+// ToolCache 按服务地址和请求头缓存工具定义, 不同的鉴权信息可以看到的工具可能不同
+type ToolCache struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[string]*toolCacheEntry
+}
+
+type toolCacheEntry struct {
+	tools    map[string]Tool
+	expireAt time.Time
+}
+
+// NewToolCache 新建工具定义缓存
+func NewToolCache(ttl time.Duration) *ToolCache {
+	return &ToolCache{ttl: ttl, entries: map[string]*toolCacheEntry{}}
+}
+
+// Refresh 调用 tools/list 重新获取服务端的工具定义并缓存
+func (c *ToolCache) Refresh(ctx context.Context, serverURL string, opts ...Option) (map[string]Tool, error) {
+	client := NewClient(serverURL, opts...)
+	defer client.Close()
+
+	if _, err := client.Initialize(ctx); err != nil {
+		return nil, err
+	}
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	toolMap := make(map[string]Tool, len(tools))
+	for _, tool := range tools {
+		toolMap[tool.Name] = tool
+	}
+	c.mu.Lock()
+	c.entries[cacheKey(serverURL, opts...)] = &toolCacheEntry{tools: toolMap, expireAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return toolMap, nil
+}
+
+// GetTool 获取工具定义, 缓存不存在或者已过期时重新获取
+func (c *ToolCache) GetTool(ctx context.Context, serverURL, name string, opts ...Option) (*Tool, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[cacheKey(serverURL, opts...)]
+	c.mu.RUnlock()
+
+	tools := map[string]Tool{}
+	if ok && time.Now().Before(entry.expireAt) {
+		tools = entry.tools
+	} else {
+		var err error
+		if tools, err = c.Refresh(ctx, serverURL, opts...); err != nil {
+			return nil, err
+		}
+	}
+
+	tool, ok := tools[name]
+	if !ok {
+		return nil, fmt.Errorf("mcp tool [%s] not found in server %s", name, serverURL)
+	}
+	return &tool, nil
+}
+
+// cacheKey 获取缓存的 key, 由服务地址和按名称排序后的请求头组成
+func cacheKey(serverURL string, opts ...Option) string {
+	c := &Client{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	names := make([]string, 0, len(c.headers))
+	for name := range c.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(serverURL)
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("\n%s: %s", name, c.headers[name]))
+	}
+	return b.String()
+}
+
+// ValidateArguments 使用工具的输入 schema 校验参数, skipFields 中的字段及其子字段不做校验
+func ValidateArguments(tool *Tool, arguments map[string]interface{}, skipFields ...string) error {
+	if len(tool.InputSchema) == 0 {
+		return nil
+	}
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+
+	result, err := gojsonschema.Validate(gojsonschema.NewGoLoader(tool.InputSchema),
+		gojsonschema.NewGoLoader(arguments))
+	if err != nil {
+		return fmt.Errorf("failed to validate arguments of mcp tool [%s]: %w", tool.Name, err)
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	errMsgs := []string{}
+	for _, resultErr := range result.Errors() {
+		if isSkipField(resultErr, skipFields) {
+			continue
+		}
+		errMsgs = append(errMsgs, fmt.Sprintf("[%s]", resultErr.String()))
+	}
+	if len(errMsgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("illegal arguments of mcp tool [%s]: %s", tool.Name, strings.Join(errMsgs, ""))
+}
+
+// isSkipField 判断错误是否属于跳过校验的字段
+func isSkipField(resultErr gojsonschema.ResultError, skipFields []string) bool {
+	field := resultErr.Field()
+	if resultErr.Type() == "required" {
+		field = fmt.Sprint(resultErr.Details()["property"])
+	}
+	for _, skipField := range skipFields {
+		if field == skipField || strings.HasPrefix(field, skipField+".") {
+			return true
+		}
+	}
+	return false
+}
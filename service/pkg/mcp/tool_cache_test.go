@@ -0,0 +1,85 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolCache_GetTool(t *testing.T) {
+	server := newStreamableHTTPServer(false)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c := NewToolCache(time.Minute)
+
+	tool, err := c.GetTool(ctx, server.URL, "echo")
+	assert.Nil(t, err)
+	assert.Equal(t, "echo", tool.Name)
+
+	_, err = c.GetTool(ctx, server.URL, "unknown")
+	assert.NotNil(t, err)
+}
+
+func TestCacheKey(t *testing.T) {
+	serverURL := "https://mcp.example.com/mcp"
+	tests := []struct {
+		name  string
+		opts1 []Option
+		opts2 []Option
+		same  bool
+	}{
+		{"没有请求头", nil, []Option{WithTransport(StreamableHTTP)}, true},
+		{"请求头顺序不同", []Option{WithHeaders(map[string]string{"a": "1", "b": "2"})},
+			[]Option{WithHeaders(map[string]string{"b": "2", "a": "1"})}, true},
+		{"鉴权信息不同", []Option{WithHeaders(map[string]string{"Authorization": "Bearer a"})},
+			[]Option{WithHeaders(map[string]string{"Authorization": "Bearer b"})}, false},
+		{"有无请求头", nil, []Option{WithHeaders(map[string]string{"Authorization": "Bearer a"})}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.same, cacheKey(serverURL, tt.opts1...) == cacheKey(serverURL, tt.opts2...))
+		})
+	}
+}
+
+func TestValidateArguments(t *testing.T) {
+	tool := &Tool{
+		Name: "search",
+		InputSchema: map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"query", "limit"},
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{"type": "string"},
+				"limit": map[string]interface{}{"type": "integer"},
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		arguments  map[string]interface{}
+		skipFields []string
+		wantErr    bool
+	}{
+		{"参数合法的情况", map[string]interface{}{"query": "a", "limit": 1}, nil, false},
+		{"缺少必填参数的情况", map[string]interface{}{"query": "a"}, nil, true},
+		{"参数类型错误的情况", map[string]interface{}{"query": "a", "limit": "1"}, nil, true},
+		{"跳过必填参数的情况", map[string]interface{}{"query": "a"}, []string{"limit"}, false},
+		{"没有 schema 的情况", nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			curTool := tool
+			if tt.arguments == nil {
+				curTool = &Tool{Name: "empty"}
+			}
+			err := ValidateArguments(curTool, tt.arguments, tt.skipFields...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateArguments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-resty/resty/v2"
 	pb "github.com/fflow-tech/fflow/api/foundation/faas"
+	"github.com/fflow-tech/fflow/service/pkg/mcp"
 	"github.com/fflow-tech/fflow/service/pkg/utils"
 
 	"google.golang.org/grpc"
@@ -23,6 +24,7 @@ type DefaultAbilityCallerConfig struct {
 type DefaultAbilityCaller struct {
 	config     *DefaultAbilityCallerConfig
 	faasClient pb.FaasClient
+	faasCaller FAASCaller // 不为空时在本地执行 FAAS 函数, 不再调用 FAAS 服务
 }
 
 func NewDefaultAbilityCaller(config *DefaultAbilityCallerConfig) (*DefaultAbilityCaller, error) {
@@ -39,8 +41,19 @@ func NewDefaultAbilityCaller(config *DefaultAbilityCallerConfig) (*DefaultAbilit
 	}, nil
 }
 
+// NewLocalAbilityCaller 创建在本地执行 FAAS 函数的客户端, 没有 FAAS 服务时使用
+func NewLocalAbilityCaller(config *DefaultAbilityCallerConfig, faasCaller FAASCaller) *DefaultAbilityCaller {
+	return &DefaultAbilityCaller{
+		config:     config,
+		faasCaller: faasCaller,
+	}
+}
+
 // CallFAAS 调用 FAAS 能力
 func (c *DefaultAbilityCaller) CallFAAS(ctx context.Context, req *CallFAASReqDTO) (map[string]interface{}, error) {
+	if c.faasCaller != nil {
+		return c.faasCaller.CallFAAS(ctx, req)
+	}
 	rsp, err := c.faasClient.Call(ctx, &pb.CallReq{
 		BasicReq: &pb.BasicReq{
 			Namespace:   req.Namespace,
@@ -73,8 +86,25 @@ func (c *DefaultAbilityCaller) CallHTTP(ctx context.Context, req *CallHTTPReqDTO
 	}
 
 	if resp.IsError() {
-		return nil, fmt.Errorf("call %s failed, resp: %d", req.URL, resp.StatusCode())
+		return nil, &HTTPStatusError{URL: req.URL, StatusCode: resp.StatusCode()}
 	}
 
 	return result, nil
 }
+
+// CallMCP 调用 MCP 工具
+func (c *DefaultAbilityCaller) CallMCP(ctx context.Context, req *CallMCPReqDTO) (*mcp.CallToolResult, error) {
+	client := mcp.NewClient(req.URL, mcp.WithTransport(mcp.Transport(req.Transport)), mcp.WithHeaders(req.Header))
+	defer client.Close()
+
+	if _, err := client.Initialize(ctx); err != nil {
+		return nil, err
+	}
+	return client.CallTool(ctx, req.Tool, req.Arguments)
+}
+
+// GetMCPTool 获取 MCP 工具定义, 优先使用缓存
+func (c *DefaultAbilityCaller) GetMCPTool(ctx context.Context, req *CallMCPReqDTO) (*mcp.Tool, error) {
+	return mcp.DefaultToolCache.GetTool(ctx, req.URL, req.Tool,
+		mcp.WithTransport(mcp.Transport(req.Transport)), mcp.WithHeaders(req.Header))
+}
@@ -0,0 +1,113 @@
+package remote
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/pkg/log"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
+)
+
+// CronJobHandler 定时任务到期时的回调, params 为添加定时任务时的回调参数
+type CronJobHandler func(ctx context.Context, params string) error
+
+// DefaultCronClient 默认分布式定时器客户端, 没有开启本地调度时不做任何处理
+type DefaultCronClient struct {
+	mutex   sync.Mutex
+	jobs    map[string]chan struct{} // 本地调度的定时任务, 通过关闭 channel 停止任务
+	handler CronJobHandler
+}
+
+// NewDefaultCronClient 新建客户端
+func NewDefaultCronClient() *DefaultCronClient {
+	return &DefaultCronClient{}
+}
+
+// NewLocalCronClient 创建在进程内调度定时任务的客户端, 没有分布式定时器服务时使用
+func NewLocalCronClient() *DefaultCronClient {
+	return &DefaultCronClient{jobs: map[string]chan struct{}{}}
+}
+
+// SetHandler 设置定时任务到期时的回调
+func (c *DefaultCronClient) SetHandler(handler CronJobHandler) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.handler = handler
+}
+
+// AddCronJob 添加定时任务, 同名的任务已经存在时会替换掉
+func (c *DefaultCronClient) AddCronJob(d *AddCronJobReqDTO) error {
+	if c.jobs == nil {
+		return nil
+	}
+	if _, err := utils.GetNextTimeByExpr(d.CronStr, time.Now()); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if stop, ok := c.jobs[d.Name]; ok {
+		close(stop)
+	}
+	stop := make(chan struct{})
+	c.jobs[d.Name] = stop
+	go c.runJob(*d, stop)
+	return nil
+}
+
+// CancelCronJob 取消定时任务
+func (c *DefaultCronClient) CancelCronJob(d *CancelCronJobReqDTO) error {
+	if c.jobs == nil {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if stop, ok := c.jobs[d.Name]; ok {
+		close(stop)
+		delete(c.jobs, d.Name)
+	}
+	return nil
+}
+
+// Close 停止所有本地调度的定时任务
+func (c *DefaultCronClient) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for name, stop := range c.jobs {
+		close(stop)
+		delete(c.jobs, name)
+	}
+	return nil
+}
+
+// runJob 按照 cron 表达式循环等待下一次触发时间, 直到任务被停止
+func (c *DefaultCronClient) runJob(d AddCronJobReqDTO, stop chan struct{}) {
+	for {
+		nextTime, err := utils.GetNextTimeByExpr(d.CronStr, time.Now())
+		if err != nil {
+			log.Errorf("Failed to get next time of cron job %s, caused by %s", d.Name, err)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(nextTime))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		c.mutex.Lock()
+		handler := c.handler
+		c.mutex.Unlock()
+		if handler == nil {
+			log.Warnf("Cron job %s is due but no handler is set, skip it", d.Name)
+			continue
+		}
+		if err := handler(context.Background(), d.Params); err != nil {
+			log.Errorf("Failed to handle cron job %s, caused by %s", d.Name, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package remote
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// everySecond 每秒触发一次的 cron 表达式
+const everySecond = "* * * * * *"
+
+// recordingHandler 记录定时任务回调的参数
+type recordingHandler struct {
+	mutex  sync.Mutex
+	params []string
+}
+
+func (h *recordingHandler) handle(ctx context.Context, params string) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.params = append(h.params, params)
+	return nil
+}
+
+func (h *recordingHandler) calls() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]string{}, h.params...)
+}
+
+func TestDefaultCronClient_AddCronJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  *DefaultCronClient
+		cronStr string
+		wantErr bool
+	}{
+		{"没有开启本地调度时忽略", NewDefaultCronClient(), "illegal", false},
+		{"cron 表达式不合法", NewLocalCronClient(), "illegal", true},
+		{"添加成功", NewLocalCronClient(), everySecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.client.Close()
+			err := tt.client.AddCronJob(&AddCronJobReqDTO{Name: "job", CronStr: tt.cronStr})
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestDefaultCronClient_RunJob(t *testing.T) {
+	handler := &recordingHandler{}
+	client := NewLocalCronClient()
+	client.SetHandler(handler.handle)
+	defer client.Close()
+
+	assert.Nil(t, client.AddCronJob(&AddCronJobReqDTO{Name: "job", Params: "old", CronStr: everySecond}))
+	// 同名任务替换后只回调新任务的参数
+	assert.Nil(t, client.AddCronJob(&AddCronJobReqDTO{Name: "job", Params: "new", CronStr: everySecond}))
+	assert.Len(t, client.jobs, 1)
+	assert.Eventually(t, func() bool { return len(handler.calls()) >= 2 }, 3*time.Second, 10*time.Millisecond)
+	for _, params := range handler.calls() {
+		assert.Equal(t, "new", params)
+	}
+}
+
+func TestDefaultCronClient_CancelCronJob(t *testing.T) {
+	handler := &recordingHandler{}
+	client := NewLocalCronClient()
+	client.SetHandler(handler.handle)
+	defer client.Close()
+
+	assert.Nil(t, client.AddCronJob(&AddCronJobReqDTO{Name: "cancelled", Params: "cancelled", CronStr: everySecond}))
+	assert.Nil(t, client.AddCronJob(&AddCronJobReqDTO{Name: "kept", Params: "kept", CronStr: everySecond}))
+	assert.Nil(t, client.CancelCronJob(&CancelCronJobReqDTO{Name: "cancelled"}))
+	// 取消不存在的任务不报错
+	assert.Nil(t, client.CancelCronJob(&CancelCronJobReqDTO{Name: "unknown"}))
+	assert.Len(t, client.jobs, 1)
+
+	assert.Eventually(t, func() bool { return len(handler.calls()) >= 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.NotContains(t, handler.calls(), "cancelled")
+}
+
+func TestDefaultCronClient_Close(t *testing.T) {
+	handler := &recordingHandler{}
+	client := NewLocalCronClient()
+	client.SetHandler(handler.handle)
+
+	assert.Nil(t, client.AddCronJob(&AddCronJobReqDTO{Name: "a", CronStr: everySecond}))
+	assert.Nil(t, client.AddCronJob(&AddCronJobReqDTO{Name: "b", CronStr: everySecond}))
+	assert.Nil(t, client.Close())
+	assert.Empty(t, client.jobs)
+	assert.Never(t, func() bool { return len(handler.calls()) > 0 }, 1200*time.Millisecond, 50*time.Millisecond)
+}
@@ -0,0 +1,62 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/fflow-tech/fflow/api/foundation/rbac"
+	"github.com/fflow-tech/fflow/service/pkg/errno"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// DefaultRbacClient 默认权限客户端, 通过鉴权服务查询用户的角色
+type DefaultRbacClient struct {
+	rbacClient pb.RbacClient
+}
+
+// NewDefaultRbacClient 创建默认权限客户端, 和校验器共用鉴权服务的配置
+func NewDefaultRbacClient(config *DefaultPermissionValidatorConfig) (*DefaultRbacClient, error) {
+	conn, err := grpc.Dial(config.AuthTarget,
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingPolicy":"%s"}`, config.LoadBalancingPolicy)),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &DefaultRbacClient{
+		rbacClient: pb.NewRbacClient(conn),
+	}, nil
+}
+
+// NewLocalRbacClient 创建本地权限客户端, 没有鉴权服务时使用, 所有用户都没有角色
+func NewLocalRbacClient() *DefaultRbacClient {
+	return &DefaultRbacClient{}
+}
+
+// GetRolesForUserInDomain 获取用户在指定域下的角色
+func (c *DefaultRbacClient) GetRolesForUserInDomain(ctx context.Context, domain, user string) ([]string, error) {
+	if c.rbacClient == nil {
+		return nil, nil
+	}
+
+	rsp, err := c.rbacClient.GetRolesForUserInDomain(ctx, &pb.RbacReq{
+		User:   user,
+		Domain: domain,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	basicRsp := rsp.GetBasicRsp()
+	if basicRsp == nil {
+		return nil, fmt.Errorf("failed to get roles for user [%s] in domain [%s]: empty response from auth",
+			user, domain)
+	}
+	if basicRsp.Code != errno.OK.Code {
+		return nil, fmt.Errorf("failed to get roles for user [%s] in domain [%s]: %s",
+			user, domain, basicRsp.Message)
+	}
+
+	return rsp.Roles, nil
+}
@@ -3,12 +3,16 @@ package remote
 
 import (
 	"context"
+
+	"github.com/fflow-tech/fflow/service/pkg/mcp"
 )
 
 // AbilityCaller 能力中心客户端
 type AbilityCaller interface {
 	CallFAAS(context.Context, *CallFAASReqDTO) (map[string]interface{}, error)
 	CallHTTP(context.Context, *CallHTTPReqDTO) (map[string]interface{}, error)
+	CallMCP(context.Context, *CallMCPReqDTO) (*mcp.CallToolResult, error)
+	GetMCPTool(context.Context, *CallMCPReqDTO) (*mcp.Tool, error)
 }
 
 // CronClient 分布式定时器客户端
@@ -17,6 +21,11 @@ type CronClient interface {
 	CancelCronJob(*CancelCronJobReqDTO) error
 }
 
+// TimerClient 定时器服务客户端
+type TimerClient interface {
+	AddDelayJob(context.Context, *AddDelayJobReqDTO) error
+}
+
 // ChatOpsClient ChatOps 客户端
 type ChatOpsClient interface {
 	SendMsgToUser(userID string, msg string) error
@@ -28,18 +37,14 @@ type CloudEventClient interface {
 	Send(ctx context.Context, req *SendCloudEventDTO) error
 }
 
-type DefaultCronClient struct{}
-
-func NewDefaultCronClient() *DefaultCronClient {
-	return &DefaultCronClient{}
-}
-
-func (*DefaultCronClient) AddCronJob(d *AddCronJobReqDTO) error {
-	return nil
+// FAASCaller 本地 FAAS 函数执行器
+type FAASCaller interface {
+	CallFAAS(ctx context.Context, req *CallFAASReqDTO) (map[string]interface{}, error)
 }
 
-func (*DefaultCronClient) CancelCronJob(d *CancelCronJobReqDTO) error {
-	return nil
+// ScriptRunner 内联脚本执行器
+type ScriptRunner interface {
+	RunScript(ctx context.Context, req *RunScriptReqDTO) (interface{}, error)
 }
 
 type DefaultChatOpsClient struct{}
@@ -0,0 +1,124 @@
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	pb "github.com/fflow-tech/fflow/api/foundation/timer"
+	"github.com/fflow-tech/fflow/service/pkg/errno"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	timerCreator     = "fflow-engine"        // 定时器的创建人
+	timerNotifyHTTP  = 3                     // 定时器通过 HTTP 回调
+	timerDelayType   = 1                     // 延时定时器
+	timerTriggerOnce = 1                     // 只触发一次
+	timerDeleteAfter = 1                     // 触发后删除
+	timerTimeFormat  = "2006-01-02 15:04:05" // 定时器服务的时间格式
+)
+
+// ErrTimerNotEnabled 没有配置定时器服务
+var ErrTimerNotEnabled = errors.New("timer client is not enabled")
+
+// DefaultTimerClientConfig 定时器服务客户端配置
+type DefaultTimerClientConfig struct {
+	TimerTarget         string `json:"timerTarget,omitempty"`   // 定时器服务的 grpc 地址, 为空时不启用
+	Namespace           string `json:"namespace,omitempty"`     // 定时器服务的命名空间
+	AccessToken         string `json:"accessToken,omitempty"`   // 访问定时器服务的 token
+	App                 string `json:"app,omitempty"`           // 定时器所属的应用, 需要提前在定时器服务中创建
+	CallbackURL         string `json:"callbackURL,omitempty"`   // 定时器触发时回调的地址
+	CallbackToken       string `json:"callbackToken,omitempty"` // 回调时携带的 token
+	LoadBalancingPolicy string `json:"loadBalancingPolicy,omitempty"`
+}
+
+// DefaultTimerClient 通过定时器服务注册持久化的延时任务, 引擎重启后不会丢失
+type DefaultTimerClient struct {
+	config      *DefaultTimerClientConfig
+	timerClient pb.EndpointClient
+}
+
+// NewDefaultTimerClient 初始化定时器服务客户端
+func NewDefaultTimerClient(config *DefaultTimerClientConfig) (*DefaultTimerClient, error) {
+	if config.TimerTarget == "" {
+		return &DefaultTimerClient{config: config}, nil
+	}
+
+	conn, err := grpc.Dial(config.TimerTarget,
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingPolicy":"%s"}`, config.LoadBalancingPolicy)),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &DefaultTimerClient{
+		config:      config,
+		timerClient: pb.NewEndpointClient(conn),
+	}, nil
+}
+
+// AddDelayJob 添加延时任务, 到期后通过 HTTP 回调, 回调的请求体为 Params
+// 定时器服务按照本地时区解析触发时间, 需要和引擎部署在相同的时区
+func (c *DefaultTimerClient) AddDelayJob(ctx context.Context, req *AddDelayJobReqDTO) error {
+	if c.timerClient == nil {
+		return ErrTimerNotEnabled
+	}
+
+	header, err := json.Marshal(map[string]string{
+		"Namespace":     c.config.Namespace,
+		"Authorization": "Bearer " + c.config.CallbackToken,
+	})
+	if err != nil {
+		return err
+	}
+
+	basicReq := &pb.BasicReq{Namespace: c.config.Namespace, AccessToken: c.config.AccessToken}
+	createRsp, err := c.timerClient.CreateTimer(ctx, &pb.CreateTimerReq{
+		BasicReq:   basicReq,
+		Name:       req.Name,
+		Creator:    timerCreator,
+		App:        c.config.App,
+		NotifyType: timerNotifyHTTP,
+		TimerType:  timerDelayType,
+		DelayTime:  req.DeliverAt.In(time.Local).Format(timerTimeFormat),
+		NotifyHttpParam: &pb.NotifyHttpParam{
+			Method: http.MethodPost,
+			Url:    c.config.CallbackURL,
+			Header: string(header),
+			Body:   req.Params,
+		},
+		TriggerType: timerTriggerOnce,
+		DeleteType:  timerDeleteAfter,
+	})
+	if err != nil {
+		return err
+	}
+	if err := checkTimerRsp(createRsp.GetBasicRsp()); err != nil {
+		return fmt.Errorf("failed to create timer %s: %w", req.Name, err)
+	}
+
+	// 新建的定时器是未激活的状态, 需要激活后才会触发
+	enableRsp, err := c.timerClient.EnableTimer(ctx, &pb.EnableTimerReq{BasicReq: basicReq, DefId: createRsp.Data})
+	if err != nil {
+		return err
+	}
+	if err := checkTimerRsp(enableRsp.GetBasicRsp()); err != nil {
+		return fmt.Errorf("failed to enable timer %s: %w", req.Name, err)
+	}
+	return nil
+}
+
+func checkTimerRsp(rsp *pb.BasicRsp) error {
+	if rsp == nil {
+		return fmt.Errorf("empty response from timer")
+	}
+	if rsp.Code != errno.OK.Code {
+		return fmt.Errorf("code=%d, message=%s", rsp.Code, rsp.Message)
+	}
+	return nil
+}
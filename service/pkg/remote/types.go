@@ -1,6 +1,11 @@
 package remote
 
-import "github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/event"
+import (
+	"fmt"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/internal/workflow-app/engine/domain/dto/event"
+)
 
 // CallRPCReqDTO RPC 请求配置和请求体
 type CallRPCReqDTO struct {
@@ -26,6 +31,16 @@ type CallFAASReqDTO struct {
 	Body      map[string]interface{} `json:"body,omitempty"`
 }
 
+// RunScriptReqDTO 内联脚本执行请求
+type RunScriptReqDTO struct {
+	Namespace string                 `json:"namespace"`
+	Name      string                 `json:"name"`
+	Language  string                 `json:"language"`
+	Code      string                 `json:"code"`
+	Input     map[string]interface{} `json:"input,omitempty"`
+	Timeout   time.Duration          `json:"timeout,omitempty"`
+}
+
 // CallHTTPReqDTO HTTP 请求配置和请求体
 type CallHTTPReqDTO struct {
 	MockMode bool                   `json:"mockMode" metakey:"mockMode"`
@@ -36,6 +51,27 @@ type CallHTTPReqDTO struct {
 	Body     map[string]interface{} `json:"body,omitempty"`
 }
 
+// HTTPStatusError HTTP 请求返回错误状态码
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+}
+
+// Error 实现 error 接口
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("call %s failed, resp: %d", e.URL, e.StatusCode)
+}
+
+// CallMCPReqDTO MCP 请求配置和请求体
+type CallMCPReqDTO struct {
+	MockMode  bool                   `json:"mockMode" metakey:"mockMode"`
+	URL       string                 `json:"url" metakey:"url"`
+	Transport string                 `json:"transport,omitempty" metakey:"transport"`
+	Header    map[string]string      `json:"header,omitempty" metakey:"header"`
+	Tool      string                 `json:"tool" metakey:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+}
+
 // ValidateTokenReqDTO 请求配置和请求体
 type ValidateTokenReqDTO struct {
 	Namespace   string `json:"namespace" metakey:"namespace"`
@@ -49,6 +85,13 @@ type AddCronJobReqDTO struct {
 	CronStr string // cron表达式，unix 时间戳，秒级
 }
 
+// AddDelayJobReqDTO 创建延时任务请求体
+type AddDelayJobReqDTO struct {
+	Name      string    // job name，需保证唯一
+	Params    string    // 回调参数
+	DeliverAt time.Time // 触发时间
+}
+
 // CancelCronJobReqDTO 取消定时任务请求体
 type CancelCronJobReqDTO struct {
 	Name string // job name，需保证唯一
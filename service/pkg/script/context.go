@@ -0,0 +1,148 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/fflow-tech/fflow-sdk-go/faas"
+
+	"github.com/fflow-tech/fflow/service/pkg/log"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
+)
+
+// runtimeContext 内联脚本的运行时上下文, 实现 faas.Context
+type runtimeContext struct {
+	ctx      context.Context
+	metadata *metadata
+	logger   *logger
+	storage  faas.Storage
+}
+
+// newRuntimeContext 初始化一个 context
+func newRuntimeContext(ctx context.Context, namespace, name string, storage faas.Storage) *runtimeContext {
+	return &runtimeContext{
+		ctx:      ctx,
+		metadata: &metadata{namespace: namespace, name: name},
+		logger:   &logger{key: fmt.Sprintf("[%s.%s]", namespace, name)},
+		storage:  storage,
+	}
+}
+
+// Logger 获取 logger 实例
+func (r *runtimeContext) Logger() faas.Logger {
+	return r.logger
+}
+
+// Storage 获取 storage 实例
+func (r *runtimeContext) Storage() faas.Storage {
+	return r.storage
+}
+
+// Logs 获取所有打印的 log 信息
+func (r *runtimeContext) Logs() []string {
+	return r.logger.getLogs()
+}
+
+// Metadata 获取脚本基础信息
+func (r *runtimeContext) Metadata() faas.Metadata {
+	return r.metadata
+}
+
+// Context 获取当前的 context
+func (r *runtimeContext) Context() context.Context {
+	return r.ctx
+}
+
+// metadata 脚本基础信息, 内联脚本没有 ID 和版本
+type metadata struct {
+	namespace string
+	name      string
+}
+
+// Namespace 命名空间
+func (m *metadata) Namespace() string {
+	return m.namespace
+}
+
+// ID 脚本 ID
+func (m *metadata) ID() string {
+	return ""
+}
+
+// Name 脚本名称
+func (m *metadata) Name() string {
+	return m.name
+}
+
+// Version 脚本版本
+func (m *metadata) Version() int {
+	return 0
+}
+
+// Attribute 获取属性
+func (m *metadata) Attribute(key string) (any, error) {
+	return nil, nil
+}
+
+// logger 收集脚本打印的日志, 同时输出到引擎的日志中
+type logger struct {
+	key  string
+	mu   sync.Mutex
+	logs []string
+}
+
+// Infof 基础的 log 方法
+func (l *logger) Infof(message string, args ...any) {
+	l.append("INFO", message, args...)
+	log.Infof("%s %s", l.key, fmt.Sprintf(message, args...))
+}
+
+// Errorf 基础的 log 方法
+func (l *logger) Errorf(message string, args ...any) {
+	l.append("ERROR", message, args...)
+	log.Errorf("%s %s", l.key, fmt.Sprintf(message, args...))
+}
+
+// Debugf 基础的 log 方法
+func (l *logger) Debugf(message string, args ...any) {
+	l.append("DEBUG", message, args...)
+	log.Debugf("%s %s", l.key, fmt.Sprintf(message, args...))
+}
+
+// Warnf 基础的 log 方法
+func (l *logger) Warnf(message string, args ...any) {
+	l.append("WARN", message, args...)
+	log.Warnf("%s %s", l.key, fmt.Sprintf(message, args...))
+}
+
+func (l *logger) append(t, message string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logs = append(l.logs, fmt.Sprintf("%s %s %s", utils.GetCurrentLogTimestamp(), t,
+		fmt.Sprintf(message, args...)))
+}
+
+func (l *logger) getLogs() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string{}, l.logs...)
+}
+
+// unsupportedStorage 内联脚本不支持持久化存储
+type unsupportedStorage struct{}
+
+// Get 获取 key 的值
+func (unsupportedStorage) Get(key string) (any, error) {
+	return nil, fmt.Errorf("storage is not supported in inline script")
+}
+
+// Set 设置 key 的值
+func (unsupportedStorage) Set(key string, value any, expireTime int64) error {
+	return fmt.Errorf("storage is not supported in inline script")
+}
+
+// Del 删除
+func (unsupportedStorage) Del(key string) error {
+	return fmt.Errorf("storage is not supported in inline script")
+}
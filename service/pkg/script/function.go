@@ -0,0 +1,110 @@
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fflow-tech/fflow/service/internal/foundation/faas/domain/dto"
+	"github.com/fflow-tech/fflow/service/internal/foundation/faas/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/foundation/faas/domain/ports"
+	"github.com/fflow-tech/fflow/service/internal/foundation/faas/domain/service/command/execution"
+	"github.com/fflow-tech/fflow/service/internal/foundation/faas/pkg/runtimecontext"
+	"github.com/fflow-tech/fflow/service/pkg/log"
+	"github.com/fflow-tech/fflow/service/pkg/remote"
+)
+
+// functionLanguages 本地函数支持的语言, 通过文件后缀区分
+var functionLanguages = []entity.LanguageType{entity.Js, entity.Golang}
+
+// FunctionCaller 在进程内执行本地目录下的 FAAS 函数, 函数文件路径为 <dir>/<namespace>/<function>.js|.go
+type FunctionCaller struct {
+	dir      string
+	executor *execution.CodeExecutor
+	storage  runtimecontext.StorageClient
+}
+
+// NewFunctionCaller 初始化本地函数执行器, storage 为函数的持久化存储后端
+func NewFunctionCaller(dir string, storage runtimecontext.StorageClient) (*FunctionCaller, error) {
+	executor, err := execution.NewCodeExecutor(&ports.RepoProviderSet{})
+	if err != nil {
+		return nil, err
+	}
+	return &FunctionCaller{dir: dir, executor: executor, storage: storage}, nil
+}
+
+// CallFAAS 执行本地函数, 返回值和 FAAS 服务一样需要是一个对象
+func (c *FunctionCaller) CallFAAS(ctx context.Context, req *remote.CallFAASReqDTO) (map[string]interface{}, error) {
+	function, err := c.loadFunction(req.Namespace, req.Function)
+	if err != nil {
+		return nil, err
+	}
+
+	input := req.Body
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+	// 日志和存储依赖 context 中的 debugMode 标记
+	runtimeCtx := runtimecontext.NewRuntimeContextWithStorage(context.WithValue(ctx, "debugMode", false),
+		function, nil, c.storage)
+	result, record, err := c.executor.Execute(runtimeCtx, &dto.CallFunctionReqDTO{
+		Namespace: req.Namespace,
+		Function:  req.Function,
+		Input:     input,
+	}, function)
+	log.Infof("Run local function [%s:%s] result: %+v, err: %v", req.Namespace, req.Function, result, err)
+	if err != nil {
+		// 执行记录的日志最后一行是错误信息, 去掉后只保留函数打印的日志
+		logs := strings.TrimSuffix(strings.TrimSuffix(record.Log, fmt.Sprintf("ERROR: %s", err)), `\n`)
+		if logs == "" {
+			return nil, err
+		}
+		return nil, withLogs(err, []string{logs})
+	}
+
+	output, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	rsp := make(map[string]interface{})
+	if err := json.Unmarshal(output, &rsp); err != nil {
+		return nil, fmt.Errorf("the return of function %s.%s must be an object: %w", req.Namespace, req.Function, err)
+	}
+	return rsp, nil
+}
+
+// loadFunction 按照命名空间和函数名查找函数文件, 同名的 .js 和 .go 文件同时存在时报错
+func (c *FunctionCaller) loadFunction(namespace, name string) (*entity.Function, error) {
+	if !isValidPathElem(namespace) || !isValidPathElem(name) {
+		return nil, fmt.Errorf("invalid function namespace=%s, function=%s", namespace, name)
+	}
+
+	var function *entity.Function
+	for _, language := range functionLanguages {
+		path := filepath.Join(c.dir, namespace, name+language.FileSuffix())
+		code, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read function file %s: %w", path, err)
+		}
+		if function != nil {
+			return nil, fmt.Errorf("function %s.%s is ambiguous, both %s and %s exist",
+				namespace, name, function.Language.FileSuffix(), language.FileSuffix())
+		}
+		function = &entity.Function{Namespace: namespace, Name: name, Language: language, Code: string(code)}
+	}
+	if function == nil {
+		return nil, fmt.Errorf("function %s.%s not found in %s", namespace, name, c.dir)
+	}
+	return function, nil
+}
+
+// isValidPathElem 命名空间和函数名只能是单层的路径, 避免读取函数目录之外的文件
+func isValidPathElem(s string) bool {
+	return s != "" && s != "." && s != ".." && !strings.ContainsAny(s, `/\`)
+}
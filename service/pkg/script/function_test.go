@@ -0,0 +1,83 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fflow-tech/fflow/service/internal/foundation/faas/pkg/runtimecontext"
+	"github.com/fflow-tech/fflow/service/pkg/remote"
+	"github.com/fflow-tech/fflow/service/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunctionCaller_CallFAAS(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"demo/counter.js": `function handler(ctx, input) {
+	var got = storage.get("count");
+	var count = got[1] ? 0 : parseInt(got[0]);
+	count += input.step;
+	storage.set("count", String(count), 60);
+	return {count: count};
+}`,
+		"demo/hello.go": `package p
+import "github.com/fflow-tech/fflow-sdk-go/faas"
+func handler(ctx faas.Context, params map[string]interface{}) (interface{}, error) {
+	return map[string]interface{}{"greeting": "hello " + params["name"].(string)}, nil
+}`,
+		"demo/scalar.js": `function handler(ctx, input) { return "ok"; }`,
+		"demo/both.js":   `function handler(ctx, input) { return {}; }`,
+		"demo/both.go":   `package p`,
+	}
+	for name, code := range files {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(code), 0644))
+	}
+
+	storage, err := runtimecontext.NewLocalStorageClient(filepath.Join(dir, "storage.json"))
+	assert.NoError(t, err)
+	caller, err := NewFunctionCaller(dir, storage)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		req     *remote.CallFAASReqDTO
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{"执行 javascript 函数并写入存储", &remote.CallFAASReqDTO{
+			Namespace: "demo", Function: "counter", Body: map[string]interface{}{"step": 2},
+		}, map[string]interface{}{"count": float64(2)}, false},
+		{"再次执行时读取存储中的值", &remote.CallFAASReqDTO{
+			Namespace: "demo", Function: "counter", Body: map[string]interface{}{"step": 3},
+		}, map[string]interface{}{"count": float64(5)}, false},
+		{"执行 golang 函数", &remote.CallFAASReqDTO{
+			Namespace: "demo", Function: "hello", Body: map[string]interface{}{"name": "fflow"},
+		}, map[string]interface{}{"greeting": "hello fflow"}, false},
+		{"函数不存在", &remote.CallFAASReqDTO{Namespace: "demo", Function: "missing"}, nil, true},
+		{"同名的 js 和 go 函数同时存在", &remote.CallFAASReqDTO{Namespace: "demo", Function: "both"}, nil, true},
+		{"函数名不能包含路径", &remote.CallFAASReqDTO{Namespace: "..", Function: "storage"}, nil, true},
+		{"返回值不是对象", &remote.CallFAASReqDTO{Namespace: "demo", Function: "scalar"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := caller.CallFAAS(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CallFAAS() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// 存储写入了文件, 重新加载后仍然可以读取
+	reloaded, err := runtimecontext.NewLocalStorageClient(filepath.Join(dir, "storage.json"))
+	assert.NoError(t, err)
+	got, err := reloaded.Get(context.Background(), fmt.Sprintf("%s.demo_count", utils.GetEnv()))
+	assert.NoError(t, err)
+	assert.Equal(t, "5", got)
+}
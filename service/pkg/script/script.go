@@ -0,0 +1,99 @@
+// Package script 在进程内执行内联的 JS/Go 脚本, 复用 FAAS 的脚本执行器, 不依赖 FAAS 服务
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fflow-tech/fflow-sdk-go/faas"
+
+	"github.com/fflow-tech/fflow/service/internal/foundation/faas/domain/entity"
+	"github.com/fflow-tech/fflow/service/internal/foundation/faas/domain/service/command/execution/golang"
+	"github.com/fflow-tech/fflow/service/internal/foundation/faas/domain/service/command/execution/js"
+	"github.com/fflow-tech/fflow/service/pkg/remote"
+)
+
+const defaultTimeout = 60 * time.Second // 没有指定超时时间时的默认值, 和 FAAS 函数一致
+
+// executor 脚本执行器, 和 FAAS 的执行器接口一致
+type executor interface {
+	Execute(faas.Context, string, map[string]interface{}) (interface{}, []string, error)
+}
+
+// Runner 内联脚本执行器
+type Runner struct {
+	executors map[string]executor
+}
+
+// NewRunner 初始化内联脚本执行器
+func NewRunner() remote.ScriptRunner {
+	return &Runner{
+		executors: map[string]executor{
+			entity.Js.String():     js.NewJavascriptExecutor(),
+			entity.Golang.String(): golang.NewGolangExecutor(),
+		},
+	}
+}
+
+// RunScript 执行脚本, 超时后中断脚本的执行并返回错误
+func (r *Runner) RunScript(ctx context.Context, req *remote.RunScriptReqDTO) (interface{}, error) {
+	executor, ok := r.executors[req.Language]
+	if !ok {
+		return nil, fmt.Errorf("invalid language %s", req.Language)
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	runtimeCtx := newRuntimeContext(ctxWithTimeout, req.Namespace, req.Name, unsupportedStorage{})
+	input := req.Input
+	if input == nil {
+		input = map[string]interface{}{}
+	}
+
+	type executeResult struct {
+		result interface{}
+		logs   []string
+		err    error
+	}
+	// 缓冲为 1, 超时返回后协程仍然可以写入结果并退出
+	done := make(chan executeResult, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- executeResult{err: fmt.Errorf("panic in execute caused by %v", p)}
+			}
+		}()
+		result, logs, err := executor.Execute(runtimeCtx, req.Code, input)
+		done <- executeResult{result: result, logs: logs, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, withLogs(res.err, res.logs)
+		}
+		// 对返回值进行检查, 不能转为 json 的返回值无法作为节点的输出
+		if _, err := json.Marshal(res.result); err != nil {
+			return nil, fmt.Errorf("the return of the script is invalid: %w", err)
+		}
+		return res.result, nil
+	case <-ctxWithTimeout.Done():
+		return nil, fmt.Errorf("execute script timeout after %s: %w", timeout, ctxWithTimeout.Err())
+	}
+}
+
+// withLogs 执行失败时带上脚本打印的日志, 便于排查
+func withLogs(err error, logs []string) error {
+	if len(logs) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w, logs: %s", err, strings.Join(logs, `\n`))
+}
@@ -0,0 +1,99 @@
+package script
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/fflow-tech/fflow/service/pkg/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunner_RunScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *remote.RunScriptReqDTO
+		want    interface{}
+		wantErr bool
+	}{
+		{"执行 javascript 脚本", &remote.RunScriptReqDTO{
+			Language: "javascript",
+			Code:     `function handler(ctx, params) { return {sum: params.a + params.b}; }`,
+			Input:    map[string]interface{}{"a": 1, "b": 2},
+		}, map[string]interface{}{"sum": int64(3)}, false},
+		{"执行 golang 脚本", &remote.RunScriptReqDTO{
+			Language: "golang",
+			Code: `package p
+import "github.com/fflow-tech/fflow-sdk-go/faas"
+func handler(ctx faas.Context, params map[string]interface{}) (interface{}, error) {
+	return params["name"], nil
+}`,
+			Input: map[string]interface{}{"name": "fflow"},
+		}, "fflow", false},
+		{"不支持的语言", &remote.RunScriptReqDTO{Language: "python", Code: "print(1)"}, nil, true},
+		{"脚本执行超时", &remote.RunScriptReqDTO{
+			Language: "javascript",
+			Code:     `function handler(ctx, params) { while (true) {} }`,
+			Timeout:  100 * time.Millisecond,
+		}, nil, true},
+		{"golang 脚本执行超时", &remote.RunScriptReqDTO{
+			Language: "golang",
+			Code: `package p
+import "github.com/fflow-tech/fflow-sdk-go/faas"
+func handler(ctx faas.Context, params map[string]interface{}) (interface{}, error) {
+	for {
+	}
+}`,
+			Timeout: 100 * time.Millisecond,
+		}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewRunner().RunScript(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RunScript() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRunner_RunScriptInterrupt(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *remote.RunScriptReqDTO
+	}{
+		{"中断 javascript 死循环", &remote.RunScriptReqDTO{
+			Language: "javascript",
+			Code:     `function handler(ctx, params) { while (true) {} }`,
+			Timeout:  50 * time.Millisecond,
+		}},
+		{"中断 golang 死循环", &remote.RunScriptReqDTO{
+			Language: "golang",
+			Code: `package p
+import "github.com/fflow-tech/fflow-sdk-go/faas"
+func handler(ctx faas.Context, params map[string]interface{}) (interface{}, error) {
+	n := 0
+	for {
+		n++
+	}
+}`,
+			Timeout: 50 * time.Millisecond,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := runtime.NumGoroutine()
+			for i := 0; i < 5; i++ {
+				_, err := NewRunner().RunScript(context.Background(), tt.req)
+				assert.Error(t, err)
+			}
+			// 超时后执行脚本的协程会被中断并退出, 不会一直占用 CPU
+			assert.Eventually(t, func() bool {
+				return runtime.NumGoroutine() <= before
+			}, 2*time.Second, 20*time.Millisecond)
+		})
+	}
+}
@@ -3,6 +3,8 @@ package sqlite
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -29,10 +31,28 @@ func NewClient(db *gorm.DB) *mysql.Client {
 	return mysql.NewClient(db)
 }
 
+// memoryDsn 共享内存数据库的连接串
+const memoryDsn = "file::memory:?cache=shared&_pragma=foreign_keys(1)&mode=memory&_txlock=immediate"
+
 // GetMySQLClient 获取一个内存数据库客户端
 func GetMySQLClient(config config.MySQLConfig) (*mysql.Client, error) {
 	// 使用DSN作为缓存键，确保相同配置复用同一个客户端
-	if client, ok := clientMap.Load(config.Dsn); ok {
+	return getClient(config.Dsn, memoryDsn, config)
+}
+
+// GetFileMySQLClient 获取一个保存在本地文件中的数据库客户端, 进程退出后数据不会丢失
+func GetFileMySQLClient(path string, config config.MySQLConfig) (*mysql.Client, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create database dir: %w", err)
+	}
+	// 多个协程同时写入时等待锁释放, 避免出现 database is locked 的错误
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_txlock=immediate", path)
+	return getClient(path, dsn, config)
+}
+
+// getClient 按照缓存键获取客户端, 不存在时新建
+func getClient(key, dsn string, config config.MySQLConfig) (*mysql.Client, error) {
+	if client, ok := clientMap.Load(key); ok {
 		return client.(*mysql.Client), nil
 	}
 
@@ -40,12 +60,12 @@ func GetMySQLClient(config config.MySQLConfig) (*mysql.Client, error) {
 	defer mutex.Unlock()
 
 	// 二次检查
-	if client, ok := clientMap.Load(config.Dsn); ok {
+	if client, ok := clientMap.Load(key); ok {
 		return client.(*mysql.Client), nil
 	}
 
-	// 创建 SQLite 共享内存数据库作为 MySQL 替代
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared&_pragma=foreign_keys(1)&mode=memory&_txlock=immediate"), &gorm.Config{
+	// 创建 SQLite 数据库作为 MySQL 替代
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
 		SkipDefaultTransaction: config.SkipDefaultTransaction,
 		Logger: logs.NewGormLogger(logs.Config{
 			SlowThreshold:             time.Duration(config.SlowThreshold) * time.Millisecond,
@@ -55,7 +75,7 @@ func GetMySQLClient(config config.MySQLConfig) (*mysql.Client, error) {
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect in-memory database: %w", err)
+		return nil, fmt.Errorf("failed to connect sqlite database: %w", err)
 	}
 
 	// 启用WAL模式以提高性能
@@ -65,7 +85,7 @@ func GetMySQLClient(config config.MySQLConfig) (*mysql.Client, error) {
 	db.Exec("PRAGMA foreign_keys=ON")
 
 	client := &mysql.Client{DB: db, Config: config}
-	clientMap.Store(config.Dsn, client)
+	clientMap.Store(key, client)
 
 	return mysql.NewClient(client.DB), nil
 }
@@ -82,6 +102,7 @@ func (c *MySQLClient) CreateTables() error {
 		&po.HistoryNodeInstPO{},
 		&po.HistoryWorkflowInstPO{},
 		&po.NodeInstPO{},
+		&po.SecretPO{},
 		&po.TriggerPO{},
 		&po.WorkflowDefPO{},
 		&po.WorkflowInstPO{},
@@ -90,7 +111,7 @@ func (c *MySQLClient) CreateTables() error {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
 	// 查询已经创建成功的表
-	tables := []string{"history_node_inst", "history_workflow_inst", "node_inst", "trigger", "workflow_def", "workflow_inst"}
+	tables := []string{"history_node_inst", "history_workflow_inst", "node_inst", "secret", "trigger", "workflow_def", "workflow_inst"}
 	for _, table := range tables {
 		if !c.DB.Migrator().HasTable(table) {
 			return fmt.Errorf("table %s not created successfully", table)
@@ -1,10 +1,14 @@
 package sqlite
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/fflow-tech/fflow/service/pkg/config"
+	"github.com/glebarez/sqlite"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func TestGetMySQLClient(t *testing.T) {
@@ -66,3 +70,24 @@ func TestMySQLClient_Close(t *testing.T) {
 	err = mysqlClient.Close()
 	assert.NoError(t, err)
 }
+
+func TestGetFileMySQLClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "fflow.db")
+	client, err := GetFileMySQLClient(path, config.MySQLConfig{})
+	assert.NoError(t, err)
+
+	// 相同的路径复用同一个客户端
+	same, err := GetFileMySQLClient(path, config.MySQLConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, client.DB, same.DB)
+
+	mysqlClient := &MySQLClient{Client: client}
+	assert.NoError(t, mysqlClient.CreateTables())
+
+	// 数据写入到文件中
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.True(t, db.Migrator().HasTable("workflow_inst"))
+}
@@ -0,0 +1,32 @@
+package utils
+
+import "testing"
+
+func TestAESEncrypt(t *testing.T) {
+	tests := []struct {
+		name       string
+		encryptKey string
+		decryptKey string
+		plaintext  string
+		wantErr    bool
+	}{
+		{"正常加解密", "key", "key", "sk-123456", false},
+		{"空字符串加解密", "key", "key", "", false},
+		{"密钥不一致", "key", "other", "sk-123456", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := AESEncrypt(tt.encryptKey, tt.plaintext)
+			if err != nil {
+				t.Fatalf("AESEncrypt() error = %v", err)
+			}
+			got, err := AESDecrypt(tt.decryptKey, ciphertext)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AESDecrypt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.plaintext {
+				t.Errorf("AESDecrypt() = %v, want %v", got, tt.plaintext)
+			}
+		})
+	}
+}
@@ -69,6 +69,23 @@ CREATE TABLE `node_inst`
     KEY                `idx_deleted_at` (`deleted_at`) COMMENT '删除时间索引'
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 shardkey=def_id;
 
+CREATE TABLE `secret`
+(
+    `id`          bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `namespace`   varchar(256) NOT NULL COMMENT '命名空间',
+    `name`        varchar(128) NOT NULL COMMENT '密钥名称',
+    `value`       text         NOT NULL COMMENT '加密后的密钥值',
+    `description` varchar(512) DEFAULT NULL COMMENT '密钥描述',
+    `creator`     varchar(128) NOT NULL COMMENT '创建人',
+    `updater`     varchar(128) DEFAULT NULL COMMENT '最后一次修改人',
+    `created_at`  datetime     NOT NULL COMMENT '创建时间',
+    `updated_at`  datetime     NOT NULL ON UPDATE CURRENT_TIMESTAMP COMMENT '修改时间',
+    `deleted_at`  datetime DEFAULT NULL COMMENT '删除时间',
+    PRIMARY KEY (`id`) USING BTREE COMMENT '主键索引',
+    UNIQUE KEY    `uniq_namespace_name` (`namespace`,`name`) USING BTREE COMMENT '命名空间密钥名称唯一索引',
+    KEY           `idx_deleted_at` (`deleted_at`) COMMENT '删除时间索引'
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4;
+
 CREATE TABLE `trigger`
 (
     `id`          bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '主键ID',